@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"html/template"
 	"io"
 	"net/http"
@@ -16,17 +17,27 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"github.com/vincentchyu/sonic-lens/config"
 	"github.com/vincentchyu/sonic-lens/core/log"
 	"github.com/vincentchyu/sonic-lens/core/lyrics"
 	"github.com/vincentchyu/sonic-lens/core/websocket"
+	"github.com/vincentchyu/sonic-lens/internal/audioscrobbler"
+	"github.com/vincentchyu/sonic-lens/internal/exporter"
+	"github.com/vincentchyu/sonic-lens/internal/importer"
+	"github.com/vincentchyu/sonic-lens/internal/ingest"
+	"github.com/vincentchyu/sonic-lens/internal/listenbrainzapi"
 	"github.com/vincentchyu/sonic-lens/internal/logic/analysis"
+	"github.com/vincentchyu/sonic-lens/internal/logic/favorite"
 	"github.com/vincentchyu/sonic-lens/internal/logic/genre"
 	"github.com/vincentchyu/sonic-lens/internal/logic/insight"
 	"github.com/vincentchyu/sonic-lens/internal/logic/musicbrainz"
+	"github.com/vincentchyu/sonic-lens/internal/logic/rewrite"
 	"github.com/vincentchyu/sonic-lens/internal/logic/track"
 	"github.com/vincentchyu/sonic-lens/internal/model"
+	"github.com/vincentchyu/sonic-lens/internal/nowplaying"
+	"github.com/vincentchyu/sonic-lens/internal/scrobbler"
 )
 
 func setupRouter(name string) *gin.Engine {
@@ -1173,23 +1184,26 @@ func setupRouter(name string) *gin.Engine {
 		},
 	)
 
-	// 获取专辑列表（支持分页、搜索、自然排序）
+	// 获取专辑列表（支持分页、搜索、自然排序，tag 与 min_rating 筛选）
 	r.GET(
 		"/api/albums", func(c *gin.Context) {
 			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 			offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-			keyword := c.Query("keyword")
+			filter := listFilter(c)
 
 			if limit > 100 {
 				limit = 100
 			}
 
-			albums, err := trackService.GetAlbums(c.Request.Context(), limit, offset, keyword)
+			albums, err := trackService.GetAlbums(c.Request.Context(), limit, offset, filter)
+			if err == nil {
+				err = model.AttachAlbumTags(c.Request.Context(), albums)
+			}
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
-			total, _ := trackService.GetAlbumsCount(c.Request.Context(), keyword)
+			total, _ := trackService.GetAlbumsCount(c.Request.Context(), filter)
 
 			c.JSON(
 				http.StatusOK, gin.H{
@@ -1202,23 +1216,26 @@ func setupRouter(name string) *gin.Engine {
 		},
 	)
 
-	// 获取曲目列表（按专辑排序，支持分页、搜索）
+	// 获取曲目列表（按专辑排序，支持分页、搜索，tag 与 min_rating 筛选）
 	r.GET(
 		"/api/tracks", func(c *gin.Context) {
 			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 			offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-			keyword := c.Query("keyword")
+			filter := listFilter(c)
 
 			if limit > 100 {
 				limit = 100
 			}
 
-			tracks, err := trackService.GetTracksOrderedByAlbum(c.Request.Context(), limit, offset, keyword)
+			tracks, err := trackService.GetTracksOrderedByAlbum(c.Request.Context(), limit, offset, filter)
+			if err == nil {
+				err = model.AttachTrackTags(c.Request.Context(), tracks)
+			}
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
-			total, _ := trackService.GetTracksOrderedByAlbumCount(c.Request.Context(), keyword)
+			total, _ := trackService.GetTracksOrderedByAlbumCount(c.Request.Context(), filter)
 
 			c.JSON(
 				http.StatusOK, gin.H{
@@ -1231,6 +1248,347 @@ func setupRouter(name string) *gin.Engine {
 		},
 	)
 
+	// 曲目与专辑的本地评分与标签
+	for _, group := range []struct{ path, targetType string }{
+		{"/api/tracks/:id", model.TagTargetTrack},
+		{"/api/albums/:id", model.TagTargetAlbum},
+	} {
+		targetType := group.targetType
+		// 设置评分，rating 为 0 时清除
+		r.PUT(
+			group.path+"/rating", func(c *gin.Context) {
+				id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+				var req struct {
+					Rating *int `json:"rating"`
+				}
+				if err != nil || id <= 0 || c.ShouldBindJSON(&req) != nil || req.Rating == nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "valid id and rating are required"})
+					return
+				}
+				if err := model.SetRating(c.Request.Context(), targetType, id, *req.Rating); err != nil {
+					respondTagError(c, err)
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"id": id, "rating": *req.Rating})
+			},
+		)
+		r.GET(
+			group.path+"/tags", func(c *gin.Context) {
+				id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+				if err != nil || id <= 0 {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+					return
+				}
+				tags, err := model.GetTags(c.Request.Context(), targetType, id)
+				if err != nil {
+					respondTagError(c, err)
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"id": id, "tags": tags})
+			},
+		)
+		// 添加标签 {"tags": ["late night", "jazz"]}
+		r.POST(
+			group.path+"/tags", func(c *gin.Context) {
+				id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+				var req struct {
+					Tags []string `json:"tags"`
+				}
+				if err != nil || id <= 0 || c.ShouldBindJSON(&req) != nil || len(req.Tags) == 0 {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "valid id and tags are required"})
+					return
+				}
+				ctx := c.Request.Context()
+				if err := model.AddTags(ctx, targetType, []int64{id}, req.Tags); err != nil {
+					respondTagError(c, err)
+					return
+				}
+				tags, err := model.GetTags(ctx, targetType, id)
+				if err != nil {
+					respondTagError(c, err)
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"id": id, "tags": tags})
+			},
+		)
+		r.DELETE(
+			group.path+"/tags/:tag", func(c *gin.Context) {
+				id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+				if err != nil || id <= 0 {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+					return
+				}
+				ctx := c.Request.Context()
+				if err := model.RemoveTags(ctx, targetType, []int64{id}, []string{c.Param("tag")}); err != nil {
+					respondTagError(c, err)
+					return
+				}
+				tags, err := model.GetTags(ctx, targetType, id)
+				if err != nil {
+					respondTagError(c, err)
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"id": id, "tags": tags})
+			},
+		)
+	}
+
+	// 合并同一录音的多个曲目 {"canonical_id": 1, "ids": [2, 3]}，被合并曲目的名称记为别名
+	r.POST(
+		"/api/tracks/merge", func(c *gin.Context) {
+			var req struct {
+				CanonicalID int64   `json:"canonical_id"`
+				IDs         []int64 `json:"ids"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil || req.CanonicalID <= 0 || len(req.IDs) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "canonical_id and ids are required"})
+				return
+			}
+			if len(req.IDs) > 100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "at most 100 ids per request"})
+				return
+			}
+			result, err := model.MergeTracks(c.Request.Context(), req.CanonicalID, req.IDs)
+			if err != nil {
+				respondTrackAliasError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, result)
+		},
+	)
+
+	r.GET(
+		"/api/tracks/:id/aliases", func(c *gin.Context) {
+			id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			if err != nil || id <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+				return
+			}
+			aliases, err := model.GetTrackAliases(c.Request.Context(), id)
+			if err != nil {
+				respondTrackAliasError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"id": id, "aliases": aliases})
+		},
+	)
+
+	// 添加别名 {"artist": "", "album": "", "track": ""}，已有同名曲目时会合并
+	r.POST(
+		"/api/tracks/:id/aliases", func(c *gin.Context) {
+			id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			var req struct {
+				Artist string `json:"artist"`
+				Album  string `json:"album"`
+				Track  string `json:"track"`
+			}
+			if err != nil || id <= 0 || c.ShouldBindJSON(&req) != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "valid id and alias are required"})
+				return
+			}
+			result, err := model.AddTrackAlias(c.Request.Context(), id, req.Artist, req.Album, req.Track)
+			if err != nil {
+				respondTrackAliasError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, result)
+		},
+	)
+
+	r.DELETE(
+		"/api/tracks/:id/aliases/:aliasId", func(c *gin.Context) {
+			id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			aliasID, aliasErr := strconv.ParseInt(c.Param("aliasId"), 10, 64)
+			if err != nil || aliasErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+				return
+			}
+			if err := model.DeleteTrackAlias(c.Request.Context(), id, aliasID); err != nil {
+				respondTrackAliasError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"deleted": aliasID})
+		},
+	)
+
+	// 曲目的多艺术家署名：主艺术家、合作、作曲与专辑艺术家
+	r.GET(
+		"/api/tracks/:id/artists", func(c *gin.Context) {
+			id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			if err != nil || id <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+				return
+			}
+			credits, err := model.GetTrackArtists(c.Request.Context(), id)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"id": id, "artists": credits})
+		},
+	)
+
+	// 元数据改写规则，按 priority、id 顺序在播放器上报后、scrobble 前执行
+	r.GET(
+		"/api/rewrite-rules", func(c *gin.Context) {
+			rules, err := model.ListRewriteRules(c.Request.Context(), false)
+			if err != nil {
+				respondRewriteRuleError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"rules": rules})
+		},
+	)
+
+	// 新建规则 {"name": "", "priority": 0, "match_artist": "^Beatles$", "actions": [{"field": "artist", "replace": "The Beatles"}]}
+	r.POST(
+		"/api/rewrite-rules", func(c *gin.Context) {
+			rule, ok := bindRewriteRule(c)
+			if !ok {
+				return
+			}
+			if err := model.CreateRewriteRule(c.Request.Context(), rule); err != nil {
+				respondRewriteRuleError(c, err)
+				return
+			}
+			rewrite.Invalidate()
+			c.JSON(http.StatusOK, rule)
+		},
+	)
+
+	r.PUT(
+		"/api/rewrite-rules/:id", func(c *gin.Context) {
+			id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			if err != nil || id <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+				return
+			}
+			rule, ok := bindRewriteRule(c)
+			if !ok {
+				return
+			}
+			rule.ID = id
+			if err := model.UpdateRewriteRule(c.Request.Context(), rule); err != nil {
+				respondRewriteRuleError(c, err)
+				return
+			}
+			rewrite.Invalidate()
+			if rule, err = model.GetRewriteRule(c.Request.Context(), id); err != nil {
+				respondRewriteRuleError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, rule)
+		},
+	)
+
+	r.DELETE(
+		"/api/rewrite-rules/:id", func(c *gin.Context) {
+			id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			if err != nil || id <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+				return
+			}
+			if err := model.DeleteRewriteRule(c.Request.Context(), id); err != nil {
+				respondRewriteRuleError(c, err)
+				return
+			}
+			rewrite.Invalidate()
+			c.JSON(http.StatusOK, gin.H{"deleted": id})
+		},
+	)
+
+	// 预览规则对已有曲目的改写，rule_id 缺省时使用全部启用的规则
+	r.GET(
+		"/api/rewrite-rules/apply", func(c *gin.Context) {
+			applyRewriteRules(c, true)
+		},
+	)
+
+	// 把规则应用到已有曲目，改写后重名的曲目会被合并
+	r.POST(
+		"/api/rewrite-rules/apply", func(c *gin.Context) {
+			applyRewriteRules(c, false)
+		},
+	)
+
+	// 全部标签及使用次数，type 为 track（默认）或 album
+	r.GET(
+		"/api/tags", func(c *gin.Context) {
+			tags, err := model.GetTagCounts(c.Request.Context(), c.DefaultQuery("type", model.TagTargetTrack))
+			if err != nil {
+				respondTagError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"tags": tags})
+		},
+	)
+
+	// 批量打标签 {"type": "track", "ids": [1, 2], "add": ["jazz"], "remove": ["rock"]}
+	r.POST(
+		"/api/tags/bulk", func(c *gin.Context) {
+			var req struct {
+				Type   string   `json:"type"`
+				IDs    []int64  `json:"ids"`
+				Add    []string `json:"add"`
+				Remove []string `json:"remove"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 || len(req.Add)+len(req.Remove) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "ids and add or remove are required"})
+				return
+			}
+			if len(req.IDs) > 1000 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "at most 1000 ids per request"})
+				return
+			}
+			ctx := c.Request.Context()
+			if err := model.AddTags(ctx, req.Type, req.IDs, req.Add); err != nil {
+				respondTagError(c, err)
+				return
+			}
+			if err := model.RemoveTags(ctx, req.Type, req.IDs, req.Remove); err != nil {
+				respondTagError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"updated": len(req.IDs)})
+		},
+	)
+
+	// 评分最高的曲目或专辑，type 为 track（默认）或 album
+	r.GET(
+		"/api/dashboard/top-rated", func(c *gin.Context) {
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+			if limit > 50 {
+				limit = 50
+			}
+			rows, err := model.GetTopRatedFromStat(c.Request.Context(), c.DefaultQuery("type", model.TagTargetTrack), limit)
+			if err != nil {
+				respondTagError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, rows)
+		},
+	)
+
+	// 按标签汇总的曲目或专辑数、播放次数与平均评分
+	r.GET(
+		"/api/dashboard/tags", func(c *gin.Context) {
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+			if limit > 50 {
+				limit = 50
+			}
+			rows, err := model.GetTagStatsFromStat(c.Request.Context(), c.DefaultQuery("type", model.TagTargetTrack), limit)
+			if err != nil {
+				respondTagError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, rows)
+		},
+	)
+
 	// 获取热门流派数据（按播放次数和曲目数）
 	genreService := genre.NewGenreService()
 	r.GET(
@@ -1294,6 +1652,228 @@ func setupRouter(name string) *gin.Engine {
 		},
 	)
 
+	// 获取播放记录在各上报目标（Last.fm、ListenBrainz）上的投递状态
+	r.GET(
+		"/api/play-records/:id/deliveries", func(c *gin.Context) {
+			idStr := c.Param("id")
+			recordID, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil || recordID <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的播放记录 ID"})
+				return
+			}
+
+			deliveries, err := model.GetScrobbleDeliveries(c.Request.Context(), recordID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, deliveries)
+		},
+	)
+
+	// 获取跳过次数最多的曲目，days 为 0 时统计全部播放
+	r.GET(
+		"/api/play-events/most-skipped", func(c *gin.Context) {
+			ctx := c.Request.Context()
+
+			days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+			if limit <= 0 || limit > 50 {
+				limit = 10
+			}
+
+			tracks, err := model.GetMostSkippedTracks(ctx, playEventsSince(days), limit)
+			if err != nil {
+				log.Error(ctx, "Failed to get most skipped tracks", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get most skipped tracks"})
+				return
+			}
+			c.JSON(http.StatusOK, tracks)
+		},
+	)
+
+	// 按艺术家或流派统计跳过率，by 可选 artist/genre，min_plays 为参与统计的最少播放次数
+	r.GET(
+		"/api/play-events/skip-rate", func(c *gin.Context) {
+			ctx := c.Request.Context()
+
+			by := c.DefaultQuery("by", "artist")
+			if by != "artist" && by != "genre" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "by 只支持 artist 或 genre"})
+				return
+			}
+			days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+			minPlays, _ := strconv.Atoi(c.DefaultQuery("min_plays", "3"))
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+			if limit <= 0 || limit > 50 {
+				limit = 10
+			}
+
+			rates, err := model.GetSkipRates(ctx, by, playEventsSince(days), minPlays, limit)
+			if err != nil {
+				log.Error(ctx, "Failed to get skip rates", zap.String("by", by), zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get skip rates"})
+				return
+			}
+			c.JSON(http.StatusOK, rates)
+		},
+	)
+
+	// 获取收听列表（按开始时间倒序，支持分页、按来源过滤），由 dashboard 重型统计划分
+	r.GET(
+		"/api/sessions", func(c *gin.Context) {
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+			offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+			if limit <= 0 || limit > 100 {
+				limit = 20
+			}
+
+			sessions, total, err := model.GetListeningSessions(c.Request.Context(), c.Query("source"), limit, offset)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(
+				http.StatusOK, gin.H{
+					"sessions": sessions,
+					"total":    total,
+					"limit":    limit,
+					"offset":   offset,
+				},
+			)
+		},
+	)
+
+	// 获取收听统计：平均时长与按星期分布
+	r.GET(
+		"/api/sessions/stats", func(c *gin.Context) {
+			ctx := c.Request.Context()
+
+			stats, err := model.GetListeningSessionStatsFromStat(ctx)
+			if err != nil {
+				log.Error(ctx, "Failed to get listening session stats", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get listening session stats"})
+				return
+			}
+			c.JSON(http.StatusOK, stats)
+		},
+	)
+
+	// 获取一次收听及其中的播放记录
+	r.GET(
+		"/api/sessions/:id", func(c *gin.Context) {
+			ctx := c.Request.Context()
+			sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			if err != nil || sessionID <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的收听 ID"})
+				return
+			}
+
+			session, err := model.GetListeningSession(ctx, sessionID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if session == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "收听不存在"})
+				return
+			}
+			records, err := model.GetListeningSessionRecords(ctx, session)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"session": session, "records": records})
+		},
+	)
+
+	// 导出数据集，dataset 可选 track_play_records/track/album/track_insight/track_lyrics
+	// format 可选 csv/ndjson/scrobbler，from/to 为日期范围，source 为来源过滤，结果以游标流式写出
+	r.GET(
+		"/api/export/:dataset", func(c *gin.Context) {
+			ctx := c.Request.Context()
+
+			dataset, err := exporter.ParseDataset(c.Param("dataset"))
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			format, err := exporter.ParseFormat(c.DefaultQuery("format", string(exporter.FormatCSV)))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			filter, err := exporter.NewFilter(c.Query("from"), c.Query("to"), c.Query("source"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.Header("Content-Type", format.ContentType())
+			c.Header("Content-Disposition", "attachment; filename=\""+format.FileName(dataset)+"\"")
+			count, err := exporter.Export(ctx, c.Writer, dataset, format, filter)
+			if err != nil {
+				log.Error(ctx, "Failed to export dataset", zap.String("dataset", string(dataset)), zap.Error(err))
+				// 已开始输出时无法再改写状态码，只能中断响应
+				if !c.Writer.Written() {
+					c.Header("Content-Disposition", "")
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				}
+				return
+			}
+			log.Info(ctx, "Exported dataset", zap.String("dataset", string(dataset)), zap.Int64("count", count))
+		},
+	)
+
+	// 上传 Spotify 扩展收听历史或 Apple Music Play Activity.csv 导入播放记录
+	// format 可选，未指定时按文件名识别
+	r.POST(
+		"/api/import", func(c *gin.Context) {
+			ctx := c.Request.Context()
+
+			fileHeader, err := c.FormFile("file")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "缺少上传文件 file"})
+				return
+			}
+
+			var format importer.Format
+			if formatName := c.PostForm("format"); formatName != "" {
+				format, err = importer.ParseFormat(formatName)
+			} else {
+				format, err = importer.DetectFormat(fileHeader.Filename)
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			file, err := fileHeader.Open()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			defer file.Close()
+
+			result, err := importer.ImportFile(ctx, format, file)
+			response := gin.H{
+				"format":     format,
+				"imported":   result.Imported,
+				"skipped":    result.Skipped(),
+				"duplicates": result.Duplicates,
+				"invalid":    result.Invalid,
+				"filtered":   result.Filtered,
+			}
+			if err != nil {
+				log.Error(ctx, "Failed to import file", zap.String("file", fileHeader.Filename), zap.Error(err))
+				response["error"] = err.Error()
+				c.JSON(http.StatusUnprocessableEntity, response)
+				return
+			}
+			c.JSON(http.StatusOK, response)
+		},
+	)
+
 	// 同步选中的未同步记录到Last.fm
 	r.POST(
 		"/api/unscrobbled-records/sync", func(c *gin.Context) {
@@ -1380,6 +1960,155 @@ func setupRouter(name string) *gin.Engine {
 		},
 	)
 
+	// 播放器列表及运行状态
+	r.GET(
+		"/api/players", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"players": scrobbler.Players()})
+		},
+	)
+
+	// 运行时启用或停用播放器
+	r.POST(
+		"/api/players", func(c *gin.Context) {
+			ctx := c.Request.Context()
+
+			var req struct {
+				Name    string `json:"name"`
+				Enabled *bool  `json:"enabled"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" || req.Enabled == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "name and enabled are required"})
+				return
+			}
+
+			if err := scrobbler.SetPlayerEnabled(ctx, req.Name, *req.Enabled); err != nil {
+				switch {
+				case errors.Is(err, scrobbler.ErrUnknownPlayer):
+					c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				case errors.Is(err, scrobbler.ErrScrobblerNotStarted):
+					c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+				default:
+					log.Error(ctx, "Failed to set player enabled", zap.Error(err))
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set player enabled"})
+				}
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"players": scrobbler.Players()})
+		},
+	)
+
+	// 当前正在播放与各播放器状态，新打开的页面无需等待下一次推送
+	r.GET(
+		"/api/now-playing", func(c *gin.Context) {
+			hub := nowplaying.Default()
+			c.JSON(http.StatusOK, gin.H{"now_playing": hub.Current(), "sources": hub.Sources()})
+		},
+	)
+
+	// 最近的播放状态变化，按时间倒序
+	r.GET(
+		"/api/now-playing/history", func(c *gin.Context) {
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+			c.JSON(http.StatusOK, gin.H{"history": nowplaying.Default().History(limit)})
+		},
+	)
+
+	// 正在播放推送 (SSE)，连接后先发送当前曲目，消息格式与 /ws 一致
+	r.GET(
+		"/api/now-playing/stream", func(c *gin.Context) {
+			hub := nowplaying.Default()
+			events, cancel := hub.Subscribe()
+			defer cancel()
+
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+
+			if current := hub.Current(); current != nil {
+				c.Render(-1, sse.Event{Event: current.Type, Data: current})
+				c.Writer.Flush()
+			}
+			c.Stream(
+				func(w io.Writer) bool {
+					select {
+					case event, ok := <-events:
+						if !ok {
+							return false
+						}
+						c.Render(-1, sse.Event{Event: event.Message.Type, Data: event.Message})
+						return true
+					case <-c.Request.Context().Done():
+						return false
+					}
+				},
+			)
+		},
+	)
+
+	// Last.fm 喜欢列表与本地喜欢标记对账预演，policy 缺省时使用配置
+	r.GET(
+		"/api/favorites/reconcile", func(c *gin.Context) {
+			reconcileFavorites(c, true)
+		},
+	)
+
+	// 执行对账
+	r.POST(
+		"/api/favorites/reconcile", func(c *gin.Context) {
+			reconcileFavorites(c, false)
+		},
+	)
+
+	// 接收 Jellyfin、Plex、Web Scrobbler 的 webhook 推送
+	r.POST(
+		"/api/ingest/:source", func(c *gin.Context) {
+			ctx := c.Request.Context()
+
+			endpoint, ok := ingest.Lookup(c.Param("source"))
+			if !ok || !endpoint.Enabled(config.ConfigObj.Ingest) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Unknown ingest source"})
+				return
+			}
+			if !endpoint.Authorize(config.ConfigObj.Ingest, c.Request) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid secret"})
+				return
+			}
+
+			event, err := endpoint.Parse(c.Request)
+			if errors.Is(err, ingest.ErrIgnored) {
+				c.JSON(http.StatusAccepted, gin.H{"status": "ignored"})
+				return
+			}
+			if err != nil {
+				log.Warn(ctx, "Failed to parse ingest payload", zap.String("source", endpoint.Name), zap.Error(err))
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			if err := scrobbler.Ingest(endpoint.Player, event); err != nil {
+				if errors.Is(err, scrobbler.ErrPlayerDisabled) || errors.Is(err, scrobbler.ErrScrobblerNotStarted) {
+					c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+					return
+				}
+				log.Error(ctx, "Failed to ingest play event", zap.String("source", endpoint.Name), zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ingest play event"})
+				return
+			}
+			c.JSON(http.StatusAccepted, gin.H{"status": "accepted", "state": event.State})
+		},
+	)
+
+	// Last.fm 2.0 兼容接口，供支持自定义 API 地址的 scrobbler 客户端提交播放记录
+	audioScrobblerServer := gin.WrapH(audioscrobbler.NewServer(config.ConfigObj.AudioScrobbler))
+	r.GET("/2.0/", audioScrobblerServer)
+	r.POST("/2.0/", audioScrobblerServer)
+
+	// ListenBrainz 兼容接口，客户端以 sonic-lens token create 签发的 token 提交收听
+	listenBrainzServer := listenbrainzapi.NewServer()
+	r.POST("/1/submit-listens", gin.WrapF(listenBrainzServer.SubmitListens))
+	r.GET("/1/validate-token", gin.WrapF(listenBrainzServer.ValidateToken))
+
 	// WebSocket endpoint
 	r.GET(
 		"/ws", func(c *gin.Context) {
@@ -1392,6 +2121,10 @@ func setupRouter(name string) *gin.Engine {
 
 			// 添加连接到连接池
 			websocket.AddClient(conn)
+			// 先发送当前曲目，不必等待下一次推送
+			if current := nowplaying.Default().Current(); current != nil {
+				websocket.SendMessage(c.Request.Context(), conn, current)
+			}
 
 			// 启动goroutine处理WebSocket消息
 			go websocket.HandleWebSocketMessages(conn)
@@ -1434,3 +2167,118 @@ func FillInTrendCycle(rangeDays int) []string {
 	rangeDayList = append(rangeDayList, now.AddDate(0, 0, 1).Format("2006-01-02"))
 	return rangeDayList
 }
+
+// playEventsSince days 小于等于 0 时返回零值，表示统计全部播放
+func playEventsSince(days int) time.Time {
+	if days <= 0 {
+		return time.Time{}
+	}
+	return time.Now().AddDate(0, 0, -days)
+}
+
+// reconcileFavorites 按 query 中的 policy（缺省取配置）对账喜欢状态，dryRun 时只返回差异与计划的动作
+func reconcileFavorites(c *gin.Context, dryRun bool) {
+	ctx := c.Request.Context()
+	policy, err := favorite.ParsePolicy(c.DefaultQuery("policy", config.ConfigObj.Favorites.Reconcile.Policy))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := favorite.NewReconciler(config.ConfigObj.Lastfm.UserUsername).Reconcile(ctx, policy, dryRun)
+	if err != nil {
+		log.Error(ctx, "Failed to reconcile favorites", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile favorites"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// listFilter 解析 /api/tracks 与 /api/albums 的 keyword、tag 与 min_rating
+func listFilter(c *gin.Context) model.ListFilter {
+	minRating, _ := strconv.Atoi(c.Query("min_rating"))
+	return model.ListFilter{Keyword: c.Query("keyword"), Tag: c.Query("tag"), MinRating: minRating}
+}
+
+// respondTagError 参数错误返回 400，对象不存在返回 404
+func respondTagError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidRating), errors.Is(err, model.ErrInvalidTagTarget), errors.Is(err, model.ErrInvalidTag):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		log.Error(c.Request.Context(), "Failed to update rating or tags", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// respondTrackAliasError 参数错误返回 400，曲目或别名不存在返回 404
+func respondTrackAliasError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrMergeSameTrack), errors.Is(err, model.ErrInvalidAlias):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		log.Error(c.Request.Context(), "Failed to merge tracks", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// bindRewriteRule 解析并校验改写规则，enabled 缺省为 true；失败时已写入 400
+func bindRewriteRule(c *gin.Context) (*model.RewriteRule, bool) {
+	var req struct {
+		Name        string               `json:"name"`
+		Enabled     *bool                `json:"enabled"`
+		Priority    int                  `json:"priority"`
+		MatchSource string               `json:"match_source"`
+		MatchArtist string               `json:"match_artist"`
+		MatchAlbum  string               `json:"match_album"`
+		MatchTitle  string               `json:"match_title"`
+		Actions     model.RewriteActions `json:"actions"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	rule := &model.RewriteRule{
+		Name:        strings.TrimSpace(req.Name),
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		Priority:    req.Priority,
+		MatchSource: req.MatchSource,
+		MatchArtist: req.MatchArtist,
+		MatchAlbum:  req.MatchAlbum,
+		MatchTitle:  req.MatchTitle,
+		Actions:     req.Actions,
+	}
+	if err := rewrite.Validate(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return rule, true
+}
+
+// applyRewriteRules 按 query 中的 rule_id 与 limit 改写已有曲目，dryRun 时只返回差异
+func applyRewriteRules(c *gin.Context, dryRun bool) {
+	ruleID, _ := strconv.ParseInt(c.Query("rule_id"), 10, 64)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	report, err := rewrite.ApplyHistory(c.Request.Context(), ruleID, limit, dryRun)
+	if err != nil {
+		respondRewriteRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// respondRewriteRuleError 规则无效返回 400，规则不存在返回 404
+func respondRewriteRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rewrite.ErrInvalidRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		log.Error(c.Request.Context(), "Failed to handle rewrite rules", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -41,6 +41,14 @@ CREATE TABLE IF NOT EXISTS tracks
     INTEGER
     DEFAULT
     0,
+    rating
+    INTEGER
+    DEFAULT
+    0,
+    tags
+    TEXT
+    DEFAULT
+    '[]',
     created_at
     TEXT
     NOT
@@ -231,6 +239,54 @@ CREATE TABLE IF NOT EXISTS play_trend_hourly_stat
 );
 CREATE INDEX IF NOT EXISTS idx_play_trend_hourly_date ON play_trend_hourly_stat(stat_date);
 
+-- 已有库补充曲目评分与标签列：
+-- ALTER TABLE tracks ADD COLUMN rating INTEGER DEFAULT 0;
+-- ALTER TABLE tracks ADD COLUMN tags TEXT DEFAULT '[]';
+
+-- 专辑评分与标签，tags 为 JSON 数组
+CREATE TABLE IF NOT EXISTS albums
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    name         TEXT    NOT NULL,
+    artist       TEXT    NOT NULL,
+    release_date TEXT    DEFAULT '',
+    rating       INTEGER DEFAULT 0,
+    tags         TEXT    DEFAULT '[]',
+    created_at   TEXT    NOT NULL,
+    updated_at   TEXT    NOT NULL,
+    UNIQUE (name, artist, release_date)
+);
+CREATE INDEX IF NOT EXISTS idx_albums_rating ON albums(rating DESC);
+
+-- 评分最高的曲目与专辑
+CREATE TABLE IF NOT EXISTS top_rated_stat
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    target_type TEXT    NOT NULL, -- track|album
+    artist      TEXT    NOT NULL,
+    album       TEXT    NOT NULL,
+    track       TEXT    DEFAULT '',
+    rating      INTEGER NOT NULL,
+    play_count  INTEGER DEFAULT 0,
+    rank        INTEGER NOT NULL,
+    updated_at  TEXT    NOT NULL,
+    UNIQUE (target_type, rank)
+);
+
+-- 按标签汇总
+CREATE TABLE IF NOT EXISTS tag_stat
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    target_type TEXT    NOT NULL, -- track|album
+    tag         TEXT    NOT NULL,
+    item_count  INTEGER DEFAULT 0,
+    play_count  INTEGER DEFAULT 0,
+    avg_rating  REAL    DEFAULT 0,
+    rank        INTEGER NOT NULL,
+    updated_at  TEXT    NOT NULL,
+    UNIQUE (target_type, tag)
+);
+
 -- 同步元数据表 (记录最后同步时间)
 CREATE TABLE IF NOT EXISTS sync_metadata
 (
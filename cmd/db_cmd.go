@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/internal/dbcopy"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// NewDBCommand returns the db command, which manages the database
+func NewDBCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "db",
+		Short: "数据库迁移与维护",
+	}
+	command.AddCommand(newDBMigrateCommand())
+	command.AddCommand(newDBStatusCommand(), newDBUpCommand(), newDBDownCommand())
+	return command
+}
+
+// addSchemaFlags 结构迁移命令默认使用配置文件中的数据库，也可以用 --database 指定
+func addSchemaFlags(command *cobra.Command) {
+	command.Flags().StringP("config", "c", "config/config.yaml", "config file")
+	command.Flags().String("database", "", "sqlite:<path> or mysql:<dsn>, overrides the database in config")
+}
+
+func newDBStatusCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "status",
+		Short: "列出结构迁移版本及执行状态",
+		RunE:  schemaStatus,
+	}
+	addSchemaFlags(command)
+	return command
+}
+
+func newDBUpCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "up",
+		Short: "执行未执行的结构迁移",
+		RunE:  schemaUp,
+	}
+	addSchemaFlags(command)
+	command.Flags().Int64("to", 0, "migrate up to this version, defaults to the latest")
+	return command
+}
+
+func newDBDownCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "down",
+		Short: "回滚最近执行的结构迁移",
+		RunE:  schemaDown,
+	}
+	addSchemaFlags(command)
+	command.Flags().Int("steps", 1, "number of migrations to roll back")
+	return command
+}
+
+func schemaStatus(cmd *cobra.Command, args []string) error {
+	gormDB, err := openSchemaDB(cmd)
+	if err != nil {
+		return err
+	}
+	states, err := model.MigrationStatus(cmd.Context(), gormDB)
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		status := "pending"
+		if state.Applied {
+			status = "applied " + state.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Printf("%4d  %-40s %s\n", state.Version, state.Description, status)
+	}
+	return nil
+}
+
+func schemaUp(cmd *cobra.Command, args []string) error {
+	gormDB, err := openSchemaDB(cmd)
+	if err != nil {
+		return err
+	}
+	target, _ := cmd.Flags().GetInt64("to")
+	done, err := model.MigrateUp(cmd.Context(), gormDB, target)
+	for _, m := range done {
+		fmt.Printf("applied %d  %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		fmt.Println("Database is up to date")
+	}
+	return nil
+}
+
+func schemaDown(cmd *cobra.Command, args []string) error {
+	gormDB, err := openSchemaDB(cmd)
+	if err != nil {
+		return err
+	}
+	steps, _ := cmd.Flags().GetInt("steps")
+	done, err := model.MigrateDown(cmd.Context(), gormDB, steps)
+	for _, m := range done {
+		fmt.Printf("rolled back %d  %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		fmt.Println("No applied migrations to roll back")
+	}
+	return nil
+}
+
+// openSchemaDB 打开数据库但不执行迁移，InitDB 会对 SQLite 自动执行迁移，因此这里不能复用
+func openSchemaDB(cmd *cobra.Command) (*gorm.DB, error) {
+	if database, _ := cmd.Flags().GetString("database"); database != "" {
+		return openDatabaseURL(database)
+	}
+	configFile, _ := cmd.Flags().GetString("config")
+	config.InitConfig(configFile)
+	dbConfig := config.ConfigObj.Database
+	if dbConfig.Type == string(common.DatabaseTypeMySQL) {
+		return model.OpenDB(dbConfig.Type, dbConfig.Mysql.GetMysqlDSN(), zap.NewNop())
+	}
+	return model.OpenDB(dbConfig.Type, dbConfig.Path, zap.NewNop())
+}
+
+func newDBMigrateCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "migrate",
+		Short: "在 SQLite 与 MySQL 之间复制全部数据并保留 ID，中断后再次运行会继续复制",
+		Example: `  sonic-lens db migrate --from sqlite:sonic-lens.db --to "mysql:user:pass@tcp(127.0.0.1:3306)/sonic_lens"
+  sonic-lens db migrate --from "mysql:user:pass@tcp(127.0.0.1:3306)/sonic_lens" --to sqlite:backup.db`,
+		RunE: migrateDB,
+	}
+	command.Flags().String("from", "", "source database, sqlite:<path> or mysql:<dsn>")
+	command.Flags().String("to", "", "target database, sqlite:<path> or mysql:<dsn>")
+	command.Flags().Int("batch-size", dbcopy.DefaultBatchSize, "rows per batch")
+	_ = command.MarkFlagRequired("from")
+	_ = command.MarkFlagRequired("to")
+	return command
+}
+
+func migrateDB(cmd *cobra.Command, args []string) error {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	if from == to {
+		return errors.New("--from and --to must be different databases")
+	}
+
+	src, err := openDatabaseURL(from)
+	if err != nil {
+		return fmt.Errorf("open source database: %w", err)
+	}
+	dst, err := openDatabaseURL(to)
+	if err != nil {
+		return fmt.Errorf("open target database: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	results, err := dbcopy.NewCopier(src, dst, batchSize).Run(
+		ctx, func(table string, copied int64) {
+			fmt.Printf("%s: copied %d rows\n", table, copied)
+		},
+	)
+	for _, result := range results {
+		if result.Missing {
+			fmt.Printf("%-24s missing in source, skipped\n", result.Table)
+			continue
+		}
+		fmt.Printf("%-24s source %d, target %d (copied %d)\n", result.Table, result.Source, result.Target, result.Copied)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate interrupted, run again to resume: %w", err)
+	}
+	fmt.Println("Migration completed, row counts match")
+	return nil
+}
+
+// openDatabaseURL 解析 sqlite:<path> 或 mysql:<dsn> 形式的地址并打开连接
+func openDatabaseURL(url string) (*gorm.DB, error) {
+	dbType, dsn, ok := strings.Cut(url, ":")
+	if !ok || dsn == "" {
+		return nil, fmt.Errorf("invalid database %q, expected sqlite:<path> or mysql:<dsn>", url)
+	}
+	if dbType == string(common.DatabaseTypeMySQL) && !strings.Contains(dsn, "parseTime=") {
+		// 时间列需要解析为 time.Time 才能写入 SQLite
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + "parseTime=True&loc=Local"
+	}
+	return model.OpenDB(dbType, dsn, zap.NewNop())
+}
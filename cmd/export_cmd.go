@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vincentchyu/sonic-lens/internal/exporter"
+)
+
+// NewExportCommand returns the export command, which writes a dataset to a file
+func NewExportCommand() *cobra.Command {
+	datasets := make([]string, 0, len(exporter.Datasets))
+	for _, dataset := range exporter.Datasets {
+		datasets = append(datasets, string(dataset))
+	}
+	command := &cobra.Command{
+		Use:   "export <dataset>",
+		Short: "导出收听数据，dataset 可选 " + strings.Join(datasets, ", "),
+		Args:  cobra.ExactArgs(1),
+		RunE:  exportDataset,
+	}
+	command.Flags().StringP("config", "c", "config/config.yaml", "config file")
+	command.Flags().StringP("format", "f", "csv", "csv, ndjson or scrobbler (.scrobbler.log, track_play_records only)")
+	command.Flags().StringP("output", "o", "", "output file, defaults to <dataset>.<ext> in the current directory")
+	command.Flags().String("from", "", "start date (inclusive), 2006-01-02 or RFC3339")
+	command.Flags().String("to", "", "end date (inclusive for dates, exclusive for RFC3339 times)")
+	command.Flags().String("source", "", "only export rows from this source, e.g. Apple Music")
+	return command
+}
+
+func exportDataset(cmd *cobra.Command, args []string) error {
+	dataset, err := exporter.ParseDataset(args[0])
+	if err != nil {
+		return err
+	}
+	formatName, _ := cmd.Flags().GetString("format")
+	format, err := exporter.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	source, _ := cmd.Flags().GetString("source")
+	filter, err := exporter.NewFilter(from, to, source)
+	if err != nil {
+		return err
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		output = format.FileName(dataset)
+	}
+
+	if err := initCommandEnv(cmd); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	count, err := exporter.Export(ctx, file, dataset, format, filter)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", dataset, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d rows of %s to %s\n", count, dataset, output)
+	return nil
+}
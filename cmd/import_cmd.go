@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/importer"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// NewImportCommand returns the import command, which imports listening history from other services
+func NewImportCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "import",
+		Short: "导入外部收听历史到 track_play_records",
+	}
+	command.PersistentFlags().StringP("config", "c", "config/config.yaml", "config file")
+
+	command.AddCommand(newImportLastfmCommand())
+	command.AddCommand(newImportFileCommand())
+	return command
+}
+
+func newImportFileCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "file [files...]",
+		Short: "导入 Spotify 扩展收听历史(endsong_*.json/Streaming_History_Audio_*.json)或 Apple Music Play Activity.csv",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  importFiles,
+	}
+	command.Flags().String("format", "", "spotify, streaming_history or apple; detected from the file name when empty")
+	return command
+}
+
+func newImportLastfmCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "lastfm",
+		Short: "通过 user.getRecentTracks 导入 Last.fm 收听历史，中断后再次运行会从断点继续",
+		RunE:  importLastfm,
+	}
+	command.Flags().StringP("user", "u", "", "Last.fm username, defaults to lastfm.userUsername in config")
+	command.Flags().Int("page-size", lastfm.RecentTracksMaxLimit, "records per page (max 200)")
+	command.Flags().Bool("reset", false, "discard the checkpoint and import the full history again")
+	return command
+}
+
+// initCommandEnv 初始化导入、导出命令所需的配置、日志与数据库
+func initCommandEnv(cmd *cobra.Command) error {
+	configFile, _ := cmd.Flags().GetString("config")
+	config.InitConfig(configFile)
+	logger, _ := log.LogInit(config.ConfigObj.Log.Path, config.ConfigObj.Log.Level, nil)
+	if err := model.InitDB(config.ConfigObj.Database.Path, logger); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return nil
+}
+
+func importFiles(cmd *cobra.Command, args []string) error {
+	if err := initCommandEnv(cmd); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	formatName, _ := cmd.Flags().GetString("format")
+	var total importer.Result
+	for _, path := range args {
+		format, err := importer.DetectFormat(path)
+		if formatName != "" {
+			format, err = importer.ParseFormat(formatName)
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err := importFile(ctx, format, path)
+		fmt.Printf(
+			"%s (%s): imported %d, duplicates %d, invalid %d, filtered %d\n",
+			path, format, result.Imported, result.Duplicates, result.Invalid, result.Filtered,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+		total.Imported += result.Imported
+		total.Duplicates += result.Duplicates
+		total.Invalid += result.Invalid
+		total.Filtered += result.Filtered
+	}
+	if len(args) > 1 {
+		fmt.Printf(
+			"Total: imported %d, skipped %d (duplicates %d, invalid %d, filtered %d)\n",
+			total.Imported, total.Skipped(), total.Duplicates, total.Invalid, total.Filtered,
+		)
+	}
+	return nil
+}
+
+func importFile(ctx context.Context, format importer.Format, path string) (importer.Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return importer.Result{}, err
+	}
+	defer file.Close()
+	return importer.ImportFile(ctx, format, file)
+}
+
+func importLastfm(cmd *cobra.Command, args []string) error {
+	if err := initCommandEnv(cmd); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	user, _ := cmd.Flags().GetString("user")
+	if user == "" {
+		user = config.ConfigObj.Lastfm.UserUsername
+	}
+	if user == "" {
+		return fmt.Errorf("last.fm user is required, use --user or set lastfm.userUsername")
+	}
+	pageSize, _ := cmd.Flags().GetInt("page-size")
+
+	client := lastfm.NewRecentTracksClient("", config.ConfigObj.Lastfm.ApiKey)
+	lastfmImporter := importer.NewLastfmImporter(client, user, pageSize)
+	if reset, _ := cmd.Flags().GetBool("reset"); reset {
+		if err := model.DeleteImportCheckpoint(ctx, lastfmImporter.CheckpointSource()); err != nil {
+			return fmt.Errorf("failed to reset checkpoint: %w", err)
+		}
+	}
+
+	fmt.Printf("Importing Last.fm history of %s...\n", user)
+	result, err := lastfmImporter.Run(
+		ctx, func(page, totalPages int, total importer.Result) {
+			fmt.Printf(
+				"page %d/%d: imported %d, duplicates %d, invalid %d\n",
+				page, totalPages, total.Imported, total.Duplicates, total.Invalid,
+			)
+		},
+	)
+	fmt.Printf(
+		"Imported %d records, skipped %d duplicates and %d records missing artist/album/track\n",
+		result.Imported, result.Duplicates, result.Invalid,
+	)
+	if err != nil {
+		return fmt.Errorf("import interrupted, run again to resume: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/vincentchyu/sonic-lens/internal/listenbrainzapi"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// NewTokenCommand returns the token command, which manages ListenBrainz-compatible client tokens
+func NewTokenCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "token",
+		Short: "管理 ListenBrainz 兼容接口的客户端 token",
+	}
+	command.PersistentFlags().StringP("config", "c", "config/config.yaml", "config file")
+
+	command.AddCommand(
+		&cobra.Command{
+			Use:   "create <name>",
+			Short: "为客户端签发 token，name 同时作为播放记录的 source",
+			Args:  cobra.ExactArgs(1),
+			RunE:  createToken,
+		},
+		&cobra.Command{
+			Use:   "list",
+			Short: "列出已签发的 token",
+			Args:  cobra.NoArgs,
+			RunE:  listTokens,
+		},
+		&cobra.Command{
+			Use:   "revoke <name>",
+			Short: "吊销客户端 token",
+			Args:  cobra.ExactArgs(1),
+			RunE:  revokeToken,
+		},
+	)
+	return command
+}
+
+func createToken(cmd *cobra.Command, args []string) error {
+	if err := initCommandEnv(cmd); err != nil {
+		return err
+	}
+	token, err := listenbrainzapi.CreateToken(cmd.Context(), args[0])
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("token for client %q already exists, revoke it first", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\t%s\n", token.Name, token.Token)
+	return nil
+}
+
+func listTokens(cmd *cobra.Command, args []string) error {
+	if err := initCommandEnv(cmd); err != nil {
+		return err
+	}
+	tokens, err := model.ListListenTokens(cmd.Context())
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTOKEN\tCREATED\tLAST USED")
+	for _, token := range tokens {
+		lastUsed := "-"
+		if token.LastUsedAt != nil {
+			lastUsed = token.LastUsedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", token.Name, token.Token, token.CreatedAt.Format("2006-01-02 15:04:05"), lastUsed)
+	}
+	return w.Flush()
+}
+
+func revokeToken(cmd *cobra.Command, args []string) error {
+	if err := initCommandEnv(cmd); err != nil {
+		return err
+	}
+	deleted, err := model.DeleteListenToken(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("no token for client %q", args[0])
+	}
+	fmt.Printf("revoked token for %s\n", args[0])
+	return nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/scrobbler"
+)
+
+// NewTraceCommand returns the trace command, which records player samples for replaying the scrobble pipeline
+func NewTraceCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "trace",
+		Short: "录制播放器采样轨迹，用于回放标记流程",
+	}
+	command.PersistentFlags().StringP("config", "c", "config/config.yaml", "config file")
+
+	record := &cobra.Command{
+		Use:   "record <player>",
+		Short: "按间隔采样播放器状态、曲目与进度并写入 JSONL，Ctrl+C 结束",
+		Args:  cobra.ExactArgs(1),
+		RunE:  recordTrace,
+	}
+	record.Flags().StringP("output", "o", "", "output file, defaults to stdout")
+	record.Flags().Duration("interval", time.Second, "sampling interval")
+	record.Flags().Duration("duration", 0, "stop after this long, 0 records until interrupted")
+	command.AddCommand(record)
+	return command
+}
+
+func recordTrace(cmd *cobra.Command, args []string) error {
+	configFile, _ := cmd.Flags().GetString("config")
+	config.InitConfig(configFile)
+	log.LogInit(config.ConfigObj.Log.Path, config.ConfigObj.Log.Level, nil)
+
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	controller, err := scrobbler.NewPlayerController(common.PlayerType(args[0]), config.ConfigObj)
+	if err != nil {
+		return fmt.Errorf("%w, registered players: %v", err, scrobbler.RegisteredPlayers())
+	}
+
+	var w io.Writer = os.Stdout
+	if output, _ := cmd.Flags().GetString("output"); output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if duration, _ := cmd.Flags().GetDuration("duration"); duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+	return scrobbler.RecordTrace(ctx, controller, w, interval)
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// featPattern 匹配 feat. / ft. / featuring，可带左括号，如 "A feat. B"、"Song (ft. B)"
+	featPattern = regexp.MustCompile(`(?i)\s*[(\[]?\s*\b(?:feat\.|ft\.|feat\s|featuring\s)\s*`)
+	// titleFeatPattern 曲名中只识别括号内的 feat.，如 "Song (feat. B)"
+	titleFeatPattern = regexp.MustCompile(`(?i)\s*[(\[]\s*(?:feat\.|ft\.|feat\s|featuring\s)\s*`)
+)
+
+// SplitArtistCredit 把艺术家署名按 feat. 拆分为主艺术家与合作艺术家，两部分再按 separators 拆分多位艺术家
+//
+// A feat. B => [A] [B]
+// Earth, Wind & Fire => [Earth, Wind & Fire]
+// A & B feat. C, separators [&] => [A B] [C]
+//
+// separators 只包含调用方明确知道用于分隔多位艺术家的写法（如播放器自己的列表格式），为空时不拆分，
+// 以免拆开 "Earth, Wind & Fire" 这类名字本身带分隔符的乐队；keep 返回 true 的片段不再拆分，可为 nil
+func SplitArtistCredit(credit string, separators []string, keep func(name string) bool) (primary, featured []string) {
+	credit = strings.TrimSpace(credit)
+	if credit == "" {
+		return nil, nil
+	}
+	if loc := featPattern.FindStringIndex(credit); loc != nil && loc[0] > 0 {
+		return SplitArtistNames(credit[:loc[0]], separators, keep),
+			SplitArtistNames(trimCreditBrackets(credit[loc[1]:]), separators, keep)
+	}
+	return SplitArtistNames(credit, separators, keep), nil
+}
+
+// SplitArtistNames 按 separators 拆分多个艺术家（作曲、专辑艺术家等），去除空白与重复；separators 为空时保留完整名字
+func SplitArtistNames(names string, separators []string, keep func(name string) bool) []string {
+	names = strings.TrimSpace(names)
+	if names == "" {
+		return nil
+	}
+	if keep != nil && keep(names) {
+		return []string{names}
+	}
+	parts := []string{names}
+	for _, separator := range separators {
+		var next []string
+		for _, part := range parts {
+			next = append(next, strings.Split(part, separator)...)
+		}
+		parts = next
+	}
+	var result []string
+	seen := make(map[string]struct{})
+	for _, name := range parts {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if _, ok := seen[key]; ok || name == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, name)
+	}
+	return result
+}
+
+// TitleFeaturedArtists 提取曲名中 (feat. X) 的合作艺术家，separators 与 keep 同 SplitArtistNames
+//
+// Hikky Burr (feat. Bill Cosby) => [Bill Cosby]
+func TitleFeaturedArtists(title string, separators []string, keep func(name string) bool) []string {
+	loc := titleFeatPattern.FindStringIndex(title)
+	if loc == nil {
+		return nil
+	}
+	rest := title[loc[1]:]
+	// 只取到配对的右括号为止，之后可能还有 (Remastered) 等后缀
+	if end := strings.IndexAny(rest, ")]"); end >= 0 {
+		rest = rest[:end]
+	}
+	return SplitArtistNames(rest, separators, keep)
+}
+
+// IsFeatJoinPhrase 判断 MusicBrainz artist-credit 的 joinphrase 是否表示之后为合作艺术家
+func IsFeatJoinPhrase(joinPhrase string) bool {
+	return featPattern.MatchString(" " + strings.TrimSpace(joinPhrase) + " ")
+}
+
+func trimCreditBrackets(s string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), ")]"))
+}
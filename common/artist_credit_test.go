@@ -0,0 +1,55 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitArtistCredit(t *testing.T) {
+	testCases := []struct {
+		credit            string
+		separators        []string
+		primary, featured []string
+	}{
+		{"Daft Punk", nil, []string{"Daft Punk"}, nil},
+		{"Daft Punk feat. Pharrell Williams", nil, []string{"Daft Punk"}, []string{"Pharrell Williams"}},
+		{"A (Ft. B)", nil, []string{"A"}, []string{"B"}},
+		{"Featurecast", nil, []string{"Featurecast"}, nil},
+		{"  ", nil, nil, nil},
+		// 默认不按 , & 拆分，名字本身带分隔符的乐队保持完整
+		{"Earth, Wind & Fire", nil, []string{"Earth, Wind & Fire"}, nil},
+		{"Crosby, Stills, Nash & Young", nil, []string{"Crosby, Stills, Nash & Young"}, nil},
+		{"Simon & Garfunkel feat. Art & Paul", nil, []string{"Simon & Garfunkel"}, []string{"Art & Paul"}},
+		// 调用方明确允许的分隔符才拆分
+		{"Roger Waters, David Gilmour, roger waters", []string{","}, []string{"Roger Waters", "David Gilmour"}, nil},
+		{"Jay-Z & Kanye West feat. Frank Ocean", []string{"&"}, []string{"Jay-Z", "Kanye West"}, []string{"Frank Ocean"}},
+		{"周杰伦、费玉清", []string{"、"}, []string{"周杰伦", "费玉清"}, nil},
+	}
+	for _, tc := range testCases {
+		primary, featured := SplitArtistCredit(tc.credit, tc.separators, nil)
+		assert.Equal(t, tc.primary, primary, tc.credit)
+		assert.Equal(t, tc.featured, featured, tc.credit)
+	}
+
+	keep := func(name string) bool { return name == "Earth, Wind & Fire" }
+	assert.Equal(t, []string{"Earth, Wind & Fire"}, SplitArtistNames("Earth, Wind & Fire", []string{",", "&"}, keep))
+	assert.Equal(t, []string{"Earth", "Wind", "Fire"}, SplitArtistNames("Earth, Wind & Fire", []string{",", "&"}, nil))
+}
+
+func TestTitleFeaturedArtists(t *testing.T) {
+	assert.Equal(t, []string{"Bill Cosby"}, TitleFeaturedArtists("Hikky Burr (feat. Bill Cosby)", nil, nil))
+	assert.Equal(t, []string{"Jukka Ahonen"}, TitleFeaturedArtists("太阳(feat.Jukka Ahonen)", nil, nil))
+	assert.Equal(t, []string{"Earth, Wind & Fire"}, TitleFeaturedArtists("Song [ft. Earth, Wind & Fire] (Remastered)", nil, nil))
+	assert.Equal(t, []string{"B", "C"}, TitleFeaturedArtists("Song (ft. B & C)", []string{"&"}, nil))
+	assert.Nil(t, TitleFeaturedArtists("Let it be (defeating)", nil, nil))
+	assert.Nil(t, TitleFeaturedArtists("No feat here", nil, nil))
+}
+
+func TestIsFeatJoinPhrase(t *testing.T) {
+	assert.True(t, IsFeatJoinPhrase(" feat. "))
+	assert.True(t, IsFeatJoinPhrase(" ft. "))
+	assert.True(t, IsFeatJoinPhrase(" featuring "))
+	assert.False(t, IsFeatJoinPhrase(" & "))
+	assert.False(t, IsFeatJoinPhrase(""))
+}
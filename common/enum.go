@@ -24,9 +24,14 @@ const (
 type PlayerType string
 
 const (
-	PlayerAudirvana  PlayerType = "Audirvana"
-	PlayerRoon       PlayerType = "Roon"
-	PlayerAppleMusic PlayerType = "Apple Music"
+	PlayerAudirvana    PlayerType = "Audirvana"
+	PlayerRoon         PlayerType = "Roon"
+	PlayerAppleMusic   PlayerType = "Apple Music"
+	PlayerMpris        PlayerType = "MPRIS"         // Linux 会话总线上的 org.mpris.MediaPlayer2.* 播放器
+	PlayerMpd          PlayerType = "MPD"           // Music Player Daemon
+	PlayerJellyfin     PlayerType = "Jellyfin"      // 通过 /api/ingest/jellyfin 推送
+	PlayerPlex         PlayerType = "Plex"          // 通过 /api/ingest/plex 推送
+	PlayerWebScrobbler PlayerType = "Web Scrobbler" // 通过 /api/ingest/webscrobbler 推送
 )
 
 // DatabaseType 定义数据库类型
@@ -9,18 +9,27 @@ import (
 var ConfigObj = &Config{}
 
 type Config struct {
-	Lastfm     ScrobblerConfig  `yaml:"lastfm"`
-	Musixmatch MusixmatchConfig `yaml:"musixmatch"`
-	Log        LogConfig        `yaml:"log"`
-	Database   DatabaseConfig   `yaml:"database"`
-	Dashboard  DashboardConfig  `yaml:"dashboard"`
-	HTTP       HTTPConfig       `yaml:"http"`
-	Telemetry  TelemetryConfig  `yaml:"telemetry"`
-	Redis      RedisConfig      `yaml:"redis"`
-	Cloudflare CloudflareConfig `yaml:"cloudflare"`
-	AI         AIConfig         `yaml:"ai"`
-	Scrobblers []string         `yaml:"scrobblers"`
-	IsDev      bool             `yaml:"isDev"`
+	Lastfm         ScrobblerConfig      `yaml:"lastfm"`
+	ListenBrainz   ListenBrainzConfig   `yaml:"listenbrainz"`
+	Musixmatch     MusixmatchConfig     `yaml:"musixmatch"`
+	Log            LogConfig            `yaml:"log"`
+	Database       DatabaseConfig       `yaml:"database"`
+	Dashboard      DashboardConfig      `yaml:"dashboard"`
+	HTTP           HTTPConfig           `yaml:"http"`
+	Telemetry      TelemetryConfig      `yaml:"telemetry"`
+	Redis          RedisConfig          `yaml:"redis"`
+	Cloudflare     CloudflareConfig     `yaml:"cloudflare"`
+	AI             AIConfig             `yaml:"ai"`
+	Mpris          MprisConfig          `yaml:"mpris"`
+	Mpd            MpdConfig            `yaml:"mpd"`
+	Ingest         IngestConfig         `yaml:"ingest"`
+	AudioScrobbler AudioScrobblerConfig `yaml:"audioscrobbler"`
+	Scrobble       ScrobbleConfig       `yaml:"scrobble"`
+	NowPlaying     NowPlayingConfig     `yaml:"nowPlaying"`
+	Favorites      FavoritesConfig      `yaml:"favorites"`
+	Scrobblers     []string             `yaml:"scrobblers"`
+	PlayerPriority []string             `yaml:"playerPriority"` // 多个播放器同时播放时持有"正在播放"的优先顺序，越靠前越优先
+	IsDev          bool                 `yaml:"isDev"`
 }
 
 type ScrobblerConfig struct {
@@ -33,6 +42,89 @@ type ScrobblerConfig struct {
 	UserPassword    string `yaml:"userPassword"`
 }
 
+// ListenBrainzConfig ListenBrainz 上报配置，token 为空时不启用
+type ListenBrainzConfig struct {
+	Token   string `yaml:"token"`   // 用户 token，见 https://listenbrainz.org/settings/
+	BaseURL string `yaml:"baseUrl"` // API 地址，默认 https://api.listenbrainz.org，自建实例可修改
+}
+
+// MprisConfig Linux MPRIS 播放器配置
+// players 为空时监听所有 org.mpris.MediaPlayer2.* 播放器，否则仅监听列出的播放器（如 spotify、vlc）
+type MprisConfig struct {
+	Players []string `yaml:"players"`
+}
+
+// MpdConfig Music Player Daemon 连接配置
+type MpdConfig struct {
+	Address  string `yaml:"address"`  // host:port 或 unix socket 路径，默认 localhost:6600
+	Password string `yaml:"password"` // 未设置 MPD password 时留空
+}
+
+// IngestConfig webhook 接入配置，secret 为空的端点不接收请求
+type IngestConfig struct {
+	Jellyfin     IngestEndpointConfig `yaml:"jellyfin"`
+	Plex         IngestEndpointConfig `yaml:"plex"`
+	WebScrobbler IngestEndpointConfig `yaml:"webScrobbler"`
+}
+
+// IngestEndpointConfig 单个 webhook 端点配置
+type IngestEndpointConfig struct {
+	Secret string `yaml:"secret"` // 请求需通过 ?secret=、X-Webhook-Secret 或 Authorization: Bearer 携带
+}
+
+// AudioScrobblerConfig Last.fm 兼容接口 (/2.0/) 配置，clients 为空时不启用
+type AudioScrobblerConfig struct {
+	Username string                       `yaml:"username"` // auth.getMobileSession 校验的用户名
+	Password string                       `yaml:"password"`
+	Relay    bool                         `yaml:"relay"` // 同时转发到 lastfm 中登录的 Last.fm 账号
+	Clients  []AudioScrobblerClientConfig `yaml:"clients"`
+}
+
+// AudioScrobblerClientConfig 一个客户端使用的 api_key 与 shared secret
+type AudioScrobblerClientConfig struct {
+	Name   string `yaml:"name"` // 写入 track_play_records.source，为空时使用 api_key
+	ApiKey string `yaml:"apiKey"`
+	Secret string `yaml:"secret"`
+}
+
+// NowPlayingConfig 正在播放推送配置
+type NowPlayingConfig struct {
+	Webhooks []string `yaml:"webhooks"` // 曲目、播放器或播放状态变化时 POST JSON 的地址
+}
+
+// FavoritesConfig 喜欢状态配置
+type FavoritesConfig struct {
+	Reconcile FavoritesReconcileConfig `yaml:"reconcile"`
+}
+
+// FavoritesReconcileConfig Last.fm 与本地喜欢状态的对账配置
+type FavoritesReconcileConfig struct {
+	Policy          string `yaml:"policy"`          // 冲突处理：remote_wins / local_wins / union，默认 union
+	IntervalMinutes int    `yaml:"intervalMinutes"` // 定时对账间隔(分钟)，为 0 时只能通过接口手动执行
+}
+
+// ScrobbleConfig 标记听歌完成的规则配置
+// players 以 PlayerType 为键（不区分大小写）按字段覆盖 default，未配置的字段沿用 default
+type ScrobbleConfig struct {
+	Default ScrobbleRuleConfig            `yaml:"default"`
+	Players map[string]ScrobbleRuleConfig `yaml:"players"`
+	Outbox  ScrobbleOutboxConfig          `yaml:"outbox"`
+}
+
+// ScrobbleOutboxConfig 上报失败重试队列配置
+type ScrobbleOutboxConfig struct {
+	Disabled          bool `yaml:"disabled"`          // 是否关闭后台重试
+	IntervalSeconds   int  `yaml:"intervalSeconds"`   // 扫描间隔(秒)，默认 60
+	MaxBackoffMinutes int  `yaml:"maxBackoffMinutes"` // 指数退避上限(分钟)，默认 360
+}
+
+// ScrobbleRuleConfig 单条标记规则，字段为 0 表示使用默认值，负数表示关闭该条件
+type ScrobbleRuleConfig struct {
+	Percent     float64 `yaml:"percent"`     // 累计收听比例阈值，默认 0.55
+	MaxSeconds  int     `yaml:"maxSeconds"`  // 累计收听达到该秒数即可标记，默认 240（Last.fm 四分钟规则）
+	MinDuration int     `yaml:"minDuration"` // 曲目时长短于该秒数不标记，默认 30
+}
+
 type LogConfig struct {
 	Path  string `yaml:"path"`
 	Level string `yaml:"level"`
@@ -60,6 +152,7 @@ type DashboardConfig struct {
 	TopN                            int  `yaml:"topN"`
 	TrendDays                       int  `yaml:"trendDays"`
 	HourlyTrendDays                 int  `yaml:"hourlyTrendDays"`
+	SessionGapMinutes               int  `yaml:"sessionGapMinutes"`
 }
 
 type MysqlConfig struct {
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,6 +21,8 @@ import (
 	coreredisclient "github.com/vincentchyu/sonic-lens/core/redis"
 )
 
+const lovedTracksPageLimit = 1000
+
 var (
 	lastfmApi    = new(Api)
 	_redisClient *redisgo.Client
@@ -115,7 +118,7 @@ type (
 		Date string `json:"Date"`
 	}
 	Track struct {
-		Name       string     `json:"Title"`
+		Name       string     `json:"Name"`
 		Mbid       string     `json:"Mbid"`
 		Url        string     `json:"Url"`
 		Date       Date       `json:"Date"`
@@ -124,10 +127,17 @@ type (
 		Streamable Streamable `json:"Streamable"`
 	}
 	Artist struct {
-		Name string `json:"Title"`
+		Name string `json:"Name"`
 		Mbid string `json:"Mbid"`
 		Url  string `json:"Url"`
 	}
+	// LovedTrack Last.fm 上喜欢的一首曲目
+	LovedTrack struct {
+		Artist  string    `json:"artist"`
+		Track   string    `json:"track"`
+		Mbid    string    `json:"mbid,omitempty"`
+		LovedAt time.Time `json:"loved_at"`
+	}
 	Images struct {
 		Size string `json:"Size"`
 		Url  string `json:"Url"`
@@ -142,10 +152,11 @@ func init() {
 }
 
 func InitLastfmApi(
-	ctx context.Context, apiKey, apiSecret, userLoginToken string, isMobile bool, userUsername, userPassword string,
+	ctx context.Context, key, secret, userLoginToken string, isMobile bool, userUsername, userPassword string,
 ) {
 	_redisClient = coreredisclient.GetRedisClient()
-	lastfmApi.Api = lastfm.New(apiKey, apiSecret)
+	lastfmApi.Api = lastfm.New(key, secret)
+	apiKey, apiSecret = key, secret
 	if isMobile {
 		err := lastfmApi.Login(userUsername, userPassword)
 		if err != nil {
@@ -237,26 +248,66 @@ func (t *TrackGetInfoReq) ToMap() (res map[string]interface{}, err error) {
 	top artist
 */
 
-func GetLovedTracksUser(user string, limit int) (result *GetLovedTracksResp, err error) {
+// GetLovedTracksUser 获取用户喜欢的曲目的一页，page 从 1 开始，limit 最大为 lovedTracksPageLimit
+func GetLovedTracksUser(user string, page, limit int) (*GetLovedTracksResp, error) {
+	// 检查API是否已初始化
+	if lastfmApi == nil || lastfmApi.Api == nil {
+		return nil, fmt.Errorf("last.fm api not initialized")
+	}
 	resp, err := lastfmApi.User.GetLovedTracks(
 		map[string]interface{}{
-			"user":  "vincentch1n",
-			"limit": "10",
+			"user":  user,
+			"page":  page,
+			"limit": limit,
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
-	marshal, err := json.Marshal(resp)
-	if err != nil {
-		return nil, err
+	result := &GetLovedTracksResp{
+		User:       resp.User,
+		Total:      resp.Total,
+		Page:       resp.Page,
+		PerPage:    resp.PerPage,
+		TotalPages: resp.TotalPages,
+		Tracks:     make([]Track, 0, len(resp.Tracks)),
+	}
+	for _, t := range resp.Tracks {
+		result.Tracks = append(
+			result.Tracks, Track{
+				Name:   t.Name,
+				Mbid:   t.Mbid,
+				Url:    t.Url,
+				Date:   Date{Uts: t.Date.Uts, Date: t.Date.Date},
+				Artist: Artist{Name: t.Artist.Name, Mbid: t.Artist.Mbid, Url: t.Artist.Url},
+			},
+		)
 	}
-	result = new(GetLovedTracksResp)
-	err = json.Unmarshal(marshal, result)
-	if err != nil {
-		return nil, err
+	return result, nil
+}
+
+// GetAllLovedTracks 逐页获取用户全部喜欢的曲目
+func GetAllLovedTracks(ctx context.Context, user string) ([]LovedTrack, error) {
+	var tracks []LovedTrack
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := GetLovedTracksUser(user, page, lovedTracksPageLimit)
+		if err != nil {
+			return nil, fmt.Errorf("get loved tracks page %d: %w", page, err)
+		}
+		for _, t := range resp.Tracks {
+			loved := LovedTrack{Artist: t.Artist.Name, Track: t.Name, Mbid: t.Mbid}
+			if uts, err := strconv.ParseInt(t.Date.Uts, 10, 64); err == nil {
+				loved.LovedAt = time.Unix(uts, 0)
+			}
+			tracks = append(tracks, loved)
+		}
+		if len(resp.Tracks) == 0 || page >= resp.TotalPages {
+			return tracks, nil
+		}
 	}
-	return result, err
 }
 
 func PushTrackScrobble(ctx context.Context, req *PushTrackScrobbleReq) (string, error) {
@@ -326,6 +377,12 @@ func TrackUpdateNowPlaying(ctx context.Context, req *TrackUpdateNowPlayingReq) e
 func IsFavorite(ctx context.Context, artist, track string) (bool, error) {
 	alog.Info(ctx, "Checking if track is loved", zap.String("artist", artist), zap.String("track", track))
 
+	// 检查API是否已初始化，未初始化时 redis 客户端同样为空
+	if lastfmApi == nil || lastfmApi.Api == nil {
+		alog.Warn(ctx, "Last.fm API not initialized")
+		return false, fmt.Errorf("last.fm api not initialized")
+	}
+
 	// Generate Redis key
 	redisKey := fmt.Sprintf("cache:isFavorite:lastfm:%s:%s", artist, track)
 	// 如果一直都没有被点赞每秒都会一直被调用，是不是可以用redis去设置缓存大概4分钟，这样每秒的播放如果一直没有被喜欢可以等他自己过期
@@ -344,11 +401,6 @@ func IsFavorite(ctx context.Context, artist, track string) (bool, error) {
 		alog.Info(ctx, "Redis error when getting cached favorite status", zap.Error(err))
 	}
 
-	// 检查API是否已初始化
-	if lastfmApi == nil || lastfmApi.Api == nil {
-		alog.Warn(ctx, "Last.fm API not initialized")
-		return false, fmt.Errorf("last.fm api not initialized")
-	}
 	req := TrackGetInfoReq{
 		Artist:   artist,
 		Track:    track,
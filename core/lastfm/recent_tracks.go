@@ -0,0 +1,174 @@
+package lastfm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shkh/lastfm-go/lastfm"
+)
+
+// RecentTracksMaxLimit user.getRecentTracks 单页最多 200 条
+const RecentTracksMaxLimit = 200
+
+type (
+	// RecentTracksClient 调用 user.getRecentTracks，只需 api_key，无需登录
+	RecentTracksClient struct {
+		endpoint   string
+		apiKey     string
+		httpClient *http.Client
+	}
+
+	// GetRecentTracksReq user.getRecentTracks 请求参数，From/To 为 UNIX 时间戳，0 表示不限制
+	GetRecentTracksReq struct {
+		User  string
+		Page  int
+		Limit int
+		From  int64
+		To    int64
+	}
+
+	// RecentTrack 单条历史记录
+	RecentTrack struct {
+		Artist     string
+		Album      string
+		Track      string
+		Mbid       string
+		Timestamp  int64
+		NowPlaying bool // 正在播放的条目没有时间戳
+	}
+
+	// RecentTracksPage 一页历史记录，按时间倒序
+	RecentTracksPage struct {
+		Page       int
+		TotalPages int
+		Total      int
+		Tracks     []RecentTrack
+	}
+
+	recentTracksResp struct {
+		Error        int    `json:"error"`
+		Message      string `json:"message"`
+		RecentTracks struct {
+			// 只有一条记录时 track 为对象而非数组
+			Track json.RawMessage `json:"track"`
+			Attr  struct {
+				Page       string `json:"page"`
+				TotalPages string `json:"totalPages"`
+				Total      string `json:"total"`
+			} `json:"@attr"`
+		} `json:"recenttracks"`
+	}
+
+	recentTrackItem struct {
+		Name   string `json:"name"`
+		Mbid   string `json:"mbid"`
+		Artist struct {
+			Text string `json:"#text"`
+		} `json:"artist"`
+		Album struct {
+			Text string `json:"#text"`
+		} `json:"album"`
+		Date struct {
+			Uts string `json:"uts"`
+		} `json:"date"`
+		Attr struct {
+			NowPlaying string `json:"nowplaying"`
+		} `json:"@attr"`
+	}
+)
+
+// NewRecentTracksClient 创建客户端，endpoint 为空时使用官方地址
+func NewRecentTracksClient(endpoint, apiKey string) *RecentTracksClient {
+	if endpoint == "" {
+		endpoint = lastfm.UriApiSecBase
+	}
+	return &RecentTracksClient{
+		endpoint:   endpoint,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetRecentTracks 获取一页收听历史
+func (c *RecentTracksClient) GetRecentTracks(ctx context.Context, req *GetRecentTracksReq) (*RecentTracksPage, error) {
+	params := url.Values{}
+	params.Set("method", "user.getrecenttracks")
+	params.Set("api_key", c.apiKey)
+	params.Set("format", "json")
+	params.Set("user", req.User)
+	if req.Page > 0 {
+		params.Set("page", strconv.Itoa(req.Page))
+	}
+	if req.Limit > 0 {
+		params.Set("limit", strconv.Itoa(min(req.Limit, RecentTracksMaxLimit)))
+	}
+	if req.From > 0 {
+		params.Set("from", strconv.FormatInt(req.From, 10))
+	}
+	if req.To > 0 {
+		params.Set("to", strconv.FormatInt(req.To, 10))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= ErrCodeHTTPServerErrorBase {
+		return nil, &APIError{Code: resp.StatusCode, Message: resp.Status}
+	}
+
+	var parsed recentTracksResp
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parse recent tracks response: %w", err)
+	}
+	if parsed.Error != 0 {
+		return nil, &APIError{Code: parsed.Error, Message: parsed.Message}
+	}
+
+	var items []recentTrackItem
+	if raw := bytes.TrimSpace(parsed.RecentTracks.Track); len(raw) > 0 {
+		if raw[0] == '{' {
+			raw = append(append([]byte{'['}, raw...), ']')
+		}
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("parse recent tracks: %w", err)
+		}
+	}
+
+	page := &RecentTracksPage{
+		Tracks: make([]RecentTrack, 0, len(items)),
+	}
+	page.Page, _ = strconv.Atoi(parsed.RecentTracks.Attr.Page)
+	page.TotalPages, _ = strconv.Atoi(parsed.RecentTracks.Attr.TotalPages)
+	page.Total, _ = strconv.Atoi(parsed.RecentTracks.Attr.Total)
+	for _, item := range items {
+		uts, _ := strconv.ParseInt(item.Date.Uts, 10, 64)
+		page.Tracks = append(
+			page.Tracks, RecentTrack{
+				Artist:     item.Artist.Text,
+				Album:      item.Album.Text,
+				Track:      item.Name,
+				Mbid:       item.Mbid,
+				Timestamp:  uts,
+				NowPlaying: item.Attr.NowPlaying == "true",
+			},
+		)
+	}
+	return page, nil
+}
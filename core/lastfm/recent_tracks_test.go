@@ -0,0 +1,112 @@
+package lastfm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentTracksClient_GetRecentTracks(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				assert.Equal(t, "user.getrecenttracks", q.Get("method"))
+				assert.Equal(t, "key", q.Get("api_key"))
+				assert.Equal(t, "json", q.Get("format"))
+				assert.Equal(t, "alice", q.Get("user"))
+				assert.Equal(t, "200", q.Get("limit"), "limit is capped")
+				assert.Equal(t, "1700000100", q.Get("to"))
+				assert.Empty(t, q.Get("from"))
+				_, _ = w.Write(
+					[]byte(`{"recenttracks":{"track":[
+  {"artist":{"mbid":"","#text":"Artist A"},"album":{"mbid":"","#text":"Album A"},"name":"Now","mbid":"","@attr":{"nowplaying":"true"}},
+  {"artist":{"mbid":"","#text":"Artist A"},"album":{"mbid":"","#text":"Album A"},"name":"Track A","mbid":"m-1","date":{"uts":"1700000000","#text":"14 Nov 2023, 22:13"}}
+],"@attr":{"user":"alice","totalPages":"3","page":"2","perPage":"200","total":"401"}}}`),
+				)
+			},
+		),
+	)
+	defer server.Close()
+
+	client := NewRecentTracksClient(server.URL, "key")
+	page, err := client.GetRecentTracks(
+		context.Background(), &GetRecentTracksReq{User: "alice", Page: 2, Limit: 500, To: 1700000100},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Page)
+	assert.Equal(t, 3, page.TotalPages)
+	assert.Equal(t, 401, page.Total)
+	require.Len(t, page.Tracks, 2)
+	assert.True(t, page.Tracks[0].NowPlaying)
+	assert.Equal(
+		t, RecentTrack{Artist: "Artist A", Album: "Album A", Track: "Track A", Mbid: "m-1", Timestamp: 1700000000},
+		page.Tracks[1],
+	)
+}
+
+func TestRecentTracksClient_GetRecentTracks_SingleAndErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantLen  int
+		wantCode int
+	}{
+		{
+			name:    "单条记录为对象",
+			status:  http.StatusOK,
+			body:    `{"recenttracks":{"track":{"artist":{"#text":"A"},"album":{"#text":"B"},"name":"C","date":{"uts":"1"}},"@attr":{"page":"1","totalPages":"1","total":"1"}}}`,
+			wantLen: 1,
+		},
+		{
+			name:    "没有记录",
+			status:  http.StatusOK,
+			body:    `{"recenttracks":{"track":[],"@attr":{"page":"1","totalPages":"0","total":"0"}}}`,
+			wantLen: 0,
+		},
+		{
+			name:     "用户不存在",
+			status:   http.StatusNotFound,
+			body:     `{"error":6,"message":"User not found"}`,
+			wantCode: ErrCodeInvalidParameters,
+		},
+		{
+			name:     "服务端错误",
+			status:   http.StatusBadGateway,
+			body:     `<html></html>`,
+			wantCode: http.StatusBadGateway,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				server := httptest.NewServer(
+					http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(tt.status)
+							_, _ = w.Write([]byte(tt.body))
+						},
+					),
+				)
+				defer server.Close()
+
+				page, err := NewRecentTracksClient(server.URL, "key").GetRecentTracks(
+					context.Background(), &GetRecentTracksReq{User: "alice"},
+				)
+				if tt.wantCode != 0 {
+					var apiErr *APIError
+					require.ErrorAs(t, err, &apiErr)
+					assert.Equal(t, tt.wantCode, apiErr.Code)
+					return
+				}
+				require.NoError(t, err)
+				assert.Len(t, page.Tracks, tt.wantLen)
+			},
+		)
+	}
+}
@@ -0,0 +1,253 @@
+package lastfm
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shkh/lastfm-go/lastfm"
+	"go.uber.org/zap"
+
+	alog "github.com/vincentchyu/sonic-lens/core/log"
+)
+
+// ScrobbleBatchSize track.scrobble 单次最多提交 50 条
+const ScrobbleBatchSize = 50
+
+// Last.fm API 错误码，参考 https://www.last.fm/api/errorcodes
+const (
+	ErrCodeInvalidMethod       = 3
+	ErrCodeAuthFailed          = 4
+	ErrCodeInvalidParameters   = 6
+	ErrCodeOperationFailed     = 8
+	ErrCodeInvalidSessionKey   = 9
+	ErrCodeInvalidAPIKey       = 10
+	ErrCodeServiceOffline      = 11
+	ErrCodeInvalidSignature    = 13
+	ErrCodeTemporaryError      = 16
+	ErrCodeSuspendedAPIKey     = 26
+	ErrCodeRateLimitExceeded   = 29
+	ErrCodeHTTPServerErrorBase = 500 // 5xx 响应以 HTTP 状态码作为错误码
+)
+
+// track.scrobble 返回的 ignoredMessage code，参考 https://www.last.fm/api/show/track.scrobble
+const (
+	IgnoredCodeNone              = 0
+	IgnoredCodeArtistIgnored     = 1
+	IgnoredCodeTrackIgnored      = 2
+	IgnoredCodeTimestampTooOld   = 3
+	IgnoredCodeTimestampTooNew   = 4
+	IgnoredCodeDailyLimitReached = 5
+)
+
+var (
+	// scrobbleEndpoint 提交地址，测试中替换为本地 HTTP 服务
+	scrobbleEndpoint   = lastfm.UriApiSecBase
+	scrobbleHTTPClient = &http.Client{Timeout: 30 * time.Second}
+	apiKey             string
+	apiSecret          string
+)
+
+type (
+	// APIError Last.fm 返回 status="failed" 或 5xx 时的错误
+	APIError struct {
+		Code    int
+		Message string
+	}
+
+	// ScrobbleItemResult 批量提交中单条记录的结果，顺序与请求一致
+	ScrobbleItemResult struct {
+		Accepted       bool
+		IgnoredCode    int
+		IgnoredMessage string
+	}
+
+	// ScrobbleBatchResult 批量提交结果
+	ScrobbleBatchResult struct {
+		Accepted int
+		Ignored  int
+		Items    []ScrobbleItemResult
+	}
+
+	scrobbleBatchResp struct {
+		XMLName xml.Name `xml:"lfm"`
+		Status  string   `xml:"status,attr"`
+		Error   struct {
+			Code    int    `xml:"code,attr"`
+			Message string `xml:",chardata"`
+		} `xml:"error"`
+		Scrobbles struct {
+			Accepted int `xml:"accepted,attr"`
+			Ignored  int `xml:"ignored,attr"`
+			Scrobble []struct {
+				IgnoredMessage struct {
+					Code    int    `xml:"code,attr"`
+					Message string `xml:",chardata"`
+				} `xml:"ignoredMessage"`
+			} `xml:"scrobble"`
+		} `xml:"scrobbles"`
+	}
+)
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("lastfm api error[%d]: %s", e.Code, e.Message)
+}
+
+// IsTransientError 判断错误是否值得稍后重试
+// 仅参数/签名错误视为永久错误；鉴权类错误在修复配置后可恢复，与服务不可用、限流一样按临时错误处理
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case ErrCodeInvalidParameters, ErrCodeInvalidSignature:
+			return false
+		}
+		return true
+	}
+	// 网络错误等其余错误同样可重试，交由调用方的退避策略兜底
+	return true
+}
+
+// IsAuthError 判断是否为鉴权失败、会话或 API key 失效、key 被停用
+// 这类错误与具体记录无关，修复账号配置前任何请求都会失败
+func IsAuthError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case ErrCodeAuthFailed, ErrCodeInvalidSessionKey, ErrCodeInvalidAPIKey, ErrCodeSuspendedAPIKey:
+		return true
+	}
+	return false
+}
+
+// IsPermanentIgnored 单条记录被忽略的原因是否不可通过重试恢复
+func (r ScrobbleItemResult) IsPermanentIgnored() bool {
+	return !r.Accepted && r.IgnoredCode != IgnoredCodeDailyLimitReached
+}
+
+// ScrobbleBatch 调用 track.scrobble 一次提交最多 ScrobbleBatchSize 条记录
+// 与 PushTrackScrobble 不同，这里直接解析响应 XML 以获取每条记录的 ignoredMessage code
+func ScrobbleBatch(ctx context.Context, reqs []*PushTrackScrobbleReq) (*ScrobbleBatchResult, error) {
+	if len(reqs) == 0 {
+		return &ScrobbleBatchResult{}, nil
+	}
+	if len(reqs) > ScrobbleBatchSize {
+		return nil, fmt.Errorf("scrobble batch size %d exceeds %d", len(reqs), ScrobbleBatchSize)
+	}
+	if lastfmApi == nil || lastfmApi.Api == nil {
+		return nil, fmt.Errorf("last.fm api not initialized")
+	}
+
+	params := map[string]string{
+		"method":  "track.scrobble",
+		"api_key": apiKey,
+		"sk":      lastfmApi.GetSessionKey(),
+	}
+	for i, req := range reqs {
+		setIndexed := func(key, value string) {
+			if value != "" {
+				params[fmt.Sprintf("%s[%d]", key, i)] = value
+			}
+		}
+		setIndexed("artist", req.Artist)
+		setIndexed("track", req.Track)
+		setIndexed("timestamp", strconv.FormatInt(req.Timestamp, 10))
+		setIndexed("album", req.Album)
+		setIndexed("albumArtist", req.AlbumArtist)
+		setIndexed("mbid", req.MusicBrainzTrackID)
+		if req.Duration > 0 {
+			setIndexed("duration", strconv.FormatInt(req.Duration, 10))
+		}
+		if req.TrackNumber > 0 {
+			setIndexed("trackNumber", strconv.FormatInt(req.TrackNumber, 10))
+		}
+	}
+	params["api_sig"] = Signature(params, apiSecret)
+
+	form := url.Values{}
+	for k, v := range params {
+		form.Set(k, v)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, scrobbleEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := scrobbleHTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= ErrCodeHTTPServerErrorBase {
+		return nil, &APIError{Code: resp.StatusCode, Message: resp.Status}
+	}
+
+	var parsed scrobbleBatchResp
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parse scrobble response: %w", err)
+	}
+	if parsed.Status != "ok" {
+		return nil, &APIError{Code: parsed.Error.Code, Message: strings.TrimSpace(parsed.Error.Message)}
+	}
+
+	result := &ScrobbleBatchResult{
+		Accepted: parsed.Scrobbles.Accepted,
+		Ignored:  parsed.Scrobbles.Ignored,
+		Items:    make([]ScrobbleItemResult, len(reqs)),
+	}
+	for i := range result.Items {
+		if i >= len(parsed.Scrobbles.Scrobble) {
+			// 响应条数不足时无法确认结果，按每日上限处理以便重试
+			result.Items[i] = ScrobbleItemResult{IgnoredCode: IgnoredCodeDailyLimitReached}
+			continue
+		}
+		ignored := parsed.Scrobbles.Scrobble[i].IgnoredMessage
+		result.Items[i] = ScrobbleItemResult{
+			Accepted:       ignored.Code == IgnoredCodeNone,
+			IgnoredCode:    ignored.Code,
+			IgnoredMessage: strings.TrimSpace(ignored.Message),
+		}
+	}
+	alog.Info(
+		ctx, "ScrobbleBatch", zap.Int("size", len(reqs)), zap.Int("accepted", result.Accepted),
+		zap.Int("ignored", result.Ignored),
+	)
+	return result, nil
+}
+
+// Signature 按 Last.fm 规则对参数签名：键排序后拼接 key+value，末尾追加 secret 再取 md5
+func Signature(params map[string]string, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString(params[k])
+	}
+	b.WriteString(secret)
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,167 @@
+package lastfm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shkh/lastfm-go/lastfm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	alog "github.com/vincentchyu/sonic-lens/core/log"
+)
+
+// withFakeScrobbleServer 将 track.scrobble 指向本地 HTTP 服务
+func withFakeScrobbleServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	if alog.Logger == nil {
+		alog.Logger = zap.NewNop()
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	prevEndpoint, prevApi, prevKey, prevSecret := scrobbleEndpoint, lastfmApi.Api, apiKey, apiSecret
+	t.Cleanup(
+		func() {
+			scrobbleEndpoint, lastfmApi.Api, apiKey, apiSecret = prevEndpoint, prevApi, prevKey, prevSecret
+		},
+	)
+	scrobbleEndpoint = server.URL
+	apiKey, apiSecret = "key", "secret"
+	lastfmApi.Api = lastfm.New(apiKey, apiSecret)
+	lastfmApi.SetSession("session")
+}
+
+func TestScrobbleBatch(t *testing.T) {
+	withFakeScrobbleServer(
+		t, func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "track.scrobble", r.PostForm.Get("method"))
+			assert.Equal(t, "session", r.PostForm.Get("sk"))
+			assert.Equal(t, "Artist A", r.PostForm.Get("artist[0]"))
+			assert.Equal(t, "Track B", r.PostForm.Get("track[1]"))
+			assert.Equal(t, "1700000060", r.PostForm.Get("timestamp[1]"))
+			assert.Empty(t, r.PostForm.Get("mbid[0]"), "empty optional fields are omitted")
+
+			params := map[string]string{}
+			for k := range r.PostForm {
+				if k != "api_sig" {
+					params[k] = r.PostForm.Get(k)
+				}
+			}
+			assert.Equal(t, Signature(params, "secret"), r.PostForm.Get("api_sig"))
+
+			_, _ = w.Write(
+				[]byte(`<?xml version="1.0" encoding="utf-8"?>
+<lfm status="ok">
+  <scrobbles accepted="1" ignored="1">
+    <scrobble><track corrected="0">Track A</track><ignoredMessage code="0"></ignoredMessage></scrobble>
+    <scrobble><track corrected="0">Track B</track><ignoredMessage code="3">Timestamp too old</ignoredMessage></scrobble>
+  </scrobbles>
+</lfm>`),
+			)
+		},
+	)
+
+	result, err := ScrobbleBatch(
+		context.Background(), []*PushTrackScrobbleReq{
+			{Artist: "Artist A", Track: "Track A", Album: "Album", Timestamp: 1700000000, Duration: 200},
+			{Artist: "Artist B", Track: "Track B", Timestamp: 1700000060},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Accepted)
+	assert.Equal(t, 1, result.Ignored)
+	require.Len(t, result.Items, 2)
+	assert.True(t, result.Items[0].Accepted)
+	assert.False(t, result.Items[1].Accepted)
+	assert.Equal(t, IgnoredCodeTimestampTooOld, result.Items[1].IgnoredCode)
+	assert.Equal(t, "Timestamp too old", result.Items[1].IgnoredMessage)
+	assert.True(t, result.Items[1].IsPermanentIgnored())
+	assert.False(t, ScrobbleItemResult{IgnoredCode: IgnoredCodeDailyLimitReached}.IsPermanentIgnored())
+}
+
+func TestScrobbleBatch_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantCode      int
+		wantTransient bool
+		wantAuth      bool
+	}{
+		{
+			name:          "服务离线",
+			status:        http.StatusOK,
+			body:          `<lfm status="failed"><error code="11">Service Offline</error></lfm>`,
+			wantCode:      ErrCodeServiceOffline,
+			wantTransient: true,
+		},
+		{
+			name:          "限流",
+			status:        http.StatusOK,
+			body:          `<lfm status="failed"><error code="29">Rate limit exceeded</error></lfm>`,
+			wantCode:      ErrCodeRateLimitExceeded,
+			wantTransient: true,
+		},
+		{
+			name:          "参数错误",
+			status:        http.StatusOK,
+			body:          `<lfm status="failed"><error code="6">Invalid parameters</error></lfm>`,
+			wantCode:      ErrCodeInvalidParameters,
+			wantTransient: false,
+		},
+		{
+			name:          "会话失效",
+			status:        http.StatusOK,
+			body:          `<lfm status="failed"><error code="9">Invalid session key</error></lfm>`,
+			wantCode:      ErrCodeInvalidSessionKey,
+			wantTransient: true,
+			wantAuth:      true,
+		},
+		{
+			name:          "API key 被停用",
+			status:        http.StatusOK,
+			body:          `<lfm status="failed"><error code="26">Suspended API key</error></lfm>`,
+			wantCode:      ErrCodeSuspendedAPIKey,
+			wantTransient: true,
+			wantAuth:      true,
+		},
+		{
+			name:          "5xx",
+			status:        http.StatusBadGateway,
+			body:          `bad gateway`,
+			wantCode:      http.StatusBadGateway,
+			wantTransient: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				withFakeScrobbleServer(
+					t, func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(tt.status)
+						_, _ = w.Write([]byte(tt.body))
+					},
+				)
+				_, err := ScrobbleBatch(
+					context.Background(), []*PushTrackScrobbleReq{{Artist: "A", Track: "T", Timestamp: 1}},
+				)
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.wantCode, apiErr.Code)
+				assert.Equal(t, tt.wantTransient, IsTransientError(err))
+				assert.Equal(t, tt.wantAuth, IsAuthError(err))
+			},
+		)
+	}
+}
+
+func TestScrobbleBatch_TooLarge(t *testing.T) {
+	reqs := make([]*PushTrackScrobbleReq, ScrobbleBatchSize+1)
+	_, err := ScrobbleBatch(context.Background(), reqs)
+	assert.Error(t, err)
+}
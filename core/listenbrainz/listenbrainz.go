@@ -0,0 +1,178 @@
+package listenbrainz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	alog "github.com/vincentchyu/sonic-lens/core/log"
+)
+
+// DefaultBaseURL ListenBrainz 官方 API 地址，自建实例可在配置中覆盖
+const DefaultBaseURL = "https://api.listenbrainz.org"
+
+// MaxListensPerRequest import 类型单次最多提交的条数
+const MaxListensPerRequest = 1000
+
+// ListenType submit-listens 的提交类型，参考 https://listenbrainz.readthedocs.io/en/latest/users/api/core.html
+type ListenType string
+
+const (
+	ListenTypePlayingNow ListenType = "playing_now" // 正在播放，不带 listened_at
+	ListenTypeSingle     ListenType = "single"      // 单条实时提交
+	ListenTypeImport     ListenType = "import"      // 批量补传
+)
+
+// submissionClientName 上报时附带的客户端名称
+const submissionClientName = "sonic-lens"
+
+type (
+	// Client ListenBrainz API 客户端
+	Client struct {
+		baseURL    string
+		token      string
+		httpClient *http.Client
+	}
+
+	// APIError 非 2xx 响应，Code 为 HTTP 状态码
+	APIError struct {
+		Code    int
+		Message string
+	}
+
+	// Listen 单条收听记录
+	Listen struct {
+		ListenedAt    int64         `json:"listened_at,omitempty"`
+		TrackMetadata TrackMetadata `json:"track_metadata"`
+	}
+
+	// TrackMetadata 曲目元数据
+	TrackMetadata struct {
+		ArtistName     string         `json:"artist_name"`
+		TrackName      string         `json:"track_name"`
+		ReleaseName    string         `json:"release_name,omitempty"`
+		AdditionalInfo AdditionalInfo `json:"additional_info"`
+	}
+
+	// AdditionalInfo 可选的附加信息
+	AdditionalInfo struct {
+		DurationMs              int64  `json:"duration_ms,omitempty"`
+		TrackNumber             int64  `json:"tracknumber,omitempty"`
+		RecordingMBID           string `json:"recording_mbid,omitempty"`
+		MediaPlayer             string `json:"media_player,omitempty"`
+		SubmissionClient        string `json:"submission_client,omitempty"`
+		SubmissionClientVersion string `json:"submission_client_version,omitempty"`
+	}
+
+	submitListensReq struct {
+		ListenType ListenType `json:"listen_type"`
+		Payload    []Listen   `json:"payload"`
+	}
+
+	errorResp struct {
+		Code  int    `json:"code"`
+		Error string `json:"error"`
+	}
+)
+
+// NewClient 创建客户端，baseURL 为空时使用官方地址
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("listenbrainz api error[%d]: %s", e.Code, e.Message)
+}
+
+// IsTransientError 判断错误是否值得稍后重试
+// 仅 400（请求内容非法）视为永久错误；401 需修复 token 后才能恢复，与限流、5xx、网络错误一样按临时错误处理
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code != http.StatusBadRequest
+	}
+	return true
+}
+
+// IsAuthError 判断是否为 token 无效（401），与具体记录无关，修复配置前任何请求都会失败
+func IsAuthError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusUnauthorized
+}
+
+// SubmitListens 调用 /1/submit-listens
+// playing_now 与 single 只能携带一条记录，import 最多 MaxListensPerRequest 条
+func (c *Client) SubmitListens(ctx context.Context, listenType ListenType, listens ...Listen) error {
+	if c.token == "" {
+		return fmt.Errorf("listenbrainz token not configured")
+	}
+	switch {
+	case len(listens) == 0:
+		return nil
+	case listenType != ListenTypeImport && len(listens) > 1:
+		return fmt.Errorf("listen type %s accepts a single listen, got %d", listenType, len(listens))
+	case len(listens) > MaxListensPerRequest:
+		return fmt.Errorf("listen batch size %d exceeds %d", len(listens), MaxListensPerRequest)
+	}
+
+	payload := make([]Listen, len(listens))
+	for i, listen := range listens {
+		if listenType == ListenTypePlayingNow {
+			listen.ListenedAt = 0
+		}
+		if listen.TrackMetadata.AdditionalInfo.SubmissionClient == "" {
+			listen.TrackMetadata.AdditionalInfo.SubmissionClient = submissionClientName
+		}
+		payload[i] = listen
+	}
+	body, err := json.Marshal(submitListensReq{ListenType: listenType, Payload: payload})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/1/submit-listens", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := resp.Status
+		var parsed errorResp
+		if json.Unmarshal(respBody, &parsed) == nil && parsed.Error != "" {
+			msg = parsed.Error
+		}
+		return &APIError{Code: resp.StatusCode, Message: msg}
+	}
+
+	alog.Info(ctx, "SubmitListens", zap.String("listen_type", string(listenType)), zap.Int("size", len(listens)))
+	return nil
+}
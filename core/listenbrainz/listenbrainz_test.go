@@ -0,0 +1,115 @@
+package listenbrainz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	alog "github.com/vincentchyu/sonic-lens/core/log"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	if alog.Logger == nil {
+		alog.Logger = zap.NewNop()
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/", "token-123")
+}
+
+func TestClient_SubmitListens(t *testing.T) {
+	listen := Listen{
+		ListenedAt: 1700000000,
+		TrackMetadata: TrackMetadata{
+			ArtistName:     "Artist",
+			TrackName:      "Track",
+			ReleaseName:    "Album",
+			AdditionalInfo: AdditionalInfo{DurationMs: 180000, MediaPlayer: "Roon"},
+		},
+	}
+	tests := []struct {
+		name           string
+		listenType     ListenType
+		listens        []Listen
+		wantListenedAt int64
+	}{
+		{name: "playing_now 去掉时间戳", listenType: ListenTypePlayingNow, listens: []Listen{listen}, wantListenedAt: 0},
+		{name: "single", listenType: ListenTypeSingle, listens: []Listen{listen}, wantListenedAt: 1700000000},
+		{name: "import 批量", listenType: ListenTypeImport, listens: []Listen{listen, listen}, wantListenedAt: 1700000000},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				client := newTestClient(
+					t, func(w http.ResponseWriter, r *http.Request) {
+						assert.Equal(t, "/1/submit-listens", r.URL.Path)
+						assert.Equal(t, "Token token-123", r.Header.Get("Authorization"))
+
+						var req submitListensReq
+						require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+						assert.Equal(t, tt.listenType, req.ListenType)
+						require.Len(t, req.Payload, len(tt.listens))
+						assert.Equal(t, tt.wantListenedAt, req.Payload[0].ListenedAt)
+						assert.Equal(t, "Track", req.Payload[0].TrackMetadata.TrackName)
+						assert.Equal(t, submissionClientName, req.Payload[0].TrackMetadata.AdditionalInfo.SubmissionClient)
+						_, _ = w.Write([]byte(`{"status":"ok"}`))
+					},
+				)
+				require.NoError(t, client.SubmitListens(context.Background(), tt.listenType, tt.listens...))
+			},
+		)
+	}
+}
+
+func TestClient_SubmitListens_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantMessage   string
+		wantTransient bool
+		wantAuth      bool
+	}{
+		{name: "请求非法", status: http.StatusBadRequest, body: `{"code":400,"error":"JSON document is invalid"}`, wantMessage: "JSON document is invalid"},
+		{name: "token 无效", status: http.StatusUnauthorized, body: `{"code":401,"error":"Invalid authorization token."}`, wantMessage: "Invalid authorization token.", wantTransient: true, wantAuth: true},
+		{name: "限流", status: http.StatusTooManyRequests, body: ``, wantMessage: "429 Too Many Requests", wantTransient: true},
+		{name: "服务端错误", status: http.StatusServiceUnavailable, body: `<html></html>`, wantMessage: "503 Service Unavailable", wantTransient: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				client := newTestClient(
+					t, func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(tt.status)
+						_, _ = w.Write([]byte(tt.body))
+					},
+				)
+				err := client.SubmitListens(context.Background(), ListenTypeSingle, Listen{ListenedAt: 1})
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.status, apiErr.Code)
+				assert.Equal(t, tt.wantMessage, apiErr.Message)
+				assert.Equal(t, tt.wantTransient, IsTransientError(err))
+				assert.Equal(t, tt.wantAuth, IsAuthError(err))
+			},
+		)
+	}
+}
+
+func TestClient_SubmitListens_Validation(t *testing.T) {
+	client := NewClient("", "")
+	assert.Error(t, client.SubmitListens(context.Background(), ListenTypeSingle, Listen{}), "missing token")
+
+	client = NewClient("", "token")
+	assert.Equal(t, DefaultBaseURL, client.baseURL)
+	assert.Error(t, client.SubmitListens(context.Background(), ListenTypeSingle, Listen{}, Listen{}))
+	assert.Error(t, client.SubmitListens(context.Background(), ListenTypeImport, make([]Listen, MaxListensPerRequest+1)...))
+	assert.NoError(t, client.SubmitListens(context.Background(), ListenTypeImport))
+}
@@ -0,0 +1,429 @@
+package mpd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	alog "github.com/vincentchyu/sonic-lens/core/log"
+)
+
+// MPD 协议常量，参考 https://mpd.readthedocs.io/en/latest/protocol.html
+const (
+	DefaultAddress = "localhost:6600"
+	DefaultTimeout = 5 * time.Second
+
+	greetingPrefix = "OK MPD "
+	responseOK     = "OK"
+	responseACK    = "ACK "
+
+	statePlay  = "play"
+	statePause = "pause"
+	stateStop  = "stop"
+)
+
+var ErrNoSong = errors.New("mpd has no current song")
+
+type (
+	// TrackInfo currentsong 与 status 中与 scrobble 相关的字段
+	TrackInfo struct {
+		File               string // 相对音乐库的文件路径或流地址
+		SongID             string // 播放队列中的 Id，切歌时变化
+		Title              string
+		Name               string // 网络电台的电台名
+		Album              string
+		Artists            []string
+		AlbumArtists       []string
+		Composers          []string
+		Genres             []string
+		TrackNumber        int64
+		DiscNumber         int64
+		Date               string
+		Duration           int64   // 秒
+		Position           float64 // 秒
+		MusicBrainzTrackID string
+	}
+
+	// Status status 命令中与播放状态相关的字段
+	Status struct {
+		State    common.PlayerState
+		SongID   string
+		Elapsed  float64 // 秒
+		Duration float64 // 秒，旧版本 MPD 不返回时为 0
+	}
+
+	// Client MPD 客户端，每次查询使用新连接，Watch 使用独立的长连接
+	Client struct {
+		address  string
+		password string
+		timeout  time.Duration
+	}
+
+	// Attr 响应中的一行 key: value，同一 key 可能出现多次
+	Attr struct {
+		Key   string
+		Value string
+	}
+
+	// Error MPD 返回的 ACK 错误
+	Error struct {
+		Code    int
+		Command string
+		Message string
+	}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("mpd error %d {%s}: %s", e.Code, e.Command, e.Message)
+}
+
+// NewClient address 为空时使用 localhost:6600，password 为空时不认证
+func NewClient(address, password string) *Client {
+	if address == "" {
+		address = DefaultAddress
+	}
+	return &Client{address: address, password: password, timeout: DefaultTimeout}
+}
+
+// Address 返回 MPD 地址
+func (c *Client) Address() string {
+	return c.address
+}
+
+// IsRunning 能连接并完成认证即视为运行中
+func (c *Client) IsRunning(ctx context.Context) bool {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		alog.Debug(ctx, "mpd dial err", zap.String("address", c.address), zap.Error(err))
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// GetStatus 执行 status
+func (c *Client) GetStatus(ctx context.Context) (*Status, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	attrs, err := conn.Command("status")
+	if err != nil {
+		return nil, err
+	}
+	return ParseStatus(attrs), nil
+}
+
+// GetState 返回播放状态
+func (c *Client) GetState(ctx context.Context) (common.PlayerState, error) {
+	status, err := c.GetStatus(ctx)
+	if err != nil {
+		return common.PlayerStateDefault, err
+	}
+	return status.State, nil
+}
+
+// GetNowPlayingTrackInfo 在同一连接上执行 currentsong 与 status
+func (c *Client) GetNowPlayingTrackInfo(ctx context.Context) (*TrackInfo, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	song, err := conn.Command("currentsong")
+	if err != nil {
+		return nil, err
+	}
+	if len(song) == 0 {
+		return nil, ErrNoSong
+	}
+	attrs, err := conn.Command("status")
+	if err != nil {
+		return nil, err
+	}
+	info := ParseSong(song)
+	status := ParseStatus(attrs)
+	info.Position = status.Elapsed
+	// duration 为带小数的秒数，优先于 currentsong 中取整的 Time
+	if status.Duration > 0 {
+		info.Duration = int64(status.Duration)
+	}
+	return info, nil
+}
+
+// Event idle 返回的变化子系统
+type Event struct {
+	Subsystem string
+}
+
+// Watch 在独立连接上循环执行 idle，subsystems 为空时监听全部子系统。
+// ctx 结束或连接断开时关闭返回的通道
+func (c *Client) Watch(ctx context.Context, subsystems ...string) (<-chan Event, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan Event, 16)
+	done := make(chan struct{})
+	go func() {
+		// idle 会一直阻塞到有变化，ctx 结束时直接关闭连接使读取返回
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+	go func() {
+		defer close(events)
+		defer close(done)
+		for {
+			attrs, err := conn.command(time.Time{}, "idle", subsystems...)
+			if err != nil {
+				if ctx.Err() == nil {
+					alog.Debug(ctx, "mpd idle err", zap.String("address", c.address), zap.Error(err))
+				}
+				return
+			}
+			for _, attr := range attrs {
+				if attr.Key != "changed" {
+					continue
+				}
+				select {
+				case events <- Event{Subsystem: attr.Value}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Conn 一条 MPD 连接，非并发安全
+type Conn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	Version string // 握手返回的协议版本
+}
+
+func (c *Client) dial(ctx context.Context) (*Conn, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	network := "tcp"
+	// 以 / 开头的地址为 unix socket
+	if strings.HasPrefix(c.address, "/") {
+		network = "unix"
+	}
+	netConn, err := dialer.DialContext(ctx, network, c.address)
+	if err != nil {
+		return nil, err
+	}
+	conn := &Conn{conn: netConn, reader: bufio.NewReader(netConn), timeout: c.timeout}
+	if err := conn.handshake(ctx); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	if c.password != "" {
+		if _, err := conn.Command("password", c.password); err != nil {
+			_ = netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *Conn) handshake(ctx context.Context) error {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, greetingPrefix) {
+		return fmt.Errorf("unexpected mpd greeting %q", line)
+	}
+	c.Version = strings.TrimPrefix(line, greetingPrefix)
+	return nil
+}
+
+// Close 关闭连接
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Command 执行一条命令并读取到 OK 为止，参数会按协议加引号转义
+func (c *Conn) Command(name string, args ...string) ([]Attr, error) {
+	return c.command(time.Now().Add(c.timeout), name, args...)
+}
+
+func (c *Conn) command(deadline time.Time, name string, args ...string) ([]Attr, error) {
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	for _, arg := range args {
+		b.WriteByte(' ')
+		b.WriteString(quote(arg))
+	}
+	b.WriteByte('\n')
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+
+	var attrs []Attr
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == responseOK {
+			return attrs, nil
+		}
+		if strings.HasPrefix(line, responseACK) {
+			return nil, parseACK(line)
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("malformed mpd response line %q", line)
+		}
+		attrs = append(attrs, Attr{Key: key, Value: value})
+	}
+}
+
+func (c *Conn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+// quote 参数中的 " 与 \ 需要转义
+func quote(arg string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(arg) + `"`
+}
+
+// parseACK 解析 ACK [error@command_listNum] {current_command} message_text
+func parseACK(line string) error {
+	e := &Error{Message: strings.TrimPrefix(line, responseACK)}
+	rest := e.Message
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.Index(rest, "]"); end > 0 {
+			code, _, _ := strings.Cut(rest[1:end], "@")
+			e.Code, _ = strconv.Atoi(code)
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+	if strings.HasPrefix(rest, "{") {
+		if end := strings.Index(rest, "}"); end > 0 {
+			e.Command = rest[1:end]
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+	e.Message = rest
+	return e
+}
+
+// ParseStatus 解析 status 响应
+func ParseStatus(attrs []Attr) *Status {
+	status := &Status{State: common.PlayerStateStopped}
+	for _, attr := range attrs {
+		switch attr.Key {
+		case "state":
+			switch attr.Value {
+			case statePlay:
+				status.State = common.PlayerStatePlaying
+			case statePause:
+				status.State = common.PlayerStatePaused
+			case stateStop:
+				status.State = common.PlayerStateStopped
+			}
+		case "songid":
+			status.SongID = attr.Value
+		case "elapsed":
+			status.Elapsed, _ = strconv.ParseFloat(attr.Value, 64)
+		case "duration":
+			status.Duration, _ = strconv.ParseFloat(attr.Value, 64)
+		case "time":
+			// 旧版本只有 time: elapsed:total，均为整数秒
+			if status.Elapsed == 0 || status.Duration == 0 {
+				elapsed, total, _ := strings.Cut(attr.Value, ":")
+				if status.Elapsed == 0 {
+					status.Elapsed, _ = strconv.ParseFloat(elapsed, 64)
+				}
+				if status.Duration == 0 {
+					status.Duration, _ = strconv.ParseFloat(total, 64)
+				}
+			}
+		}
+	}
+	return status
+}
+
+// ParseSong 解析 currentsong 响应，标签名不区分大小写，多值标签按出现顺序保留
+func ParseSong(attrs []Attr) *TrackInfo {
+	info := &TrackInfo{}
+	for _, attr := range attrs {
+		switch strings.ToLower(attr.Key) {
+		case "file":
+			info.File = attr.Value
+		case "id":
+			info.SongID = attr.Value
+		case "title":
+			info.Title = attr.Value
+		case "name":
+			info.Name = attr.Value
+		case "album":
+			info.Album = attr.Value
+		case "artist":
+			info.Artists = append(info.Artists, attr.Value)
+		case "albumartist":
+			info.AlbumArtists = append(info.AlbumArtists, attr.Value)
+		case "composer":
+			info.Composers = append(info.Composers, attr.Value)
+		case "genre":
+			info.Genres = append(info.Genres, attr.Value)
+		case "track":
+			info.TrackNumber = parseNumber(attr.Value)
+		case "disc":
+			info.DiscNumber = parseNumber(attr.Value)
+		case "date":
+			info.Date = attr.Value
+		case "duration":
+			if d, err := strconv.ParseFloat(attr.Value, 64); err == nil {
+				info.Duration = int64(d)
+			}
+		case "time":
+			if info.Duration == 0 {
+				info.Duration = parseNumber(attr.Value)
+			}
+		case "musicbrainz_trackid":
+			info.MusicBrainzTrackID = attr.Value
+		}
+	}
+	return info
+}
+
+// parseNumber Track、Disc 可能为 "3/12" 形式
+func parseNumber(value string) int64 {
+	value, _, _ = strings.Cut(value, "/")
+	n, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	return n
+}
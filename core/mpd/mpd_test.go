@@ -0,0 +1,226 @@
+package mpd
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/log"
+)
+
+// fakeServer 按脚本应答的 MPD 服务端，idle 阻塞到 changed 收到子系统名
+type fakeServer struct {
+	listener  net.Listener
+	password  string
+	responses map[string]string
+	changed   chan string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func startFakeServer(t *testing.T, password string, responses map[string]string) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{
+		listener: listener, password: password, responses: responses, changed: make(chan string, 4),
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+	_, _ = conn.Write([]byte("OK MPD 0.23.5\n"))
+	reader := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSuffix(line, "\n")
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		name, _, _ := strings.Cut(line, " ")
+		switch {
+		case name == "password":
+			if line == "password "+quote(s.password) {
+				authed = true
+				_, _ = conn.Write([]byte("OK\n"))
+			} else {
+				_, _ = conn.Write([]byte("ACK [3@0] {password} incorrect password\n"))
+			}
+		case !authed:
+			_, _ = conn.Write([]byte("ACK [4@0] {" + name + "} you don't have permission for \"" + name + "\"\n"))
+		case name == "idle":
+			subsystem := <-s.changed
+			_, _ = conn.Write([]byte("changed: " + subsystem + "\nOK\n"))
+		default:
+			_, _ = conn.Write([]byte(s.responses[name] + "OK\n"))
+		}
+	}
+}
+
+func (s *fakeServer) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+const (
+	currentSongResponse = `file: Pink Floyd/The Wall/1-03 Another Brick in the Wall.flac
+Last-Modified: 2021-03-01T10:00:00Z
+Format: 44100:16:2
+Artist: Pink Floyd
+Artist: Guest
+AlbumArtist: Pink Floyd
+Title: Another Brick in the Wall, Part 1
+Album: The Wall
+Track: 3/13
+Disc: 1/2
+Date: 1979-11-30
+Genre: Progressive Rock
+Composer: Roger Waters
+MUSICBRAINZ_TRACKID: 8a2b6e3c-0000-4c3e-9f0e-000000000003
+Time: 191
+duration: 191.373
+Pos: 2
+Id: 17
+`
+	statusResponse = `volume: 80
+repeat: 0
+state: play
+song: 2
+songid: 17
+time: 42:191
+elapsed: 42.512
+bitrate: 1411
+duration: 191.373
+`
+)
+
+func TestClient_GetNowPlayingTrackInfo(t *testing.T) {
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	server := startFakeServer(
+		t, "s3cr\"et", map[string]string{"currentsong": currentSongResponse, "status": statusResponse},
+	)
+	client := NewClient(server.listener.Addr().String(), "s3cr\"et")
+	ctx := context.Background()
+
+	assert.True(t, client.IsRunning(ctx))
+	state, err := client.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, common.PlayerState(common.PlayerStatePlaying), state)
+
+	info, err := client.GetNowPlayingTrackInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Pink Floyd/The Wall/1-03 Another Brick in the Wall.flac", info.File)
+	assert.Equal(t, "17", info.SongID)
+	assert.Equal(t, "Another Brick in the Wall, Part 1", info.Title)
+	assert.Equal(t, "The Wall", info.Album)
+	assert.Equal(t, []string{"Pink Floyd", "Guest"}, info.Artists)
+	assert.Equal(t, []string{"Pink Floyd"}, info.AlbumArtists)
+	assert.Equal(t, []string{"Roger Waters"}, info.Composers)
+	assert.Equal(t, []string{"Progressive Rock"}, info.Genres)
+	assert.EqualValues(t, 3, info.TrackNumber)
+	assert.EqualValues(t, 1, info.DiscNumber)
+	assert.Equal(t, "1979-11-30", info.Date)
+	assert.EqualValues(t, 191, info.Duration)
+	assert.InDelta(t, 42.512, info.Position, 0.001)
+	assert.Equal(t, "8a2b6e3c-0000-4c3e-9f0e-000000000003", info.MusicBrainzTrackID)
+
+	assert.Contains(t, server.Commands(), `password "s3cr\"et"`, "password is quoted and escaped")
+}
+
+func TestClient_Errors(t *testing.T) {
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	server := startFakeServer(t, "secret", map[string]string{"currentsong": "", "status": "state: stop\n"})
+	ctx := context.Background()
+
+	_, err := NewClient(server.listener.Addr().String(), "wrong").GetStatus(ctx)
+	var mpdErr *Error
+	require.ErrorAs(t, err, &mpdErr)
+	assert.Equal(t, 3, mpdErr.Code)
+	assert.Equal(t, "password", mpdErr.Command)
+	assert.Equal(t, "incorrect password", mpdErr.Message)
+	assert.False(t, NewClient(server.listener.Addr().String(), "wrong").IsRunning(ctx))
+
+	client := NewClient(server.listener.Addr().String(), "secret")
+	state, err := client.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, common.PlayerState(common.PlayerStateStopped), state)
+	_, err = client.GetNowPlayingTrackInfo(ctx)
+	assert.ErrorIs(t, err, ErrNoSong)
+
+	// 端口未监听
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	assert.False(t, NewClient(addr, "").IsRunning(ctx))
+}
+
+func TestClient_Watch(t *testing.T) {
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	server := startFakeServer(t, "", nil)
+	client := NewClient(server.listener.Addr().String(), "")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := client.Watch(ctx, "player")
+	require.NoError(t, err)
+
+	server.changed <- "player"
+	select {
+	case event := <-events:
+		assert.Equal(t, "player", event.Subsystem)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no idle event")
+	}
+	assert.Contains(t, server.Commands(), `idle "player"`)
+
+	// ctx 结束后关闭连接，通道随之关闭
+	cancel()
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("events channel not closed")
+	}
+}
+
+func TestParseStatus_LegacyTime(t *testing.T) {
+	status := ParseStatus([]Attr{{Key: "state", Value: "pause"}, {Key: "time", Value: "12:300"}})
+	assert.Equal(t, common.PlayerState(common.PlayerStatePaused), status.State)
+	assert.InDelta(t, 12, status.Elapsed, 0.001)
+	assert.InDelta(t, 300, status.Duration, 0.001)
+}
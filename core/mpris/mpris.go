@@ -0,0 +1,488 @@
+package mpris
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	alog "github.com/vincentchyu/sonic-lens/core/log"
+)
+
+// MPRIS D-Bus 规范常量，参考 https://specifications.freedesktop.org/mpris-spec/latest/
+const (
+	BusNamePrefix   = "org.mpris.MediaPlayer2."
+	ObjectPath      = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	RootInterface   = "org.mpris.MediaPlayer2"
+	PlayerInterface = "org.mpris.MediaPlayer2.Player"
+
+	propertiesGetAll = "org.freedesktop.DBus.Properties.GetAll"
+	propertiesGet    = "org.freedesktop.DBus.Properties.Get"
+	listNames        = "org.freedesktop.DBus.ListNames"
+	getNameOwner     = "org.freedesktop.DBus.GetNameOwner"
+	nameOwnerChanged = "org.freedesktop.DBus.NameOwnerChanged"
+
+	playbackStatusPlaying = "Playing"
+	playbackStatusPaused  = "Paused"
+	playbackStatusStopped = "Stopped"
+)
+
+var ErrNoPlayer = errors.New("no mpris player found")
+
+type (
+	// TrackInfo MPRIS Metadata 中与 scrobble 相关的字段
+	TrackInfo struct {
+		BusName            string
+		Identity           string
+		TrackID            string
+		Title              string
+		Album              string
+		Artists            []string
+		AlbumArtists       []string
+		Composers          []string
+		Genres             []string
+		TrackNumber        int64
+		DiscNumber         int64
+		Duration           int64   // 秒
+		Position           float64 // 秒
+		ContentCreated     string
+		Url                string
+		MusicBrainzTrackID string
+	}
+
+	// Client 通过 D-Bus 读取 org.mpris.MediaPlayer2.* 播放器状态
+	// players 为空时接受所有播放器，否则仅接受总线名后缀匹配的播放器（如 spotify、vlc）
+	Client struct {
+		conn    *dbus.Conn
+		players []string
+	}
+)
+
+var (
+	sessionMu   sync.Mutex
+	sessionConn *dbus.Conn
+)
+
+// NewClient 基于已有连接创建客户端，便于在私有总线上测试
+func NewClient(conn *dbus.Conn, players []string) *Client {
+	return &Client{conn: conn, players: players}
+}
+
+// SessionClient 返回共用会话总线连接的客户端，播放器过滤按每次调用的 players 生效，连接失败时下次调用会重试
+func SessionClient(players []string) (*Client, error) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if sessionConn == nil || !sessionConn.Connected() {
+		conn, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return nil, fmt.Errorf("connect session bus: %w", err)
+		}
+		sessionConn = conn
+	}
+	return NewClient(sessionConn, append([]string(nil), players...)), nil
+}
+
+// Conn 返回底层 D-Bus 连接
+func (c *Client) Conn() *dbus.Conn {
+	return c.conn
+}
+
+// ListPlayers 列出总线上所有符合过滤条件的 MPRIS 播放器
+func (c *Client) ListPlayers(ctx context.Context) ([]string, error) {
+	var names []string
+	err := c.conn.BusObject().CallWithContext(ctx, listNames, 0).Store(&names)
+	if err != nil {
+		return nil, err
+	}
+	players := make([]string, 0, len(names))
+	for _, name := range names {
+		if !strings.HasPrefix(name, BusNamePrefix) {
+			continue
+		}
+		if !c.accept(name) {
+			continue
+		}
+		players = append(players, name)
+	}
+	sort.Strings(players)
+	return players, nil
+}
+
+func (c *Client) accept(busName string) bool {
+	if len(c.players) == 0 {
+		return true
+	}
+	suffix := strings.ToLower(strings.TrimPrefix(busName, BusNamePrefix))
+	for _, p := range c.players {
+		p = strings.ToLower(p)
+		// 同一播放器多实例时总线名形如 org.mpris.MediaPlayer2.vlc.instance1234
+		if suffix == p || strings.HasPrefix(suffix, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ActivePlayer 返回优先正在播放的播放器，其次是暂停中的播放器
+func (c *Client) ActivePlayer(ctx context.Context) (string, common.PlayerState, error) {
+	players, err := c.ListPlayers(ctx)
+	if err != nil {
+		return "", common.PlayerStateDefault, err
+	}
+	if len(players) == 0 {
+		return "", common.PlayerStateDefault, ErrNoPlayer
+	}
+
+	var paused string
+	for _, name := range players {
+		status, err := c.playbackStatus(ctx, name)
+		if err != nil {
+			alog.Debug(ctx, "mpris PlaybackStatus err", zap.String("player", name), zap.Error(err))
+			continue
+		}
+		switch status {
+		case playbackStatusPlaying:
+			return name, common.PlayerStatePlaying, nil
+		case playbackStatusPaused:
+			if paused == "" {
+				paused = name
+			}
+		}
+	}
+	if paused != "" {
+		return paused, common.PlayerStatePaused, nil
+	}
+	return players[0], common.PlayerStateStopped, nil
+}
+
+// IsRunning 总线上存在任意符合条件的播放器即视为运行中
+func (c *Client) IsRunning(ctx context.Context) bool {
+	players, err := c.ListPlayers(ctx)
+	if err != nil {
+		alog.Debug(ctx, "mpris ListPlayers err", zap.Error(err))
+		return false
+	}
+	return len(players) > 0
+}
+
+// GetState 返回当前活跃播放器的播放状态
+func (c *Client) GetState(ctx context.Context) (common.PlayerState, error) {
+	_, state, err := c.ActivePlayer(ctx)
+	if errors.Is(err, ErrNoPlayer) {
+		return common.PlayerStateStopped, nil
+	}
+	return state, err
+}
+
+// GetNowPlayingTrackInfo 读取当前活跃播放器的 Metadata 与 Position
+func (c *Client) GetNowPlayingTrackInfo(ctx context.Context) (*TrackInfo, error) {
+	name, _, err := c.ActivePlayer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetTrackInfo(ctx, name)
+}
+
+// GetTrackInfo 读取指定播放器的曲目信息
+func (c *Client) GetTrackInfo(ctx context.Context, busName string) (*TrackInfo, error) {
+	obj := c.conn.Object(busName, ObjectPath)
+	var props map[string]dbus.Variant
+	if err := obj.CallWithContext(ctx, propertiesGetAll, 0, PlayerInterface).Store(&props); err != nil {
+		return nil, err
+	}
+
+	var metadata map[string]dbus.Variant
+	if v, ok := props["Metadata"]; ok {
+		_ = v.Store(&metadata)
+	}
+	info := ParseMetadata(metadata)
+	info.BusName = busName
+
+	// Position 不会随 PropertiesChanged 推送，部分播放器也不会出现在 GetAll 结果中，需要单独读取
+	var position int64
+	if v, ok := props["Position"]; ok {
+		position = variantInt64(v)
+	} else {
+		var pv dbus.Variant
+		if err := obj.CallWithContext(ctx, propertiesGet, 0, PlayerInterface, "Position").Store(&pv); err == nil {
+			position = variantInt64(pv)
+		}
+	}
+	info.Position = float64(position) / float64(time.Second/time.Microsecond)
+
+	var identity dbus.Variant
+	if err := obj.CallWithContext(ctx, propertiesGet, 0, RootInterface, "Identity").Store(&identity); err == nil {
+		info.Identity, _ = identity.Value().(string)
+	}
+	return info, nil
+}
+
+func (c *Client) playbackStatus(ctx context.Context, busName string) (string, error) {
+	var v dbus.Variant
+	err := c.conn.Object(busName, ObjectPath).CallWithContext(
+		ctx, propertiesGet, 0, PlayerInterface, "PlaybackStatus",
+	).Store(&v)
+	if err != nil {
+		return "", err
+	}
+	status, _ := v.Value().(string)
+	return status, nil
+}
+
+// ParseMetadata 将 MPRIS Metadata(a{sv}) 转换为 TrackInfo
+func ParseMetadata(metadata map[string]dbus.Variant) *TrackInfo {
+	info := &TrackInfo{}
+	if metadata == nil {
+		return info
+	}
+	info.TrackID = variantString(metadata["mpris:trackid"])
+	info.Duration = variantInt64(metadata["mpris:length"]) / int64(time.Second/time.Microsecond)
+	info.Title = variantString(metadata["xesam:title"])
+	info.Album = variantString(metadata["xesam:album"])
+	info.Artists = variantStrings(metadata["xesam:artist"])
+	info.AlbumArtists = variantStrings(metadata["xesam:albumArtist"])
+	info.Composers = variantStrings(metadata["xesam:composer"])
+	info.Genres = variantStrings(metadata["xesam:genre"])
+	info.TrackNumber = variantInt64(metadata["xesam:trackNumber"])
+	info.DiscNumber = variantInt64(metadata["xesam:discNumber"])
+	info.ContentCreated = variantString(metadata["xesam:contentCreated"])
+	info.Url = variantString(metadata["xesam:url"])
+	if ids := variantStrings(metadata["xesam:musicBrainzTrackID"]); len(ids) > 0 {
+		info.MusicBrainzTrackID = ids[0]
+	}
+	return info
+}
+
+func variantString(v dbus.Variant) string {
+	switch val := v.Value().(type) {
+	case string:
+		return val
+	case dbus.ObjectPath:
+		return string(val)
+	case []string:
+		if len(val) > 0 {
+			return val[0]
+		}
+	}
+	return ""
+}
+
+func variantStrings(v dbus.Variant) []string {
+	switch val := v.Value().(type) {
+	case []string:
+		return val
+	case string:
+		if val != "" {
+			return []string{val}
+		}
+	}
+	return nil
+}
+
+// variantInt64 规范要求 mpris:length 为 int64，但不少播放器会发送 uint64/int32/double
+func variantInt64(v dbus.Variant) int64 {
+	switch val := v.Value().(type) {
+	case int64:
+		return val
+	case uint64:
+		return int64(val)
+	case int32:
+		return int64(val)
+	case uint32:
+		return int64(val)
+	case int16:
+		return int64(val)
+	case uint16:
+		return int64(val)
+	case byte:
+		return int64(val)
+	case float64:
+		return int64(val)
+	}
+	return 0
+}
+
+// EventType MPRIS 信号转换后的事件类型
+type EventType string
+
+const (
+	EventTrack EventType = "track" // Metadata 变化，切换曲目
+	EventState EventType = "state" // PlaybackStatus 变化或播放器出现/退出
+	EventSeek  EventType = "seek"  // Seeked 信号，播放进度跳转
+)
+
+// Event 由 PropertiesChanged / Seeked / NameOwnerChanged 信号转换而来
+// BusName 对 PropertiesChanged 与 Seeked 为发送方唯一名（如 :1.42），对 NameOwnerChanged 为播放器总线名
+type Event struct {
+	Type     EventType
+	BusName  string
+	Status   string  // 仅 EventState 且来自 PlaybackStatus 时有值
+	Position float64 // 仅 EventSeek 有值，单位秒
+}
+
+// Watch 订阅 MPRIS 信号，ctx 结束或连接断开时关闭返回的通道
+func (c *Client) Watch(ctx context.Context) (<-chan Event, error) {
+	matches := [][]dbus.MatchOption{
+		{
+			dbus.WithMatchObjectPath(ObjectPath),
+			dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+			dbus.WithMatchMember("PropertiesChanged"),
+			dbus.WithMatchArg(0, PlayerInterface),
+		},
+		{
+			dbus.WithMatchObjectPath(ObjectPath),
+			dbus.WithMatchInterface(PlayerInterface),
+			dbus.WithMatchMember("Seeked"),
+		},
+		{
+			dbus.WithMatchSender("org.freedesktop.DBus"),
+			dbus.WithMatchInterface("org.freedesktop.DBus"),
+			dbus.WithMatchMember("NameOwnerChanged"),
+			dbus.WithMatchArg0Namespace(strings.TrimSuffix(BusNamePrefix, ".")),
+		},
+	}
+	for i, m := range matches {
+		if err := c.conn.AddMatchSignalContext(ctx, m...); err != nil {
+			for _, added := range matches[:i] {
+				_ = c.conn.RemoveMatchSignal(added...)
+			}
+			return nil, fmt.Errorf("add match signal: %w", err)
+		}
+	}
+
+	signals := make(chan *dbus.Signal, 16)
+	c.conn.Signal(signals)
+	// 先订阅再读取现有播放器的唯一名，期间上线的播放器由 NameOwnerChanged 补齐
+	owners := c.playerOwners(ctx)
+	events := make(chan Event, 16)
+	go func() {
+		defer close(events)
+		defer func() {
+			c.conn.RemoveSignal(signals)
+			for _, m := range matches {
+				_ = c.conn.RemoveMatchSignal(m...)
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig, ok := <-signals:
+				if !ok {
+					// 连接关闭时 godbus 会关闭信号通道
+					return
+				}
+				if !c.fromPlayer(owners, sig) {
+					continue
+				}
+				for _, event := range c.toEvents(sig) {
+					select {
+					case events <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// playerOwners 配置了播放器过滤时返回符合条件的播放器唯一名到总线名的映射，未过滤时返回 nil
+func (c *Client) playerOwners(ctx context.Context) map[string]string {
+	if len(c.players) == 0 {
+		return nil
+	}
+	owners := make(map[string]string)
+	players, err := c.ListPlayers(ctx)
+	if err != nil {
+		alog.Debug(ctx, "mpris ListPlayers err", zap.Error(err))
+		return owners
+	}
+	for _, name := range players {
+		var owner string
+		if err := c.conn.BusObject().CallWithContext(ctx, getNameOwner, 0, name).Store(&owner); err != nil {
+			alog.Debug(ctx, "mpris GetNameOwner err", zap.String("player", name), zap.Error(err))
+			continue
+		}
+		owners[owner] = name
+	}
+	return owners
+}
+
+// fromPlayer 判断信号是否来自符合条件的播放器。PropertiesChanged 与 Seeked 的发送方为唯一名，
+// 按 NameOwnerChanged 维护的 owners 过滤；owners 为 nil 时不过滤
+func (c *Client) fromPlayer(owners map[string]string, sig *dbus.Signal) bool {
+	if owners == nil {
+		return true
+	}
+	if sig.Name == nameOwnerChanged {
+		if len(sig.Body) < 3 {
+			return true
+		}
+		name, _ := sig.Body[0].(string)
+		oldOwner, _ := sig.Body[1].(string)
+		newOwner, _ := sig.Body[2].(string)
+		if strings.HasPrefix(name, BusNamePrefix) && c.accept(name) {
+			delete(owners, oldOwner)
+			if newOwner != "" {
+				owners[newOwner] = name
+			}
+		}
+		return true
+	}
+	_, ok := owners[sig.Sender]
+	return ok
+}
+
+// toEvents 将单个 D-Bus 信号转换为零到多个事件
+func (c *Client) toEvents(sig *dbus.Signal) []Event {
+	switch sig.Name {
+	case "org.freedesktop.DBus.Properties.PropertiesChanged":
+		if len(sig.Body) < 2 {
+			return nil
+		}
+		changed, ok := sig.Body[1].(map[string]dbus.Variant)
+		if !ok {
+			return nil
+		}
+		var events []Event
+		if v, ok := changed["PlaybackStatus"]; ok {
+			status, _ := v.Value().(string)
+			events = append(events, Event{Type: EventState, BusName: sig.Sender, Status: status})
+		}
+		if _, ok := changed["Metadata"]; ok {
+			events = append(events, Event{Type: EventTrack, BusName: sig.Sender})
+		}
+		return events
+	case PlayerInterface + ".Seeked":
+		if len(sig.Body) < 1 {
+			return nil
+		}
+		position := variantInt64(dbus.MakeVariant(sig.Body[0]))
+		return []Event{
+			{
+				Type:     EventSeek,
+				BusName:  sig.Sender,
+				Position: float64(position) / float64(time.Second/time.Microsecond),
+			},
+		}
+	case nameOwnerChanged:
+		if len(sig.Body) < 1 {
+			return nil
+		}
+		name, _ := sig.Body[0].(string)
+		if !strings.HasPrefix(name, BusNamePrefix) || !c.accept(name) {
+			return nil
+		}
+		return []Event{{Type: EventState, BusName: name}}
+	}
+	return nil
+}
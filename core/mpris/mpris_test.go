@@ -0,0 +1,290 @@
+package mpris
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/common"
+)
+
+const privateBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>`
+
+// startPrivateBus 启动一个私有 dbus-daemon，避免依赖宿主机会话总线
+func startPrivateBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found, skip mpris private bus test")
+	}
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "bus.conf")
+	cfg := strings.Replace(privateBusConfig, "%s", dir, 1)
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfg), 0o600))
+
+	cmd := exec.Command(daemon, "--config-file="+cfgPath, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(
+		func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		},
+	)
+
+	addrCh := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		addrCh <- strings.TrimSpace(line)
+	}()
+	select {
+	case addr := <-addrCh:
+		require.NotEmpty(t, addr)
+		return addr
+	case <-time.After(5 * time.Second):
+		t.Fatal("dbus-daemon did not print address")
+	}
+	return ""
+}
+
+// fakePlayer 在私有总线上导出一个最小化的 MPRIS 播放器
+type fakePlayer struct {
+	conn  *dbus.Conn
+	props *prop.Properties
+}
+
+func newFakePlayer(t *testing.T, addr, name, status string, metadata map[string]dbus.Variant, position int64) *fakePlayer {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	reply, err := conn.RequestName(BusNamePrefix+name, dbus.NameFlagDoNotQueue)
+	require.NoError(t, err)
+	require.Equal(t, dbus.RequestNameReplyPrimaryOwner, reply)
+
+	props, err := prop.Export(
+		conn, ObjectPath, prop.Map{
+			RootInterface: {
+				"Identity": {Value: strings.ToUpper(name[:1]) + name[1:], Emit: prop.EmitFalse},
+			},
+			PlayerInterface: {
+				"PlaybackStatus": {Value: status, Emit: prop.EmitTrue},
+				"Metadata":       {Value: metadata, Emit: prop.EmitTrue},
+				"Position":       {Value: position, Emit: prop.EmitFalse},
+			},
+		},
+	)
+	require.NoError(t, err)
+	return &fakePlayer{conn: conn, props: props}
+}
+
+func sampleMetadata() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"mpris:trackid":            dbus.MakeVariant(dbus.ObjectPath("/org/mpd/Tracks/42")),
+		"mpris:length":             dbus.MakeVariant(int64(245 * time.Second / time.Microsecond)),
+		"mpris:artUrl":             dbus.MakeVariant("file:///music/cover.jpg"),
+		"xesam:title":              dbus.MakeVariant("杀死那个石家庄人"),
+		"xesam:album":              dbus.MakeVariant("万能青年旅店"),
+		"xesam:artist":             dbus.MakeVariant([]string{"万能青年旅店"}),
+		"xesam:albumArtist":        dbus.MakeVariant([]string{"万能青年旅店"}),
+		"xesam:composer":           dbus.MakeVariant([]string{"董亚千"}),
+		"xesam:genre":              dbus.MakeVariant([]string{"Rock"}),
+		"xesam:trackNumber":        dbus.MakeVariant(int32(6)),
+		"xesam:discNumber":         dbus.MakeVariant(int32(1)),
+		"xesam:contentCreated":     dbus.MakeVariant("2010-01-01T00:00:00Z"),
+		"xesam:url":                dbus.MakeVariant("file:///music/06.flac"),
+		"xesam:musicBrainzTrackID": dbus.MakeVariant([]string{"9b7a8e0e-0000-4000-8000-000000000000"}),
+	}
+}
+
+func TestClient_PrivateBus(t *testing.T) {
+	addr := startPrivateBus(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := dbus.Connect(addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := NewClient(conn, nil)
+	assert.False(t, client.IsRunning(ctx))
+	state, err := client.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, common.PlayerState(common.PlayerStateStopped), state)
+
+	// 一个暂停的播放器 + 一个正在播放的播放器，应优先选择正在播放的
+	newFakePlayer(t, addr, "vlc", playbackStatusPaused, map[string]dbus.Variant{}, 0)
+	newFakePlayer(t, addr, "spotify", playbackStatusPlaying, sampleMetadata(), int64(130*time.Second/time.Microsecond))
+
+	players, err := client.ListPlayers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{BusNamePrefix + "spotify", BusNamePrefix + "vlc"}, players)
+	assert.True(t, client.IsRunning(ctx))
+
+	state, err = client.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, common.PlayerState(common.PlayerStatePlaying), state)
+
+	info, err := client.GetNowPlayingTrackInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, BusNamePrefix+"spotify", info.BusName)
+	assert.Equal(t, "Spotify", info.Identity)
+	assert.Equal(t, "/org/mpd/Tracks/42", info.TrackID)
+	assert.Equal(t, "杀死那个石家庄人", info.Title)
+	assert.Equal(t, "万能青年旅店", info.Album)
+	assert.Equal(t, []string{"万能青年旅店"}, info.Artists)
+	assert.Equal(t, []string{"Rock"}, info.Genres)
+	assert.Equal(t, int64(245), info.Duration)
+	assert.InDelta(t, 130.0, info.Position, 0.001)
+	assert.Equal(t, int64(6), info.TrackNumber)
+	assert.Equal(t, int64(1), info.DiscNumber)
+	assert.Equal(t, "9b7a8e0e-0000-4000-8000-000000000000", info.MusicBrainzTrackID)
+	assert.Equal(t, "file:///music/06.flac", info.Url)
+
+	// 过滤后只剩暂停中的 vlc
+	filtered := NewClient(conn, []string{"vlc"})
+	state, err = filtered.GetState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, common.PlayerState(common.PlayerStatePaused), state)
+}
+
+func TestSessionClient_PlayersPerCall(t *testing.T) {
+	addr := startPrivateBus(t)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", addr)
+	sessionMu.Lock()
+	sessionConn = nil
+	sessionMu.Unlock()
+	t.Cleanup(
+		func() {
+			sessionMu.Lock()
+			defer sessionMu.Unlock()
+			if sessionConn != nil {
+				_ = sessionConn.Close()
+				sessionConn = nil
+			}
+		},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	newFakePlayer(t, addr, "vlc", playbackStatusPaused, map[string]dbus.Variant{}, 0)
+	newFakePlayer(t, addr, "spotify", playbackStatusPlaying, sampleMetadata(), 0)
+
+	// 配置变更后以新的过滤条件重新创建，复用同一连接但各自生效
+	vlc, err := SessionClient([]string{"vlc"})
+	require.NoError(t, err)
+	spotify, err := SessionClient([]string{"spotify"})
+	require.NoError(t, err)
+	assert.Same(t, vlc.Conn(), spotify.Conn())
+
+	players, err := vlc.ListPlayers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{BusNamePrefix + "vlc"}, players)
+	players, err = spotify.ListPlayers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{BusNamePrefix + "spotify"}, players)
+}
+
+func TestParseMetadata_LooseTypes(t *testing.T) {
+	// 部分播放器将 mpris:length 作为 uint64 发送、将 artist 作为单个字符串发送
+	info := ParseMetadata(
+		map[string]dbus.Variant{
+			"mpris:length": dbus.MakeVariant(uint64(61_500_000)),
+			"xesam:artist": dbus.MakeVariant("Radiohead"),
+		},
+	)
+	assert.Equal(t, int64(61), info.Duration)
+	assert.Equal(t, []string{"Radiohead"}, info.Artists)
+	assert.Empty(t, ParseMetadata(nil).Title)
+}
+
+func TestClient_Watch(t *testing.T) {
+	addr := startPrivateBus(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := dbus.Connect(addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := NewClient(conn, []string{"spotify"})
+	events, err := client.Watch(ctx)
+	require.NoError(t, err)
+
+	next := func() Event {
+		t.Helper()
+		select {
+		case event, ok := <-events:
+			require.True(t, ok, "events channel closed")
+			return event
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for mpris event")
+		}
+		return Event{}
+	}
+
+	// 被过滤的播放器上线不产生事件，符合条件的播放器上线产生 state 事件
+	other := newFakePlayer(t, addr, "vlc", playbackStatusPaused, map[string]dbus.Variant{}, 0)
+	player := newFakePlayer(t, addr, "spotify", playbackStatusPaused, sampleMetadata(), 0)
+	event := next()
+	assert.Equal(t, EventState, event.Type)
+	assert.Equal(t, BusNamePrefix+"spotify", event.BusName)
+
+	// 被过滤的播放器的属性变化与跳转同样不产生事件
+	other.props.SetMust(PlayerInterface, "PlaybackStatus", playbackStatusPlaying)
+	require.NoError(t, other.conn.Emit(ObjectPath, PlayerInterface+".Seeked", int64(0)))
+	player.props.SetMust(PlayerInterface, "PlaybackStatus", playbackStatusPlaying)
+	event = next()
+	assert.Equal(t, EventState, event.Type)
+	assert.Equal(t, playbackStatusPlaying, event.Status)
+	assert.Equal(t, player.conn.Names()[0], event.BusName)
+
+	player.props.SetMust(PlayerInterface, "Metadata", map[string]dbus.Variant{"xesam:title": dbus.MakeVariant("下一首")})
+	assert.Equal(t, EventTrack, next().Type)
+
+	require.NoError(t, player.conn.Emit(ObjectPath, PlayerInterface+".Seeked", int64(90*time.Second/time.Microsecond)))
+	event = next()
+	assert.Equal(t, EventSeek, event.Type)
+	assert.InDelta(t, 90.0, event.Position, 0.001)
+
+	// 启动监听前已在线的播放器按唯一名过滤
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	defer watchCancel()
+	events, err = client.Watch(watchCtx)
+	require.NoError(t, err)
+	other.props.SetMust(PlayerInterface, "Metadata", map[string]dbus.Variant{"xesam:title": dbus.MakeVariant("vlc")})
+	player.props.SetMust(PlayerInterface, "PlaybackStatus", playbackStatusPaused)
+	event = next()
+	assert.Equal(t, EventState, event.Type)
+	assert.Equal(t, playbackStatusPaused, event.Status)
+	watchCancel()
+	for range events {
+	}
+
+	// ctx 结束后通道关闭
+	cancel()
+	for range events {
+	}
+}
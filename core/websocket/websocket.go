@@ -80,6 +80,21 @@ func BroadcastMessage(ctx context.Context, message *WsTrackInfo) {
 	}
 }
 
+// SendMessage 向单个客户端发送消息，与广播互斥
+func SendMessage(ctx context.Context, conn *websocket.Conn, message *WsTrackInfo) {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Error(ctx, "Failed to marshal message", zap.Error(err))
+		return
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		log.Error(ctx, "Failed to send message to client", zap.Error(err))
+	}
+}
+
 // UpgradeConnection 升级HTTP连接到WebSocket连接
 func UpgradeConnection(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	return upgrader.Upgrade(w, r, nil)
@@ -0,0 +1,273 @@
+package audioscrobbler
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// Server Last.fm 2.0 (AudioScrobbler) 兼容接口，移动端 scrobbler、foobar2000 插件等客户端
+// 把 API 地址指向 /2.0/ 后即可把 SonicLens 当作 Last.fm 使用。
+// 仅实现 auth.getMobileSession、track.updateNowPlaying、track.scrobble 与 track.love
+type Server struct {
+	cfg   config.AudioScrobblerConfig
+	relay scrobble.Target // 为 nil 时不转发到 Last.fm
+	love  func(ctx context.Context, artist, track string, loved bool) error
+	now   func() time.Time
+}
+
+// request 通过 api_key、签名与会话校验后的请求
+type request struct {
+	params  url.Values
+	client  config.AudioScrobblerClientConfig
+	session *model.ScrobbleSession
+}
+
+type methodFunc func(s *Server, ctx context.Context, req *request) (*response, error)
+
+// methods 方法名不区分大小写
+var methods = map[string]methodFunc{
+	"auth.getmobilesession":  (*Server).getMobileSession,
+	"track.updatenowplaying": (*Server).updateNowPlaying,
+	"track.scrobble":         (*Server).scrobble,
+	"track.love":             (*Server).loveTrack,
+}
+
+// NewServer 按配置创建兼容接口，relay 开启时经 core/lastfm 转发到已登录的 Last.fm 账号
+func NewServer(cfg config.AudioScrobblerConfig) *Server {
+	s := &Server{cfg: cfg, now: time.Now}
+	if cfg.Relay {
+		s.relay = scrobble.NewLastfmTarget()
+		s.love = lastfm.SetFavorite
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := r.ParseForm(); err != nil {
+		writeError(w, r.Form.Get("format"), invalidParameters(err.Error()))
+		return
+	}
+	format := r.Form.Get("format")
+	resp, err := s.handle(ctx, r.Form)
+	if err != nil {
+		var apiErr *lastfm.APIError
+		if !errors.As(err, &apiErr) {
+			log.Error(ctx, "AudioScrobbler request failed", zap.String("method", r.Form.Get("method")), zap.Error(err))
+			apiErr = &lastfm.APIError{Code: lastfm.ErrCodeOperationFailed, Message: "Operation failed - Most likely the backend service failed. Please try again."}
+		}
+		writeError(w, format, apiErr)
+		return
+	}
+	writeResponse(w, format, resp)
+}
+
+func (s *Server) handle(ctx context.Context, params url.Values) (*response, error) {
+	method, ok := methods[strings.ToLower(params.Get("method"))]
+	if !ok {
+		return nil, &lastfm.APIError{Code: lastfm.ErrCodeInvalidMethod, Message: "Invalid Method - No method with that name in this package"}
+	}
+	client, ok := s.client(params.Get("api_key"))
+	if !ok {
+		return nil, &lastfm.APIError{Code: lastfm.ErrCodeInvalidAPIKey, Message: "Invalid API key - You must be granted a valid key by last.fm"}
+	}
+	if !validSignature(params, client.Secret) {
+		return nil, &lastfm.APIError{Code: lastfm.ErrCodeInvalidSignature, Message: "Invalid method signature supplied"}
+	}
+
+	req := &request{params: params, client: client}
+	if !strings.EqualFold(params.Get("method"), "auth.getMobileSession") {
+		session, err := model.GetScrobbleSession(ctx, params.Get("sk"))
+		if err != nil {
+			return nil, err
+		}
+		// 会话只对签发时的客户端有效
+		if session == nil || session.ApiKey != client.ApiKey {
+			return nil, &lastfm.APIError{Code: lastfm.ErrCodeInvalidSessionKey, Message: "Invalid session key - Please re-authenticate"}
+		}
+		req.session = session
+	}
+	return method(s, ctx, req)
+}
+
+func (s *Server) client(apiKey string) (config.AudioScrobblerClientConfig, bool) {
+	if apiKey == "" {
+		return config.AudioScrobblerClientConfig{}, false
+	}
+	for _, client := range s.cfg.Clients {
+		if client.ApiKey == apiKey {
+			return client, true
+		}
+	}
+	return config.AudioScrobblerClientConfig{}, false
+}
+
+// source 客户端写入 track_play_records.source 的名称
+func source(client config.AudioScrobblerClientConfig) string {
+	if client.Name != "" {
+		return client.Name
+	}
+	return client.ApiKey
+}
+
+// getMobileSession 校验用户名与密码（或 authToken = md5(username + md5(password))）后签发会话
+func (s *Server) getMobileSession(ctx context.Context, req *request) (*response, error) {
+	username := req.params.Get("username")
+	if username == "" || (req.params.Get("password") == "" && req.params.Get("authToken") == "") {
+		return nil, invalidParameters("username and password are required")
+	}
+	if !s.authenticate(username, req.params.Get("password"), req.params.Get("authToken")) {
+		return nil, &lastfm.APIError{Code: lastfm.ErrCodeAuthFailed, Message: "Authentication Failed - You do not have permissions to access the service"}
+	}
+
+	key, err := newSessionKey()
+	if err != nil {
+		return nil, err
+	}
+	session := &model.ScrobbleSession{SessionKey: key, ApiKey: req.client.ApiKey, Username: s.cfg.Username}
+	if err := model.CreateScrobbleSession(ctx, session); err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "AudioScrobbler session created", zap.String("client", source(req.client)))
+	return &response{
+		name: "session",
+		body: &sessionBody{Name: s.cfg.Username, Key: key},
+	}, nil
+}
+
+func (s *Server) authenticate(username, password, authToken string) bool {
+	if s.cfg.Username == "" || s.cfg.Password == "" || !strings.EqualFold(username, s.cfg.Username) {
+		return false
+	}
+	if password != "" {
+		return subtle.ConstantTimeCompare([]byte(password), []byte(s.cfg.Password)) == 1
+	}
+	expected := md5Hex(username + md5Hex(s.cfg.Password))
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(authToken)), []byte(expected)) == 1
+}
+
+// validSignature 按 Last.fm 规则校验 api_sig，format 与 callback 不参与签名
+func validSignature(params url.Values, secret string) bool {
+	signed := make(map[string]string, len(params))
+	for k := range params {
+		switch k {
+		case "api_sig", "format", "callback":
+			continue
+		}
+		signed[k] = params.Get(k)
+	}
+	expected := lastfm.Signature(signed, secret)
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(params.Get("api_sig"))), []byte(expected)) == 1
+}
+
+func newSessionKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func invalidParameters(message string) *lastfm.APIError {
+	return &lastfm.APIError{Code: lastfm.ErrCodeInvalidParameters, Message: "Invalid parameters - " + message}
+}
+
+// response 成功响应，XML 中 body 位于 <lfm status="ok"> 内，JSON 中为 {name: body}
+type response struct {
+	name string
+	body any // 为 nil 时返回空的 <lfm status="ok"/> 与 {}
+}
+
+type lfmEnvelope struct {
+	XMLName xml.Name `xml:"lfm"`
+	Status  string   `xml:"status,attr"`
+	Body    any
+}
+
+type lfmError struct {
+	XMLName xml.Name `xml:"error" json:"-"`
+	Code    int      `xml:"code,attr" json:"error"`
+	Message string   `xml:",chardata" json:"message"`
+}
+
+type sessionBody struct {
+	XMLName    xml.Name `xml:"session" json:"-"`
+	Name       string   `xml:"name" json:"name"`
+	Key        string   `xml:"key" json:"key"`
+	Subscriber int      `xml:"subscriber" json:"subscriber"`
+}
+
+// correctedText 带 corrected 属性的文本，JSON 中文本位于 #text
+type correctedText struct {
+	Corrected string `xml:"corrected,attr" json:"corrected"`
+	Text      string `xml:",chardata" json:"#text"`
+}
+
+type ignoredMessage struct {
+	Code string `xml:"code,attr" json:"code"`
+	Text string `xml:",chardata" json:"#text"`
+}
+
+func writeResponse(w http.ResponseWriter, format string, resp *response) {
+	if strings.EqualFold(format, "json") {
+		body := map[string]any{}
+		if resp.body != nil {
+			body[resp.name] = resp.body
+		}
+		writeJSON(w, http.StatusOK, body)
+		return
+	}
+	writeXML(w, http.StatusOK, &lfmEnvelope{Status: "ok", Body: resp.body})
+}
+
+func writeError(w http.ResponseWriter, format string, apiErr *lastfm.APIError) {
+	status := http.StatusBadRequest
+	switch apiErr.Code {
+	case lastfm.ErrCodeAuthFailed, lastfm.ErrCodeInvalidSessionKey, lastfm.ErrCodeInvalidAPIKey, lastfm.ErrCodeInvalidSignature:
+		status = http.StatusForbidden
+	case lastfm.ErrCodeOperationFailed:
+		status = http.StatusInternalServerError
+	}
+	body := &lfmError{Code: apiErr.Code, Message: apiErr.Message}
+	if strings.EqualFold(format, "json") {
+		writeJSON(w, status, body)
+		return
+	}
+	writeXML(w, status, &lfmEnvelope{Status: "failed", Body: body})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeXML(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,353 @@
+package audioscrobbler
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/internal/logic/rewrite"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+var testConfig = config.AudioScrobblerConfig{
+	Username: "vincent",
+	Password: "hunter2",
+	Clients: []config.AudioScrobblerClientConfig{
+		{Name: "Pano Scrobbler", ApiKey: "pano-key", Secret: "pano-secret"},
+		{ApiKey: "other-key", Secret: "other-secret"},
+	},
+}
+
+// call 按客户端 secret 签名后以表单 POST 到 /2.0/
+func call(t *testing.T, s *Server, secret string, params map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	signed := make(map[string]string, len(params))
+	form := url.Values{}
+	for k, v := range params {
+		form.Set(k, v)
+		if k != "format" {
+			signed[k] = v
+		}
+	}
+	form.Set("api_sig", lastfm.Signature(signed, secret))
+	req := httptest.NewRequest(http.MethodPost, "/2.0/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func login(t *testing.T, s *Server) string {
+	t.Helper()
+	w := call(
+		t, s, "pano-secret", map[string]string{
+			"method": "auth.getMobileSession", "api_key": "pano-key", "username": "Vincent", "password": "hunter2",
+		},
+	)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Status  string `xml:"status,attr"`
+		Session struct {
+			Name string `xml:"name"`
+			Key  string `xml:"key"`
+		} `xml:"session"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "vincent", resp.Session.Name)
+	require.Len(t, resp.Session.Key, 32)
+	return resp.Session.Key
+}
+
+func lfmErrorCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	var resp struct {
+		Status string `xml:"status,attr"`
+		Error  struct {
+			Code int `xml:"code,attr"`
+		} `xml:"error"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	assert.Equal(t, "failed", resp.Status)
+	return resp.Error.Code
+}
+
+func TestServer_Auth(t *testing.T) {
+	model.SetupTestDB(t)
+	s := NewServer(testConfig)
+	sk := login(t, s)
+
+	// authToken = md5(username + md5(password))
+	w := call(
+		t, s, "pano-secret", map[string]string{
+			"method": "auth.getMobileSession", "api_key": "pano-key", "username": "vincent",
+			"authToken": md5Hex("vincent" + md5Hex("hunter2")),
+		},
+	)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = call(
+		t, s, "pano-secret", map[string]string{
+			"method": "auth.getMobileSession", "api_key": "pano-key", "username": "vincent", "password": "wrong",
+		},
+	)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, lastfm.ErrCodeAuthFailed, lfmErrorCode(t, w))
+
+	w = call(
+		t, s, "wrong-secret", map[string]string{
+			"method": "track.love", "api_key": "pano-key", "sk": sk, "artist": "A", "track": "T",
+		},
+	)
+	assert.Equal(t, lastfm.ErrCodeInvalidSignature, lfmErrorCode(t, w))
+
+	w = call(t, s, "x", map[string]string{"method": "track.love", "api_key": "unknown"})
+	assert.Equal(t, lastfm.ErrCodeInvalidAPIKey, lfmErrorCode(t, w))
+
+	// 会话只对签发时的 api_key 有效
+	w = call(
+		t, s, "other-secret", map[string]string{
+			"method": "track.love", "api_key": "other-key", "sk": sk, "artist": "A", "track": "T",
+		},
+	)
+	assert.Equal(t, lastfm.ErrCodeInvalidSessionKey, lfmErrorCode(t, w))
+
+	w = call(t, s, "pano-secret", map[string]string{"method": "user.getInfo", "api_key": "pano-key"})
+	assert.Equal(t, lastfm.ErrCodeInvalidMethod, lfmErrorCode(t, w))
+}
+
+func TestServer_Scrobble(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	s := NewServer(testConfig)
+	sk := login(t, s)
+
+	params := map[string]string{
+		"method": "track.scrobble", "api_key": "pano-key", "sk": sk,
+		"artist[0]": "陳綺貞", "track[0]": "太陽", "album[0]": "太陽", "timestamp[0]": "1614592800", "duration[0]": "210",
+		"artist[1]": "Quincy Jones", "track[1]": "Hikky Burr(feat.Bill Cosby)", "album[1]": "Smackwater Jack",
+		"albumArtist[1]": "Quincy Jones", "timestamp[1]": "1614600240", "trackNumber[1]": "3",
+		"artist[2]": "No Album", "track[2]": "Single", "timestamp[2]": "1614600480",
+	}
+	w := call(t, s, "pano-secret", params)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Scrobbles struct {
+			Accepted int `xml:"accepted,attr"`
+			Ignored  int `xml:"ignored,attr"`
+			Scrobble []struct {
+				Track          string `xml:"track"`
+				IgnoredMessage struct {
+					Code int `xml:"code,attr"`
+				} `xml:"ignoredMessage"`
+			} `xml:"scrobble"`
+		} `xml:"scrobbles"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Scrobbles.Accepted)
+	assert.Equal(t, 1, resp.Scrobbles.Ignored)
+	require.Len(t, resp.Scrobbles.Scrobble, 3)
+	assert.Equal(t, "Hikky Burr (feat. Bill Cosby)", resp.Scrobbles.Scrobble[1].Track)
+	assert.Equal(t, lastfm.IgnoredCodeTrackIgnored, resp.Scrobbles.Scrobble[2].IgnoredMessage.Code)
+
+	var records []*model.TrackPlayRecord
+	require.NoError(t, model.GetDB().Order("play_time").Find(&records).Error)
+	require.Len(t, records, 2)
+	assert.Equal(t, "陈绮贞", records[0].Artist, "normalized like live plays")
+	assert.Equal(t, "Pano Scrobbler", records[0].Source)
+	assert.True(t, records[0].Scrobbled, "nothing to relay")
+	assert.EqualValues(t, 210, records[0].Duration)
+	assert.EqualValues(t, 3, records[1].TrackNumber)
+	got, err := model.GetTrack(ctx, "陈绮贞", "太阳", "太阳")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, 1, got.PlayCount)
+
+	// 客户端重发同一批不会重复写入
+	w = call(t, s, "pano-secret", params)
+	require.Equal(t, http.StatusOK, w.Code)
+	var count int64
+	require.NoError(t, model.GetDB().Model(&model.TrackPlayRecord{}).Count(&count).Error)
+	assert.EqualValues(t, 2, count)
+
+	// JSON 格式中单条 scrobble 为对象
+	w = call(
+		t, s, "pano-secret", map[string]string{
+			"method": "track.scrobble", "api_key": "pano-key", "sk": sk, "format": "json",
+			"artist": "Joni Mitchell", "track": "River", "album": "Blue", "timestamp": "1588162317",
+		},
+	)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var jsonResp struct {
+		Scrobbles struct {
+			Attr     map[string]int `json:"@attr"`
+			Scrobble struct {
+				Track struct {
+					Text string `json:"#text"`
+				} `json:"track"`
+			} `json:"scrobble"`
+		} `json:"scrobbles"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jsonResp))
+	assert.Equal(t, 1, jsonResp.Scrobbles.Attr["accepted"])
+	assert.Equal(t, "River", jsonResp.Scrobbles.Scrobble.Track.Text)
+}
+
+// fakeTarget 记录转发内容，err 不为空时整批失败
+type fakeTarget struct {
+	nowPlaying []*scrobble.Scrobble
+	scrobbles  []*scrobble.Scrobble
+	err        error
+}
+
+func (f *fakeTarget) Name() string   { return model.OutboxTargetLastfm }
+func (f *fakeTarget) BatchSize() int { return lastfm.ScrobbleBatchSize }
+func (f *fakeTarget) UpdateNowPlaying(ctx context.Context, s *scrobble.Scrobble) error {
+	f.nowPlaying = append(f.nowPlaying, s)
+	return nil
+}
+func (f *fakeTarget) Scrobble(ctx context.Context, s *scrobble.Scrobble) error {
+	return errors.New("not used")
+}
+func (f *fakeTarget) ScrobbleBatch(ctx context.Context, items []*scrobble.Scrobble) ([]scrobble.ItemResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.scrobbles = append(f.scrobbles, items...)
+	results := make([]scrobble.ItemResult, len(items))
+	for i := range results {
+		results[i] = scrobble.ItemResult{Accepted: true}
+	}
+	return results, nil
+}
+func (f *fakeTarget) ClassifyError(err error) scrobble.ErrorClass { return scrobble.ErrorTransient }
+func (f *fakeTarget) ErrorCode(err error) int                     { return lastfm.ErrCodeServiceOffline }
+
+func TestServer_Relay(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	target := &fakeTarget{}
+	var loved []string
+	s := NewServer(testConfig)
+	s.relay = target
+	s.love = func(ctx context.Context, artist, track string, isLoved bool) error {
+		loved = append(loved, artist+" - "+track)
+		return nil
+	}
+	s.now = func() time.Time { return time.Unix(1700000000, 0) }
+	sk := login(t, s)
+
+	w := call(
+		t, s, "pano-secret", map[string]string{
+			"method": "track.updateNowPlaying", "api_key": "pano-key", "sk": sk,
+			"artist": "Pink Floyd", "track": "Time", "album": "The Dark Side of the Moon",
+		},
+	)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, target.nowPlaying, 1)
+	assert.Equal(t, "Pink Floyd", target.nowPlaying[0].AlbumArtist)
+
+	scrobbleParams := func(track, timestamp string) map[string]string {
+		return map[string]string{
+			"method": "track.scrobble", "api_key": "pano-key", "sk": sk,
+			"artist": "Pink Floyd", "track": track, "album": "The Dark Side of the Moon", "timestamp": timestamp,
+		}
+	}
+	require.Equal(t, http.StatusOK, call(t, s, "pano-secret", scrobbleParams("Time", "1699999000")).Code)
+	require.Len(t, target.scrobbles, 1)
+	assert.Equal(t, "Pano Scrobbler", target.scrobbles[0].Source)
+
+	// 转发失败时记录仍写入，并由 outbox 重试
+	target.err = &lastfm.APIError{Code: lastfm.ErrCodeServiceOffline, Message: "offline"}
+	require.Equal(t, http.StatusOK, call(t, s, "pano-secret", scrobbleParams("Money", "1699999400")).Code)
+
+	// 超前的时间戳被忽略
+	w = call(t, s, "pano-secret", scrobbleParams("Us and Them", "1700086400"))
+	assert.Contains(t, w.Body.String(), `ignored="1"`)
+
+	var records []*model.TrackPlayRecord
+	require.NoError(t, model.GetDB().Order("play_time").Find(&records).Error)
+	require.Len(t, records, 2)
+	assert.True(t, records[0].Scrobbled)
+	assert.False(t, records[1].Scrobbled)
+	deliveries, err := model.GetScrobbleDeliveries(ctx, records[1].ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.OutboxStatusPending, deliveries[0].Status)
+	assert.Equal(t, lastfm.ErrCodeServiceOffline, deliveries[0].ErrorCode)
+
+	w = call(
+		t, s, "pano-secret", map[string]string{
+			"method": "track.love", "api_key": "pano-key", "sk": sk, "artist": "Pink Floyd", "track": "Time",
+		},
+	)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"Pink Floyd - Time"}, loved)
+	got, err := model.GetTrack(ctx, "Pink Floyd", "The Dark Side of the Moon", "Time")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.True(t, got.IsLastFmFav)
+}
+
+func TestServer_RewriteRules(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	require.NoError(
+		t, model.CreateRewriteRule(
+			ctx, &model.RewriteRule{
+				Name: "beatles", Enabled: true, MatchSource: "^Pano Scrobbler$", MatchArtist: "(?i)^beatles$",
+				Actions: model.RewriteActions{{Field: model.RewriteFieldArtist, Replace: "The Beatles"}},
+			},
+		),
+	)
+	rewrite.Invalidate()
+	t.Cleanup(rewrite.Invalidate)
+	target := &fakeTarget{}
+	s := NewServer(testConfig)
+	s.relay = target
+	sk := login(t, s)
+
+	// 规则在去重、转发与写库前生效，响应中标记被改写的字段
+	params := map[string]string{
+		"method": "track.scrobble", "api_key": "pano-key", "sk": sk, "format": "json",
+		"artist": "beatles", "track": "Something", "album": "Abbey Road", "timestamp": "1614592800",
+	}
+	for i := 0; i < 2; i++ {
+		w := call(t, s, "pano-secret", params)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Scrobbles struct {
+				Scrobble struct {
+					Artist correctedText `json:"artist"`
+					Track  correctedText `json:"track"`
+				} `json:"scrobble"`
+			} `json:"scrobbles"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, correctedText{Corrected: "1", Text: "The Beatles"}, resp.Scrobbles.Scrobble.Artist)
+		assert.Equal(t, correctedText{Corrected: "0", Text: "Something"}, resp.Scrobbles.Scrobble.Track)
+	}
+	require.Len(t, target.scrobbles, 1)
+	assert.Equal(t, "The Beatles", target.scrobbles[0].Artist)
+
+	var records []*model.TrackPlayRecord
+	require.NoError(t, model.GetDB().Find(&records).Error)
+	require.Len(t, records, 1)
+	assert.Equal(t, "The Beatles", records[0].Artist)
+	got, err := model.GetTrack(ctx, "The Beatles", "Abbey Road", "Something")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.PlayCount)
+}
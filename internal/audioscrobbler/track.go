@@ -0,0 +1,362 @@
+package audioscrobbler
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/logic/rewrite"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// maxTimestampSkew 时间戳晚于当前时间超过该值的记录按 Last.fm 规则忽略
+const maxTimestampSkew = 10 * time.Minute
+
+// trackParams track.* 方法中描述一首曲目的参数，已按实时播放的规则规范化
+type trackParams struct {
+	artist      string
+	track       string
+	album       string
+	albumArtist string
+	mbid        string
+	timestamp   int64
+	duration    int64
+	trackNumber int64
+}
+
+// nowPlayingBody track.updateNowPlaying 的响应
+type nowPlayingBody struct {
+	XMLName        xml.Name       `xml:"nowplaying" json:"-"`
+	Track          correctedText  `xml:"track" json:"track"`
+	Artist         correctedText  `xml:"artist" json:"artist"`
+	Album          correctedText  `xml:"album" json:"album"`
+	AlbumArtist    correctedText  `xml:"albumArtist" json:"albumArtist"`
+	IgnoredMessage ignoredMessage `xml:"ignoredMessage" json:"ignoredMessage"`
+}
+
+// scrobbleResult track.scrobble 响应中的一条，顺序与请求一致
+type scrobbleResult struct {
+	Track          correctedText  `xml:"track" json:"track"`
+	Artist         correctedText  `xml:"artist" json:"artist"`
+	Album          correctedText  `xml:"album" json:"album"`
+	AlbumArtist    correctedText  `xml:"albumArtist" json:"albumArtist"`
+	Timestamp      string         `xml:"timestamp" json:"timestamp"`
+	IgnoredMessage ignoredMessage `xml:"ignoredMessage" json:"ignoredMessage"`
+}
+
+// scrobblesBody track.scrobble 的响应
+type scrobblesBody struct {
+	XMLName   xml.Name          `xml:"scrobbles"`
+	Accepted  int               `xml:"accepted,attr"`
+	Ignored   int               `xml:"ignored,attr"`
+	Scrobbles []*scrobbleResult `xml:"scrobble"`
+}
+
+// MarshalJSON 与 Last.fm 一致，只有一条时 scrobble 为对象而不是数组
+func (b *scrobblesBody) MarshalJSON() ([]byte, error) {
+	var scrobbles any = b.Scrobbles
+	if len(b.Scrobbles) == 1 {
+		scrobbles = b.Scrobbles[0]
+	}
+	return json.Marshal(
+		map[string]any{
+			"@attr":    map[string]int{"accepted": b.Accepted, "ignored": b.Ignored},
+			"scrobble": scrobbles,
+		},
+	)
+}
+
+// updateNowPlaying 只转发到 Last.fm，不写入播放记录
+func (s *Server) updateNowPlaying(ctx context.Context, req *request) (*response, error) {
+	item, err := parseTrack(req.params, "")
+	if err != nil {
+		return nil, err
+	}
+	before := *item
+	item.applyRewriteRules(ctx, source(req.client))
+	if s.relay != nil {
+		if err := s.relay.UpdateNowPlaying(ctx, item.toScrobble(source(req.client))); err != nil {
+			log.Warn(ctx, "AudioScrobbler relay now playing failed", zap.Error(err))
+		}
+	}
+	return &response{
+		name: "nowplaying",
+		body: &nowPlayingBody{
+			Track:          corrected(before.track, item.track),
+			Artist:         corrected(before.artist, item.artist),
+			Album:          corrected(before.album, item.album),
+			AlbumArtist:    corrected(before.albumArtist, item.albumArtist),
+			IgnoredMessage: ignoredMessage{Code: "0"},
+		},
+	}, nil
+}
+
+// scrobble 批量写入播放记录，source 为客户端名称；开启 relay 时再转发到 Last.fm，
+// 转发失败的记录与实时标记一样由 outbox 后台重试
+func (s *Server) scrobble(ctx context.Context, req *request) (*response, error) {
+	items, err := parseScrobbles(req.params)
+	if err != nil {
+		return nil, err
+	}
+	clientSource := source(req.client)
+	body := &scrobblesBody{Scrobbles: make([]*scrobbleResult, len(items))}
+	var pending []int // 需要写入的记录在 items 中的下标
+	for i, item := range items {
+		// 改写规则在去重、转发与写库前生效，响应中标记被改写的字段
+		before := *item
+		item.applyRewriteRules(ctx, clientSource)
+		result := &scrobbleResult{
+			Track:          corrected(before.track, item.track),
+			Artist:         corrected(before.artist, item.artist),
+			Album:          corrected(before.album, item.album),
+			AlbumArtist:    corrected(before.albumArtist, item.albumArtist),
+			Timestamp:      strconv.FormatInt(item.timestamp, 10),
+			IgnoredMessage: ignoredMessage{Code: strconv.Itoa(lastfm.IgnoredCodeNone)},
+		}
+		body.Scrobbles[i] = result
+
+		code, message := s.check(item)
+		if code == lastfm.IgnoredCodeNone {
+			// 客户端在网络异常后会重发，已存在的记录视为接受但不再写入
+			exists, err := model.ExistsTrackPlayRecord(ctx, item.artist, item.track, time.Unix(item.timestamp, 0))
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				pending = append(pending, i)
+			}
+			body.Accepted++
+			continue
+		}
+		result.IgnoredMessage = ignoredMessage{Code: strconv.Itoa(code), Text: message}
+		body.Ignored++
+	}
+
+	// 与实时标记一致：先转发，再按结果写入 scrobbled 与投递状态
+	deliveries := make([]*delivery, len(pending))
+	if s.relay != nil && len(pending) > 0 {
+		batch := make([]*scrobble.Scrobble, len(pending))
+		for j, i := range pending {
+			batch[j] = items[i].toScrobble(clientSource)
+		}
+		results, err := s.relay.ScrobbleBatch(ctx, batch)
+		for j := range pending {
+			switch {
+			case err != nil:
+				deliveries[j] = &delivery{code: s.relay.ErrorCode(err), message: err.Error()}
+			case j < len(results):
+				deliveries[j] = &delivery{sent: results[j].Accepted, code: results[j].Code, message: results[j].Message}
+			default:
+				deliveries[j] = &delivery{}
+			}
+		}
+		if err != nil {
+			log.Warn(ctx, "AudioScrobbler relay scrobble failed", zap.Int("size", len(batch)), zap.Error(err))
+		}
+	}
+
+	for j, i := range pending {
+		if err := s.save(ctx, items[i], clientSource, deliveries[j]); err != nil {
+			return nil, err
+		}
+	}
+	log.Info(
+		ctx, "AudioScrobbler scrobble", zap.String("client", clientSource), zap.Int("accepted", body.Accepted),
+		zap.Int("ignored", body.Ignored), zap.Int("saved", len(pending)),
+	)
+	return &response{name: "scrobbles", body: body}, nil
+}
+
+// loveTrack track.love 只携带艺术家与曲目名，标记所有专辑中的同名曲目
+func (s *Server) loveTrack(ctx context.Context, req *request) (*response, error) {
+	artist := normalizeArtist(req.params.Get("artist"))
+	track := normalizeTrack(req.params.Get("track"))
+	if artist == "" || track == "" {
+		return nil, invalidParameters("artist and track are required")
+	}
+	if _, err := model.SetLastFmFavoriteByArtistTrack(ctx, artist, track, true); err != nil {
+		return nil, err
+	}
+	if s.love != nil {
+		if err := s.love(ctx, artist, track, true); err != nil {
+			log.Warn(ctx, "AudioScrobbler relay love failed", zap.Error(err))
+		}
+	}
+	return &response{}, nil
+}
+
+// check 判断一条记录是否应被忽略，返回 Last.fm 的 ignoredMessage code
+func (s *Server) check(item *trackParams) (int, string) {
+	if item.artist == "" {
+		return lastfm.IgnoredCodeArtistIgnored, "Artist name is empty"
+	}
+	if item.track == "" || item.album == "" {
+		// 播放记录要求专辑非空
+		return lastfm.IgnoredCodeTrackIgnored, "Track or album name is empty"
+	}
+	if time.Unix(item.timestamp, 0).After(s.now().Add(maxTimestampSkew)) {
+		return lastfm.IgnoredCodeTimestampTooNew, "Timestamp is in the future"
+	}
+	return lastfm.IgnoredCodeNone, ""
+}
+
+// delivery 转发到 Last.fm 的结果
+type delivery struct {
+	sent    bool
+	code    int
+	message string
+}
+
+// save 写入播放记录并累加播放次数；d 为 nil 表示未开启转发，记录直接视为已同步
+func (s *Server) save(ctx context.Context, item *trackParams, source string, d *delivery) error {
+	record := &model.TrackPlayRecord{
+		Artist:        item.artist,
+		AlbumArtist:   item.albumArtist,
+		Track:         item.track,
+		Album:         item.album,
+		Duration:      item.duration,
+		PlayTime:      time.Unix(item.timestamp, 0),
+		Scrobbled:     d == nil || d.sent,
+		MusicBrainzID: item.mbid,
+		TrackNumber:   int8(item.trackNumber),
+		Source:        source,
+	}
+	if err := model.InsertTrackPlayRecord(ctx, record); err != nil {
+		return err
+	}
+	if d != nil {
+		if err := model.SaveScrobbleDelivery(
+			ctx, record.ID, s.relay.Name(), d.sent, d.code, d.message,
+		); err != nil {
+			log.Warn(ctx, "AudioScrobbler failed to save scrobble delivery", zap.Error(err))
+		}
+	}
+	return model.IncrementTrackPlayCount(
+		model.IncrementTrackPlayCountParams{
+			Ctx:    ctx,
+			Artist: item.artist,
+			Album:  item.album,
+			Track:  item.track,
+			TrackMetadata: model.TrackMetadata{
+				AlbumArtist:   item.albumArtist,
+				TrackNumber:   int8(item.trackNumber),
+				Duration:      item.duration,
+				MusicBrainzID: item.mbid,
+				Source:        source,
+				DiscNumber:    1, // 协议不携带碟号，与 track.disc_number 的默认值保持一致
+			},
+		},
+	)
+}
+
+// applyRewriteRules 与实时播放一样按改写规则修正元数据
+func (t *trackParams) applyRewriteRules(ctx context.Context, source string) {
+	after, _ := rewrite.Rewrite(
+		ctx, rewrite.Fields{
+			Source: source, Artist: t.artist, Album: t.album, Title: t.track, AlbumArtist: t.albumArtist,
+		},
+	)
+	t.artist, t.album, t.track, t.albumArtist = after.Artist, after.Album, after.Title, after.AlbumArtist
+}
+
+// corrected 字段被改写时按 Last.fm 的约定标记 corrected="1"
+func corrected(before, after string) correctedText {
+	if before != after {
+		return correctedText{Corrected: "1", Text: after}
+	}
+	return correctedText{Corrected: "0", Text: after}
+}
+
+func (t *trackParams) toScrobble(source string) *scrobble.Scrobble {
+	return &scrobble.Scrobble{
+		Artist:        t.artist,
+		AlbumArtist:   t.albumArtist,
+		Track:         t.track,
+		Album:         t.album,
+		Duration:      t.duration,
+		Timestamp:     t.timestamp,
+		TrackNumber:   t.trackNumber,
+		MusicBrainzID: t.mbid,
+		Source:        source,
+	}
+}
+
+// parseScrobbles 解析 artist[i]、track[i]、timestamp[i] 等批量参数，单条提交时也接受不带下标的参数
+func parseScrobbles(params url.Values) ([]*trackParams, error) {
+	var items []*trackParams
+	for i := 0; i < lastfm.ScrobbleBatchSize; i++ {
+		suffix := fmt.Sprintf("[%d]", i)
+		if !params.Has("artist"+suffix) && !params.Has("track"+suffix) {
+			break
+		}
+		item, err := parseScrobble(params, suffix)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 && params.Has("track") {
+		item, err := parseScrobble(params, "")
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, invalidParameters("no scrobbles submitted")
+	}
+	return items, nil
+}
+
+func parseScrobble(params url.Values, suffix string) (*trackParams, error) {
+	item, err := parseTrack(params, suffix)
+	if err != nil {
+		return nil, err
+	}
+	if item.timestamp, err = strconv.ParseInt(params.Get("timestamp"+suffix), 10, 64); err != nil {
+		return nil, invalidParameters("timestamp" + suffix + " must be a UNIX timestamp")
+	}
+	return item, nil
+}
+
+// parseTrack 读取一首曲目的参数，并做与实时播放相同的标题、符号与繁简规范化
+func parseTrack(params url.Values, suffix string) (*trackParams, error) {
+	get := func(key string) string {
+		return strings.TrimSpace(params.Get(key + suffix))
+	}
+	if get("artist") == "" && get("track") == "" {
+		return nil, invalidParameters("artist and track are required")
+	}
+	item := &trackParams{
+		artist:      normalizeArtist(get("artist")),
+		track:       normalizeTrack(get("track")),
+		album:       common.ConversionSimplifiedFx(get("album")),
+		albumArtist: normalizeArtist(get("albumArtist")),
+		mbid:        get("mbid"),
+	}
+	if item.albumArtist == "" {
+		item.albumArtist = item.artist
+	}
+	item.duration, _ = strconv.ParseInt(get("duration"), 10, 64)
+	item.trackNumber, _ = strconv.ParseInt(get("trackNumber"), 10, 64)
+	return item, nil
+}
+
+func normalizeArtist(artist string) string {
+	return common.ConversionSimplifiedFx(common.ArtistCustomFit(artist))
+}
+
+func normalizeTrack(track string) string {
+	return common.ConversionSimplifiedFx(common.UnityFixAll(common.TrackCustomFit(track)))
+}
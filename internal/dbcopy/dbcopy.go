@@ -0,0 +1,198 @@
+package dbcopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// DefaultBatchSize 每批复制的行数
+const DefaultBatchSize = 500
+
+// ErrRowCountMismatch 复制完成后源库与目标库行数不一致
+var ErrRowCountMismatch = errors.New("row count mismatch")
+
+// TableResult 单表复制结果
+type TableResult struct {
+	Table   string
+	Copied  int64 // 本次运行写入的行数，断点续传时不含之前已复制的行
+	Source  int64
+	Target  int64
+	Missing bool // 源库版本较旧、没有该表，已跳过
+}
+
+// ProgressFunc 每复制完一批回调一次
+type ProgressFunc func(table string, copied int64)
+
+// Copier 按模型依赖顺序把源库的全部表复制到目标库
+type Copier struct {
+	src       *gorm.DB
+	dst       *gorm.DB
+	batchSize int
+}
+
+func NewCopier(src, dst *gorm.DB, batchSize int) *Copier {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Copier{src: src, dst: dst, batchSize: batchSize}
+}
+
+// Run 先在目标库建表，再逐表复制并校验行数。
+// 整数单列主键的表从目标库已有的最大主键处继续，因此中断后重新运行即可续传；
+// 复合主键或日期主键的统计表数据量小，每次整表复制，已存在的行被忽略；
+// 源库版本较旧时缺少的表会被跳过，并在结果中标记为 Missing
+func (c *Copier) Run(ctx context.Context, progress ProgressFunc) ([]TableResult, error) {
+	if _, err := model.MigrateUp(ctx, c.dst, 0); err != nil {
+		return nil, fmt.Errorf("migrate target schema: %w", err)
+	}
+
+	var results []TableResult
+	var mismatched []string
+	for _, m := range model.Models() {
+		stmt := &gorm.Statement{DB: c.src}
+		if err := stmt.Parse(m); err != nil {
+			return results, err
+		}
+		table := stmt.Schema.Table
+		if !c.src.Migrator().HasTable(table) {
+			results = append(results, TableResult{Table: table, Missing: true})
+			continue
+		}
+		copied, err := c.copyTable(ctx, stmt.Schema, progress)
+		if err != nil {
+			return results, fmt.Errorf("copy %s: %w", table, err)
+		}
+
+		result := TableResult{Table: table, Copied: copied}
+		if err := c.src.WithContext(ctx).Table(table).Count(&result.Source).Error; err != nil {
+			return results, err
+		}
+		if err := c.dst.WithContext(ctx).Table(table).Count(&result.Target).Error; err != nil {
+			return results, err
+		}
+		results = append(results, result)
+		if result.Source != result.Target {
+			mismatched = append(mismatched, fmt.Sprintf("%s (%d != %d)", table, result.Source, result.Target))
+		}
+	}
+	if len(mismatched) > 0 {
+		return results, fmt.Errorf("%w: %s", ErrRowCountMismatch, strings.Join(mismatched, ", "))
+	}
+	return results, nil
+}
+
+func (c *Copier) copyTable(ctx context.Context, s *schema.Schema, progress ProgressFunc) (int64, error) {
+	if len(s.PrimaryFields) == 1 {
+		// DataType 会被 type 标签覆盖，按 Go 类型判断是否为整数主键
+		switch s.PrimaryFields[0].IndirectFieldType.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			return c.copyByKey(ctx, s.Table, s.PrimaryFieldDBNames[0], progress)
+		}
+	}
+	orderBy := s.PrimaryFieldDBNames
+	if len(orderBy) == 0 {
+		// 没有主键时按全部列排序，保证分页结果稳定，否则 OFFSET 分页可能跳过或重复读取行
+		columns, err := c.src.WithContext(ctx).Migrator().ColumnTypes(s.Table)
+		if err != nil {
+			return 0, err
+		}
+		for _, column := range columns {
+			orderBy = append(orderBy, column.Name())
+		}
+	}
+	return c.copyByOffset(ctx, s.Table, orderBy, progress)
+}
+
+// copyByKey 按主键分批复制，从目标库当前最大主键之后开始
+func (c *Copier) copyByKey(ctx context.Context, table, primaryKey string, progress ProgressFunc) (int64, error) {
+	var lastKey int64
+	if err := c.dst.WithContext(ctx).Table(table).Select("COALESCE(MAX(" + primaryKey + "), 0)").
+		Scan(&lastKey).Error; err != nil {
+		return 0, err
+	}
+
+	var copied int64
+	for {
+		var rows []map[string]interface{}
+		if err := c.src.WithContext(ctx).Table(table).Where(primaryKey+" > ?", lastKey).
+			Order(primaryKey).Limit(c.batchSize).Find(&rows).Error; err != nil {
+			return copied, err
+		}
+		if len(rows) == 0 {
+			return copied, nil
+		}
+		if err := c.insert(ctx, table, rows); err != nil {
+			return copied, err
+		}
+		copied += int64(len(rows))
+		if progress != nil {
+			progress(table, copied)
+		}
+
+		key, err := toInt64(rows[len(rows)-1][primaryKey])
+		if err != nil {
+			return copied, err
+		}
+		lastKey = key
+	}
+}
+
+// copyByOffset 按主键（没有主键时为全部列）排序分页复制，用于复合主键、非整数主键或无主键的表
+func (c *Copier) copyByOffset(ctx context.Context, table string, orderBy []string, progress ProgressFunc) (int64, error) {
+	var copied int64
+	for offset := 0; ; offset += c.batchSize {
+		var rows []map[string]interface{}
+		if err := c.src.WithContext(ctx).Table(table).Order(strings.Join(orderBy, ", ")).
+			Offset(offset).Limit(c.batchSize).Find(&rows).Error; err != nil {
+			return copied, err
+		}
+		if len(rows) == 0 {
+			return copied, nil
+		}
+		if err := c.insert(ctx, table, rows); err != nil {
+			return copied, err
+		}
+		copied += int64(len(rows))
+		if progress != nil {
+			progress(table, copied)
+		}
+	}
+}
+
+// insert 以 map 写入，所有列原样保留，避免 GORM 对带 default 的零值字段套用默认值
+func (c *Copier) insert(ctx context.Context, table string, rows []map[string]interface{}) error {
+	for _, row := range rows {
+		for column, value := range row {
+			// MySQL 驱动以 []byte 返回文本列，写入 SQLite 时会变成 BLOB
+			if b, ok := value.([]byte); ok {
+				row[column] = string(b)
+			}
+		}
+	}
+	return c.dst.WithContext(ctx).Table(table).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("unsupported primary key type %T", value)
+}
@@ -0,0 +1,224 @@
+package dbcopy
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+var testDBSeq atomic.Int64
+
+func openTestDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	dsn := fmt.Sprintf("file:%s_%s_%d?mode=memory&cache=shared", t.Name(), name, testDBSeq.Add(1))
+	gormDB, err := model.OpenDB(string(common.DatabaseTypeSQLite), dsn, zap.NewNop())
+	require.NoError(t, err)
+	return gormDB
+}
+
+func seedSource(t *testing.T, src *gorm.DB) {
+	t.Helper()
+	_, err := model.MigrateUp(context.Background(), src, 0)
+	require.NoError(t, err)
+	playTime := time.Date(2024, 5, 1, 20, 0, 0, 0, time.Local)
+	for i := 1; i <= 7; i++ {
+		require.NoError(
+			t, src.Create(
+				&model.TrackPlayRecord{
+					ID: int64(i * 10), Artist: "Artist", Album: "Album", Track: fmt.Sprintf("Track %d", i),
+					Source: "Roon", PlayTime: playTime.Add(time.Duration(i) * time.Minute),
+				},
+			).Error,
+		)
+	}
+	// disc_number 带 default:1，用 map 写入 0 验证复制时不被默认值覆盖
+	require.NoError(
+		t, src.Table("track").Create(
+			map[string]interface{}{"id": 3, "artist": "Artist", "album": "Album", "track": "Track 1", "disc_number": 0, "play_count": 2},
+		).Error,
+	)
+	require.NoError(
+		t, src.Create(
+			&model.PlayTrendHourlyStat{StatDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Hour: 20, PlayCount: 7},
+		).Error,
+	)
+	// 单列日期主键
+	for day := 1; day <= 4; day++ {
+		require.NoError(
+			t, src.Create(
+				&model.PlayTrendDailyStat{StatDate: time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC), PlayCount: int64(day)},
+			).Error,
+		)
+	}
+}
+
+func TestCopier_Run(t *testing.T) {
+	src := openTestDB(t, "src")
+	dst := openTestDB(t, "dst")
+	seedSource(t, src)
+	ctx := context.Background()
+
+	var batches int
+	results, err := NewCopier(src, dst, 3).Run(ctx, func(table string, copied int64) { batches++ })
+	require.NoError(t, err)
+	assert.Equal(t, 7, batches, "7 play records in batches of 3, 1 track, 1 hourly stat, 4 daily stats in batches of 3")
+
+	counts := make(map[string]int64)
+	for _, result := range results {
+		assert.Equal(t, result.Source, result.Target, result.Table)
+		counts[result.Table] = result.Copied
+	}
+	assert.EqualValues(t, 7, counts["track_play_records"])
+	assert.EqualValues(t, 1, counts["play_trend_hourly_stat"])
+	assert.EqualValues(t, 4, counts["play_trend_daily_stat"])
+
+	var daily []*model.PlayTrendDailyStat
+	require.NoError(t, dst.Order("stat_date").Find(&daily).Error)
+	require.Len(t, daily, 4)
+	assert.EqualValues(t, 4, daily[3].PlayCount)
+
+	var records []*model.TrackPlayRecord
+	require.NoError(t, dst.Order("id").Find(&records).Error)
+	require.Len(t, records, 7)
+	assert.EqualValues(t, 10, records[0].ID, "ids are kept")
+	assert.EqualValues(t, 70, records[6].ID)
+	assert.True(t, records[0].PlayTime.Equal(time.Date(2024, 5, 1, 20, 1, 0, 0, time.Local)))
+
+	var track model.Track
+	require.NoError(t, dst.First(&track, 3).Error)
+	assert.EqualValues(t, 0, track.DiscNumber)
+	assert.Equal(t, 2, track.PlayCount)
+
+	// 新插入的记录继续使用源库之后的自增 ID
+	next := &model.TrackPlayRecord{Artist: "Artist", Album: "Album", Track: "New", Source: "Roon", PlayTime: time.Now()}
+	require.NoError(t, dst.Create(next).Error)
+	assert.EqualValues(t, 71, next.ID)
+}
+
+func TestCopier_Resume(t *testing.T) {
+	src := openTestDB(t, "src")
+	dst := openTestDB(t, "dst")
+	seedSource(t, src)
+	ctx := context.Background()
+
+	// 模拟中断：目标库已复制前 4 条
+	_, err := model.MigrateUp(ctx, dst, 0)
+	require.NoError(t, err)
+	var partial []*model.TrackPlayRecord
+	require.NoError(t, src.Order("id").Limit(4).Find(&partial).Error)
+	require.NoError(t, dst.Create(&partial).Error)
+
+	results, err := NewCopier(src, dst, 100).Run(ctx, nil)
+	require.NoError(t, err)
+	for _, result := range results {
+		if result.Table == "track_play_records" {
+			assert.EqualValues(t, 3, result.Copied, "only the rest is copied")
+			assert.EqualValues(t, 7, result.Target)
+		}
+	}
+
+	// 再次运行不会重复写入，整表复制的统计表已存在的行被忽略
+	results, err = NewCopier(src, dst, 100).Run(ctx, nil)
+	require.NoError(t, err)
+	for _, result := range results {
+		switch result.Table {
+		case "track_play_records":
+			assert.Zero(t, result.Copied)
+		case "play_trend_daily_stat":
+			assert.EqualValues(t, 4, result.Target)
+		}
+	}
+}
+
+func TestCopier_RowCountMismatch(t *testing.T) {
+	src := openTestDB(t, "src")
+	dst := openTestDB(t, "dst")
+	seedSource(t, src)
+	ctx := context.Background()
+	_, err := model.MigrateUp(ctx, dst, 0)
+	require.NoError(t, err)
+	require.NoError(
+		t, dst.Create(
+			&model.TrackPlayRecord{ID: 5, Artist: "Other", Album: "Other", Track: "Other", Source: "Roon", PlayTime: time.Now()},
+		).Error,
+	)
+
+	_, err = NewCopier(src, dst, 100).Run(ctx, nil)
+	assert.ErrorIs(t, err, ErrRowCountMismatch)
+}
+
+func TestCopier_OlderSourceSchema(t *testing.T) {
+	src := openTestDB(t, "src")
+	dst := openTestDB(t, "dst")
+	ctx := context.Background()
+	// 源库停留在 v3，没有之后新增的表
+	_, err := model.MigrateUp(ctx, src, 3)
+	require.NoError(t, err)
+	require.NoError(
+		t, src.Table("track_play_records").Create(
+			map[string]interface{}{"id": 1, "artist": "Artist", "album": "Album", "track": "Track", "source": "Roon", "play_time": time.Now()},
+		).Error,
+	)
+
+	results, err := NewCopier(src, dst, 100).Run(ctx, nil)
+	require.NoError(t, err)
+	missing := make(map[string]bool)
+	for _, result := range results {
+		if result.Missing {
+			missing[result.Table] = true
+		}
+		if result.Table == "track_play_records" {
+			assert.False(t, result.Missing)
+			assert.EqualValues(t, 1, result.Target)
+		}
+	}
+	assert.True(t, missing["play_event"])
+	assert.True(t, missing["rewrite_rule"])
+	assert.False(t, missing["track"])
+}
+
+// noKeyRow 没有主键的表
+type noKeyRow struct {
+	Name  string
+	Value int
+}
+
+func (noKeyRow) TableName() string {
+	return "no_key_row"
+}
+
+func TestCopier_TableWithoutPrimaryKey(t *testing.T) {
+	src := openTestDB(t, "src")
+	dst := openTestDB(t, "dst")
+	ctx := context.Background()
+	require.NoError(t, src.AutoMigrate(&noKeyRow{}))
+	require.NoError(t, dst.AutoMigrate(&noKeyRow{}))
+	// 含重复行，插入顺序与排序顺序不同
+	rows := []*noKeyRow{{"c", 1}, {"a", 2}, {"b", 1}, {"a", 1}, {"c", 1}, {"b", 3}, {"a", 2}}
+	require.NoError(t, src.Create(&rows).Error)
+
+	stmt := &gorm.Statement{DB: src}
+	require.NoError(t, stmt.Parse(&noKeyRow{}))
+	require.Empty(t, stmt.Schema.PrimaryFields)
+	copied, err := NewCopier(src, dst, 2).copyTable(ctx, stmt.Schema, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, copied)
+
+	var got []*noKeyRow
+	require.NoError(t, dst.Order("name, value").Find(&got).Error)
+	assert.Equal(t, []*noKeyRow{{"a", 1}, {"a", 2}, {"a", 2}, {"b", 1}, {"b", 3}, {"c", 1}, {"c", 1}}, got)
+}
@@ -0,0 +1,172 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// Dataset 可导出的数据集，取值与表名一致
+type Dataset string
+
+const (
+	DatasetTrackPlayRecords Dataset = "track_play_records"
+	DatasetTrack            Dataset = "track"
+	DatasetAlbum            Dataset = "album"
+	DatasetTrackInsight     Dataset = "track_insight"
+	DatasetTrackLyrics      Dataset = "track_lyrics"
+)
+
+// Datasets 全部可导出的数据集
+var Datasets = []Dataset{DatasetTrackPlayRecords, DatasetTrack, DatasetAlbum, DatasetTrackInsight, DatasetTrackLyrics}
+
+var (
+	ErrSourceFilterUnsupported = errors.New("source filter is not supported by this dataset")
+	ErrScrobblerLogUnsupported = errors.New("scrobbler log format only supports track_play_records")
+)
+
+// datasetColumns 数据集用于日期范围与来源过滤的列，sourceColumn 为空表示不支持来源过滤
+type datasetColumns struct {
+	timeColumn   string
+	sourceColumn string
+}
+
+var datasetFilterColumns = map[Dataset]datasetColumns{
+	DatasetTrackPlayRecords: {timeColumn: "play_time", sourceColumn: "source"},
+	DatasetTrack:            {timeColumn: "created_at", sourceColumn: "source"},
+	DatasetAlbum:            {timeColumn: "created_at"},
+	DatasetTrackInsight:     {timeColumn: "created_at"},
+	DatasetTrackLyrics:      {timeColumn: "created_at", sourceColumn: "lyrics_source"},
+}
+
+// ParseDataset 解析数据集名，除表名外也接受 plays/tracks/albums/insights/lyrics 等简称
+func ParseDataset(name string) (Dataset, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case string(DatasetTrackPlayRecords), "plays", "records", "play_records":
+		return DatasetTrackPlayRecords, nil
+	case string(DatasetTrack), "tracks":
+		return DatasetTrack, nil
+	case string(DatasetAlbum), "albums":
+		return DatasetAlbum, nil
+	case string(DatasetTrackInsight), "insights", "insight":
+		return DatasetTrackInsight, nil
+	case string(DatasetTrackLyrics), "lyrics":
+		return DatasetTrackLyrics, nil
+	}
+	return "", fmt.Errorf("unknown export dataset %q", name)
+}
+
+// Filter 导出过滤条件，From 包含、To 不包含，零值表示不限
+type Filter struct {
+	From   time.Time
+	To     time.Time
+	Source string
+}
+
+// NewFilter 解析日期范围，支持 2006-01-02 与 RFC3339 两种写法；只有日期的 to 包含当天
+func NewFilter(from, to, source string) (Filter, error) {
+	filter := Filter{Source: strings.TrimSpace(source)}
+	var err error
+	if filter.From, _, err = parseBound(from); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	var dateOnly bool
+	if filter.To, dateOnly, err = parseBound(to); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+	if dateOnly {
+		filter.To = filter.To.AddDate(0, 0, 1)
+	}
+	return filter, nil
+}
+
+func parseBound(value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// Export 以游标逐行读取数据集并按格式写入 w，返回导出的行数
+func Export(ctx context.Context, w io.Writer, dataset Dataset, format Format, filter Filter) (int64, error) {
+	columns, ok := datasetFilterColumns[dataset]
+	if !ok {
+		return 0, fmt.Errorf("unknown export dataset %q", dataset)
+	}
+	if filter.Source != "" && columns.sourceColumn == "" {
+		return 0, fmt.Errorf("%s: %w", dataset, ErrSourceFilterUnsupported)
+	}
+	if format == FormatScrobblerLog && dataset != DatasetTrackPlayRecords {
+		return 0, ErrScrobblerLogUnsupported
+	}
+
+	writer, err := newRowWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	query := model.GetDB().WithContext(ctx)
+	if !filter.From.IsZero() {
+		query = query.Where(columns.timeColumn+" >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where(columns.timeColumn+" < ?", filter.To)
+	}
+	if filter.Source != "" {
+		query = query.Where(columns.sourceColumn+" = ?", filter.Source)
+	}
+	query = query.Order("id")
+
+	switch dataset {
+	case DatasetTrackPlayRecords:
+		return exportRows[model.TrackPlayRecord](query, writer)
+	case DatasetTrack:
+		return exportRows[model.Track](query, writer)
+	case DatasetAlbum:
+		return exportRows[model.Album](query, writer)
+	case DatasetTrackInsight:
+		return exportRows[model.TrackInsight](query, writer)
+	default:
+		return exportRows[model.TrackLyrics](query, writer)
+	}
+}
+
+// exportRows 通过 Rows 游标逐行扫描，避免一次性把整张表读入内存
+func exportRows[T any](query *gorm.DB, writer rowWriter) (int64, error) {
+	rows, err := query.Model(new(T)).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if err := writer.WriteHeader(new(T)); err != nil {
+		return 0, err
+	}
+	var count int64
+	for rows.Next() {
+		var item T
+		if err := query.ScanRows(rows, &item); err != nil {
+			return count, err
+		}
+		if err := writer.WriteRow(&item); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	return count, writer.Flush()
+}
@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+func setupExportTestDB(t *testing.T) {
+	t.Helper()
+	model.SetupTestDB(t)
+
+	records := []*model.TrackPlayRecord{
+		{Artist: "Joni Mitchell", Album: "Blue", Track: "River", Duration: 244, TrackNumber: 7, Source: "Apple Music", PlayTime: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)},
+		{Artist: "Quincy Jones", Album: "Smackwater Jack", Track: "Hikky Burr\t(feat. Bill Cosby)", Duration: 300, Source: "Roon", PlayTime: time.Date(2024, 1, 2, 23, 30, 0, 0, time.Local)},
+		{Artist: "陈绮贞", Album: "太阳", Track: "太阳", Duration: 210, Source: "Apple Music", PlayTime: time.Date(2024, 1, 3, 8, 0, 0, 0, time.Local)},
+	}
+	for _, record := range records {
+		require.NoError(t, model.InsertTrackPlayRecord(context.Background(), record))
+	}
+	require.NoError(
+		t, model.GetDB().Create(
+			&model.TrackInsight{
+				Artist: "Joni Mitchell", Album: "Blue", Track: "River", AnalysisSummary: "Christmas, \"skating\" away",
+				AnalysisBySection: model.JSONText{"verse": "snow"},
+			},
+		).Error,
+	)
+}
+
+func TestExport_CSV(t *testing.T) {
+	setupExportTestDB(t)
+	filter, err := NewFilter("2024-01-02", "2024-01-03", "")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	count, err := Export(context.Background(), &buf, DatasetTrackPlayRecords, FormatCSV, filter)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count, "date-only to includes the whole day")
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"id", "artist", "album_artist", "track", "album", "album_id", "duration"}, rows[0][:7])
+	assert.Equal(t, "Hikky Burr\t(feat. Bill Cosby)", rows[1][3])
+	assert.Equal(t, "陈绮贞", rows[2][1])
+
+	buf.Reset()
+	_, err = Export(context.Background(), &buf, DatasetTrackInsight, FormatCSV, Filter{})
+	require.NoError(t, err)
+	rows, err = csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	summary := indexOf(rows[0], "analysis_summary")
+	section := indexOf(rows[0], "analysis_by_section")
+	assert.Equal(t, "Christmas, \"skating\" away", rows[1][summary])
+	assert.JSONEq(t, `{"verse":"snow"}`, rows[1][section])
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	setupExportTestDB(t)
+
+	var buf bytes.Buffer
+	count, err := Export(context.Background(), &buf, DatasetTrackPlayRecords, FormatNDJSON, Filter{Source: "Apple Music"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	scanner := bufio.NewScanner(&buf)
+	var tracks []string
+	for scanner.Scan() {
+		var record model.TrackPlayRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.Equal(t, "Apple Music", record.Source)
+		tracks = append(tracks, record.Track)
+	}
+	assert.Equal(t, []string{"River", "太阳"}, tracks)
+}
+
+func TestExport_ScrobblerLog(t *testing.T) {
+	setupExportTestDB(t)
+
+	var buf bytes.Buffer
+	count, err := Export(context.Background(), &buf, DatasetTrackPlayRecords, FormatScrobblerLog, Filter{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, []string{"#AUDIOSCROBBLER/1.1", "#TZ/UTC", "#CLIENT/sonic-lens"}, lines[:3])
+	river := time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local).Unix()
+	assert.Equal(t, fmt.Sprintf("Joni Mitchell\tBlue\tRiver\t7\t244\tL\t%d\t", river), lines[3])
+	assert.Len(t, strings.Split(lines[4], "\t"), 8, "tabs inside values are replaced")
+}
+
+func TestExport_Errors(t *testing.T) {
+	setupExportTestDB(t)
+	ctx := context.Background()
+
+	_, err := Export(ctx, &bytes.Buffer{}, DatasetTrack, FormatScrobblerLog, Filter{})
+	assert.ErrorIs(t, err, ErrScrobblerLogUnsupported)
+	_, err = Export(ctx, &bytes.Buffer{}, DatasetAlbum, FormatCSV, Filter{Source: "Roon"})
+	assert.ErrorIs(t, err, ErrSourceFilterUnsupported)
+	_, err = NewFilter("yesterday", "", "")
+	assert.Error(t, err)
+
+	dataset, err := ParseDataset("plays")
+	require.NoError(t, err)
+	assert.Equal(t, DatasetTrackPlayRecords, dataset)
+	format, err := ParseFormat("jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, FormatNDJSON, format)
+	assert.Equal(t, ".scrobbler.log", FormatScrobblerLog.FileName(DatasetTrackPlayRecords))
+}
+
+func indexOf(values []string, target string) int {
+	for i, value := range values {
+		if value == target {
+			return i
+		}
+	}
+	return -1
+}
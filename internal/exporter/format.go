@@ -0,0 +1,217 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	FormatCSV          Format = "csv"
+	FormatNDJSON       Format = "ndjson"
+	FormatScrobblerLog Format = "scrobbler" // .scrobbler.log (AUDIOSCROBBLER/1.1)，可导入 Rockbox 等兼容工具
+)
+
+// scrobblerLogClient 写入 .scrobbler.log 头部的客户端标识
+const scrobblerLogClient = "sonic-lens"
+
+// ParseFormat 解析格式名，不区分大小写
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", string(FormatCSV):
+		return FormatCSV, nil
+	case string(FormatNDJSON), "jsonl", "json-lines":
+		return FormatNDJSON, nil
+	case string(FormatScrobblerLog), "scrobbler.log", "scrobbler-log", "audioscrobbler":
+		return FormatScrobblerLog, nil
+	}
+	return "", fmt.Errorf("unknown export format %q", name)
+}
+
+// ContentType 返回 HTTP 响应的 Content-Type
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatScrobblerLog:
+		return "text/plain; charset=utf-8"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// FileName 返回数据集按该格式导出时的默认文件名
+func (f Format) FileName(dataset Dataset) string {
+	switch f {
+	case FormatNDJSON:
+		return string(dataset) + ".ndjson"
+	case FormatScrobblerLog:
+		return ".scrobbler.log"
+	default:
+		return string(dataset) + ".csv"
+	}
+}
+
+// rowWriter 按格式逐行写出，WriteHeader 在第一行之前调用一次
+type rowWriter interface {
+	WriteHeader(sample any) error
+	WriteRow(row any) error
+	Flush() error
+}
+
+func newRowWriter(w io.Writer, format Format) (rowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		buffered := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buffered)
+		encoder.SetEscapeHTML(false)
+		return &ndjsonWriter{w: buffered, encoder: encoder}, nil
+	case FormatScrobblerLog:
+		return &scrobblerLogWriter{w: bufio.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// csvWriter 列名取结构体的 json tag，与 NDJSON 的字段名保持一致
+type csvWriter struct {
+	w      *csv.Writer
+	fields []int
+}
+
+func (c *csvWriter) WriteHeader(sample any) error {
+	t := reflect.TypeOf(sample).Elem()
+	var header []string
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := jsonFieldName(t.Field(i))
+		if !ok {
+			continue
+		}
+		c.fields = append(c.fields, i)
+		header = append(header, name)
+	}
+	return c.w.Write(header)
+}
+
+func (c *csvWriter) WriteRow(row any) error {
+	v := reflect.ValueOf(row).Elem()
+	record := make([]string, len(c.fields))
+	for i, field := range c.fields {
+		value, err := formatCSVValue(v.Field(field))
+		if err != nil {
+			return err
+		}
+		record[i] = value
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
+
+func formatCSVValue(v reflect.Value) (string, error) {
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(time.RFC3339), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	// map 等复合字段按 JSON 写入单元格
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) WriteHeader(any) error { return nil }
+
+func (n *ndjsonWriter) WriteRow(row any) error { return n.encoder.Encode(row) }
+
+func (n *ndjsonWriter) Flush() error { return n.w.Flush() }
+
+// scrobblerLogWriter 按 AUDIOSCROBBLER/1.1 规范写出，时间统一为 UTC 时间戳
+// ARTIST	ALBUM	TITLE	TRACKNUM	LENGTH	RATING	TIMESTAMP	MUSICBRAINZ_TRACKID
+type scrobblerLogWriter struct {
+	w *bufio.Writer
+}
+
+func (s *scrobblerLogWriter) WriteHeader(any) error {
+	_, err := fmt.Fprintf(s.w, "#AUDIOSCROBBLER/1.1\n#TZ/UTC\n#CLIENT/%s\n", scrobblerLogClient)
+	return err
+}
+
+func (s *scrobblerLogWriter) WriteRow(row any) error {
+	record, ok := row.(*model.TrackPlayRecord)
+	if !ok {
+		return ErrScrobblerLogUnsupported
+	}
+	trackNumber := ""
+	if record.TrackNumber > 0 {
+		trackNumber = strconv.Itoa(int(record.TrackNumber))
+	}
+	fields := []string{
+		scrobblerLogField(record.Artist),
+		scrobblerLogField(record.Album),
+		scrobblerLogField(record.Track),
+		trackNumber,
+		strconv.FormatInt(record.Duration, 10),
+		"L", // 记录的都是完整收听，跳过的播放不会入库
+		strconv.FormatInt(record.PlayTime.Unix(), 10),
+		scrobblerLogField(record.MusicBrainzID),
+	}
+	_, err := s.w.WriteString(strings.Join(fields, "\t") + "\n")
+	return err
+}
+
+func (s *scrobblerLogWriter) Flush() error { return s.w.Flush() }
+
+// scrobblerLogField 字段以制表符分隔，值中的制表符与换行替换为空格
+func scrobblerLogField(value string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(value)
+}
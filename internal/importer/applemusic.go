@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Apple 隐私数据导出中 Apple Music Play Activity.csv 的列名，不同年份的导出列名略有差异，按顺序取第一个存在的列
+var (
+	appleColumnsTrack      = []string{"Song Name", "Content Name"}
+	appleColumnsArtist     = []string{"Artist Name", "Container Artist Name"}
+	appleColumnsAlbum      = []string{"Album Name", "Container Album Name"}
+	appleColumnsStart      = []string{"Event Start Timestamp", "Event Received Timestamp"}
+	appleColumnsPlayed     = []string{"Play Duration Milliseconds"}
+	appleColumnsDuration   = []string{"Media Duration In Milliseconds"}
+	appleColumnsEventType  = []string{"Event Type"}
+	appleColumnsMediaType  = []string{"Media Type"}
+	appleColumnsGenre      = []string{"Genre"}
+	appleEventTypePlayEnd  = "PLAY_END"
+	appleMediaTypeVideo    = "VIDEO"
+	errAppleMissingColumns = errors.New("apple music play activity csv is missing song or timestamp columns")
+)
+
+type appleColumns map[string]int
+
+func (c appleColumns) get(record []string, names []string) string {
+	for _, name := range names {
+		if i, ok := c[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+	}
+	return ""
+}
+
+func (c appleColumns) has(names []string) bool {
+	for _, name := range names {
+		if _, ok := c[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAppleMusicPlayActivity 逐行读取 CSV，只保留播放结束事件，播放不足 30 秒或视频被过滤
+func parseAppleMusicPlayActivity(r io.Reader, emit func(Listen, bool) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("read apple music play activity header: %w", err)
+	}
+	columns := make(appleColumns, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	if !columns.has(appleColumnsTrack) || !columns.has(appleColumnsStart) {
+		return errAppleMissingColumns
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read apple music play activity: %w", err)
+		}
+		listen, ok := columns.toListen(record)
+		if err := emit(listen, ok); err != nil {
+			return err
+		}
+	}
+}
+
+func (c appleColumns) toListen(record []string) (Listen, bool) {
+	if eventType := c.get(record, appleColumnsEventType); eventType != "" && eventType != appleEventTypePlayEnd {
+		return Listen{}, false
+	}
+	if strings.EqualFold(c.get(record, appleColumnsMediaType), appleMediaTypeVideo) {
+		return Listen{}, false
+	}
+	if played := c.get(record, appleColumnsPlayed); played != "" {
+		if ms, err := strconv.ParseInt(played, 10, 64); err != nil || ms < minPlayedMillis {
+			return Listen{}, false
+		}
+	}
+	start, err := time.Parse(time.RFC3339Nano, c.get(record, appleColumnsStart))
+	if err != nil {
+		return Listen{}, false
+	}
+
+	listen := Listen{
+		Track:    c.get(record, appleColumnsTrack),
+		Artist:   c.get(record, appleColumnsArtist),
+		Album:    c.get(record, appleColumnsAlbum),
+		Genre:    c.get(record, appleColumnsGenre),
+		PlayTime: start.Truncate(time.Second).Local(),
+	}
+	listen.AlbumArtist = listen.Artist
+	if ms, err := strconv.ParseInt(c.get(record, appleColumnsDuration), 10, 64); err == nil && ms > 0 {
+		listen.Duration = ms / 1000
+	}
+	return listen, true
+}
@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format 导出文件格式，同时作为导入记录的 source
+type Format string
+
+const (
+	FormatSpotifyEndsong          Format = "Spotify endsong"           // endsong_*.json
+	FormatSpotifyStreamingHistory Format = "Spotify Streaming History" // Streaming_History_Audio_*.json
+	FormatAppleMusicPlayActivity  Format = "Apple Music Play Activity" // Apple Music Play Activity.csv
+)
+
+// fileBatchSize 解析出的记录按批写入
+const fileBatchSize = 500
+
+// minPlayedMillis 播放不足 30 秒不计为收听，与 Last.fm 的最短时长规则一致
+const minPlayedMillis = 30_000
+
+// ParseFormat 解析命令行或接口传入的格式名，不区分大小写
+func ParseFormat(name string) (Format, error) {
+	for _, format := range []Format{FormatSpotifyEndsong, FormatSpotifyStreamingHistory, FormatAppleMusicPlayActivity} {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	switch strings.ToLower(name) {
+	case "spotify", "endsong":
+		return FormatSpotifyEndsong, nil
+	case "streaming_history", "streaming-history":
+		return FormatSpotifyStreamingHistory, nil
+	case "apple", "apple-music", "apple_music":
+		return FormatAppleMusicPlayActivity, nil
+	}
+	return "", fmt.Errorf("unknown import format %q", name)
+}
+
+// DetectFormat 按导出文件的命名规则识别格式
+func DetectFormat(filename string) (Format, error) {
+	base := strings.ToLower(filepath.Base(filename))
+	switch {
+	case strings.HasPrefix(base, "endsong") && strings.HasSuffix(base, ".json"):
+		return FormatSpotifyEndsong, nil
+	case strings.HasPrefix(base, "streaming_history_audio") && strings.HasSuffix(base, ".json"):
+		return FormatSpotifyStreamingHistory, nil
+	case strings.Contains(base, "play activity") && strings.HasSuffix(base, ".csv"):
+		return FormatAppleMusicPlayActivity, nil
+	}
+	return "", fmt.Errorf("cannot detect import format of %s", filepath.Base(filename))
+}
+
+// ImportFile 解析导出文件并写入播放记录，文件以流的方式读取
+func ImportFile(ctx context.Context, format Format, r io.Reader) (Result, error) {
+	var total Result
+	var batch []Listen
+	flush := func() error {
+		result, err := saveListens(ctx, batch)
+		total.add(result)
+		batch = batch[:0]
+		return err
+	}
+	emit := func(listen Listen, ok bool) error {
+		if !ok {
+			total.Filtered++
+			return nil
+		}
+		listen.Source = string(format)
+		listen.Scrobbled = true
+		normalize(&listen)
+		batch = append(batch, listen)
+		if len(batch) >= fileBatchSize {
+			return flush()
+		}
+		return ctx.Err()
+	}
+
+	var err error
+	switch format {
+	case FormatSpotifyEndsong, FormatSpotifyStreamingHistory:
+		err = parseSpotifyHistory(r, emit)
+	case FormatAppleMusicPlayActivity:
+		err = parseAppleMusicPlayActivity(r, emit)
+	default:
+		err = fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return total, err
+	}
+	return total, flush()
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/internal/logic/rewrite"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+const spotifyHistoryFixture = `[
+  {"ts":"2021-03-01T10:03:30Z","ms_played":210000,"master_metadata_track_name":"太陽","master_metadata_album_artist_name":"陳綺貞","master_metadata_album_album_name":"太陽","spotify_track_uri":"spotify:track:1","spotify_episode_uri":null},
+  {"ts":"2021-03-01T10:05:00Z","ms_played":5000,"master_metadata_track_name":"Skipped","master_metadata_album_artist_name":"Artist","master_metadata_album_album_name":"Album","spotify_track_uri":"spotify:track:2","spotify_episode_uri":null},
+  {"ts":"2021-03-01T11:00:00Z","ms_played":1800000,"master_metadata_track_name":null,"master_metadata_album_artist_name":null,"master_metadata_album_album_name":null,"spotify_track_uri":null,"episode_name":"Podcast","spotify_episode_uri":"spotify:episode:3"},
+  {"ts":"2021-03-01T12:04:00Z","ms_played":240000,"master_metadata_track_name":"Hikky Burr(feat.Bill Cosby)","master_metadata_album_artist_name":"Quincy Jones","master_metadata_album_album_name":"Smackwater Jack","spotify_track_uri":"spotify:track:4","spotify_episode_uri":null},
+  {"ts":"2021-03-01T12:04:00Z","ms_played":240000,"master_metadata_track_name":"Hikky Burr(feat.Bill Cosby)","master_metadata_album_artist_name":"Quincy Jones","master_metadata_album_album_name":"Smackwater Jack","spotify_track_uri":"spotify:track:4","spotify_episode_uri":null},
+  {"ts":"2021-03-01T13:00:00Z","ms_played":240000,"master_metadata_track_name":"No Album","master_metadata_album_artist_name":"Artist","master_metadata_album_album_name":"","spotify_track_uri":"spotify:track:5","spotify_episode_uri":null},
+  {"ts":"not-a-time","ms_played":240000,"master_metadata_track_name":"Bad Time","master_metadata_album_artist_name":"Artist","master_metadata_album_album_name":"Album","spotify_track_uri":"spotify:track:6","spotify_episode_uri":null},
+  {"ts":"2021-03-01T14:04:00Z","ms_played":240000,"master_metadata_track_name":"After Bad Time","master_metadata_album_artist_name":"Artist","master_metadata_album_album_name":"Album","spotify_track_uri":"spotify:track:7","spotify_episode_uri":null}
+]`
+
+const appleMusicFixture = "\ufeff" + `Album Name,Artist Name,Song Name,Event Start Timestamp,Event Type,Play Duration Milliseconds,Media Duration In Milliseconds,Media Type,Genre
+Blue,Joni Mitchell,River,2020-04-29T12:11:57.349Z,PLAY_END,240000,244000,AUDIO,Pop
+Blue,Joni Mitchell,River,2020-04-29T12:11:57.349Z,PLAY_START,0,244000,AUDIO,Pop
+Blue,Joni Mitchell,"A Case of You",2020-04-29T12:20:00.000Z,PLAY_END,12000,260000,AUDIO,Pop
+Live,Joni Mitchell,Video,2020-04-29T12:30:00.000Z,PLAY_END,200000,200000,VIDEO,Pop
+Blue,Joni Mitchell,California,not-a-time,PLAY_END,200000,230000,AUDIO,Pop
+Blue,,Carey,2020-04-29T12:40:00.000Z,PLAY_END,180000,182000,AUDIO,Pop
+`
+
+func TestImportFile_Spotify(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+
+	result, err := ImportFile(ctx, FormatSpotifyStreamingHistory, strings.NewReader(spotifyHistoryFixture))
+	require.NoError(t, err)
+	// ts 无法解析的记录计为无效，之后的记录照常导入
+	assert.Equal(t, Result{Imported: 3, Duplicates: 1, Invalid: 2, Filtered: 2}, result)
+
+	var records []*model.TrackPlayRecord
+	require.NoError(t, model.GetDB().Order("play_time").Find(&records).Error)
+	require.Len(t, records, 3)
+	assert.Equal(t, "陈绮贞", records[0].Artist, "traditional Chinese is converted like live plays")
+	assert.Equal(t, "太阳", records[0].Track)
+	assert.Equal(t, string(FormatSpotifyStreamingHistory), records[0].Source)
+	assert.True(t, records[0].PlayTime.Equal(time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)), "start = ts - ms_played")
+	assert.Equal(t, "Hikky Burr (feat. Bill Cosby)", records[1].Track)
+	assert.Equal(t, "After Bad Time", records[2].Track)
+
+	// 再次导入同一文件全部判定为重复
+	result, err = ImportFile(ctx, FormatSpotifyStreamingHistory, strings.NewReader(spotifyHistoryFixture))
+	require.NoError(t, err)
+	assert.Equal(t, Result{Duplicates: 4, Invalid: 2, Filtered: 2}, result)
+}
+
+func TestImportFile_RewriteRules(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	require.NoError(
+		t, model.CreateRewriteRule(
+			ctx, &model.RewriteRule{
+				Name: "quincy", Enabled: true, MatchSource: "^Spotify", MatchArtist: "^Quincy Jones$",
+				Actions: model.RewriteActions{{Field: model.RewriteFieldAlbum, Replace: "Smackwater Jack (Remastered)"}},
+			},
+		),
+	)
+	rewrite.Invalidate()
+	t.Cleanup(rewrite.Invalidate)
+
+	// 改写在去重前生效，同一文件重复导入仍全部判定为重复
+	for _, expected := range []Result{
+		{Imported: 3, Duplicates: 1, Invalid: 2, Filtered: 2},
+		{Duplicates: 4, Invalid: 2, Filtered: 2},
+	} {
+		result, err := ImportFile(ctx, FormatSpotifyStreamingHistory, strings.NewReader(spotifyHistoryFixture))
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	}
+	got, err := model.GetTrack(ctx, "Quincy Jones", "Smackwater Jack (Remastered)", "Hikky Burr (feat. Bill Cosby)")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.PlayCount)
+	var count int64
+	require.NoError(t, model.GetDB().Model(&model.TrackPlayRecord{}).Where("album = ?", "Smackwater Jack").Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestImportFile_AppleMusic(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+
+	result, err := ImportFile(ctx, FormatAppleMusicPlayActivity, strings.NewReader(appleMusicFixture))
+	require.NoError(t, err)
+	assert.Equal(t, Result{Imported: 1, Invalid: 1, Filtered: 4}, result)
+
+	var records []*model.TrackPlayRecord
+	require.NoError(t, model.GetDB().Find(&records).Error)
+	require.Len(t, records, 1)
+	assert.Equal(t, "River", records[0].Track)
+	assert.Equal(t, int64(244), records[0].Duration)
+	assert.Equal(t, string(FormatAppleMusicPlayActivity), records[0].Source)
+	assert.True(t, records[0].Scrobbled)
+
+	track, err := model.GetTrack(ctx, "Joni Mitchell", "Blue", "River")
+	require.NoError(t, err)
+	assert.Equal(t, "Pop", track.Genre)
+	assert.EqualValues(t, 1, track.PlayCount)
+}
+
+func TestImportFile_Errors(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+
+	_, err := ImportFile(ctx, FormatSpotifyEndsong, strings.NewReader(`{"ts":"x"}`))
+	assert.Error(t, err)
+	_, err = ImportFile(ctx, FormatAppleMusicPlayActivity, strings.NewReader("Foo,Bar\n1,2\n"))
+	assert.ErrorIs(t, err, errAppleMissingColumns)
+	_, err = ImportFile(ctx, Format("zip"), strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     Format
+		wantErr  bool
+	}{
+		{filename: "MyData/endsong_0.json", want: FormatSpotifyEndsong},
+		{filename: "Streaming_History_Audio_2019-2021_0.json", want: FormatSpotifyStreamingHistory},
+		{filename: "Apple Media Services/Apple Music Play Activity.csv", want: FormatAppleMusicPlayActivity},
+		{filename: "StreamingHistory0.json", wantErr: true},
+		{filename: "notes.csv", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.filename, func(t *testing.T) {
+				got, err := DetectFormat(tt.filename)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+
+	format, err := ParseFormat("apple")
+	require.NoError(t, err)
+	assert.Equal(t, FormatAppleMusicPlayActivity, format)
+	format, err = ParseFormat("spotify streaming history")
+	require.NoError(t, err)
+	assert.Equal(t, FormatSpotifyStreamingHistory, format)
+}
@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"context"
+	"time"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/internal/cache"
+	"github.com/vincentchyu/sonic-lens/internal/logic/rewrite"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// Listen 一条待导入的历史收听
+type Listen struct {
+	Artist        string
+	AlbumArtist   string
+	Album         string
+	Track         string
+	PlayTime      time.Time
+	Duration      int64 // 秒，未知为 0
+	MusicBrainzID string
+	Genre         string
+	Source        string // 写入 track_play_records.source
+	// Scrobbled 为 false 时会由 outbox 补传到 Last.fm
+	// 导入的历史一律为 true：Last.fm 拒收两周前的时间戳，补传只会产生大量失败条目
+	Scrobbled bool
+}
+
+// Result 导入统计
+type Result struct {
+	Imported   int64 // 新写入的播放记录
+	Duplicates int64 // 已存在相同艺术家/曲目/时间的记录
+	Invalid    int64 // 缺少艺术家、专辑、曲目或播放时间等必填信息
+	Filtered   int64 // 不计为收听的行，如播客、播放过短或非播放结束事件
+}
+
+// Skipped 跳过的总条数
+func (r *Result) Skipped() int64 {
+	return r.Duplicates + r.Invalid + r.Filtered
+}
+
+func (r *Result) add(other Result) {
+	r.Imported += other.Imported
+	r.Duplicates += other.Duplicates
+	r.Invalid += other.Invalid
+	r.Filtered += other.Filtered
+}
+
+// normalize 与实时播放相同的标题、符号与繁简规范化
+func normalize(listen *Listen) {
+	listen.Track = common.ConversionSimplifiedFx(common.UnityFixAll(common.TrackCustomFit(listen.Track)))
+	listen.Album = common.ConversionSimplifiedFx(listen.Album)
+	listen.Artist = common.ConversionSimplifiedFx(listen.Artist)
+	listen.AlbumArtist = common.ConversionSimplifiedFx(listen.AlbumArtist)
+	if listen.Genre != "" {
+		listen.Genre = cache.GetEnglishGenre(common.GenreCustomFit(listen.Genre))
+	}
+}
+
+// applyRewriteRules 与实时播放一样按改写规则修正元数据
+func applyRewriteRules(ctx context.Context, listen *Listen) {
+	after, _ := rewrite.Rewrite(
+		ctx, rewrite.Fields{
+			Source:      listen.Source,
+			Artist:      listen.Artist,
+			Album:       listen.Album,
+			Title:       listen.Track,
+			AlbumArtist: listen.AlbumArtist,
+			Genre:       listen.Genre,
+		},
+	)
+	listen.Artist, listen.Album, listen.Track = after.Artist, after.Album, after.Title
+	listen.AlbumArtist, listen.Genre = after.AlbumArtist, after.Genre
+}
+
+// saveListens 逐条按改写规则修正、去重后写入播放记录并累加曲目播放次数，与实时标记走相同的 model 入口
+func saveListens(ctx context.Context, listens []Listen) (Result, error) {
+	var result Result
+	for _, listen := range listens {
+		if listen.PlayTime.IsZero() {
+			result.Invalid++
+			continue
+		}
+		applyRewriteRules(ctx, &listen)
+		if err := common.ValidateTrackInfo(ctx, listen.Artist, listen.Album, listen.Track); err != nil {
+			result.Invalid++
+			continue
+		}
+		exists, err := model.ExistsTrackPlayRecord(ctx, listen.Artist, listen.Track, listen.PlayTime)
+		if err != nil {
+			return result, err
+		}
+		if exists {
+			result.Duplicates++
+			continue
+		}
+
+		record := &model.TrackPlayRecord{
+			Artist:        listen.Artist,
+			AlbumArtist:   listen.AlbumArtist,
+			Track:         listen.Track,
+			Album:         listen.Album,
+			Duration:      listen.Duration,
+			PlayTime:      listen.PlayTime,
+			Scrobbled:     listen.Scrobbled,
+			MusicBrainzID: listen.MusicBrainzID,
+			Source:        listen.Source,
+		}
+		if err := model.InsertTrackPlayRecord(ctx, record); err != nil {
+			return result, err
+		}
+		if err := model.IncrementTrackPlayCount(
+			model.IncrementTrackPlayCountParams{
+				Ctx:    ctx,
+				Artist: listen.Artist,
+				Album:  listen.Album,
+				Track:  listen.Track,
+				TrackMetadata: model.TrackMetadata{
+					AlbumArtist:   listen.AlbumArtist,
+					Duration:      listen.Duration,
+					Genre:         listen.Genre,
+					MusicBrainzID: listen.MusicBrainzID,
+					Source:        listen.Source,
+					DiscNumber:    1, // 历史记录没有碟号，与 track.disc_number 的默认值保持一致以便命中已有曲目
+				},
+			},
+		); err != nil {
+			return result, err
+		}
+		result.Imported++
+	}
+	return result, nil
+}
+
+// loadCheckpoint 读取断点，不存在时返回尚未保存的新断点
+func loadCheckpoint(ctx context.Context, source string) (*model.ImportCheckpoint, error) {
+	checkpoint, err := model.GetImportCheckpoint(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		checkpoint = &model.ImportCheckpoint{Source: source}
+	}
+	return checkpoint, nil
+}
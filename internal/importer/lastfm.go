@@ -0,0 +1,184 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// SourceLastfm 从 Last.fm 导入的播放记录来源
+const SourceLastfm = "Last.fm"
+
+const (
+	defaultLastfmPause      = 250 * time.Millisecond // 翻页间隔，避免触发 Last.fm 限流
+	defaultLastfmRetryDelay = 5 * time.Second
+	lastfmMaxAttempts       = 3
+)
+
+// ProgressFunc 每页处理完成后回调，total 为本次运行的累计结果
+type ProgressFunc func(page, totalPages int, total Result)
+
+// lastfmCursor Last.fm 导入断点
+// 每轮导入固定 To 后按时间倒序翻页，新产生的 scrobble 不会让页码错位；一轮完成后 From 推进到上一轮的 To，下次只导入增量
+type lastfmCursor struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	Page int   `json:"page"` // 下一页页码，0 表示本轮已完成
+}
+
+// LastfmImporter 通过 user.getRecentTracks 导入 Last.fm 收听历史
+type LastfmImporter struct {
+	client     *lastfm.RecentTracksClient
+	user       string
+	pageSize   int
+	pause      time.Duration
+	retryDelay time.Duration
+	now        func() time.Time
+}
+
+// NewLastfmImporter 创建 Last.fm 历史导入器
+func NewLastfmImporter(client *lastfm.RecentTracksClient, user string, pageSize int) *LastfmImporter {
+	if pageSize <= 0 || pageSize > lastfm.RecentTracksMaxLimit {
+		pageSize = lastfm.RecentTracksMaxLimit
+	}
+	return &LastfmImporter{
+		client:     client,
+		user:       user,
+		pageSize:   pageSize,
+		pause:      defaultLastfmPause,
+		retryDelay: defaultLastfmRetryDelay,
+		now:        time.Now,
+	}
+}
+
+// CheckpointSource 断点在 import_checkpoint 表中的 source
+func (i *LastfmImporter) CheckpointSource() string {
+	return "lastfm:" + i.user
+}
+
+// Run 从断点处继续导入，直到本轮所有页面处理完成；出错时已处理的页面保留在断点中
+func (i *LastfmImporter) Run(ctx context.Context, progress ProgressFunc) (Result, error) {
+	var total Result
+	checkpoint, err := loadCheckpoint(ctx, i.CheckpointSource())
+	if err != nil {
+		return total, err
+	}
+	var cursor lastfmCursor
+	if checkpoint.State != "" {
+		if err := json.Unmarshal([]byte(checkpoint.State), &cursor); err != nil {
+			return total, fmt.Errorf("parse lastfm import checkpoint: %w", err)
+		}
+	}
+	if cursor.Page == 0 {
+		cursor.To = i.now().Unix()
+		cursor.Page = 1
+	}
+
+	for {
+		page, err := i.fetch(ctx, cursor)
+		if err != nil {
+			return total, fmt.Errorf("fetch page %d: %w", cursor.Page, err)
+		}
+
+		listens := make([]Listen, 0, len(page.Tracks))
+		for _, track := range page.Tracks {
+			if track.NowPlaying || track.Timestamp == 0 {
+				continue
+			}
+			listens = append(
+				listens, Listen{
+					Artist:        track.Artist,
+					Album:         track.Album,
+					Track:         track.Track,
+					PlayTime:      time.Unix(track.Timestamp, 0),
+					MusicBrainzID: track.Mbid,
+					Source:        SourceLastfm,
+					Scrobbled:     true,
+				},
+			)
+		}
+		result, err := saveListens(ctx, listens)
+		total.add(result)
+		checkpoint.Imported += result.Imported
+		checkpoint.Skipped += result.Skipped()
+		if err != nil {
+			// 当前页未推进，续传时重新处理该页，已写入的记录会被去重
+			_ = i.saveCheckpoint(ctx, checkpoint, cursor)
+			return total, err
+		}
+
+		done := cursor.Page >= page.TotalPages
+		currentPage := cursor.Page
+		if done {
+			cursor = lastfmCursor{From: cursor.To}
+		} else {
+			cursor.Page++
+		}
+		if err := i.saveCheckpoint(ctx, checkpoint, cursor); err != nil {
+			return total, err
+		}
+		if progress != nil {
+			progress(currentPage, page.TotalPages, total)
+		}
+		if done {
+			return total, nil
+		}
+		if err := sleepContext(ctx, i.pause); err != nil {
+			return total, err
+		}
+	}
+}
+
+// fetch 获取一页记录，临时错误按固定间隔重试
+func (i *LastfmImporter) fetch(ctx context.Context, cursor lastfmCursor) (*lastfm.RecentTracksPage, error) {
+	req := &lastfm.GetRecentTracksReq{
+		User:  i.user,
+		Page:  cursor.Page,
+		Limit: i.pageSize,
+		From:  cursor.From,
+		To:    cursor.To,
+	}
+	var err error
+	for attempt := 1; attempt <= lastfmMaxAttempts; attempt++ {
+		var page *lastfm.RecentTracksPage
+		page, err = i.client.GetRecentTracks(ctx, req)
+		if err == nil {
+			return page, nil
+		}
+		// 鉴权类错误需修复配置，重试无意义
+		if !lastfm.IsTransientError(err) || lastfm.IsAuthError(err) || attempt == lastfmMaxAttempts {
+			break
+		}
+		if err := sleepContext(ctx, i.retryDelay*time.Duration(attempt)); err != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (i *LastfmImporter) saveCheckpoint(ctx context.Context, checkpoint *model.ImportCheckpoint, cursor lastfmCursor) error {
+	state, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	checkpoint.State = string(state)
+	return model.SaveImportCheckpoint(ctx, checkpoint)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+type fakeScrobble struct {
+	artist, album, track string
+	uts                  int64
+}
+
+// fakeRecentTracks 模拟 user.getRecentTracks：按时间倒序分页并遵守 from/to
+type fakeRecentTracks struct {
+	mu        sync.Mutex
+	scrobbles []fakeScrobble // 按时间倒序
+	requests  []recentTracksRequest
+	failures  map[int]int // 页码 -> 剩余的失败次数
+}
+
+type recentTracksRequest struct {
+	page     int
+	from, to int64
+}
+
+func (f *fakeRecentTracks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	from, _ := strconv.ParseInt(q.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(q.Get("to"), 10, 64)
+	f.requests = append(f.requests, recentTracksRequest{page: page, from: from, to: to})
+	if f.failures[page] > 0 {
+		f.failures[page]--
+		_, _ = w.Write([]byte(`{"error":8,"message":"Operation failed"}`))
+		return
+	}
+
+	type item struct {
+		Name   string            `json:"name"`
+		Artist map[string]string `json:"artist"`
+		Album  map[string]string `json:"album"`
+		Date   map[string]string `json:"date,omitempty"`
+		Attr   map[string]string `json:"@attr,omitempty"`
+	}
+	// 正在播放的条目总在第一页最前面
+	var tracks []item
+	if page == 1 {
+		tracks = append(
+			tracks, item{
+				Name: "Now", Artist: map[string]string{"#text": "Artist"}, Album: map[string]string{"#text": "Album"},
+				Attr: map[string]string{"nowplaying": "true"},
+			},
+		)
+	}
+	var matched []fakeScrobble
+	for _, s := range f.scrobbles {
+		if (from == 0 || s.uts >= from) && (to == 0 || s.uts <= to) {
+			matched = append(matched, s)
+		}
+	}
+	totalPages := (len(matched) + limit - 1) / limit
+	for i := (page - 1) * limit; i < min(page*limit, len(matched)); i++ {
+		s := matched[i]
+		tracks = append(
+			tracks, item{
+				Name: s.track, Artist: map[string]string{"#text": s.artist}, Album: map[string]string{"#text": s.album},
+				Date: map[string]string{"uts": strconv.FormatInt(s.uts, 10)},
+			},
+		)
+	}
+	resp := map[string]any{
+		"recenttracks": map[string]any{
+			"track": tracks,
+			"@attr": map[string]string{
+				"page": strconv.Itoa(page), "totalPages": strconv.Itoa(totalPages),
+				"total": strconv.Itoa(len(matched)),
+			},
+		},
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeRecentTracks) add(s fakeScrobble) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scrobbles = append([]fakeScrobble{s}, f.scrobbles...)
+}
+
+func newTestLastfmImporter(t *testing.T, fake *fakeRecentTracks, now int64) *LastfmImporter {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	importer := NewLastfmImporter(lastfm.NewRecentTracksClient(server.URL, "key"), "alice", 2)
+	importer.pause = 0
+	importer.retryDelay = 0
+	importer.now = func() time.Time { return time.Unix(now, 0) }
+	return importer
+}
+
+func TestLastfmImporter_Run(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+
+	fake := &fakeRecentTracks{
+		scrobbles: []fakeScrobble{
+			{"Artist", "Album", "Song C", 1700000400},
+			{"Artist", "", "No Album", 1700000300},
+			{"Artist", "Album", "Song B", 1700000200},
+			{"Artist", "Album", "Song A", 1700000100},
+			{"Artist", "Album", "Song A", 1700000000},
+		},
+	}
+	// 已由播放器实时记录的同一次播放
+	require.NoError(
+		t, model.InsertTrackPlayRecord(
+			ctx, &model.TrackPlayRecord{
+				Artist: "Artist", Album: "Album", Track: "Song B", PlayTime: time.Unix(1700000200, 0),
+				Scrobbled: true, Source: string(common.PlayerRoon),
+			},
+		),
+	)
+
+	importer := newTestLastfmImporter(t, fake, 1700001000)
+	var pages []int
+	result, err := importer.Run(
+		ctx, func(page, totalPages int, total Result) {
+			pages = append(pages, page)
+			assert.Equal(t, 3, totalPages)
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, pages)
+	assert.Equal(t, Result{Imported: 3, Duplicates: 1, Invalid: 1}, result)
+	for _, req := range fake.requests {
+		assert.Equal(t, int64(1700001000), req.to, "to is fixed for the whole pass")
+		assert.Zero(t, req.from)
+	}
+
+	var records []*model.TrackPlayRecord
+	require.NoError(t, model.GetDB().Where("source = ?", SourceLastfm).Order("play_time").Find(&records).Error)
+	require.Len(t, records, 3)
+	assert.True(t, records[0].Scrobbled, "imported plays must not be scrobbled again")
+	track, err := model.GetTrack(ctx, "Artist", "Album", "Song A")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, track.PlayCount)
+
+	checkpoint, err := model.GetImportCheckpoint(ctx, importer.CheckpointSource())
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, checkpoint.Imported)
+	assert.EqualValues(t, 2, checkpoint.Skipped)
+	assert.JSONEq(t, `{"from":1700001000,"to":0,"page":0}`, checkpoint.State)
+
+	// 下一轮只请求上一轮之后的增量
+	fake.add(fakeScrobble{"Artist", "Album", "Song D", 1700002000})
+	fake.requests = nil
+	importer.now = func() time.Time { return time.Unix(1700003000, 0) }
+	result, err = importer.Run(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Imported: 1}, result)
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, recentTracksRequest{page: 1, from: 1700001000, to: 1700003000}, fake.requests[0])
+}
+
+func TestLastfmImporter_ResumeFromCheckpoint(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+
+	// 第 2 页连续失败，超过重试次数后中断
+	fake := &fakeRecentTracks{failures: map[int]int{2: lastfmMaxAttempts}}
+	for i := 5; i >= 0; i-- {
+		fake.scrobbles = append(fake.scrobbles, fakeScrobble{"Artist", "Album", fmt.Sprintf("Song %d", i), int64(1700000000 + i*100)})
+	}
+	importer := newTestLastfmImporter(t, fake, 1700001000)
+
+	result, err := importer.Run(ctx, nil)
+	require.Error(t, err)
+	assert.Equal(t, Result{Imported: 2}, result)
+	require.Len(t, fake.requests, 1+lastfmMaxAttempts)
+	assert.Equal(t, 2, fake.requests[len(fake.requests)-1].page)
+
+	// 中断期间 Last.fm 上出现新的 scrobble，续传时页码不会错位
+	fake.add(fakeScrobble{"Artist", "Album", "Newer", 1700002000})
+	fake.requests = nil
+	importer.now = func() time.Time { return time.Unix(1700005000, 0) }
+	result, err = importer.Run(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Imported: 4}, result)
+	assert.Equal(t, recentTracksRequest{page: 2, to: 1700001000}, fake.requests[0])
+
+	count, err := model.GetUnscrobbledRecordsCount(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	var total int64
+	require.NoError(t, model.GetDB().Model(&model.TrackPlayRecord{}).Count(&total).Error)
+	assert.EqualValues(t, 6, total)
+}
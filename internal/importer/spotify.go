@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// spotifyStream Spotify 扩展收听历史中的一条记录，endsong 与 Streaming_History_Audio 字段相同
+type spotifyStream struct {
+	Ts              string  `json:"ts"` // 播放结束时间(UTC)
+	MsPlayed        int64   `json:"ms_played"`
+	TrackName       *string `json:"master_metadata_track_name"`
+	ArtistName      *string `json:"master_metadata_album_artist_name"`
+	AlbumName       *string `json:"master_metadata_album_album_name"`
+	SpotifyTrackURI *string `json:"spotify_track_uri"`
+	EpisodeURI      *string `json:"spotify_episode_uri"`
+}
+
+// parseSpotifyHistory 逐条解码 JSON 数组，播客与播放不足 30 秒的记录被过滤
+func parseSpotifyHistory(r io.Reader, emit func(Listen, bool) error) error {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("read spotify history: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("spotify history must be a JSON array")
+	}
+	for decoder.More() {
+		var stream spotifyStream
+		if err := decoder.Decode(&stream); err != nil {
+			return fmt.Errorf("decode spotify history: %w", err)
+		}
+		if err := emit(stream.toListen()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toListen ts 无法解析时返回不带播放时间的记录，由写入时计为无效而不中断导入
+func (s *spotifyStream) toListen() (Listen, bool) {
+	if s.EpisodeURI != nil || s.TrackName == nil || s.MsPlayed < minPlayedMillis {
+		return Listen{}, false
+	}
+	listen := Listen{Track: *s.TrackName}
+	if end, err := time.Parse(time.RFC3339, s.Ts); err == nil {
+		// ts 为结束时间，倒推开始时间与实时记录保持一致
+		listen.PlayTime = end.Add(-time.Duration(s.MsPlayed) * time.Millisecond).Truncate(time.Second).Local()
+	}
+	if s.ArtistName != nil {
+		listen.Artist = *s.ArtistName
+		listen.AlbumArtist = *s.ArtistName
+	}
+	if s.AlbumName != nil {
+		listen.Album = *s.AlbumName
+	}
+	return listen, true
+}
@@ -0,0 +1,119 @@
+package ingest
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+)
+
+var (
+	// ErrIgnored 事件与播放无关（如视频、收藏、库更新），接收后直接忽略
+	ErrIgnored = errors.New("event ignored")
+	// ErrInvalidPayload 请求体无法解析或缺少曲目信息
+	ErrInvalidPayload = errors.New("invalid webhook payload")
+)
+
+// Track webhook 中的曲目信息，缺失的字段留空
+type Track struct {
+	Title         string
+	Album         string
+	Artist        string
+	AlbumArtist   string
+	TrackNumber   int64
+	DiscNumber    int64
+	Duration      int64 // 秒，未知时为 0
+	Genre         string
+	ReleaseDate   string
+	MusicBrainzID string
+	Url           string // 文件路径或页面地址
+	UniqueID      string // 服务端的条目 ID
+	Client        string // 播放客户端或网站名称
+}
+
+// Event 一次 webhook 推送转换后的播放事件
+type Event struct {
+	State    common.PlayerState
+	Track    *Track
+	Position *float64 // 秒，payload 未携带进度时为 nil
+}
+
+// Endpoint 一个 webhook 接入端点
+type Endpoint struct {
+	Name   string            // 路由中的名称，即 /api/ingest/{name}
+	Player common.PlayerType // 播放记录的 source，需要在 scrobblers 中启用
+	Parse  func(r *http.Request) (*Event, error)
+	secret func(cfg config.IngestConfig) string
+}
+
+var endpoints = []Endpoint{
+	{
+		Name: "jellyfin", Player: common.PlayerJellyfin, Parse: parseJellyfinRequest,
+		secret: func(cfg config.IngestConfig) string { return cfg.Jellyfin.Secret },
+	},
+	{
+		Name: "plex", Player: common.PlayerPlex, Parse: parsePlexRequest,
+		secret: func(cfg config.IngestConfig) string { return cfg.Plex.Secret },
+	},
+	{
+		Name: "webscrobbler", Player: common.PlayerWebScrobbler, Parse: parseWebScrobblerRequest,
+		secret: func(cfg config.IngestConfig) string { return cfg.WebScrobbler.Secret },
+	},
+}
+
+// Lookup 按路由名称查找端点
+func Lookup(name string) (Endpoint, bool) {
+	for _, endpoint := range endpoints {
+		if strings.EqualFold(endpoint.Name, name) {
+			return endpoint, true
+		}
+	}
+	return Endpoint{}, false
+}
+
+// Enabled 配置了 secret 的端点才接收请求
+func (e Endpoint) Enabled(cfg config.IngestConfig) bool {
+	return e.secret(cfg) != ""
+}
+
+// Authorize 校验请求携带的 secret。Plex 与 Web Scrobbler 只能配置 URL，
+// 因此同时接受 ?secret=、X-Webhook-Secret 与 Authorization: Bearer
+func (e Endpoint) Authorize(cfg config.IngestConfig, r *http.Request) bool {
+	secret := e.secret(cfg)
+	if secret == "" {
+		return false
+	}
+	given := r.URL.Query().Get("secret")
+	if given == "" {
+		given = r.Header.Get("X-Webhook-Secret")
+	}
+	if given == "" {
+		given = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(given), []byte(secret)) == 1
+}
+
+// normalize 统一曲目字段，缺少标题或艺术家的事件无法记录
+func normalize(event *Event) (*Event, error) {
+	if event.Track == nil {
+		return event, nil
+	}
+	track := event.Track
+	track.Title = strings.TrimSpace(track.Title)
+	track.Artist = strings.TrimSpace(track.Artist)
+	track.Album = strings.TrimSpace(track.Album)
+	if track.Title == "" || track.Artist == "" {
+		return nil, ErrInvalidPayload
+	}
+	if track.AlbumArtist == "" {
+		track.AlbumArtist = track.Artist
+	}
+	return event, nil
+}
+
+func seconds(v float64) *float64 {
+	return &v
+}
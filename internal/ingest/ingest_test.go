@@ -0,0 +1,129 @@
+package ingest
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+)
+
+func TestParseJellyfin(t *testing.T) {
+	endpoint, ok := Lookup("jellyfin")
+	require.True(t, ok)
+	body := `{
+		"NotificationType": "PlaybackProgress", "ItemType": "Audio", "ItemId": "a1b2",
+		"Name": "Hey You", "Album": "The Wall", "Artist": "Pink Floyd", "AlbumArtist": "",
+		"Genre": "Progressive Rock", "Year": "1979", "IndexNumber": 1, "ParentIndexNumber": "2",
+		"RunTimeTicks": 2800000000, "PlaybackPositionTicks": "425000000", "IsPaused": "True",
+		"Provider_musicbrainztrack": "8a2b6e3c", "ClientName": "Finamp"
+	}`
+	event, err := endpoint.Parse(httptest.NewRequest(http.MethodPost, "/api/ingest/jellyfin", strings.NewReader(body)))
+	require.NoError(t, err)
+	assert.Equal(t, common.PlayerState(common.PlayerStatePaused), event.State)
+	require.NotNil(t, event.Position)
+	assert.InDelta(t, 42.5, *event.Position, 0.001)
+	assert.Equal(
+		t, &Track{
+			Title: "Hey You", Album: "The Wall", Artist: "Pink Floyd", AlbumArtist: "Pink Floyd",
+			TrackNumber: 1, DiscNumber: 2, Duration: 280, Genre: "Progressive Rock", ReleaseDate: "1979",
+			MusicBrainzID: "8a2b6e3c", UniqueID: "a1b2", Client: "Finamp",
+		}, event.Track,
+	)
+
+	_, err = ParseJellyfin(&JellyfinPayload{NotificationType: "PlaybackStart", ItemType: "Movie", Name: "Alien"})
+	assert.ErrorIs(t, err, ErrIgnored)
+	_, err = ParseJellyfin(&JellyfinPayload{NotificationType: "PlaybackStart", ItemType: "Audio", Name: "No Artist"})
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestParsePlex(t *testing.T) {
+	endpoint, ok := Lookup("plex")
+	require.True(t, ok)
+	payload := `{
+		"event": "media.play",
+		"Player": {"title": "Plexamp"},
+		"Metadata": {
+			"type": "track", "guid": "mbid://8a2b6e3c", "ratingKey": "1234", "title": "Comfortably Numb",
+			"parentTitle": "The Wall", "grandparentTitle": "Pink Floyd", "index": 6, "parentIndex": 2,
+			"parentYear": 1979, "duration": 382000, "Genre": [{"tag": "Rock"}]
+		}
+	}`
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("payload", payload))
+	require.NoError(t, writer.Close())
+	req := httptest.NewRequest(http.MethodPost, "/api/ingest/plex", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	event, err := endpoint.Parse(req)
+	require.NoError(t, err)
+	assert.Equal(t, common.PlayerState(common.PlayerStatePlaying), event.State)
+	assert.Nil(t, event.Position, "media.play carries no viewOffset")
+	assert.Equal(t, "Comfortably Numb", event.Track.Title)
+	assert.Equal(t, "Pink Floyd", event.Track.Artist)
+	assert.EqualValues(t, 382, event.Track.Duration)
+	assert.EqualValues(t, 2, event.Track.DiscNumber)
+	assert.Equal(t, "8a2b6e3c", event.Track.MusicBrainzID)
+	assert.Equal(t, "Rock", event.Track.Genre)
+	assert.Equal(t, "Plexamp", event.Track.Client)
+
+	var p PlexPayload
+	p.Event = "media.play"
+	p.Metadata.Type = "episode"
+	_, err = ParsePlex(&p)
+	assert.ErrorIs(t, err, ErrIgnored)
+}
+
+func TestParseWebScrobbler(t *testing.T) {
+	endpoint, ok := Lookup("WebScrobbler")
+	require.True(t, ok)
+	body := `{
+		"eventName": "nowplaying",
+		"data": {"song": {
+			"parsed": {"track": "Time (Live)", "artist": "Pink Floyd", "album": "", "duration": 421.5,
+				"currentTime": 12, "uniqueID": "yt-abc", "originUrl": "https://www.youtube.com/watch?v=abc"},
+			"processed": {"track": "Time", "artist": "Pink Floyd", "album": "The Dark Side of the Moon", "duration": null},
+			"connectorLabel": "YouTube"
+		}}
+	}`
+	event, err := endpoint.Parse(httptest.NewRequest(http.MethodPost, "/api/ingest/webscrobbler", strings.NewReader(body)))
+	require.NoError(t, err)
+	assert.Equal(t, common.PlayerState(common.PlayerStatePlaying), event.State)
+	require.NotNil(t, event.Position)
+	assert.InDelta(t, 12, *event.Position, 0.001)
+	assert.Equal(t, "Time", event.Track.Title, "processed values win")
+	assert.Equal(t, "The Dark Side of the Moon", event.Track.Album)
+	assert.EqualValues(t, 421, event.Track.Duration, "falls back to parsed duration")
+	assert.Equal(t, "YouTube", event.Track.Client)
+
+	_, err = endpoint.Parse(
+		httptest.NewRequest(http.MethodPost, "/api/ingest/webscrobbler", strings.NewReader(`{"eventName": "loved"}`)),
+	)
+	assert.ErrorIs(t, err, ErrIgnored)
+}
+
+func TestEndpoint_Authorize(t *testing.T) {
+	cfg := config.IngestConfig{Plex: config.IngestEndpointConfig{Secret: "s3cret"}}
+	plex, _ := Lookup("plex")
+	jellyfin, _ := Lookup("jellyfin")
+	assert.True(t, plex.Enabled(cfg))
+	assert.False(t, jellyfin.Enabled(cfg), "no secret, endpoint disabled")
+
+	assert.True(t, plex.Authorize(cfg, httptest.NewRequest(http.MethodPost, "/api/ingest/plex?secret=s3cret", nil)))
+	req := httptest.NewRequest(http.MethodPost, "/api/ingest/plex", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	assert.True(t, plex.Authorize(cfg, req))
+	req = httptest.NewRequest(http.MethodPost, "/api/ingest/plex", nil)
+	req.Header.Set("X-Webhook-Secret", "wrong")
+	assert.False(t, plex.Authorize(cfg, req))
+	assert.False(t, plex.Authorize(cfg, httptest.NewRequest(http.MethodPost, "/api/ingest/plex", nil)))
+	assert.False(t, jellyfin.Authorize(cfg, httptest.NewRequest(http.MethodPost, "/api/ingest/jellyfin?secret=", nil)))
+}
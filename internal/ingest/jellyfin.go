@@ -0,0 +1,111 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vincentchyu/sonic-lens/common"
+)
+
+// jellyfinTicksPerSecond Jellyfin 的 Ticks 单位为 100 纳秒
+const jellyfinTicksPerSecond = 10_000_000
+
+// JellyfinPayload jellyfin-plugin-webhook Generic 目标的请求体，模板见 README，
+// 数字与布尔值无论模板中是否加引号都能解析
+type JellyfinPayload struct {
+	NotificationType      string     `json:"NotificationType"` // PlaybackStart / PlaybackProgress / PlaybackStop
+	ItemType              string     `json:"ItemType"`
+	ItemId                string     `json:"ItemId"`
+	Name                  string     `json:"Name"`
+	Album                 string     `json:"Album"`
+	Artist                string     `json:"Artist"`
+	AlbumArtist           string     `json:"AlbumArtist"`
+	Genre                 string     `json:"Genre"`
+	Year                  flexNumber `json:"Year"`
+	IndexNumber           flexNumber `json:"IndexNumber"`
+	ParentIndexNumber     flexNumber `json:"ParentIndexNumber"`
+	RunTimeTicks          flexNumber `json:"RunTimeTicks"`
+	PlaybackPositionTicks flexNumber `json:"PlaybackPositionTicks"`
+	IsPaused              flexBool   `json:"IsPaused"`
+	MusicBrainzTrackID    string     `json:"Provider_musicbrainztrack"`
+	ClientName            string     `json:"ClientName"`
+}
+
+func parseJellyfinRequest(r *http.Request) (*Event, error) {
+	var payload JellyfinPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return ParseJellyfin(&payload)
+}
+
+// ParseJellyfin 只处理音频的播放开始、进度与停止
+func ParseJellyfin(payload *JellyfinPayload) (*Event, error) {
+	if !strings.EqualFold(payload.ItemType, "Audio") {
+		return nil, ErrIgnored
+	}
+	event := &Event{}
+	switch payload.NotificationType {
+	case "PlaybackStart":
+		event.State = common.PlayerStatePlaying
+	case "PlaybackProgress":
+		event.State = common.PlayerStatePlaying
+		if payload.IsPaused {
+			event.State = common.PlayerStatePaused
+		}
+	case "PlaybackStop":
+		event.State = common.PlayerStateStopped
+	default:
+		return nil, ErrIgnored
+	}
+	if payload.PlaybackPositionTicks > 0 || payload.NotificationType == "PlaybackStart" {
+		event.Position = seconds(float64(payload.PlaybackPositionTicks) / jellyfinTicksPerSecond)
+	}
+
+	event.Track = &Track{
+		Title:         payload.Name,
+		Album:         payload.Album,
+		Artist:        payload.Artist,
+		AlbumArtist:   payload.AlbumArtist,
+		TrackNumber:   int64(payload.IndexNumber),
+		DiscNumber:    int64(payload.ParentIndexNumber),
+		Duration:      int64(payload.RunTimeTicks) / jellyfinTicksPerSecond,
+		Genre:         payload.Genre,
+		MusicBrainzID: payload.MusicBrainzTrackID,
+		UniqueID:      payload.ItemId,
+		Client:        payload.ClientName,
+	}
+	if payload.Year > 0 {
+		event.Track.ReleaseDate = strconv.FormatInt(int64(payload.Year), 10)
+	}
+	return normalize(event)
+}
+
+// flexNumber 兼容 123、"123" 与空字符串
+type flexNumber int64
+
+func (n *flexNumber) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*n = flexNumber(v)
+	return nil
+}
+
+// flexBool 兼容 true、"True" 与空字符串
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	*b = flexBool(strings.EqualFold(s, "true"))
+	return nil
+}
@@ -0,0 +1,108 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vincentchyu/sonic-lens/common"
+)
+
+// plexMaxMemory multipart 中除 payload 外还可能带封面缩略图
+const plexMaxMemory = 8 << 20
+
+// PlexPayload Plex webhook multipart 中 payload 字段的 JSON
+type PlexPayload struct {
+	Event  string `json:"event"` // media.play / media.pause / media.resume / media.stop / media.scrobble
+	Player struct {
+		Title string `json:"title"`
+	} `json:"Player"`
+	Metadata struct {
+		Type             string `json:"type"`
+		GUID             string `json:"guid"`
+		RatingKey        string `json:"ratingKey"`
+		Title            string `json:"title"`
+		ParentTitle      string `json:"parentTitle"`      // 专辑
+		GrandparentTitle string `json:"grandparentTitle"` // 专辑艺术家
+		OriginalTitle    string `json:"originalTitle"`    // 与专辑艺术家不同时的曲目艺术家
+		Index            int64  `json:"index"`
+		ParentIndex      int64  `json:"parentIndex"`
+		ParentYear       int64  `json:"parentYear"`
+		Duration         int64  `json:"duration"`   // 毫秒
+		ViewOffset       *int64 `json:"viewOffset"` // 毫秒
+		Genre            []struct {
+			Tag string `json:"tag"`
+		} `json:"Genre"`
+	} `json:"Metadata"`
+}
+
+func parsePlexRequest(r *http.Request) (*Event, error) {
+	var payload PlexPayload
+	// Plex 以 multipart/form-data 发送，JSON 在 payload 字段中；也接受直接提交的 JSON 便于调试
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		return ParsePlex(&payload)
+	}
+	if err := r.ParseMultipartForm(plexMaxMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if err := json.Unmarshal([]byte(r.FormValue("payload")), &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return ParsePlex(&payload)
+}
+
+// ParsePlex 只处理音乐曲目的播放事件
+func ParsePlex(payload *PlexPayload) (*Event, error) {
+	metadata := payload.Metadata
+	if metadata.Type != "track" {
+		return nil, ErrIgnored
+	}
+	event := &Event{}
+	switch payload.Event {
+	case "media.play", "media.resume", "media.scrobble":
+		event.State = common.PlayerStatePlaying
+	case "media.pause":
+		event.State = common.PlayerStatePaused
+	case "media.stop":
+		event.State = common.PlayerStateStopped
+	default:
+		return nil, ErrIgnored
+	}
+	if metadata.ViewOffset != nil {
+		event.Position = seconds(float64(*metadata.ViewOffset) / 1000)
+	}
+
+	artist := metadata.GrandparentTitle
+	if metadata.OriginalTitle != "" {
+		artist = metadata.OriginalTitle
+	}
+	event.Track = &Track{
+		Title:       metadata.Title,
+		Album:       metadata.ParentTitle,
+		Artist:      artist,
+		AlbumArtist: metadata.GrandparentTitle,
+		TrackNumber: metadata.Index,
+		DiscNumber:  metadata.ParentIndex,
+		Duration:    metadata.Duration / 1000,
+		UniqueID:    metadata.RatingKey,
+		Url:         metadata.GUID,
+		Client:      payload.Player.Title,
+	}
+	if len(metadata.Genre) > 0 {
+		event.Track.Genre = metadata.Genre[0].Tag
+	}
+	if metadata.ParentYear > 0 {
+		event.Track.ReleaseDate = strconv.FormatInt(metadata.ParentYear, 10)
+	}
+	// 匹配了 MusicBrainz 代理的曲目 guid 形如 mbid://<recording id>
+	if mbid, ok := strings.CutPrefix(metadata.GUID, "mbid://"); ok {
+		event.Track.MusicBrainzID = mbid
+	}
+	return normalize(event)
+}
@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/vincentchyu/sonic-lens/common"
+)
+
+// webScrobblerSong Web Scrobbler 中的曲目字段，processed 为用户编辑或规则修正后的值
+type webScrobblerSong struct {
+	Track       string   `json:"track"`
+	Artist      string   `json:"artist"`
+	AlbumArtist string   `json:"albumArtist"`
+	Album       string   `json:"album"`
+	Duration    *float64 `json:"duration"`
+}
+
+// WebScrobblerPayload Web Scrobbler 扩展 Webhook 的请求体
+type WebScrobblerPayload struct {
+	EventName string `json:"eventName"` // nowplaying / paused / resumedplaying / scrobble / loved
+	Data      struct {
+		Song struct {
+			Parsed struct {
+				webScrobblerSong
+				UniqueID    string   `json:"uniqueID"`
+				CurrentTime *float64 `json:"currentTime"`
+				OriginURL   string   `json:"originUrl"`
+				IsPodcast   bool     `json:"isPodcast"`
+			} `json:"parsed"`
+			Processed      webScrobblerSong `json:"processed"`
+			ConnectorLabel string           `json:"connectorLabel"`
+			Metadata       struct {
+				Label string `json:"label"`
+			} `json:"metadata"`
+		} `json:"song"`
+	} `json:"data"`
+}
+
+func parseWebScrobblerRequest(r *http.Request) (*Event, error) {
+	var payload WebScrobblerPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return ParseWebScrobbler(&payload)
+}
+
+// ParseWebScrobbler 播客与收藏事件不记录
+func ParseWebScrobbler(payload *WebScrobblerPayload) (*Event, error) {
+	song := payload.Data.Song
+	if song.Parsed.IsPodcast {
+		return nil, ErrIgnored
+	}
+	event := &Event{Position: song.Parsed.CurrentTime}
+	switch payload.EventName {
+	case "nowplaying", "resumedplaying", "scrobble":
+		event.State = common.PlayerStatePlaying
+	case "paused":
+		event.State = common.PlayerStatePaused
+	default:
+		return nil, ErrIgnored
+	}
+
+	pick := func(processed, parsed string) string {
+		if processed != "" {
+			return processed
+		}
+		return parsed
+	}
+	duration := song.Processed.Duration
+	if duration == nil {
+		duration = song.Parsed.Duration
+	}
+	client := song.ConnectorLabel
+	if client == "" {
+		client = song.Metadata.Label
+	}
+	event.Track = &Track{
+		Title:       pick(song.Processed.Track, song.Parsed.Track),
+		Album:       pick(song.Processed.Album, song.Parsed.Album),
+		Artist:      pick(song.Processed.Artist, song.Parsed.Artist),
+		AlbumArtist: pick(song.Processed.AlbumArtist, song.Parsed.AlbumArtist),
+		UniqueID:    song.Parsed.UniqueID,
+		Url:         song.Parsed.OriginURL,
+		Client:      client,
+	}
+	if duration != nil {
+		event.Track.Duration = int64(*duration)
+	}
+	return normalize(event)
+}
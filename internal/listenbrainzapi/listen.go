@@ -0,0 +1,216 @@
+package listenbrainzapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/listenbrainz"
+	"github.com/vincentchyu/sonic-lens/internal/cache"
+	"github.com/vincentchyu/sonic-lens/internal/logic/rewrite"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// submitListensRequest submit-listens 的请求体
+type submitListensRequest struct {
+	ListenType listenbrainz.ListenType `json:"listen_type"`
+	Payload    []listenPayload         `json:"payload"`
+}
+
+// listenPayload 一条收听，additional_info 中的字段由不同客户端以数字或字符串提交，按键读取
+type listenPayload struct {
+	ListenedAt    *int64 `json:"listened_at"`
+	TrackMetadata struct {
+		ArtistName     string         `json:"artist_name"`
+		TrackName      string         `json:"track_name"`
+		ReleaseName    string         `json:"release_name"`
+		AdditionalInfo map[string]any `json:"additional_info"`
+	} `json:"track_metadata"`
+}
+
+// listen 校验并规范化后的收听
+type listen struct {
+	artist      string
+	albumArtist string
+	track       string
+	album       string
+	playTime    time.Time
+	duration    int64 // 秒
+	trackNumber int64
+	discNumber  int64
+	genre       string
+	mbid        string
+}
+
+// validate 按 ListenBrainz 的规则校验请求，返回规范化后的收听
+func (r *submitListensRequest) validate() ([]*listen, error) {
+	switch r.ListenType {
+	case listenbrainz.ListenTypeSingle, listenbrainz.ListenTypePlayingNow:
+		if len(r.Payload) != 1 {
+			return nil, fmt.Errorf("JSON document should contain exactly one listen for listen_type %s", r.ListenType)
+		}
+	case listenbrainz.ListenTypeImport:
+		if len(r.Payload) == 0 {
+			return nil, fmt.Errorf("JSON document should contain at least one listen for listen_type import")
+		}
+		if len(r.Payload) > listenbrainz.MaxListensPerRequest {
+			return nil, fmt.Errorf("too many listens, at most %d per request", listenbrainz.MaxListensPerRequest)
+		}
+	default:
+		return nil, fmt.Errorf("JSON document requires a valid listen_type key")
+	}
+
+	listens := make([]*listen, 0, len(r.Payload))
+	for i, payload := range r.Payload {
+		metadata := payload.TrackMetadata
+		if strings.TrimSpace(metadata.ArtistName) == "" || strings.TrimSpace(metadata.TrackName) == "" {
+			return nil, fmt.Errorf("listen %d: track_metadata.artist_name and track_metadata.track_name are required", i)
+		}
+		l := &listen{
+			artist:      normalizeArtist(metadata.ArtistName),
+			track:       common.ConversionSimplifiedFx(common.UnityFixAll(common.TrackCustomFit(strings.TrimSpace(metadata.TrackName)))),
+			album:       common.ConversionSimplifiedFx(strings.TrimSpace(metadata.ReleaseName)),
+			albumArtist: normalizeArtist(infoString(metadata.AdditionalInfo, "release_artist_name", "albumartist")),
+			trackNumber: infoInt(metadata.AdditionalInfo, "tracknumber"),
+			discNumber:  infoInt(metadata.AdditionalInfo, "discnumber"),
+			mbid:        infoString(metadata.AdditionalInfo, "recording_mbid", "track_mbid"),
+		}
+		if l.albumArtist == "" {
+			l.albumArtist = l.artist
+		}
+		if ms := infoInt(metadata.AdditionalInfo, "duration_ms"); ms > 0 {
+			l.duration = ms / 1000
+		} else {
+			l.duration = infoInt(metadata.AdditionalInfo, "duration")
+		}
+		if genre := infoString(metadata.AdditionalInfo, "genre"); genre != "" {
+			l.genre = cache.GetEnglishGenre(common.GenreCustomFit(genre))
+		}
+		if r.ListenType != listenbrainz.ListenTypePlayingNow {
+			if payload.ListenedAt == nil || *payload.ListenedAt <= 0 {
+				return nil, fmt.Errorf("listen %d: listened_at is required for listen_type %s", i, r.ListenType)
+			}
+			l.playTime = time.Unix(*payload.ListenedAt, 0)
+		}
+		listens = append(listens, l)
+	}
+	return listens, nil
+}
+
+type saveResult struct {
+	saved      int
+	duplicates int
+	invalid    int // 缺少专辑等播放记录必填信息
+}
+
+// saveListens 逐条按改写规则修正、去重后写入播放记录并累加播放次数，与实时标记走相同的 model 入口。
+// 提交的收听已由客户端自行上报，记录直接标记为已同步，不再经 outbox 转发
+func saveListens(ctx context.Context, listens []*listen, source string) (saveResult, error) {
+	var result saveResult
+	for _, l := range listens {
+		l.applyRewriteRules(ctx, source)
+		if err := common.ValidateTrackInfo(ctx, l.artist, l.album, l.track); err != nil {
+			result.invalid++
+			continue
+		}
+		exists, err := model.ExistsTrackPlayRecord(ctx, l.artist, l.track, l.playTime)
+		if err != nil {
+			return result, err
+		}
+		if exists {
+			result.duplicates++
+			continue
+		}
+
+		record := &model.TrackPlayRecord{
+			Artist:        l.artist,
+			AlbumArtist:   l.albumArtist,
+			Track:         l.track,
+			Album:         l.album,
+			Duration:      l.duration,
+			PlayTime:      l.playTime,
+			Scrobbled:     true,
+			MusicBrainzID: l.mbid,
+			TrackNumber:   int8(l.trackNumber),
+			Source:        source,
+		}
+		if err := model.InsertTrackPlayRecord(ctx, record); err != nil {
+			return result, err
+		}
+		discNumber := int8(l.discNumber)
+		if discNumber <= 0 {
+			discNumber = 1
+		}
+		if err := model.IncrementTrackPlayCount(
+			model.IncrementTrackPlayCountParams{
+				Ctx:    ctx,
+				Artist: l.artist,
+				Album:  l.album,
+				Track:  l.track,
+				TrackMetadata: model.TrackMetadata{
+					AlbumArtist:   l.albumArtist,
+					TrackNumber:   int8(l.trackNumber),
+					Duration:      l.duration,
+					Genre:         l.genre,
+					MusicBrainzID: l.mbid,
+					Source:        source,
+					DiscNumber:    discNumber,
+				},
+			},
+		); err != nil {
+			return result, err
+		}
+		result.saved++
+	}
+	return result, nil
+}
+
+// applyRewriteRules 与实时播放一样按改写规则修正元数据
+func (l *listen) applyRewriteRules(ctx context.Context, source string) {
+	after, _ := rewrite.Rewrite(
+		ctx, rewrite.Fields{
+			Source:      source,
+			Artist:      l.artist,
+			Album:       l.album,
+			Title:       l.track,
+			AlbumArtist: l.albumArtist,
+			Genre:       l.genre,
+		},
+	)
+	l.artist, l.album, l.track = after.Artist, after.Album, after.Title
+	l.albumArtist, l.genre = after.AlbumArtist, after.Genre
+}
+
+func normalizeArtist(artist string) string {
+	return common.ConversionSimplifiedFx(common.ArtistCustomFit(strings.TrimSpace(artist)))
+}
+
+// infoString 返回第一个非空的字符串字段
+func infoString(info map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := info[key].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// infoInt 兼容数字与 "3"、"3/12" 形式的字符串
+func infoInt(info map[string]any, key string) int64 {
+	switch v := info[key].(type) {
+	case float64:
+		return int64(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	case string:
+		s, _, _ := strings.Cut(strings.TrimSpace(v), "/")
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package listenbrainzapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/core/listenbrainz"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// maxRequestBytes submit-listens 请求体上限，import 最多 1000 条
+const maxRequestBytes = 10 << 20
+
+// Server ListenBrainz 兼容的提交接口，只实现 POST /1/submit-listens 与 GET /1/validate-token，
+// 客户端以 listen_token 表中的 token 鉴权，收听写入 track_play_records，source 为 token 的客户端名称
+type Server struct {
+	now func() time.Time
+}
+
+// NewServer 创建兼容接口
+func NewServer() *Server {
+	return &Server{now: time.Now}
+}
+
+// apiError ListenBrainz 的错误响应 {"code": 400, "error": "..."}
+type apiError struct {
+	Code  int    `json:"code"`
+	Error string `json:"error"`
+}
+
+var errMissingToken = &apiError{Code: http.StatusUnauthorized, Error: "You need to provide an Authorization header."}
+
+// ValidateToken GET /1/validate-token，token 可放在 Authorization: Token 头或 ?token= 中
+func (s *Server) ValidateToken(w http.ResponseWriter, r *http.Request) {
+	given := requestToken(r)
+	if given == "" {
+		given = r.URL.Query().Get("token")
+	}
+	if given == "" {
+		writeJSON(w, http.StatusBadRequest, &apiError{Code: http.StatusBadRequest, Error: "You need to provide an Authorization token."})
+		return
+	}
+	token, err := model.GetListenToken(r.Context(), given)
+	if err != nil {
+		log.Error(r.Context(), "Failed to validate listen token", zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, &apiError{Code: http.StatusInternalServerError, Error: "Internal server error."})
+		return
+	}
+	if token == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"code": http.StatusOK, "message": "Token invalid.", "valid": false})
+		return
+	}
+	writeJSON(
+		w, http.StatusOK, map[string]any{"code": http.StatusOK, "message": "Token valid.", "valid": true, "user_name": token.Name},
+	)
+}
+
+// SubmitListens POST /1/submit-listens，playing_now 只校验不保存
+func (s *Server) SubmitListens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	given := requestToken(r)
+	if given == "" {
+		writeJSON(w, http.StatusUnauthorized, errMissingToken)
+		return
+	}
+	token, err := model.GetListenToken(ctx, given)
+	if err != nil {
+		log.Error(ctx, "Failed to validate listen token", zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, &apiError{Code: http.StatusInternalServerError, Error: "Internal server error."})
+		return
+	}
+	if token == nil {
+		writeJSON(w, http.StatusUnauthorized, &apiError{Code: http.StatusUnauthorized, Error: "Invalid authorization token."})
+		return
+	}
+
+	var req submitListensRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &apiError{Code: http.StatusBadRequest, Error: "Cannot parse JSON document: " + err.Error()})
+		return
+	}
+	listens, err := req.validate()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &apiError{Code: http.StatusBadRequest, Error: err.Error()})
+		return
+	}
+
+	if err := model.TouchListenToken(ctx, token.ID, s.now()); err != nil {
+		log.Warn(ctx, "Failed to touch listen token", zap.Error(err))
+	}
+	if req.ListenType == listenbrainz.ListenTypePlayingNow {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	result, err := saveListens(ctx, listens, token.Name)
+	if err != nil {
+		log.Error(ctx, "Failed to save listens", zap.String("client", token.Name), zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, &apiError{Code: http.StatusInternalServerError, Error: "Internal server error."})
+		return
+	}
+	log.Info(
+		ctx, "ListenBrainz submit-listens", zap.String("client", token.Name), zap.String("listen_type", string(req.ListenType)),
+		zap.Int("saved", result.saved), zap.Int("duplicates", result.duplicates), zap.Int("invalid", result.invalid),
+	)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// requestToken 读取 Authorization: Token <token>
+func requestToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	if !ok || !strings.EqualFold(scheme, "Token") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// NewToken 生成 UUID 格式的随机 token，与 ListenBrainz 的用户 token 形式一致
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:32]), nil
+}
+
+// CreateToken 为客户端签发 token
+func CreateToken(ctx context.Context, name string) (*model.ListenToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("client name is required")
+	}
+	value, err := NewToken()
+	if err != nil {
+		return nil, err
+	}
+	token := &model.ListenToken{Name: name, Token: value}
+	if err := model.CreateListenToken(ctx, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
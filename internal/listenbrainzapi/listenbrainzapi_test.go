@@ -0,0 +1,168 @@
+package listenbrainzapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/internal/logic/rewrite"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+func submit(t *testing.T, s *Server, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/1/submit-listens", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	w := httptest.NewRecorder()
+	s.SubmitListens(w, req)
+	return w
+}
+
+func TestServer_ValidateToken(t *testing.T) {
+	model.SetupTestDB(t)
+	token, err := CreateToken(context.Background(), "Navidrome")
+	require.NoError(t, err)
+	assert.Len(t, token.Token, 36)
+
+	_, err = CreateToken(context.Background(), "Navidrome")
+	assert.Error(t, err, "one token per client name")
+
+	s := NewServer()
+	validate := func(req *http.Request) map[string]any {
+		w := httptest.NewRecorder()
+		s.ValidateToken(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/1/validate-token", nil)
+	req.Header.Set("Authorization", "Token "+token.Token)
+	resp := validate(req)
+	assert.Equal(t, true, resp["valid"])
+	assert.Equal(t, "Navidrome", resp["user_name"])
+
+	resp = validate(httptest.NewRequest(http.MethodGet, "/1/validate-token?token=bogus", nil))
+	assert.Equal(t, false, resp["valid"])
+
+	w := httptest.NewRecorder()
+	s.ValidateToken(w, httptest.NewRequest(http.MethodGet, "/1/validate-token", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_SubmitListens(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	token, err := CreateToken(ctx, "Navidrome")
+	require.NoError(t, err)
+	s := NewServer()
+
+	w := submit(t, s, "", `{"listen_type":"single","payload":[]}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = submit(t, s, "bogus", `{"listen_type":"single","payload":[]}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	for _, body := range []string{
+		`not json`,
+		`{"listen_type":"unknown","payload":[{}]}`,
+		`{"listen_type":"single","payload":[]}`,
+		`{"listen_type":"single","payload":[{"track_metadata":{"artist_name":"A","track_name":"T"}}]}`,
+		`{"listen_type":"import","payload":[{"listened_at":1614592800,"track_metadata":{"artist_name":"A"}}]}`,
+	} {
+		w = submit(t, s, token.Token, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	// playing_now 不写入播放记录
+	w = submit(t, s, token.Token, `{"listen_type":"playing_now","payload":[{"track_metadata":{"artist_name":"A","track_name":"T","release_name":"R"}}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	body := `{"listen_type":"import","payload":[
+		{"listened_at":1614592800,"track_metadata":{"artist_name":"陳綺貞","track_name":"太陽","release_name":"太陽",
+			"additional_info":{"duration_ms":210000,"tracknumber":"1/10","recording_mbid":"f8f7a1a2-0000-0000-0000-000000000000"}}},
+		{"listened_at":1614600240,"track_metadata":{"artist_name":"Quincy Jones","track_name":"Hikky Burr(feat.Bill Cosby)",
+			"release_name":"Smackwater Jack","additional_info":{"duration":295,"tracknumber":3,"discnumber":1}}},
+		{"listened_at":1614600480,"track_metadata":{"artist_name":"No Album","track_name":"Single"}}
+	]}`
+	w = submit(t, s, token.Token, body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+
+	var records []*model.TrackPlayRecord
+	require.NoError(t, model.GetDB().Order("play_time").Find(&records).Error)
+	require.Len(t, records, 2, "listen without release is skipped")
+	assert.Equal(t, "陈绮贞", records[0].Artist)
+	assert.Equal(t, "太阳", records[0].Track)
+	assert.Equal(t, "Navidrome", records[0].Source)
+	assert.True(t, records[0].Scrobbled)
+	assert.EqualValues(t, 210, records[0].Duration)
+	assert.EqualValues(t, 1, records[0].TrackNumber)
+	assert.Equal(t, "f8f7a1a2-0000-0000-0000-000000000000", records[0].MusicBrainzID)
+	assert.Equal(t, "Hikky Burr (feat. Bill Cosby)", records[1].Track)
+	assert.EqualValues(t, 295, records[1].Duration)
+	assert.EqualValues(t, 3, records[1].TrackNumber)
+
+	got, err := model.GetTrack(ctx, "陈绮贞", "太阳", "太阳")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, 1, got.PlayCount)
+
+	// 重复提交不会重复写入
+	w = submit(t, s, token.Token, body)
+	require.Equal(t, http.StatusOK, w.Code)
+	var count int64
+	require.NoError(t, model.GetDB().Model(&model.TrackPlayRecord{}).Count(&count).Error)
+	assert.EqualValues(t, 2, count)
+
+	used, err := model.GetListenTokenByName(ctx, "Navidrome")
+	require.NoError(t, err)
+	assert.NotNil(t, used.LastUsedAt)
+
+	deleted, err := model.DeleteListenToken(ctx, "Navidrome")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	w = submit(t, s, token.Token, body)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "revoked token")
+}
+
+func TestServer_SubmitListensRewriteRules(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	token, err := CreateToken(ctx, "Navidrome")
+	require.NoError(t, err)
+	require.NoError(
+		t, model.CreateRewriteRule(
+			ctx, &model.RewriteRule{
+				Name: "remaster", Enabled: true, MatchSource: "^Navidrome$",
+				Actions: model.RewriteActions{{Field: model.RewriteFieldTitle, Pattern: `\s*-\s*Remastered \d{4}$`}},
+			},
+		),
+	)
+	rewrite.Invalidate()
+	t.Cleanup(rewrite.Invalidate)
+	s := NewServer()
+
+	// 改写后再去重，重复提交不会写入第二条
+	body := `{"listen_type":"single","payload":[{"listened_at":1614592800,
+		"track_metadata":{"artist_name":"The Beatles","track_name":"Something - Remastered 2009","release_name":"Abbey Road"}}]}`
+	for i := 0; i < 2; i++ {
+		w := submit(t, s, token.Token, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	var records []*model.TrackPlayRecord
+	require.NoError(t, model.GetDB().Find(&records).Error)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Something", records[0].Track)
+	got, err := model.GetTrack(ctx, "The Beatles", "Abbey Road", "Something")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.PlayCount)
+}
@@ -0,0 +1,290 @@
+package favorite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// Policy Last.fm 与本地喜欢状态不一致时的处理方式
+type Policy string
+
+const (
+	PolicyRemoteWins Policy = "remote_wins" // 以 Last.fm 为准
+	PolicyLocalWins  Policy = "local_wins"  // 以本地为准
+	PolicyUnion      Policy = "union"       // 任一侧喜欢即双方都喜欢
+)
+
+// 对账动作
+const (
+	ActionLoveLocal    = "love_local"    // 本地标记 Last.fm 喜欢
+	ActionUnloveLocal  = "unlove_local"  // 清除本地的 Last.fm 喜欢标记，Apple Music 喜欢不受影响
+	ActionLoveRemote   = "love_remote"   // 在 Last.fm 喜欢，并标记本地
+	ActionUnloveRemote = "unlove_remote" // 在 Last.fm 取消喜欢
+)
+
+var ErrUnknownPolicy = errors.New("unknown reconcile policy")
+
+// ParsePolicy 解析冲突处理方式，为空时使用 union
+func ParsePolicy(s string) (Policy, error) {
+	switch policy := Policy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return PolicyUnion, nil
+	case PolicyRemoteWins, PolicyLocalWins, PolicyUnion:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownPolicy, s)
+	}
+}
+
+// Diff 一首曲目在两侧的喜欢状态，按归一后的艺术家与曲目名（不区分大小写）匹配，不区分专辑
+type Diff struct {
+	Artist        string     `json:"artist"`
+	Track         string     `json:"track"`
+	TrackIDs      []int64    `json:"track_ids,omitempty"` // 本地匹配到的曲目
+	LastFmFav     bool       `json:"is_last_fm_fav"`      // 本地 is_last_fm_fav
+	AppleMusicFav bool       `json:"is_apple_music_fav"`  // 本地 is_apple_music_fav
+	LovedAt       *time.Time `json:"loved_at,omitempty"`  // Last.fm 喜欢的时间
+}
+
+// Action 对账时执行（或预演）的一个动作
+type Action struct {
+	Artist string `json:"artist"`
+	Track  string `json:"track"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report 对账结果。本地任一曲目行的 is_last_fm_fav 或 is_apple_music_fav 为真即视为本地喜欢
+type Report struct {
+	Policy      Policy    `json:"policy"`
+	DryRun      bool      `json:"dry_run"`
+	StartedAt   time.Time `json:"started_at"`
+	RemoteLoved int       `json:"remote_loved"` // Last.fm 喜欢的曲目数
+	LocalLoved  int       `json:"local_loved"`  // 本地喜欢的曲目数（按艺术家与曲目名合并）
+	InSync      int       `json:"in_sync"`
+	RemoteOnly  []Diff    `json:"remote_only"` // Last.fm 喜欢，本地有曲目但未喜欢
+	LocalOnly   []Diff    `json:"local_only"`  // 本地喜欢，Last.fm 未喜欢
+	StaleFlags  []Diff    `json:"stale_flags"` // 双方都喜欢，但本地部分曲目行未标记 is_last_fm_fav
+	Unmatched   []Diff    `json:"unmatched"`   // Last.fm 喜欢但本地没有对应曲目，不做处理
+	Actions     []Action  `json:"actions"`
+	Applied     int       `json:"applied"`
+	Failed      int       `json:"failed"`
+}
+
+// Reconciler 以 Last.fm 喜欢列表与本地 track 表的喜欢标记对账
+type Reconciler struct {
+	user       string
+	fetchLoved func(ctx context.Context, user string) ([]lastfm.LovedTrack, error)
+	setRemote  func(ctx context.Context, artist, track string, loved bool) error
+	now        func() time.Time
+}
+
+// NewReconciler user 为 Last.fm 用户名
+func NewReconciler(user string) *Reconciler {
+	return &Reconciler{
+		user:       user,
+		fetchLoved: lastfm.GetAllLovedTracks,
+		setRemote:  lastfm.SetFavorite,
+		now:        time.Now,
+	}
+}
+
+// localGroup 本地同一艺术家与曲目名的曲目行
+type localGroup struct {
+	artist, track string
+	ids           []int64
+	lastFmAll     bool // 所有行都标记了 is_last_fm_fav
+	lastFmAny     bool
+	appleMusicAny bool
+}
+
+func (g *localGroup) loved() bool {
+	return g.lastFmAny || g.appleMusicAny
+}
+
+func (g *localGroup) diff() Diff {
+	return Diff{
+		Artist: g.artist, Track: g.track, TrackIDs: g.ids, LastFmFav: g.lastFmAny, AppleMusicFav: g.appleMusicAny,
+	}
+}
+
+// key 两侧名称按播放器写入本地时相同的规则归一后再比较，例如繁体转简体、统一标点与 feat 写法
+func key(artist, track string) string {
+	artist = common.ConversionSimplifiedFx(common.ArtistCustomFit(strings.TrimSpace(artist)))
+	track = common.ConversionSimplifiedFx(common.UnityFixAll(common.TrackCustomFit(strings.TrimSpace(track))))
+	return strings.ToLower(strings.TrimSpace(artist)) + "\x00" + strings.ToLower(strings.TrimSpace(track))
+}
+
+// Reconcile 计算两侧差异并按 policy 生成动作，dryRun 为 true 时只返回报告不做修改
+func (r *Reconciler) Reconcile(ctx context.Context, policy Policy, dryRun bool) (*Report, error) {
+	policy, err := ParsePolicy(string(policy))
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		Policy: policy, DryRun: dryRun, StartedAt: r.now(),
+		RemoteOnly: []Diff{}, LocalOnly: []Diff{}, StaleFlags: []Diff{}, Unmatched: []Diff{}, Actions: []Action{},
+	}
+
+	loved, err := r.fetchLoved(ctx, r.user)
+	if err != nil {
+		return nil, fmt.Errorf("fetch last.fm loved tracks: %w", err)
+	}
+	tracks, err := model.GetTrackFavoriteStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get local favorite states: %w", err)
+	}
+
+	groups := make(map[string]*localGroup)
+	for _, t := range tracks {
+		k := key(t.Artist, t.Track)
+		g, ok := groups[k]
+		if !ok {
+			g = &localGroup{artist: t.Artist, track: t.Track, lastFmAll: true}
+			groups[k] = g
+		}
+		g.ids = append(g.ids, t.ID)
+		g.lastFmAll = g.lastFmAll && t.IsLastFmFav
+		g.lastFmAny = g.lastFmAny || t.IsLastFmFav
+		g.appleMusicAny = g.appleMusicAny || t.IsAppleMusicFav
+	}
+	for _, g := range groups {
+		if g.loved() {
+			report.LocalLoved++
+		}
+	}
+
+	remote := make(map[string]struct{}, len(loved))
+	for _, l := range loved {
+		k := key(l.Artist, l.Track)
+		if _, ok := remote[k]; ok {
+			continue
+		}
+		remote[k] = struct{}{}
+		g, ok := groups[k]
+		if !ok {
+			diff := Diff{Artist: l.Artist, Track: l.Track}
+			if !l.LovedAt.IsZero() {
+				diff.LovedAt = &l.LovedAt
+			}
+			report.Unmatched = append(report.Unmatched, diff)
+			continue
+		}
+		diff := g.diff()
+		if !l.LovedAt.IsZero() {
+			diff.LovedAt = &l.LovedAt
+		}
+		switch {
+		case !g.loved():
+			report.RemoteOnly = append(report.RemoteOnly, diff)
+		case !g.lastFmAll:
+			report.StaleFlags = append(report.StaleFlags, diff)
+		default:
+			report.InSync++
+		}
+	}
+	report.RemoteLoved = len(remote)
+	for k, g := range groups {
+		if _, ok := remote[k]; !ok && g.loved() {
+			report.LocalOnly = append(report.LocalOnly, g.diff())
+		}
+	}
+	for _, diffs := range [][]Diff{report.RemoteOnly, report.LocalOnly, report.StaleFlags, report.Unmatched} {
+		sortDiffs(diffs)
+	}
+
+	r.plan(report)
+	if !dryRun {
+		r.apply(ctx, report)
+	}
+	return report, nil
+}
+
+// plan 按 policy 为每个差异生成动作
+func (r *Reconciler) plan(report *Report) {
+	add := func(diff Diff, action string) {
+		report.Actions = append(report.Actions, Action{Artist: diff.Artist, Track: diff.Track, Action: action})
+	}
+	for _, diff := range report.RemoteOnly {
+		if report.Policy == PolicyLocalWins {
+			add(diff, ActionUnloveRemote)
+		} else {
+			add(diff, ActionLoveLocal)
+		}
+	}
+	for _, diff := range report.LocalOnly {
+		if report.Policy == PolicyRemoteWins {
+			add(diff, ActionUnloveLocal)
+		} else {
+			add(diff, ActionLoveRemote)
+		}
+	}
+	// 双方都喜欢时只补齐本地标记，与 policy 无关
+	for _, diff := range report.StaleFlags {
+		add(diff, ActionLoveLocal)
+	}
+}
+
+// apply 依次执行动作，单个动作失败不影响其余动作
+func (r *Reconciler) apply(ctx context.Context, report *Report) {
+	ids := make(map[string][]int64)
+	for _, diffs := range [][]Diff{report.RemoteOnly, report.LocalOnly, report.StaleFlags} {
+		for _, diff := range diffs {
+			ids[key(diff.Artist, diff.Track)] = diff.TrackIDs
+		}
+	}
+
+	for i := range report.Actions {
+		action := &report.Actions[i]
+		trackIDs := ids[key(action.Artist, action.Track)]
+		var err error
+		switch action.Action {
+		case ActionLoveLocal:
+			err = model.SetLastFmFavoritesByIDs(ctx, trackIDs, true)
+		case ActionUnloveLocal:
+			err = model.SetLastFmFavoritesByIDs(ctx, trackIDs, false)
+		case ActionLoveRemote:
+			if err = r.setRemote(ctx, action.Artist, action.Track, true); err == nil {
+				err = model.SetLastFmFavoritesByIDs(ctx, trackIDs, true)
+			}
+		case ActionUnloveRemote:
+			err = r.setRemote(ctx, action.Artist, action.Track, false)
+		}
+		if err != nil {
+			log.Warn(
+				ctx, "favorite reconcile action failed", zap.String("action", action.Action),
+				zap.String("artist", action.Artist), zap.String("track", action.Track), zap.Error(err),
+			)
+			action.Error = err.Error()
+			report.Failed++
+			continue
+		}
+		report.Applied++
+	}
+	log.Info(
+		ctx, "favorite reconcile finished", zap.String("policy", string(report.Policy)),
+		zap.Int("applied", report.Applied), zap.Int("failed", report.Failed),
+	)
+}
+
+func sortDiffs(diffs []Diff) {
+	sort.Slice(
+		diffs, func(i, j int) bool {
+			if diffs[i].Artist != diffs[j].Artist {
+				return diffs[i].Artist < diffs[j].Artist
+			}
+			return diffs[i].Track < diffs[j].Track
+		},
+	)
+}
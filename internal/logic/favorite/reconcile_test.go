@@ -0,0 +1,177 @@
+package favorite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+func insertTrack(t *testing.T, artist, album, track string, lastFm, appleMusic bool) int64 {
+	t.Helper()
+	row := &model.Track{
+		Artist: artist, Album: album, Track: track, DiscNumber: 1, IsLastFmFav: lastFm, IsAppleMusicFav: appleMusic,
+	}
+	require.NoError(t, model.GetDB().Create(row).Error)
+	return row.ID
+}
+
+func trackFlags(t *testing.T, id int64) (bool, bool) {
+	t.Helper()
+	var row model.Track
+	require.NoError(t, model.GetDB().First(&row, id).Error)
+	return row.IsLastFmFav, row.IsAppleMusicFav
+}
+
+// fakeRemote Last.fm 喜欢列表，记录 love/unlove 调用
+type fakeRemote struct {
+	loved []lastfm.LovedTrack
+	calls []string
+	err   error
+}
+
+func (f *fakeRemote) reconciler() *Reconciler {
+	r := NewReconciler("tester")
+	r.fetchLoved = func(context.Context, string) ([]lastfm.LovedTrack, error) { return f.loved, nil }
+	r.setRemote = func(_ context.Context, artist, track string, loved bool) error {
+		if f.err != nil {
+			return f.err
+		}
+		f.calls = append(f.calls, fmt.Sprintf("%s/%s/%t", artist, track, loved))
+		return nil
+	}
+	return r
+}
+
+// seed 准备五种情况：一致、仅 Last.fm 喜欢、仅本地喜欢（Apple Music）、本地标记缺失、本地不存在。
+// 其中两首一致的曲目在 Last.fm 上的名称与本地写法不同，需要归一后才能匹配
+func seed(t *testing.T) (*fakeRemote, map[string]int64) {
+	model.SetupTestDB(t)
+	ids := map[string]int64{
+		"synced":     insertTrack(t, "Pink Floyd", "The Dark Side of the Moon", "Time", true, false),
+		"syncedFit":  insertTrack(t, "Pink Floyd", "The Wall", "Another Brick in the Wall, Part 2", true, false),
+		"syncedZh":   insertTrack(t, "万能青年旅店", "冀西南林路行", "杀死那个石家庄人", true, false),
+		"remoteOnly": insertTrack(t, "Pink Floyd", "The Wall", "Comfortably Numb", false, false),
+		"localOnly":  insertTrack(t, "Radiohead", "OK Computer", "Airbag", false, true),
+		"staleA":     insertTrack(t, "Björk", "Homogenic", "Jóga", true, false),
+		"staleB":     insertTrack(t, "Björk", "Greatest Hits", "Jóga", false, false),
+	}
+	lovedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &fakeRemote{
+		loved: []lastfm.LovedTrack{
+			{Artist: "Pink Floyd", Track: "Time", LovedAt: lovedAt},
+			{Artist: "Pink Floyd", Track: "Another Brick In the Wall, Pt. 2"},
+			{Artist: "Omnipotent Youth Society", Track: "殺死那個石家莊人"},
+			{Artist: "pink floyd", Track: "comfortably numb", LovedAt: lovedAt},
+			{Artist: "Björk", Track: "Jóga"},
+			{Artist: "Björk", Track: "Jóga"}, // 重复条目只计一次
+			{Artist: "Boards of Canada", Track: "Roygbiv"},
+		},
+	}, ids
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, PolicyUnion, policy)
+	policy, err = ParsePolicy(" Remote_Wins ")
+	require.NoError(t, err)
+	assert.Equal(t, PolicyRemoteWins, policy)
+	_, err = ParsePolicy("newest")
+	assert.ErrorIs(t, err, ErrUnknownPolicy)
+}
+
+func TestReconcile_DryRun(t *testing.T) {
+	remote, ids := seed(t)
+	report, err := remote.reconciler().Reconcile(context.Background(), "", true)
+	require.NoError(t, err)
+
+	assert.Equal(t, PolicyUnion, report.Policy)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 6, report.RemoteLoved)
+	assert.Equal(t, 5, report.LocalLoved)
+	assert.Equal(t, 3, report.InSync)
+	require.Len(t, report.RemoteOnly, 1)
+	assert.Equal(t, "Comfortably Numb", report.RemoteOnly[0].Track)
+	require.NotNil(t, report.RemoteOnly[0].LovedAt)
+	require.Len(t, report.LocalOnly, 1)
+	assert.True(t, report.LocalOnly[0].AppleMusicFav)
+	require.Len(t, report.StaleFlags, 1)
+	assert.ElementsMatch(t, []int64{ids["staleA"], ids["staleB"]}, report.StaleFlags[0].TrackIDs)
+	require.Len(t, report.Unmatched, 1)
+	assert.Equal(t, "Roygbiv", report.Unmatched[0].Track)
+	assert.Equal(
+		t, []Action{
+			{Artist: "Pink Floyd", Track: "Comfortably Numb", Action: ActionLoveLocal},
+			{Artist: "Radiohead", Track: "Airbag", Action: ActionLoveRemote},
+			{Artist: "Björk", Track: "Jóga", Action: ActionLoveLocal},
+		}, report.Actions,
+	)
+
+	// 预演不做任何修改
+	assert.Empty(t, remote.calls)
+	lastFm, _ := trackFlags(t, ids["remoteOnly"])
+	assert.False(t, lastFm)
+}
+
+func TestReconcile_Policies(t *testing.T) {
+	t.Run(
+		"union", func(t *testing.T) {
+			remote, ids := seed(t)
+			report, err := remote.reconciler().Reconcile(context.Background(), PolicyUnion, false)
+			require.NoError(t, err)
+			assert.Equal(t, 3, report.Applied)
+			assert.Equal(t, []string{"Radiohead/Airbag/true"}, remote.calls)
+			for _, name := range []string{"remoteOnly", "localOnly", "staleB"} {
+				lastFm, _ := trackFlags(t, ids[name])
+				assert.True(t, lastFm, name)
+			}
+		},
+	)
+	t.Run(
+		"remote_wins", func(t *testing.T) {
+			remote, ids := seed(t)
+			_, err := remote.reconciler().Reconcile(context.Background(), PolicyRemoteWins, false)
+			require.NoError(t, err)
+			assert.Empty(t, remote.calls)
+			lastFm, _ := trackFlags(t, ids["remoteOnly"])
+			assert.True(t, lastFm)
+			// 只清除 Last.fm 标记，Apple Music 喜欢保留
+			lastFm, appleMusic := trackFlags(t, ids["localOnly"])
+			assert.False(t, lastFm)
+			assert.True(t, appleMusic)
+		},
+	)
+	t.Run(
+		"local_wins", func(t *testing.T) {
+			remote, ids := seed(t)
+			_, err := remote.reconciler().Reconcile(context.Background(), PolicyLocalWins, false)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"Pink Floyd/Comfortably Numb/false", "Radiohead/Airbag/true"}, remote.calls)
+			lastFm, _ := trackFlags(t, ids["remoteOnly"])
+			assert.False(t, lastFm)
+			lastFm, appleMusic := trackFlags(t, ids["localOnly"])
+			assert.True(t, lastFm)
+			assert.True(t, appleMusic)
+		},
+	)
+}
+
+func TestReconcile_RemoteFailure(t *testing.T) {
+	remote, ids := seed(t)
+	remote.err = errors.New("service offline")
+	report, err := remote.reconciler().Reconcile(context.Background(), PolicyUnion, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Applied)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, "service offline", report.Actions[1].Error)
+	lastFm, _ := trackFlags(t, ids["localOnly"])
+	assert.False(t, lastFm, "local flag is kept when last.fm love fails")
+}
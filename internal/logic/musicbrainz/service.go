@@ -312,6 +312,16 @@ func DeepingMaintenance(ctx context.Context, albumID int64) error {
 							return err
 						}
 
+						// 以 MusicBrainz 的 artist-credit 替换解析出的主艺术家与合作艺术家
+						if credits := trackArtistCredits(mbTrack.Track); len(credits) > 0 {
+							if err := model.ReplaceTrackArtists(
+								tx, trackObj.ID, []string{model.ArtistRolePrimary, model.ArtistRoleFeatured}, credits,
+								model.CreditSourceMusicBrainz,
+							); err != nil {
+								return err
+							}
+						}
+
 						// 更新关联表
 						if err := tx.Model(ta).Updates(
 							map[string]interface{}{
@@ -440,3 +450,18 @@ func DeepingMaintenance(ctx context.Context, albumID int64) error {
 
 	return err
 }
+
+// trackArtistCredits 按 artist-credit 的 joinphrase 区分主艺术家与 feat. 之后的合作艺术家
+func trackArtistCredits(track musicbrainzws2.Track) []model.ArtistCredit {
+	credits := make([]model.ArtistCredit, 0, len(track.ArtistCredit))
+	role := model.ArtistRolePrimary
+	for _, credit := range track.ArtistCredit {
+		credits = append(
+			credits, model.ArtistCredit{Name: credit.Name, Role: role, MusicBrainzID: string(credit.Artist.ID)},
+		)
+		if common.IsFeatJoinPhrase(credit.JoinPhrase) {
+			role = model.ArtistRoleFeatured
+		}
+	}
+	return credits
+}
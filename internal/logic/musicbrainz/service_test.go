@@ -2,7 +2,14 @@ package musicbrainz
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uploadedlobster.com/musicbrainzws2"
+
+	"github.com/vincentchyu/sonic-lens/internal/model"
 )
 
 func TestInitializeAlbums(t *testing.T) {
@@ -20,3 +27,29 @@ func TestInitializeAlbums(t *testing.T) {
 	}
 	t.Log("Successfully initialized albums")
 }
+
+func TestTrackArtistCredits(t *testing.T) {
+	var track musicbrainzws2.Track
+	require.NoError(
+		t, json.Unmarshal(
+			[]byte(`{
+				"title": "September",
+				"artist-credit": [
+					{"name": "Earth, Wind & Fire", "joinphrase": " & ", "artist": {"id": "mbid-ewf"}},
+					{"name": "The Emotions", "joinphrase": " feat. ", "artist": {"id": "mbid-emotions"}},
+					{"name": "Simon & Garfunkel", "joinphrase": "", "artist": {"id": "mbid-sg"}}
+				]
+			}`), &track,
+		),
+	)
+
+	// 按 artist-credit 的条目取名字，名字本身带 , & 的乐队保持完整，feat. 之后为合作艺术家
+	assert.Equal(
+		t, []model.ArtistCredit{
+			{Name: "Earth, Wind & Fire", Role: model.ArtistRolePrimary, MusicBrainzID: "mbid-ewf"},
+			{Name: "The Emotions", Role: model.ArtistRolePrimary, MusicBrainzID: "mbid-emotions"},
+			{Name: "Simon & Garfunkel", Role: model.ArtistRoleFeatured, MusicBrainzID: "mbid-sg"},
+		}, trackArtistCredits(track),
+	)
+	assert.Empty(t, trackArtistCredits(musicbrainzws2.Track{}))
+}
@@ -0,0 +1,146 @@
+package rewrite
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+var errLimitReached = errors.New("limit reached")
+
+// Change 一首历史曲目的改写
+type Change struct {
+	TrackID   int64   `json:"track_id"`
+	Before    Fields  `json:"before"`
+	After     Fields  `json:"after"`
+	RuleIDs   []int64 `json:"rule_ids"`
+	MergeInto int64   `json:"merge_into,omitempty"` // 改写后与该曲目重名，将合并到该曲目
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report 历史曲目改写结果
+type Report struct {
+	DryRun    bool      `json:"dry_run"`
+	StartedAt time.Time `json:"started_at"`
+	Scanned   int       `json:"scanned"`
+	Changes   []Change  `json:"changes"`
+	Applied   int       `json:"applied"`
+	Merged    int       `json:"merged"`
+	Failed    int       `json:"failed"`
+}
+
+// ApplyHistory 把规则应用到 track 表中的已有曲目。ruleID 为 0 时使用全部启用的规则，
+// 否则只使用该规则（未启用也可预览）；limit 大于 0 时最多处理 limit 首；dryRun 时只返回差异
+func ApplyHistory(ctx context.Context, ruleID int64, limit int, dryRun bool) (*Report, error) {
+	var rules []*model.RewriteRule
+	if ruleID > 0 {
+		rule, err := model.GetRewriteRule(ctx, ruleID)
+		if err != nil {
+			return nil, err
+		}
+		rule.Enabled = true
+		rules = append(rules, rule)
+	} else {
+		var err error
+		if rules, err = model.ListRewriteRules(ctx, true); err != nil {
+			return nil, err
+		}
+	}
+	engine, err := Compile(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: dryRun, StartedAt: time.Now(), Changes: []Change{}}
+	if len(engine.rules) == 0 {
+		return report, nil
+	}
+	if err := engine.scan(ctx, report, limit); err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		apply(ctx, report)
+	}
+	return report, nil
+}
+
+// scan 逐批读取曲目并计算改写结果
+func (e *Engine) scan(ctx context.Context, report *Report, limit int) error {
+	var tracks []*model.Track
+	usesSource := e.usesSource()
+	err := model.GetDB().WithContext(ctx).
+		Select("id", "artist", "album", "track", "album_artist", "genre", "composer", "track_number", "disc_number").
+		FindInBatches(
+			&tracks, 500, func(_ *gorm.DB, _ int) error {
+				for _, track := range tracks {
+					report.Scanned++
+					before := Fields{
+						Artist: track.Artist, Album: track.Album, Title: track.Track,
+						AlbumArtist: track.AlbumArtist, Genre: track.Genre, Composer: track.Composer,
+					}
+					var sources []string
+					if usesSource {
+						if err := model.GetDB().WithContext(ctx).Model(&model.TrackPlayRecord{}).
+							Where("artist = ? AND album = ? AND track = ?", track.Artist, track.Album, track.Track).
+							Distinct().Pluck("source", &sources).Error; err != nil {
+							return err
+						}
+					}
+					after, matched := e.applyAnySource(before, sources)
+					if after == before || after.Artist == "" || after.Album == "" || after.Title == "" {
+						continue
+					}
+					change := Change{TrackID: track.ID, Before: before, After: after, RuleIDs: matched}
+					mergeInto, err := model.RewriteTarget(ctx, track, trackRewrite(after))
+					if err != nil {
+						return err
+					}
+					change.MergeInto = mergeInto
+					report.Changes = append(report.Changes, change)
+					if limit > 0 && len(report.Changes) >= limit {
+						return errLimitReached
+					}
+				}
+				return nil
+			},
+		).Error
+	if errors.Is(err, errLimitReached) {
+		return nil
+	}
+	return err
+}
+
+// apply 依次改写曲目，单首失败不影响其余曲目
+func apply(ctx context.Context, report *Report) {
+	for i := range report.Changes {
+		change := &report.Changes[i]
+		mergedInto, err := model.RewriteTrack(ctx, change.TrackID, trackRewrite(change.After))
+		if err != nil {
+			log.Warn(ctx, "rewrite track failed", zap.Int64("trackID", change.TrackID), zap.Error(err))
+			change.Error = err.Error()
+			report.Failed++
+			continue
+		}
+		change.MergeInto = mergedInto
+		if mergedInto > 0 {
+			report.Merged++
+		}
+		report.Applied++
+	}
+	log.Info(
+		ctx, "rewrite history finished", zap.Int("applied", report.Applied),
+		zap.Int("merged", report.Merged), zap.Int("failed", report.Failed),
+	)
+}
+
+func trackRewrite(f Fields) model.TrackRewrite {
+	return model.TrackRewrite{
+		Artist: f.Artist, Album: f.Album, Track: f.Title, AlbumArtist: f.AlbumArtist, Genre: f.Genre, Composer: f.Composer,
+	}
+}
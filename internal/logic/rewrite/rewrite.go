@@ -0,0 +1,231 @@
+package rewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+var ErrInvalidRule = errors.New("invalid rewrite rule")
+
+// Fields 参与匹配与改写的元数据，Source 只用于匹配
+type Fields struct {
+	Source      string `json:"source,omitempty"`
+	Artist      string `json:"artist"`
+	Album       string `json:"album"`
+	Title       string `json:"title"`
+	AlbumArtist string `json:"album_artist"`
+	Genre       string `json:"genre"`
+	Composer    string `json:"composer"`
+}
+
+func (f *Fields) field(name string) *string {
+	switch name {
+	case model.RewriteFieldArtist:
+		return &f.Artist
+	case model.RewriteFieldAlbum:
+		return &f.Album
+	case model.RewriteFieldTitle:
+		return &f.Title
+	case model.RewriteFieldAlbumArtist:
+		return &f.AlbumArtist
+	case model.RewriteFieldGenre:
+		return &f.Genre
+	case model.RewriteFieldComposer:
+		return &f.Composer
+	}
+	return nil
+}
+
+type action struct {
+	field   string
+	pattern *regexp.Regexp // nil 时整个字段替换
+	replace string
+}
+
+type compiledRule struct {
+	id                           int64
+	source, artist, album, title *regexp.Regexp // nil 表示不限
+	actions                      []action
+}
+
+// Engine 编译后的规则集，按 priority、id 顺序执行
+type Engine struct {
+	rules []*compiledRule
+}
+
+// Compile 编译启用的规则，任一规则无效时返回 ErrInvalidRule
+func Compile(rules []*model.RewriteRule) (*Engine, error) {
+	engine := &Engine{}
+	for _, rule := range rules {
+		compiled, err := compile(rule)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Enabled {
+			engine.rules = append(engine.rules, compiled)
+		}
+	}
+	return engine, nil
+}
+
+// Validate 检查规则的正则与字段是否有效
+func Validate(rule *model.RewriteRule) error {
+	_, err := compile(rule)
+	return err
+}
+
+func compile(rule *model.RewriteRule) (*compiledRule, error) {
+	if strings.TrimSpace(rule.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if len(rule.Actions) == 0 {
+		return nil, fmt.Errorf("%w: at least one action is required", ErrInvalidRule)
+	}
+	compiled := &compiledRule{id: rule.ID}
+	for _, match := range []struct {
+		name, expr string
+		re         **regexp.Regexp
+	}{
+		{"match_source", rule.MatchSource, &compiled.source},
+		{"match_artist", rule.MatchArtist, &compiled.artist},
+		{"match_album", rule.MatchAlbum, &compiled.album},
+		{"match_title", rule.MatchTitle, &compiled.title},
+	} {
+		if match.expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(match.expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRule, match.name, err)
+		}
+		*match.re = re
+	}
+	for _, a := range rule.Actions {
+		if (&Fields{}).field(a.Field) == nil {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidRule, a.Field)
+		}
+		act := action{field: a.Field, replace: a.Replace}
+		if a.Pattern != "" {
+			re, err := regexp.Compile(a.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: pattern for %s: %v", ErrInvalidRule, a.Field, err)
+			}
+			act.pattern = re
+		}
+		compiled.actions = append(compiled.actions, act)
+	}
+	return compiled, nil
+}
+
+// Apply 依次执行规则，返回改写后的字段与命中的规则
+func (e *Engine) Apply(f Fields) (Fields, []int64) {
+	return e.apply(f, func(re *regexp.Regexp) bool { return re.MatchString(f.Source) })
+}
+
+// applyAnySource 来源条件命中 sources 中任意一个即视为命中，用于历史曲目
+func (e *Engine) applyAnySource(f Fields, sources []string) (Fields, []int64) {
+	return e.apply(
+		f, func(re *regexp.Regexp) bool {
+			for _, source := range sources {
+				if re.MatchString(source) {
+					return true
+				}
+			}
+			return false
+		},
+	)
+}
+
+func (e *Engine) apply(f Fields, matchSource func(*regexp.Regexp) bool) (Fields, []int64) {
+	var matched []int64
+	if e == nil {
+		return f, matched
+	}
+	for _, rule := range e.rules {
+		if (rule.source != nil && !matchSource(rule.source)) ||
+			(rule.artist != nil && !rule.artist.MatchString(f.Artist)) ||
+			(rule.album != nil && !rule.album.MatchString(f.Album)) ||
+			(rule.title != nil && !rule.title.MatchString(f.Title)) {
+			continue
+		}
+		for _, a := range rule.actions {
+			value := f.field(a.field)
+			if a.pattern == nil {
+				*value = a.replace
+			} else {
+				*value = a.pattern.ReplaceAllString(*value, a.replace)
+			}
+			*value = strings.TrimSpace(*value)
+		}
+		matched = append(matched, rule.id)
+	}
+	return f, matched
+}
+
+// usesSource 是否有规则按来源匹配
+func (e *Engine) usesSource() bool {
+	for _, rule := range e.rules {
+		if rule.source != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// loaded 一次加载的结果，加载或编译失败时 engine 为 nil
+type loaded struct {
+	engine *Engine
+}
+
+var current atomic.Pointer[loaded]
+
+// Current 返回数据库中启用规则编译后的引擎，规则变更后调用 Invalidate 重新加载；
+// 加载或编译失败时不改写，失败结果同样缓存到 Invalidate 为止，避免每次上报都重新查询
+func Current(ctx context.Context) *Engine {
+	if cached := current.Load(); cached != nil {
+		return cached.engine
+	}
+	if model.GetDB() == nil {
+		return nil
+	}
+	rules, err := model.ListRewriteRules(ctx, true)
+	if err != nil {
+		log.Warn(ctx, "load rewrite rules failed", zap.Error(err))
+		current.Store(&loaded{})
+		return nil
+	}
+	engine, err := Compile(rules)
+	if err != nil {
+		log.Warn(ctx, "compile rewrite rules failed", zap.Error(err))
+		engine = nil
+	}
+	current.Store(&loaded{engine: engine})
+	return engine
+}
+
+// Invalidate 规则增删改后清除缓存
+func Invalidate() {
+	current.Store(nil)
+}
+
+// Rewrite 按当前启用的规则改写一条元数据，没有规则命中时原样返回。
+// 实时播放、外部上报接口与历史导入都在去重、转发与写库前调用，保证同一首曲目落到同一行
+func Rewrite(ctx context.Context, f Fields) (Fields, []int64) {
+	after, matched := Current(ctx).Apply(f)
+	if after != f {
+		log.Debug(
+			ctx, "metadata rewritten", zap.String("source", f.Source), zap.Int64s("rules", matched),
+			zap.Any("before", f), zap.Any("after", after),
+		)
+	}
+	return after, matched
+}
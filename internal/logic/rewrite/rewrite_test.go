@@ -0,0 +1,177 @@
+package rewrite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+func TestEngineApply(t *testing.T) {
+	engine, err := Compile(
+		[]*model.RewriteRule{
+			{
+				ID: 1, Name: "beatles", Enabled: true, MatchArtist: "(?i)^beatles$",
+				Actions: model.RewriteActions{{Field: model.RewriteFieldArtist, Replace: "The Beatles"}},
+			},
+			{
+				ID: 2, Name: "remaster", Enabled: true, MatchSource: "^Roon$",
+				Actions: model.RewriteActions{
+					{Field: model.RewriteFieldTitle, Pattern: `\s*-\s*Remastered \d{4}$`},
+					{Field: model.RewriteFieldAlbum, Pattern: `\s*\(Remastered\)`},
+				},
+			},
+			{
+				ID: 3, Name: "genre", Enabled: true, MatchArtist: "^The Beatles$",
+				Actions: model.RewriteActions{{Field: model.RewriteFieldGenre, Replace: "Rock"}},
+			},
+			{
+				ID: 4, Name: "disabled", MatchArtist: ".*",
+				Actions: model.RewriteActions{{Field: model.RewriteFieldArtist, Replace: "Nobody"}},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	before := Fields{Source: "Roon", Artist: "beatles", Album: "Abbey Road (Remastered)", Title: "Something - Remastered 2009"}
+	after, matched := engine.Apply(before)
+	// 规则 3 看到的是规则 1 改写后的艺术家
+	assert.Equal(t, []int64{1, 2, 3}, matched)
+	assert.Equal(t, "The Beatles", after.Artist)
+	assert.Equal(t, "Abbey Road", after.Album)
+	assert.Equal(t, "Something", after.Title)
+	assert.Equal(t, "Rock", after.Genre)
+
+	before.Source = "Apple Music"
+	after, matched = engine.Apply(before)
+	assert.Equal(t, []int64{1, 3}, matched)
+	assert.Equal(t, "Something - Remastered 2009", after.Title)
+
+	after, matched = engine.Apply(Fields{Artist: "Queen", Title: "Bohemian Rhapsody"})
+	assert.Empty(t, matched)
+	assert.Equal(t, Fields{Artist: "Queen", Title: "Bohemian Rhapsody"}, after)
+}
+
+func TestValidate(t *testing.T) {
+	valid := model.RewriteActions{{Field: model.RewriteFieldArtist, Replace: "A"}}
+	testCases := []*model.RewriteRule{
+		{Actions: valid},
+		{Name: "no actions"},
+		{Name: "bad match", MatchTitle: "(", Actions: valid},
+		{Name: "bad field", Actions: model.RewriteActions{{Field: "year", Replace: "2000"}}},
+		{Name: "bad pattern", Actions: model.RewriteActions{{Field: model.RewriteFieldAlbum, Pattern: "["}}},
+	}
+	for _, rule := range testCases {
+		assert.True(t, errors.Is(Validate(rule), ErrInvalidRule), rule.Name)
+	}
+	assert.NoError(t, Validate(&model.RewriteRule{Name: "ok", MatchSource: "^Roon$", Actions: valid}))
+}
+
+// setupTestDB 使用内存 SQLite 初始化数据库
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	model.SetupTestDB(t)
+	Invalidate()
+	t.Cleanup(Invalidate)
+}
+
+func insertTrack(t *testing.T, artist, album, track, source string) int64 {
+	t.Helper()
+	row := &model.Track{Artist: artist, Album: album, Track: track, DiscNumber: 1, PlayCount: 1}
+	require.NoError(t, model.GetDB().Create(row).Error)
+	require.NoError(
+		t, model.GetDB().Create(
+			&model.TrackPlayRecord{Artist: artist, Album: album, Track: track, Source: source},
+		).Error,
+	)
+	return row.ID
+}
+
+func TestApplyHistory(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+
+	wrongID := insertTrack(t, "beatles", "Abbey Road", "Something", "Roon")
+	rightID := insertTrack(t, "The Beatles", "Abbey Road", "Something", "Apple Music")
+	otherID := insertTrack(t, "beatles", "Help!", "Yesterday", "Apple Music")
+	insertTrack(t, "Queen", "A Night at the Opera", "Love of My Life", "Roon")
+
+	rule := &model.RewriteRule{
+		Name: "beatles", Enabled: true, MatchArtist: "^beatles$",
+		Actions: model.RewriteActions{{Field: model.RewriteFieldArtist, Replace: "The Beatles"}},
+	}
+	require.NoError(t, model.CreateRewriteRule(ctx, rule))
+	assert.Len(t, Current(ctx).rules, 1)
+
+	report, err := ApplyHistory(ctx, 0, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Scanned)
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, wrongID, report.Changes[0].TrackID)
+	assert.Equal(t, rightID, report.Changes[0].MergeInto)
+	assert.Equal(t, []int64{rule.ID}, report.Changes[0].RuleIDs)
+	assert.Equal(t, otherID, report.Changes[1].TrackID)
+	assert.Zero(t, report.Changes[1].MergeInto)
+
+	// 预览不修改数据
+	var count int64
+	require.NoError(t, model.GetDB().Model(&model.Track{}).Where("artist = ?", "beatles").Count(&count).Error)
+	assert.EqualValues(t, 2, count)
+
+	report, err = ApplyHistory(ctx, 0, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Applied)
+	assert.Equal(t, 1, report.Merged)
+	assert.Zero(t, report.Failed)
+
+	var right model.Track
+	require.NoError(t, model.GetDB().First(&right, rightID).Error)
+	assert.Equal(t, 2, right.PlayCount)
+	var other model.Track
+	require.NoError(t, model.GetDB().First(&other, otherID).Error)
+	assert.Equal(t, "The Beatles", other.Artist)
+	require.NoError(t, model.GetDB().Model(&model.TrackPlayRecord{}).Where("artist = ?", "beatles").Count(&count).Error)
+	assert.Zero(t, count)
+
+	// 按来源匹配时使用播放记录的来源
+	sourceRule := &model.RewriteRule{
+		Name: "queen", MatchSource: "^Roon$", MatchArtist: "^Queen$",
+		Actions: model.RewriteActions{{Field: model.RewriteFieldGenre, Replace: "Rock"}},
+	}
+	require.NoError(t, model.CreateRewriteRule(ctx, sourceRule))
+	report, err = ApplyHistory(ctx, sourceRule.ID, 0, true)
+	require.NoError(t, err)
+	require.Len(t, report.Changes, 1)
+	assert.Equal(t, "Rock", report.Changes[0].After.Genre)
+
+	_, err = ApplyHistory(ctx, sourceRule.ID+1, 0, true)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestCurrent_CachesFailure(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+
+	// 绕过校验写入无效规则，加载失败后不改写，也不会每次重新查询
+	rule := &model.RewriteRule{
+		Name: "broken", Enabled: true, MatchTitle: "(",
+		Actions: model.RewriteActions{{Field: model.RewriteFieldTitle, Replace: "fixed"}},
+	}
+	require.NoError(t, model.GetDB().Create(rule).Error)
+	assert.Nil(t, Current(ctx))
+	require.NoError(t, model.GetDB().Model(rule).Update("match_title", "^broken$").Error)
+	assert.Nil(t, Current(ctx))
+	after, _ := Rewrite(ctx, Fields{Title: "broken"})
+	assert.Equal(t, "broken", after.Title)
+
+	Invalidate()
+	require.NotNil(t, Current(ctx))
+	after, matched := Rewrite(ctx, Fields{Title: "broken"})
+	assert.Equal(t, "fixed", after.Title)
+	assert.Equal(t, []int64{rule.ID}, matched)
+}
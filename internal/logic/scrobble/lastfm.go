@@ -0,0 +1,94 @@
+package scrobble
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// LastfmTarget 基于 core/lastfm 的上报目标
+type LastfmTarget struct{}
+
+// NewLastfmTarget 创建 Last.fm 上报目标，需先调用 lastfm.InitLastfmApi 完成登录
+func NewLastfmTarget() *LastfmTarget {
+	return &LastfmTarget{}
+}
+
+func (t *LastfmTarget) Name() string {
+	return model.OutboxTargetLastfm
+}
+
+func (t *LastfmTarget) BatchSize() int {
+	return lastfm.ScrobbleBatchSize
+}
+
+func (t *LastfmTarget) UpdateNowPlaying(ctx context.Context, s *Scrobble) error {
+	return lastfm.TrackUpdateNowPlaying(
+		ctx, &lastfm.TrackUpdateNowPlayingReq{
+			Artist:      s.Artist,
+			AlbumArtist: s.AlbumArtist,
+			Track:       s.Track,
+			Album:       s.Album,
+			Duration:    s.Duration,
+		},
+	)
+}
+
+func (t *LastfmTarget) Scrobble(ctx context.Context, s *Scrobble) error {
+	_, err := lastfm.PushTrackScrobble(ctx, toLastfmReq(s))
+	return err
+}
+
+func (t *LastfmTarget) ScrobbleBatch(ctx context.Context, items []*Scrobble) ([]ItemResult, error) {
+	reqs := make([]*lastfm.PushTrackScrobbleReq, 0, len(items))
+	for _, s := range items {
+		reqs = append(reqs, toLastfmReq(s))
+	}
+	result, err := lastfm.ScrobbleBatch(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ItemResult, len(result.Items))
+	for i, item := range result.Items {
+		results[i] = ItemResult{
+			Accepted:  item.Accepted,
+			Permanent: item.IsPermanentIgnored(),
+			Code:      item.IgnoredCode,
+			Message:   item.IgnoredMessage,
+		}
+	}
+	return results, nil
+}
+
+func (t *LastfmTarget) ClassifyError(err error) ErrorClass {
+	switch {
+	case lastfm.IsAuthError(err):
+		return ErrorTarget
+	case lastfm.IsTransientError(err):
+		return ErrorTransient
+	}
+	return ErrorPermanent
+}
+
+func (t *LastfmTarget) ErrorCode(err error) int {
+	var apiErr *lastfm.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+func toLastfmReq(s *Scrobble) *lastfm.PushTrackScrobbleReq {
+	return &lastfm.PushTrackScrobbleReq{
+		Artist:             s.Artist,
+		AlbumArtist:        s.AlbumArtist,
+		Track:              s.Track,
+		Album:              s.Album,
+		Duration:           s.Duration,
+		Timestamp:          s.Timestamp,
+		MusicBrainzTrackID: s.MusicBrainzID,
+		TrackNumber:        s.TrackNumber,
+	}
+}
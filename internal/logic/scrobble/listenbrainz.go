@@ -0,0 +1,89 @@
+package scrobble
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vincentchyu/sonic-lens/core/listenbrainz"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// listenBrainzBatchSize 补传时每批条数，远小于接口上限以控制单次请求体积
+const listenBrainzBatchSize = 100
+
+// ListenBrainzTarget 基于 submit-listens 的上报目标
+type ListenBrainzTarget struct {
+	client *listenbrainz.Client
+}
+
+// NewListenBrainzTarget 创建 ListenBrainz 上报目标
+func NewListenBrainzTarget(client *listenbrainz.Client) *ListenBrainzTarget {
+	return &ListenBrainzTarget{client: client}
+}
+
+func (t *ListenBrainzTarget) Name() string {
+	return model.OutboxTargetListenBrainz
+}
+
+func (t *ListenBrainzTarget) BatchSize() int {
+	return listenBrainzBatchSize
+}
+
+func (t *ListenBrainzTarget) UpdateNowPlaying(ctx context.Context, s *Scrobble) error {
+	return t.client.SubmitListens(ctx, listenbrainz.ListenTypePlayingNow, toListen(s))
+}
+
+func (t *ListenBrainzTarget) Scrobble(ctx context.Context, s *Scrobble) error {
+	return t.client.SubmitListens(ctx, listenbrainz.ListenTypeSingle, toListen(s))
+}
+
+// ScrobbleBatch 以 import 类型提交，接口对整批只返回一个结果
+func (t *ListenBrainzTarget) ScrobbleBatch(ctx context.Context, items []*Scrobble) ([]ItemResult, error) {
+	listens := make([]listenbrainz.Listen, 0, len(items))
+	for _, s := range items {
+		listens = append(listens, toListen(s))
+	}
+	if err := t.client.SubmitListens(ctx, listenbrainz.ListenTypeImport, listens...); err != nil {
+		return nil, err
+	}
+	results := make([]ItemResult, len(items))
+	for i := range results {
+		results[i].Accepted = true
+	}
+	return results, nil
+}
+
+func (t *ListenBrainzTarget) ClassifyError(err error) ErrorClass {
+	switch {
+	case listenbrainz.IsAuthError(err):
+		return ErrorTarget
+	case listenbrainz.IsTransientError(err):
+		return ErrorTransient
+	}
+	return ErrorPermanent
+}
+
+func (t *ListenBrainzTarget) ErrorCode(err error) int {
+	var apiErr *listenbrainz.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+func toListen(s *Scrobble) listenbrainz.Listen {
+	return listenbrainz.Listen{
+		ListenedAt: s.Timestamp,
+		TrackMetadata: listenbrainz.TrackMetadata{
+			ArtistName:  s.Artist,
+			TrackName:   s.Track,
+			ReleaseName: s.Album,
+			AdditionalInfo: listenbrainz.AdditionalInfo{
+				DurationMs:    s.Duration * 1000,
+				TrackNumber:   s.TrackNumber,
+				RecordingMBID: s.MusicBrainzID,
+				MediaPlayer:   s.Source,
+			},
+		},
+	}
+}
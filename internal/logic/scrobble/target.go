@@ -0,0 +1,83 @@
+package scrobble
+
+import (
+	"context"
+
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/listenbrainz"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// Scrobble 一次待上报的收听
+type Scrobble struct {
+	Artist        string
+	AlbumArtist   string
+	Track         string
+	Album         string
+	Duration      int64 // 秒
+	Timestamp     int64 // 开始播放的 UNIX 时间戳(UTC)
+	TrackNumber   int64
+	MusicBrainzID string
+	Source        string // 播放器
+}
+
+// ItemResult 批量提交中单条记录的结果，顺序与请求一致
+type ItemResult struct {
+	Accepted  bool
+	Permanent bool // 被目标拒绝且重试无意义
+	Code      int
+	Message   string
+}
+
+// ErrorClass 整批提交失败时的处理方式
+type ErrorClass int
+
+const (
+	ErrorTransient ErrorClass = iota // 服务不可用、限流、网络错误等，整批稍后重试
+	ErrorTarget                      // 鉴权或配置问题，与具体记录无关，整个目标暂停且条目保持待投递
+	ErrorPermanent                   // 请求内容被拒绝，可能只由个别记录引起
+)
+
+// Target 上报目标（Last.fm、ListenBrainz 等）
+type Target interface {
+	// Name 目标名称，同时作为 scrobble_outbox.target 的取值
+	Name() string
+	// BatchSize 单次 ScrobbleBatch 最多提交的条数
+	BatchSize() int
+	// UpdateNowPlaying 上报正在播放
+	UpdateNowPlaying(ctx context.Context, s *Scrobble) error
+	// Scrobble 实时上报一条听歌完成
+	Scrobble(ctx context.Context, s *Scrobble) error
+	// ScrobbleBatch 补传多条记录，返回的 err 表示整批失败
+	ScrobbleBatch(ctx context.Context, items []*Scrobble) ([]ItemResult, error)
+	// ClassifyError 判断整批失败的错误应如何处理
+	ClassifyError(err error) ErrorClass
+	// ErrorCode 从错误中提取目标侧的错误码，无法识别时返回 0
+	ErrorCode(err error) int
+}
+
+// NewTargets 按配置创建启用的上报目标，Last.fm 始终启用，ListenBrainz 在配置 token 后启用
+func NewTargets(cfg *config.Config) []Target {
+	targets := []Target{NewLastfmTarget()}
+	if cfg.ListenBrainz.Token != "" {
+		targets = append(
+			targets, NewListenBrainzTarget(listenbrainz.NewClient(cfg.ListenBrainz.BaseURL, cfg.ListenBrainz.Token)),
+		)
+	}
+	return targets
+}
+
+// FromRecord 由播放记录构建上报内容
+func FromRecord(record *model.TrackPlayRecord) *Scrobble {
+	return &Scrobble{
+		Artist:        record.Artist,
+		AlbumArtist:   record.AlbumArtist,
+		Track:         record.Track,
+		Album:         record.Album,
+		Duration:      record.Duration,
+		Timestamp:     record.PlayTime.Unix(),
+		TrackNumber:   int64(record.TrackNumber),
+		MusicBrainzID: record.MusicBrainzID,
+		Source:        record.Source,
+	}
+}
@@ -62,13 +62,13 @@ type TrackService interface {
 	GetTopAlbumsByPlayCount(ctx context.Context, days int, limit int) ([]*model.TopAlbum, error)
 	// Genre related methods
 	// GetAlbums 获取专辑列表（分页）
-	GetAlbums(ctx context.Context, limit, offset int, keyword string) ([]*model.Album, error)
+	GetAlbums(ctx context.Context, limit, offset int, filter model.ListFilter) ([]*model.Album, error)
 	// GetAlbumsCount 获取专辑总数
-	GetAlbumsCount(ctx context.Context, keyword string) (int64, error)
+	GetAlbumsCount(ctx context.Context, filter model.ListFilter) (int64, error)
 	// GetTracksOrderedByAlbum 按专辑排序获取曲目列表（分页）
-	GetTracksOrderedByAlbum(ctx context.Context, limit, offset int, keyword string) ([]*model.Track, error)
+	GetTracksOrderedByAlbum(ctx context.Context, limit, offset int, filter model.ListFilter) ([]*model.Track, error)
 	// GetTracksOrderedByAlbumCount 获取按专辑排序的曲目总数
-	GetTracksOrderedByAlbumCount(ctx context.Context, keyword string) (int64, error)
+	GetTracksOrderedByAlbumCount(ctx context.Context, filter model.ListFilter) (int64, error)
 }
 
 // TrackServiceImpl 实现TrackService接口
@@ -322,21 +322,21 @@ func (s *TrackServiceImpl) GetTopGenresByPlayCount(ctx context.Context, limit in
 }
 
 // GetAlbums 获取专辑列表
-func (s *TrackServiceImpl) GetAlbums(ctx context.Context, limit, offset int, keyword string) ([]*model.Album, error) {
-	return model.GetAlbums(ctx, limit, offset, keyword)
+func (s *TrackServiceImpl) GetAlbums(ctx context.Context, limit, offset int, filter model.ListFilter) ([]*model.Album, error) {
+	return model.GetAlbums(ctx, limit, offset, filter)
 }
 
 // GetAlbumsCount 获取专辑总数
-func (s *TrackServiceImpl) GetAlbumsCount(ctx context.Context, keyword string) (int64, error) {
-	return model.GetAlbumsCount(ctx, keyword)
+func (s *TrackServiceImpl) GetAlbumsCount(ctx context.Context, filter model.ListFilter) (int64, error) {
+	return model.GetAlbumsCount(ctx, filter)
 }
 
 // GetTracksOrderedByAlbum 获取曲目列表
-func (s *TrackServiceImpl) GetTracksOrderedByAlbum(ctx context.Context, limit, offset int, keyword string) ([]*model.Track, error) {
-	return model.GetTracksOrderedByAlbum(ctx, limit, offset, keyword)
+func (s *TrackServiceImpl) GetTracksOrderedByAlbum(ctx context.Context, limit, offset int, filter model.ListFilter) ([]*model.Track, error) {
+	return model.GetTracksOrderedByAlbum(ctx, limit, offset, filter)
 }
 
 // GetTracksOrderedByAlbumCount 获取曲目总数
-func (s *TrackServiceImpl) GetTracksOrderedByAlbumCount(ctx context.Context, keyword string) (int64, error) {
-	return model.GetTracksOrderedByAlbumCount(ctx, keyword)
+func (s *TrackServiceImpl) GetTracksOrderedByAlbumCount(ctx context.Context, filter model.ListFilter) (int64, error) {
+	return model.GetTracksOrderedByAlbumCount(ctx, filter)
 }
@@ -3,6 +3,8 @@ package model
 import (
 	"context"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Album represents a music album
@@ -19,8 +21,10 @@ type Album struct {
 	TotalDiscs  int       `gorm:"column:total_discs;type:int;default:1" json:"total_discs"` // 总碟数
 	DiscInfos   string    `gorm:"column:disc_infos;type:varchar(255)" json:"disc_infos"`    // 各碟信息(如 track counts)
 	SyncStatus  int       `gorm:"column:sync_status;type:tinyint;default:0" json:"sync_status"` // 0:默认, 1:初选搜索完成, 2:初选关联完成, 3:精选维护完成
+	Rating      int8      `gorm:"column:rating;type:tinyint;not null;default:0" json:"rating"`   // 本地评分 1-5，0 表示未评分
 	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+	Tags        []string  `gorm:"-" json:"tags,omitempty"` // 本地标签，由 AttachAlbumTags 填充
 }
 
 // TableName sets the table name for the Album model
@@ -87,25 +91,23 @@ func GetAlbumWithTracks(ctx context.Context, albumID int64) (*AlbumDetail, error
 		ReleaseMB:   mbLink,
 	}, nil
 }
-// GetAlbums retrieves albums with pagination and optional keyword search
-func GetAlbums(ctx context.Context, limit, offset int, keyword string) ([]*Album, error) {
+// GetAlbums retrieves albums with pagination, keyword search and tag/rating filters
+func GetAlbums(ctx context.Context, limit, offset int, filter ListFilter) ([]*Album, error) {
 	var albums []*Album
-	db := GetDB().WithContext(ctx)
-	if keyword != "" {
-		kw := "%" + keyword + "%"
-		db = db.Where("name LIKE ? OR artist LIKE ?", kw, kw)
-	}
-	err := db.Order("name ASC").Limit(limit).Offset(offset).Find(&albums).Error
+	err := filterAlbums(GetDB().WithContext(ctx), filter).Order("name ASC").Limit(limit).Offset(offset).Find(&albums).Error
 	return albums, err
 }
 
-func GetAlbumsCount(ctx context.Context, keyword string) (int64, error) {
+func GetAlbumsCount(ctx context.Context, filter ListFilter) (int64, error) {
 	var count int64
-	db := GetDB().WithContext(ctx).Model(&Album{})
-	if keyword != "" {
-		kw := "%" + keyword + "%"
+	err := filterAlbums(GetDB().WithContext(ctx).Model(&Album{}), filter).Count(&count).Error
+	return count, err
+}
+
+func filterAlbums(db *gorm.DB, filter ListFilter) *gorm.DB {
+	if filter.Keyword != "" {
+		kw := "%" + filter.Keyword + "%"
 		db = db.Where("name LIKE ? OR artist LIKE ?", kw, kw)
 	}
-	err := db.Count(&count).Error
-	return count, err
+	return filter.apply(db, TagTargetAlbum, "album")
 }
@@ -0,0 +1,236 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/vincentchyu/sonic-lens/common"
+)
+
+// 曲目署名中艺术家的角色
+const (
+	ArtistRolePrimary     = "primary"      // 主艺术家，热门艺术家统计按此计入
+	ArtistRoleFeatured    = "featured"     // feat. 合作艺术家
+	ArtistRoleComposer    = "composer"     // 作曲
+	ArtistRoleAlbumArtist = "album_artist" // 专辑艺术家
+)
+
+// 署名来源，MusicBrainz 的署名不会被解析结果覆盖
+const (
+	CreditSourceParsed      = "parsed"
+	CreditSourceMusicBrainz = "musicbrainz"
+)
+
+var artistRoles = []string{ArtistRolePrimary, ArtistRoleFeatured, ArtistRoleComposer, ArtistRoleAlbumArtist}
+
+// Artist 对应 artist 表，艺术家实体
+type Artist struct {
+	ID            int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	Name          string    `gorm:"column:name;type:varchar(255);not null;uniqueIndex:uidx_artist_name" json:"name"`
+	MusicBrainzID string    `gorm:"column:music_brainz_id;type:varchar(64);index:idx_artist_mbid" json:"music_brainz_id"`
+	CreatedAt     time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the table name for the Artist model
+func (Artist) TableName() string {
+	return "artist"
+}
+
+// TrackArtist 对应 track_artist 表，曲目与艺术家的多对多署名
+type TrackArtist struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	TrackID   int64     `gorm:"column:track_id;type:bigint;not null;uniqueIndex:uidx_track_artist_role" json:"track_id"`
+	ArtistID  int64     `gorm:"column:artist_id;type:bigint;not null;uniqueIndex:uidx_track_artist_role;index:idx_track_artist_artist" json:"artist_id"`
+	Role      string    `gorm:"column:role;type:varchar(20);not null;uniqueIndex:uidx_track_artist_role" json:"role"`
+	Position  int       `gorm:"column:position;type:int;not null;default:0" json:"position"` // 同一角色内的署名顺序
+	Source    string    `gorm:"column:source;type:varchar(20);not null;default:'parsed'" json:"source"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName sets the table name for the TrackArtist model
+func (TrackArtist) TableName() string {
+	return "track_artist"
+}
+
+// ArtistCredit 一条署名
+type ArtistCredit struct {
+	ArtistID      int64  `json:"artist_id,omitempty"`
+	Name          string `json:"name"`
+	Role          string `json:"role"`
+	MusicBrainzID string `json:"music_brainz_id,omitempty"`
+	Source        string `json:"source,omitempty"`
+}
+
+// GetTrackArtists 获取曲目的全部署名，依次为主艺术家、合作、作曲与专辑艺术家
+func GetTrackArtists(ctx context.Context, trackID int64) ([]*ArtistCredit, error) {
+	if err := GetDB().WithContext(ctx).Select("id").First(&Track{}, trackID).Error; err != nil {
+		return nil, err
+	}
+	credits := make([]*ArtistCredit, 0)
+	err := GetDB().WithContext(ctx).Table("track_artist ta").
+		Select("a.id AS artist_id, a.name, ta.role, a.music_brainz_id, ta.source").
+		Joins("JOIN artist a ON a.id = ta.artist_id").
+		Where("ta.track_id = ?", trackID).
+		Order("ta.role DESC, ta.position").
+		Scan(&credits).Error
+	return credits, err
+}
+
+// ReplaceTrackArtists 用 credits 替换曲目在 roles 下的署名，其余角色保持不变
+func ReplaceTrackArtists(tx *gorm.DB, trackID int64, roles []string, credits []ArtistCredit, source string) error {
+	if err := tx.Where("track_id = ? AND role IN ?", trackID, roles).Delete(&TrackArtist{}).Error; err != nil {
+		return err
+	}
+
+	positions := make(map[string]int)
+	added := make(map[string]struct{})
+	rows := make([]*TrackArtist, 0, len(credits))
+	for _, credit := range credits {
+		artist, err := getOrCreateArtist(tx, credit.Name, credit.MusicBrainzID)
+		if err != nil {
+			return err
+		}
+		key := credit.Role + "\x00" + strings.ToLower(artist.Name)
+		if _, ok := added[key]; ok {
+			continue
+		}
+		added[key] = struct{}{}
+		rows = append(
+			rows, &TrackArtist{
+				TrackID: trackID, ArtistID: artist.ID, Role: credit.Role, Position: positions[credit.Role], Source: source,
+			},
+		)
+		positions[credit.Role]++
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// parseTrackArtists 从艺术家署名、曲名、专辑艺术家与作曲解析署名。
+// 只按 feat. 区分合作艺术家，署名中的多位艺术家仅按播放器给出的 separators 拆分，
+// 专辑艺术家与作曲保留完整名字，避免拆开 "Earth, Wind & Fire" 这类乐队；
+// artist 表中已有的完整名字（如 "Simon & Garfunkel"）即使包含分隔符也不拆分
+func parseTrackArtists(tx *gorm.DB, artistCredit string, separators []string, title, albumArtist, composer string) []ArtistCredit {
+	keep := func(name string) bool {
+		var count int64
+		tx.Model(&Artist{}).Where("name = ?", name).Count(&count)
+		return count > 0
+	}
+	var credits []ArtistCredit
+	add := func(role string, names []string) {
+		for _, name := range names {
+			credits = append(credits, ArtistCredit{Name: name, Role: role})
+		}
+	}
+	primary, featured := common.SplitArtistCredit(artistCredit, separators, keep)
+	add(ArtistRolePrimary, primary)
+	add(ArtistRoleFeatured, featured)
+	add(ArtistRoleFeatured, common.TitleFeaturedArtists(title, nil, keep))
+	add(ArtistRoleAlbumArtist, common.SplitArtistNames(albumArtist, nil, keep))
+	add(ArtistRoleComposer, common.SplitArtistNames(composer, nil, keep))
+	return credits
+}
+
+// ensureTrackArtists 曲目还没有署名时按解析结果补齐，artistCredit 为空时使用曲目的艺术家。
+// 已有署名（包括 MusicBrainz 署名）时不做修改
+func ensureTrackArtists(tx *gorm.DB, track *Track, artistCredit string, separators []string) error {
+	var count int64
+	if err := tx.Model(&TrackArtist{}).Where("track_id = ?", track.ID).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+	if artistCredit == "" {
+		artistCredit = track.Artist
+	}
+	credits := parseTrackArtists(tx, artistCredit, separators, track.Track, track.AlbumArtist, track.Composer)
+	return ReplaceTrackArtists(tx, track.ID, artistRoles, credits, CreditSourceParsed)
+}
+
+// backfillTrackArtists 为已有曲目补齐解析出的署名，历史数据不知道播放器的分隔写法，艺术家保留完整名字
+func backfillTrackArtists(tx *gorm.DB) error {
+	var tracks []*Track
+	return tx.Select("id", "artist", "track", "album_artist", "composer").
+		FindInBatches(
+			&tracks, 500, func(_ *gorm.DB, _ int) error {
+				for _, track := range tracks {
+					if err := ensureTrackArtists(tx, track, "", nil); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		).Error
+}
+
+// moveTrackArtists 把 source 曲目的署名并入规范曲目：规范曲目缺少的角色整体迁入；
+// 同一角色两边都有时优先使用 MusicBrainz 署名，来源相同时补上规范曲目缺少的艺术家
+func moveTrackArtists(tx *gorm.DB, canonicalID, sourceID int64) error {
+	var rows []*TrackArtist
+	if err := tx.Where("track_id IN ?", []int64{canonicalID, sourceID}).
+		Order("role, position").Find(&rows).Error; err != nil {
+		return err
+	}
+	kept := make(map[string][]*TrackArtist)
+	for _, row := range rows {
+		if row.TrackID == canonicalID {
+			kept[row.Role] = append(kept[row.Role], row)
+		}
+	}
+	for _, row := range rows {
+		if row.TrackID != sourceID {
+			continue
+		}
+		existing := kept[row.Role]
+		if len(existing) > 0 && (existing[0].Source == CreditSourceMusicBrainz) != (row.Source == CreditSourceMusicBrainz) {
+			if row.Source != CreditSourceMusicBrainz {
+				continue
+			}
+			if err := tx.Where("track_id = ? AND role = ?", canonicalID, row.Role).
+				Delete(&TrackArtist{}).Error; err != nil {
+				return err
+			}
+			existing = nil
+		}
+		duplicate := false
+		for _, other := range existing {
+			duplicate = duplicate || other.ArtistID == row.ArtistID
+		}
+		if duplicate {
+			continue
+		}
+		if err := tx.Model(row).Updates(
+			map[string]any{"track_id": canonicalID, "position": len(existing)},
+		).Error; err != nil {
+			return err
+		}
+		kept[row.Role] = append(existing, row)
+	}
+	return tx.Where("track_id = ?", sourceID).Delete(&TrackArtist{}).Error
+}
+
+func getOrCreateArtist(tx *gorm.DB, name, musicBrainzID string) (*Artist, error) {
+	artist := Artist{Name: strings.TrimSpace(name)}
+	if err := tx.Where("name = ?", artist.Name).FirstOrCreate(&artist).Error; err != nil {
+		return nil, err
+	}
+	if musicBrainzID != "" && artist.MusicBrainzID != musicBrainzID {
+		artist.MusicBrainzID = musicBrainzID
+		if err := tx.Model(&artist).Update("music_brainz_id", musicBrainzID).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &artist, nil
+}
+
+// creditedArtists 以主艺术家署名展开曲目，合作曲目计入每位主艺术家；没有署名的曲目按 track.artist 计
+func creditedArtists(db *gorm.DB) *gorm.DB {
+	return db.Table("track t").
+		Joins("LEFT JOIN track_artist ta ON ta.track_id = t.id AND ta.role = ?", ArtistRolePrimary).
+		Joins("LEFT JOIN artist a ON a.id = ta.artist_id").
+		Group("COALESCE(a.name, t.artist)")
+}
@@ -133,6 +133,7 @@ type DashboardStatRuntimeConfig struct {
 	TopN                 int
 	TrendDays            int
 	HourlyTrendDays      int
+	SessionGapMinutes    int
 }
 
 func GetDashboardStatRuntimeConfig() DashboardStatRuntimeConfig {
@@ -164,6 +165,9 @@ func GetDashboardStatRuntimeConfig() DashboardStatRuntimeConfig {
 	if runtimeCfg.HourlyTrendDays > runtimeCfg.TrendDays {
 		runtimeCfg.HourlyTrendDays = runtimeCfg.TrendDays
 	}
+	if runtimeCfg.SessionGapMinutes = cfg.SessionGapMinutes; runtimeCfg.SessionGapMinutes <= 0 {
+		runtimeCfg.SessionGapMinutes = defaultSessionGapMinutes
+	}
 
 	return runtimeCfg
 }
@@ -173,148 +177,22 @@ func GetDashboardStatConfig() (enabled bool, intervalMinutes int, topN int, tren
 	return runtimeCfg.Enabled, runtimeCfg.IntervalMinutes, runtimeCfg.TopN, runtimeCfg.TrendDays
 }
 
-func EnsureDashboardStatSchema(ctx context.Context) error {
-	db := GetDB().WithContext(ctx)
-	migrator := db.Migrator()
-
-	if err := ensureTableAndColumns(
-		migrator, &DashboardStat{},
-		[]string{"ID", "TotalPlays", "TotalTracks", "TotalArtist", "TotalAlbums", "UpdatedAt"},
-	); err != nil {
-		return err
-	}
-	if err := ensureTableAndColumns(
-		migrator, &PlaySourceStat{}, []string{"ID", "Source", "Count", "UpdatedAt"},
-	); err != nil {
-		return err
-	}
-	if err := ensureTableAndColumns(
-		migrator, &TopArtistStat{},
-		[]string{"ID", "PeriodDays", "MetricType", "Artist", "MetricValue", "Rank", "UpdatedAt"},
-	); err != nil {
-		return err
-	}
-	if err := ensureTableAndColumns(
-		migrator, &TopAlbumStat{}, []string{"ID", "PeriodDays", "AlbumID", "Album", "Artist", "PlayCount", "Rank", "UpdatedAt"},
-	); err != nil {
-		return err
-	}
-	if err := ensureTableAndColumns(
-		migrator, &TopGenreStat{},
-		[]string{"ID", "GenreName", "GenreNameZh", "TrackGenreCount", "GenreCount", "Rank", "UpdatedAt"},
-	); err != nil {
-		return err
-	}
-	if err := ensureTableAndColumns(
-		migrator, &PlayTrendDailyStat{}, []string{"StatDate", "PlayCount", "UpdatedAt"},
-	); err != nil {
-		return err
-	}
-	if err := ensureTableAndColumns(
-		migrator, &PlayTrendHourlyStat{}, []string{"StatDate", "Hour", "PlayCount", "UpdatedAt"},
-	); err != nil {
-		return err
-	}
-	if err := ensureTableAndColumns(
-		migrator, &TrackRankStat{},
-		[]string{"ID", "PeriodType", "Artist", "Album", "Track", "PlayCount", "Rank", "UpdatedAt"},
-	); err != nil {
-		return err
-	}
-	if err := ensureTrackRankStatIndexes(ctx); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func ensureTableAndColumns(migrator gorm.Migrator, model interface{}, columns []string) error {
-	if !migrator.HasTable(model) {
-		if err := migrator.CreateTable(model); err != nil {
-			return err
-		}
-	}
-	for _, col := range columns {
-		if !migrator.HasColumn(model, col) {
-			if err := migrator.AddColumn(model, col); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
-
 func RefreshDashboardStats(ctx context.Context) error {
 	runtimeCfg := GetDashboardStatRuntimeConfig()
 	if err := RefreshDashboardStatsLight(ctx); err != nil {
 		return err
 	}
-	return refreshDashboardStatsHeavyWithOptions(ctx, runtimeCfg.TopN, runtimeCfg.TrendDays, runtimeCfg.HourlyTrendDays)
+	return refreshDashboardStatsHeavyWithOptions(ctx, runtimeCfg)
 }
 
 func RefreshDashboardStatsLight(ctx context.Context) error {
-	if err := EnsureDashboardStatSchema(ctx); err != nil {
-		return err
-	}
 	cfg := GetDashboardStatRuntimeConfig()
-	if err := refreshDashboardStatsLightOnly(ctx, cfg.TopN); err != nil {
-		if isLegacyDashboardSchemaError(err) {
-			if rebuildErr := rebuildBrokenDashboardStatTable(ctx, err); rebuildErr != nil {
-				return fmt.Errorf("rebuild broken dashboard stat table failed: %w", rebuildErr)
-			}
-			return refreshDashboardStatsLightOnly(ctx, cfg.TopN)
-		}
-		return err
-	}
-	return nil
+	return refreshDashboardStatsLightOnly(ctx, cfg.TopN)
 }
 
 func RefreshDashboardStatsHeavy(ctx context.Context) error {
 	cfg := GetDashboardStatRuntimeConfig()
-	return refreshDashboardStatsHeavyWithOptions(ctx, cfg.TopN, cfg.TrendDays, cfg.HourlyTrendDays)
-}
-
-func isLegacyDashboardSchemaError(err error) bool {
-	if err == nil {
-		return false
-	}
-	msg := strings.ToLower(err.Error())
-	return strings.Contains(msg, "unknown column") ||
-		strings.Contains(msg, "doesn't have a default value") ||
-		strings.Contains(msg, "doesn't exist")
-}
-
-func rebuildBrokenDashboardStatTable(ctx context.Context, rootErr error) error {
-	db := GetDB().WithContext(ctx)
-	migrator := db.Migrator()
-	msg := strings.ToLower(rootErr.Error())
-
-	type tableModel struct {
-		name  string
-		model interface{}
-	}
-	tables := []tableModel{
-		{name: "dashboard_stat", model: &DashboardStat{}},
-		{name: "play_source_stat", model: &PlaySourceStat{}},
-		{name: "top_artist_stat", model: &TopArtistStat{}},
-		{name: "top_album_stat", model: &TopAlbumStat{}},
-		{name: "top_genre_stat", model: &TopGenreStat{}},
-		{name: "play_trend_daily_stat", model: &PlayTrendDailyStat{}},
-		{name: "play_trend_hourly_stat", model: &PlayTrendHourlyStat{}},
-		{name: "track_rank_stat", model: &TrackRankStat{}},
-	}
-
-	for _, t := range tables {
-		if strings.Contains(msg, t.name) {
-			if err := migrator.DropTable(t.model); err != nil {
-				return err
-			}
-			return db.AutoMigrate(t.model)
-		}
-	}
-
-	// 无法定位具体表时，不做任何 destructive 操作
-	return rootErr
+	return refreshDashboardStatsHeavyWithOptions(ctx, cfg)
 }
 
 func refreshDashboardStatsLightOnly(ctx context.Context, topN int) error {
@@ -335,57 +213,40 @@ func refreshDashboardStatsLightOnly(ctx context.Context, topN int) error {
 	)
 }
 
-func refreshDashboardStatsHeavyWithOptions(ctx context.Context, topN, trendDays, hourlyTrendDays int) error {
-	if err := EnsureDashboardStatSchema(ctx); err != nil {
-		return err
-	}
+func refreshDashboardStatsHeavyWithOptions(ctx context.Context, cfg DashboardStatRuntimeConfig) error {
 	db := GetDB().WithContext(ctx)
-	err := db.Transaction(
+	return db.Transaction(
 		func(tx *gorm.DB) error {
-			if err := refreshTopArtistStats(tx, topN); err != nil {
+			if err := refreshTopArtistStats(tx, cfg.TopN); err != nil {
+				return err
+			}
+			if err := refreshTopAlbumStats(tx, cfg.TopN); err != nil {
+				return err
+			}
+			if err := refreshTopGenreStats(tx, cfg.TopN); err != nil {
+				return err
+			}
+			if err := refreshTrendStats(tx, cfg.TrendDays, cfg.HourlyTrendDays); err != nil {
+				return err
+			}
+			if err := refreshTrackRankStats(tx, cfg.TopN, []string{"all", "week", "month"}); err != nil {
 				return err
 			}
-			if err := refreshTopAlbumStats(tx, topN); err != nil {
+			if err := refreshListeningSessions(tx, time.Duration(cfg.SessionGapMinutes)*time.Minute); err != nil {
 				return err
 			}
-			if err := refreshTopGenreStats(tx, topN); err != nil {
+			if err := refreshSessionWeekdayStats(tx); err != nil {
 				return err
 			}
-			if err := refreshTrendStats(tx, trendDays, hourlyTrendDays); err != nil {
+			if err := refreshTopRatedStats(tx, cfg.TopN); err != nil {
 				return err
 			}
-			if err := refreshTrackRankStats(tx, topN, []string{"all", "week", "month"}); err != nil {
+			if err := refreshTagStats(tx, cfg.TopN); err != nil {
 				return err
 			}
 			return nil
 		},
 	)
-	if err != nil && isLegacyDashboardSchemaError(err) {
-		if rebuildErr := rebuildBrokenDashboardStatTable(ctx, err); rebuildErr != nil {
-			return fmt.Errorf("rebuild broken dashboard stat table failed: %w", rebuildErr)
-		}
-		return db.Transaction(
-			func(tx *gorm.DB) error {
-				if err := refreshTopArtistStats(tx, topN); err != nil {
-					return err
-				}
-				if err := refreshTopAlbumStats(tx, topN); err != nil {
-					return err
-				}
-				if err := refreshTopGenreStats(tx, topN); err != nil {
-					return err
-				}
-				if err := refreshTrendStats(tx, trendDays, hourlyTrendDays); err != nil {
-					return err
-				}
-				if err := refreshTrackRankStats(tx, topN, []string{"all", "week", "month"}); err != nil {
-					return err
-				}
-				return nil
-			},
-		)
-	}
-	return err
 }
 
 func refreshDashboardOverview(tx *gorm.DB) error {
@@ -467,9 +328,8 @@ func refreshTopArtistStats(tx *gorm.DB, topN int) error {
 
 	loadAndStore := func(metricType, selectSQL, orderSQL string) error {
 		var rows []topArtistRow
-		if err := tx.Model(&Track{}).
+		if err := creditedArtists(tx).
 			Select(selectSQL).
-			Group("artist").
 			Order(orderSQL).
 			Limit(topN).
 			Find(&rows).Error; err != nil {
@@ -507,10 +367,12 @@ func refreshTopArtistStats(tx *gorm.DB, topN int) error {
 		return tx.Create(&items).Error
 	}
 
-	if err := loadAndStore("plays", "artist, SUM(play_count) as metric_value", "metric_value DESC"); err != nil {
+	// 合作曲目计入每位主艺术家
+	const artistColumn = "COALESCE(a.name, t.artist) AS artist"
+	if err := loadAndStore("plays", artistColumn+", SUM(t.play_count) as metric_value", "metric_value DESC"); err != nil {
 		return err
 	}
-	if err := loadAndStore("tracks", "artist, COUNT(*) as metric_value", "metric_value DESC"); err != nil {
+	if err := loadAndStore("tracks", artistColumn+", COUNT(*) as metric_value", "metric_value DESC"); err != nil {
 		return err
 	}
 	return nil
@@ -841,32 +703,6 @@ func refreshTrackRankStats(tx *gorm.DB, topN int, periods []string) error {
 	return nil
 }
 
-func ensureTrackRankStatIndexes(ctx context.Context) error {
-	if config.ConfigObj.Database.Type != string(common.DatabaseTypeMySQL) {
-		return nil
-	}
-	db := GetDB().WithContext(ctx)
-	migrator := db.Migrator()
-
-	if !migrator.HasIndex(&TrackRankStat{}, "idx_track_rank_period_rank") {
-		if err := db.Exec("ALTER TABLE track_rank_stat ADD INDEX idx_track_rank_period_rank (period_type, `rank`)").Error; err != nil {
-			return err
-		}
-	}
-	if !migrator.HasIndex(&TrackRankStat{}, "uk_track_rank_period_track") {
-		// utf8mb4 下联合唯一索引总长度不能超过 3072 bytes，使用前缀索引规避超长。
-		if err := db.Exec("ALTER TABLE track_rank_stat ADD UNIQUE KEY uk_track_rank_period_track (period_type, artist(191), album(191), track(191))").Error; err != nil {
-			return err
-		}
-	}
-	if !migrator.HasIndex(&TrackRankStat{}, "idx_track_rank_stat_fts") {
-		if err := db.Exec("ALTER TABLE track_rank_stat ADD FULLTEXT idx_track_rank_stat_fts(track, artist, album) WITH PARSER ngram").Error; err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func parseDateOnly(s string) (time.Time, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
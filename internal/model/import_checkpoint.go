@@ -0,0 +1,59 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImportCheckpoint 对应 import_checkpoint 表，记录历史导入的断点以便中断后续传
+type ImportCheckpoint struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	Source    string    `gorm:"column:source;type:varchar(191);not null;uniqueIndex:idx_import_checkpoint_source" json:"source"` // 导入来源，如 lastfm:username
+	State     string    `gorm:"column:state;type:text" json:"state"`                                                             // 导入器自定义的断点(JSON)
+	Imported  int64     `gorm:"column:imported;type:bigint;not null;default:0" json:"imported"`                                  // 累计导入条数
+	Skipped   int64     `gorm:"column:skipped;type:bigint;not null;default:0" json:"skipped"`                                    // 累计跳过条数（重复或信息不全）
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the table name for the ImportCheckpoint model
+func (ImportCheckpoint) TableName() string {
+	return "import_checkpoint"
+}
+
+// GetImportCheckpoint 获取导入断点，不存在时返回 nil
+func GetImportCheckpoint(ctx context.Context, source string) (*ImportCheckpoint, error) {
+	var checkpoint ImportCheckpoint
+	err := GetDB().WithContext(ctx).Where("source = ?", source).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// SaveImportCheckpoint 按 source 写入或更新导入断点
+func SaveImportCheckpoint(ctx context.Context, checkpoint *ImportCheckpoint) error {
+	now := time.Now()
+	if checkpoint.CreatedAt.IsZero() {
+		checkpoint.CreatedAt = now
+	}
+	checkpoint.UpdatedAt = now
+	return GetDB().WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "source"}},
+			DoUpdates: clause.AssignmentColumns([]string{"state", "imported", "skipped", "updated_at"}),
+		},
+	).Create(checkpoint).Error
+}
+
+// DeleteImportCheckpoint 删除导入断点，下次导入从头开始
+func DeleteImportCheckpoint(ctx context.Context, source string) error {
+	return GetDB().WithContext(ctx).Where("source = ?", source).Delete(&ImportCheckpoint{}).Error
+}
@@ -1,12 +1,16 @@
 package model
 
 import (
+	"context"
 	"errors"
+	"strings"
 
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 
 	"github.com/vincentchyu/sonic-lens/common"
 	"github.com/vincentchyu/sonic-lens/config"
@@ -23,87 +27,66 @@ func GetDB() *gorm.DB {
 	return GlobalDBForSqlLite
 }
 
+// models 全部业务模型，按依赖顺序排列，被引用的表在前，跨库数据迁移按此顺序处理。
+// 新增模型时同时在 migrations 中追加建表迁移
+var models = []interface{}{
+	&Track{}, &Album{}, &Genre{}, &TrackPlayRecord{},
+	&TrackAlbum{}, &ReleaseMB{}, &AlbumReleaseMB{},
+	&TrackInsight{}, &TrackInsightFeedback{}, &TrackLyrics{}, &LLMCallLog{},
+	&DashboardStat{}, &PlaySourceStat{}, &TopArtistStat{}, &TopAlbumStat{}, &TopGenreStat{}, &PlayTrendDailyStat{}, &PlayTrendHourlyStat{}, &TrackRankStat{},
+	&ScrobbleOutbox{}, &ImportCheckpoint{}, &ScrobbleSession{}, &ListenToken{}, &PlayEvent{},
+	&ListeningSession{}, &SessionWeekdayStat{}, &UserTag{}, &TopRatedStat{}, &TagStat{},
+	&TrackAlias{}, &Artist{}, &TrackArtist{}, &RewriteRule{},
+}
+
+// Models 返回全部模型，按依赖顺序排列
+func Models() []interface{} {
+	return append([]interface{}(nil), models...)
+}
+
+// OpenDB 按数据库类型打开连接，不修改全局连接，供迁移等需要同时访问两个库的场景使用
+func OpenDB(dbType, dataSourceName string, l *zap.Logger) (*gorm.DB, error) {
+	gormConfig := &gorm.Config{Logger: db.NewCustomLogger(l)}
+	switch dbType {
+	case string(common.DatabaseTypeSQLite):
+		return gorm.Open(sqliteDialector{sqlite.Open(dataSourceName).(*sqlite.Dialector)}, gormConfig)
+	case string(common.DatabaseTypeMySQL):
+		return gorm.Open(mysql.Open(db.MysqlDSN(dataSourceName)), gormConfig)
+	default:
+		return nil, errors.New("unsupported database type" + dbType)
+	}
+}
+
 func InitDB(dataSourceName string, l *zap.Logger) error {
 	var err error
 
-	// Create custom logger with OpenTelemetry
-	customLogger := db.NewCustomLogger(l)
 	switch config.ConfigObj.Database.Type {
 	case string(common.DatabaseTypeSQLite):
-		// Open SQLite database with custom logger
-		GlobalDBForSqlLite, err = gorm.Open(
-			sqlite.Open(dataSourceName), &gorm.Config{
-				Logger: customLogger,
-			},
-		)
+		GlobalDBForSqlLite, err = OpenDB(config.ConfigObj.Database.Type, dataSourceName, l)
 		if err != nil {
 			return err
 		}
-
-		// Auto migrate the schema for core tables
-		if err = GlobalDBForSqlLite.AutoMigrate(&TrackPlayRecord{}); err != nil {
-			return err
-		}
-		if err = GlobalDBForSqlLite.AutoMigrate(&Track{}); err != nil {
-			return err
-		}
-		if err = GlobalDBForSqlLite.AutoMigrate(&Genre{}); err != nil {
-			return err
-		}
-		if err = GlobalDBForSqlLite.AutoMigrate(
-			&DashboardStat{}, &PlaySourceStat{}, &TopArtistStat{}, &TopAlbumStat{}, &TopGenreStat{}, &PlayTrendDailyStat{}, &PlayTrendHourlyStat{}, &TrackRankStat{},
-		); err != nil {
-			return err
-		}
-		// Auto migrate the schema for AI insight related tables
-		if err = GlobalDBForSqlLite.AutoMigrate(&TrackInsight{}, &TrackInsightFeedback{}); err != nil {
-			return err
-		}
-		// Auto migrate the schema for LLM call log table
-		if err = GlobalDBForSqlLite.AutoMigrate(&LLMCallLog{}); err != nil {
-			return err
-		}
-		// Auto migrate MusicBrainz related tables
-		if err = GlobalDBForSqlLite.AutoMigrate(&Album{}, &TrackAlbum{}, &ReleaseMB{}, &AlbumReleaseMB{}); err != nil {
+		// 执行未执行的版本化迁移
+		if _, err = MigrateUp(context.Background(), GlobalDBForSqlLite, 0); err != nil {
 			return err
 		}
 	case string(common.DatabaseTypeMySQL):
-		// Open MySQL database with custom logger
-		GlobalDBForMysql, err = gorm.Open(
-			mysql.Open(db.MysqlDSN(config.ConfigObj.Database.Mysql.GetMysqlDSN())), &gorm.Config{
-				Logger: customLogger,
-			},
-		)
+		GlobalDBForMysql, err = OpenDB(config.ConfigObj.Database.Type, config.ConfigObj.Database.Mysql.GetMysqlDSN(), l)
 		if err != nil {
 			return err
 		}
 		if config.ConfigObj.IsDev {
-			// Auto migrate the schema for core tables
-			if err = GlobalDBForMysql.AutoMigrate(&TrackPlayRecord{}); err != nil {
+			// 开发环境自动执行迁移，生产环境通过 sonic-lens db up 显式执行
+			if _, err = MigrateUp(context.Background(), GlobalDBForMysql, 0); err != nil {
 				return err
 			}
-			if err = GlobalDBForMysql.AutoMigrate(&Track{}); err != nil {
+		} else {
+			// 统计缓存表仍在启动时建好，其余迁移需要显式执行
+			if _, err = migrateAuto(context.Background(), GlobalDBForMysql); err != nil {
 				return err
 			}
-			if err = GlobalDBForMysql.AutoMigrate(&Genre{}); err != nil {
-				return err
-			}
-			if err = GlobalDBForMysql.AutoMigrate(
-				&DashboardStat{}, &PlaySourceStat{}, &TopArtistStat{}, &TopAlbumStat{}, &TopGenreStat{}, &PlayTrendDailyStat{}, &PlayTrendHourlyStat{}, &TrackRankStat{},
-			); err != nil {
-				return err
-			}
-			// Auto migrate the schema for AI insight related tables
-			if err = GlobalDBForMysql.AutoMigrate(&TrackInsight{}, &TrackInsightFeedback{}); err != nil {
-				return err
-			}
-			// Auto migrate the schema for LLM call log table
-			if err = GlobalDBForMysql.AutoMigrate(&LLMCallLog{}); err != nil {
-				return err
-			}
-			// Auto migrate MusicBrainz related tables
-			if err = GlobalDBForMysql.AutoMigrate(&Album{}, &TrackAlbum{}, &ReleaseMB{}, &AlbumReleaseMB{}); err != nil {
-				return err
+			if pending, pendingErr := PendingMigrations(context.Background(), GlobalDBForMysql); pendingErr == nil && len(pending) > 0 && l != nil {
+				l.Warn("database has pending migrations, run `sonic-lens db up`", zap.Int("pending", len(pending)))
 			}
 		}
 	default:
@@ -112,3 +95,39 @@ func InitDB(dataSourceName string, l *zap.Logger) error {
 
 	return nil
 }
+
+// sqliteDialector 包装 SQLite 方言，修正 MySQL 风格的模型定义在全新 SQLite 数据库上建表的问题：
+//  1. 去掉 MySQL 专有的 ON UPDATE CURRENT_TIMESTAMP，否则 AutoMigrate 报 near "ON": syntax error
+//  2. 自增主键 bigint 改为 integer，只有 INTEGER PRIMARY KEY 才是 rowid 别名，否则插入后 id 为 NULL
+//  3. 已有表再次 AutoMigrate 时按去掉 ON UPDATE 后的默认值比较，避免每次启动都重建表并再次报错
+type sqliteDialector struct {
+	*sqlite.Dialector
+}
+
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqliteMigrator{d.Dialector.Migrator(db)}
+}
+
+type sqliteMigrator struct {
+	gorm.Migrator
+}
+
+const sqliteOnUpdateSuffix = " ON UPDATE CURRENT_TIMESTAMP"
+
+func (m sqliteMigrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	expr := m.Migrator.FullDataTypeOf(field)
+	expr.SQL = strings.Replace(expr.SQL, sqliteOnUpdateSuffix, "", 1)
+	if field.PrimaryKey && field.AutoIncrement && strings.HasPrefix(strings.ToLower(expr.SQL), "bigint") {
+		expr.SQL = "integer" + expr.SQL[len("bigint"):]
+	}
+	return expr
+}
+
+func (m sqliteMigrator) MigrateColumn(value interface{}, field *schema.Field, columnType gorm.ColumnType) error {
+	if strings.HasSuffix(field.DefaultValue, sqliteOnUpdateSuffix) {
+		sqliteField := *field
+		sqliteField.DefaultValue = strings.TrimSuffix(field.DefaultValue, sqliteOnUpdateSuffix)
+		field = &sqliteField
+	}
+	return m.Migrator.MigrateColumn(value, field, columnType)
+}
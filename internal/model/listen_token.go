@@ -0,0 +1,78 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListenToken 对应 listen_token 表，ListenBrainz 兼容接口按客户端签发的 token
+type ListenToken struct {
+	ID         int64      `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	Name       string     `gorm:"column:name;type:varchar(100);not null;uniqueIndex:idx_listen_token_name" json:"name"` // 客户端名称，同时作为 track_play_records.source
+	Token      string     `gorm:"column:token;type:varchar(64);not null;uniqueIndex:idx_listen_token_token" json:"token"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;type:timestamp;null" json:"last_used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName sets the table name for the ListenToken model
+func (ListenToken) TableName() string {
+	return "listen_token"
+}
+
+// CreateListenToken 保存新签发的 token，同名客户端已存在时返回 gorm.ErrDuplicatedKey
+func CreateListenToken(ctx context.Context, token *ListenToken) error {
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	exists, err := GetListenTokenByName(ctx, token.Name)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return gorm.ErrDuplicatedKey
+	}
+	return GetDB().WithContext(ctx).Create(token).Error
+}
+
+// GetListenToken 按 token 获取，不存在时返回 nil
+func GetListenToken(ctx context.Context, token string) (*ListenToken, error) {
+	return findListenToken(ctx, "token = ?", token)
+}
+
+// GetListenTokenByName 按客户端名称获取，不存在时返回 nil
+func GetListenTokenByName(ctx context.Context, name string) (*ListenToken, error) {
+	return findListenToken(ctx, "name = ?", name)
+}
+
+func findListenToken(ctx context.Context, query string, arg string) (*ListenToken, error) {
+	var token ListenToken
+	err := GetDB().WithContext(ctx).Where(query, arg).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListListenTokens 列出全部 token，按创建时间升序
+func ListListenTokens(ctx context.Context) ([]*ListenToken, error) {
+	var tokens []*ListenToken
+	err := GetDB().WithContext(ctx).Order("created_at ASC, id ASC").Find(&tokens).Error
+	return tokens, err
+}
+
+// TouchListenToken 记录 token 最近一次使用时间
+func TouchListenToken(ctx context.Context, id int64, usedAt time.Time) error {
+	return GetDB().WithContext(ctx).Model(&ListenToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+// DeleteListenToken 按客户端名称吊销 token，返回是否存在
+func DeleteListenToken(ctx context.Context, name string) (bool, error) {
+	result := GetDB().WithContext(ctx).Where("name = ?", name).Delete(&ListenToken{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,274 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultSessionGapMinutes = 30
+
+// ListeningSession 对应 listening_session 表，同一来源相邻两次播放的间隔不超过 sessionGapMinutes 时归为一次收听。
+// 由 dashboard 重型统计按来源增量重建，来源最后一次收听以及上次重建后写入的记录所影响的收听会重新计算
+type ListeningSession struct {
+	ID              int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	Source          string    `gorm:"column:source;type:varchar(100);not null;index:idx_listening_session_source" json:"source"`
+	StartedAt       time.Time `gorm:"column:started_at;type:timestamp;not null;index:idx_listening_session_started_at" json:"started_at"`
+	EndedAt         time.Time `gorm:"column:ended_at;type:timestamp;not null" json:"ended_at"`     // 最后一首的开始时间加时长
+	DurationSeconds int64     `gorm:"column:duration_seconds;type:bigint" json:"duration_seconds"` // 从开始到结束的秒数
+	TrackCount      int       `gorm:"column:track_count;type:int" json:"track_count"`
+	DominantGenre   string    `gorm:"column:dominant_genre;type:varchar(255)" json:"dominant_genre"` // 出现次数最多的流派
+	LastRecordID    int64     `gorm:"column:last_record_id;type:bigint;not null;default:0" json:"-"` // 重建时来源播放记录的最大 ID，之后写入的记录 ID 更大
+	UpdatedAt       time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the table name for the ListeningSession model
+func (ListeningSession) TableName() string {
+	return "listening_session"
+}
+
+// SessionWeekdayStat 按开始时间所在星期统计的收听次数与时长，weekday 0 为周日
+type SessionWeekdayStat struct {
+	Weekday      int       `gorm:"column:weekday;type:tinyint;primaryKey;autoIncrement:false" json:"weekday"`
+	SessionCount int64     `gorm:"column:session_count;type:bigint;default:0" json:"session_count"`
+	TotalSeconds int64     `gorm:"column:total_seconds;type:bigint;default:0" json:"total_seconds"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (SessionWeekdayStat) TableName() string {
+	return "session_weekday_stat"
+}
+
+// sessionRecord 划分收听所需的播放记录字段
+type sessionRecord struct {
+	PlayTime time.Time
+	Duration int64
+	Genre    string
+}
+
+// refreshListeningSessions 逐个来源从受影响的最早收听起重新划分
+func refreshListeningSessions(tx *gorm.DB, gap time.Duration) error {
+	var sources []string
+	if err := tx.Model(&TrackPlayRecord{}).Distinct("source").Pluck("source", &sources).Error; err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if err := rebuildListeningSessions(tx, source, gap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildListeningSessions 来源最后一次收听可能仍在延续，总是重新划分；
+// 上次重建后写入的播放记录（导入、补传）可能早于最后一次收听，此时从其中最早的播放时间所影响的收听起重建
+func rebuildListeningSessions(tx *gorm.DB, source string, gap time.Duration) error {
+	// 先取游标，重建期间写入的记录 ID 更大，下次仍会被发现
+	var lastRecordID int64
+	if err := tx.Model(&TrackPlayRecord{}).Where("source = ?", source).
+		Select("COALESCE(MAX(id), 0)").Scan(&lastRecordID).Error; err != nil {
+		return err
+	}
+
+	var since time.Time
+	var last ListeningSession
+	err := tx.Where("source = ?", source).Order("started_at DESC").First(&last).Error
+	switch {
+	case err == nil:
+		since = last.StartedAt
+		changedSince, err := earliestNewPlayTime(tx, source)
+		if err != nil {
+			return err
+		}
+		if !changedSince.IsZero() && changedSince.Before(since) {
+			since, err = affectedSessionStart(tx, source, changedSince, gap)
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("source = ? AND started_at >= ?", source, since).Delete(&ListeningSession{}).Error; err != nil {
+			return err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	var records []sessionRecord
+	if err := tx.Table("track_play_records AS r").
+		Select(
+			"r.play_time, r.duration, "+
+				"COALESCE((SELECT MAX(t.genre) FROM track AS t WHERE t.artist = r.artist AND t.album = r.album AND t.track = r.track), '') AS genre",
+		).
+		Where("r.source = ? AND r.play_time >= ? AND r.id <= ?", source, since, lastRecordID).
+		Order("r.play_time ASC").
+		Scan(&records).Error; err != nil {
+		return err
+	}
+	sessions := groupListeningSessions(source, records, gap)
+	if len(sessions) == 0 {
+		return nil
+	}
+	for _, session := range sessions {
+		session.LastRecordID = lastRecordID
+	}
+	return tx.CreateInBatches(sessions, 500).Error
+}
+
+// earliestNewPlayTime 上次重建之后写入的播放记录中最早的播放时间，没有时返回零值
+func earliestNewPlayTime(tx *gorm.DB, source string) (time.Time, error) {
+	var cursor int64
+	if err := tx.Model(&ListeningSession{}).Where("source = ?", source).
+		Select("COALESCE(MAX(last_record_id), 0)").Scan(&cursor).Error; err != nil {
+		return time.Time{}, err
+	}
+	var record TrackPlayRecord
+	err := tx.Select("play_time").Where("source = ? AND id > ?", source, cursor).
+		Order("play_time ASC").First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return record.PlayTime, err
+}
+
+// affectedSessionStart 在 playTime 写入一条播放会并入结束时间不早于 playTime - gap 的收听，返回其中最早的开始时间
+func affectedSessionStart(tx *gorm.DB, source string, playTime time.Time, gap time.Duration) (time.Time, error) {
+	var first ListeningSession
+	err := tx.Where("source = ? AND ended_at >= ?", source, playTime.Add(-gap)).Order("started_at ASC").First(&first).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return playTime, nil
+	case err != nil:
+		return time.Time{}, err
+	case first.StartedAt.Before(playTime):
+		return first.StartedAt, nil
+	}
+	return playTime, nil
+}
+
+// groupListeningSessions records 需按播放时间升序
+func groupListeningSessions(source string, records []sessionRecord, gap time.Duration) []*ListeningSession {
+	var sessions []*ListeningSession
+	var current *ListeningSession
+	var genres map[string]int
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.DurationSeconds = int64(current.EndedAt.Sub(current.StartedAt).Seconds())
+		current.DominantGenre = dominantGenre(genres)
+		sessions = append(sessions, current)
+	}
+	for _, record := range records {
+		end := record.PlayTime.Add(time.Duration(record.Duration) * time.Second)
+		if current == nil || record.PlayTime.Sub(current.EndedAt) > gap {
+			flush()
+			current = &ListeningSession{Source: source, StartedAt: record.PlayTime, EndedAt: end}
+			genres = make(map[string]int)
+		}
+		if end.After(current.EndedAt) {
+			current.EndedAt = end
+		}
+		current.TrackCount++
+		if record.Genre != "" {
+			genres[record.Genre]++
+		}
+	}
+	flush()
+	return sessions
+}
+
+// dominantGenre 次数相同时取名称较小的，保证重建结果稳定
+func dominantGenre(genres map[string]int) string {
+	var best string
+	for genre, count := range genres {
+		if count > genres[best] || (count == genres[best] && genre < best) {
+			best = genre
+		}
+	}
+	return best
+}
+
+func refreshSessionWeekdayStats(tx *gorm.DB) error {
+	var sessions []*ListeningSession
+	if err := tx.Select("started_at", "duration_seconds").Find(&sessions).Error; err != nil {
+		return err
+	}
+	items := make([]SessionWeekdayStat, 7)
+	for i := range items {
+		items[i].Weekday = i
+	}
+	for _, session := range sessions {
+		item := &items[session.StartedAt.In(time.Local).Weekday()]
+		item.SessionCount++
+		item.TotalSeconds += session.DurationSeconds
+	}
+
+	if err := tx.Where("1 = 1").Delete(&SessionWeekdayStat{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&items).Error
+}
+
+// GetListeningSessions 按开始时间倒序分页，source 为空时返回全部来源
+func GetListeningSessions(ctx context.Context, source string, limit, offset int) ([]*ListeningSession, int64, error) {
+	query := GetDB().WithContext(ctx).Model(&ListeningSession{})
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var sessions []*ListeningSession
+	err := query.Order("started_at DESC").Limit(limit).Offset(offset).Find(&sessions).Error
+	return sessions, total, err
+}
+
+// GetListeningSession 不存在时返回 nil
+func GetListeningSession(ctx context.Context, id int64) (*ListeningSession, error) {
+	var session ListeningSession
+	err := GetDB().WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetListeningSessionRecords 返回一次收听中的播放记录，按播放时间升序
+func GetListeningSessionRecords(ctx context.Context, session *ListeningSession) ([]*TrackPlayRecord, error) {
+	var records []*TrackPlayRecord
+	err := GetDB().WithContext(ctx).
+		Where("source = ? AND play_time >= ? AND play_time <= ?", session.Source, session.StartedAt, session.EndedAt).
+		Order("play_time ASC").
+		Find(&records).Error
+	return records, err
+}
+
+// ListeningSessionStats 收听统计
+type ListeningSessionStats struct {
+	SessionCount   int64                 `json:"session_count"`
+	AverageSeconds int64                 `json:"average_seconds"`
+	Weekdays       []*SessionWeekdayStat `json:"weekdays"`
+}
+
+// GetListeningSessionStatsFromStat 读取 dashboard 重型统计生成的按星期统计
+func GetListeningSessionStatsFromStat(ctx context.Context) (*ListeningSessionStats, error) {
+	stats := &ListeningSessionStats{}
+	if err := GetDB().WithContext(ctx).Order("weekday ASC").Find(&stats.Weekdays).Error; err != nil {
+		return nil, err
+	}
+	var totalSeconds int64
+	for _, weekday := range stats.Weekdays {
+		stats.SessionCount += weekday.SessionCount
+		totalSeconds += weekday.TotalSeconds
+	}
+	if stats.SessionCount > 0 {
+		stats.AverageSeconds = totalSeconds / stats.SessionCount
+	}
+	return stats, nil
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrIrreversibleMigration 迁移没有提供 Down，无法回滚
+var ErrIrreversibleMigration = errors.New("migration cannot be rolled back")
+
+// Migration 一次版本化的结构变更，Version 递增且不可复用。
+// 新增表或字段时在 migrations 末尾追加一条，表结构快照放在 migrations_schema.go，不要修改已发布的迁移
+type Migration struct {
+	Version     int64
+	Description string
+	Auto        bool // 只涉及可由播放记录重新计算的缓存表，MySQL 生产环境启动时同样自动执行
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+}
+
+// SchemaMigration 记录已执行的迁移版本
+type SchemaMigration struct {
+	Version     int64     `gorm:"column:version;type:bigint;primaryKey;autoIncrement:false" json:"version"`
+	Description string    `gorm:"column:description;type:varchar(255)" json:"description"`
+	AppliedAt   time.Time `gorm:"column:applied_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"applied_at"`
+}
+
+// TableName 自定义表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationState 迁移及其在当前库上的执行状态
+type MigrationState struct {
+	*Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrations 返回全部迁移，按版本升序
+func Migrations() []*Migration {
+	return append([]*Migration(nil), migrations...)
+}
+
+// MigrationStatus 列出全部迁移的执行状态
+func MigrationStatus(ctx context.Context, gormDB *gorm.DB) ([]MigrationState, error) {
+	applied, err := appliedMigrations(ctx, gormDB)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := MigrationState{Migration: m}
+		if record, ok := applied[m.Version]; ok {
+			state.Applied = true
+			state.AppliedAt = record.AppliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// MigrateUp 按版本顺序执行未执行的迁移，target 为 0 时执行到最新版本，返回本次执行的迁移
+func MigrateUp(ctx context.Context, gormDB *gorm.DB, target int64) ([]*Migration, error) {
+	return migrateUp(ctx, gormDB, func(m *Migration) bool { return target <= 0 || m.Version <= target })
+}
+
+// migrateAuto 只执行标记为 Auto 的缓存表迁移，供不自动执行迁移的 MySQL 生产环境启动时使用
+func migrateAuto(ctx context.Context, gormDB *gorm.DB) ([]*Migration, error) {
+	return migrateUp(ctx, gormDB, func(m *Migration) bool { return m.Auto })
+}
+
+func migrateUp(ctx context.Context, gormDB *gorm.DB, include func(m *Migration) bool) ([]*Migration, error) {
+	tx := gormDB.WithContext(ctx)
+	if err := tx.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, gormDB)
+	if err != nil {
+		return nil, err
+	}
+	var done []*Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok || !include(m) {
+			continue
+		}
+		// MySQL 的 DDL 会隐式提交，迁移不放在事务里，成功后再记录版本
+		if err := m.Up(tx); err != nil {
+			return done, fmt.Errorf("migration %d (%s) up: %w", m.Version, m.Description, err)
+		}
+		record := &SchemaMigration{Version: m.Version, Description: m.Description, AppliedAt: time.Now()}
+		if err := tx.Create(record).Error; err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown 按版本倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func MigrateDown(ctx context.Context, gormDB *gorm.DB, steps int) ([]*Migration, error) {
+	applied, err := appliedMigrations(ctx, gormDB)
+	if err != nil {
+		return nil, err
+	}
+	tx := gormDB.WithContext(ctx)
+	var done []*Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return done, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, ErrIrreversibleMigration)
+		}
+		if err := m.Down(tx); err != nil {
+			return done, fmt.Errorf("migration %d (%s) down: %w", m.Version, m.Description, err)
+		}
+		if err := tx.Delete(&SchemaMigration{}, m.Version).Error; err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// PendingMigrations 返回尚未执行的迁移
+func PendingMigrations(ctx context.Context, gormDB *gorm.DB) ([]*Migration, error) {
+	states, err := MigrationStatus(ctx, gormDB)
+	if err != nil {
+		return nil, err
+	}
+	var pending []*Migration
+	for _, state := range states {
+		if !state.Applied {
+			pending = append(pending, state.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// appliedMigrations 只读查询已执行的迁移，schema_migrations 不存在时视为全部未执行
+func appliedMigrations(ctx context.Context, gormDB *gorm.DB) (map[int64]*SchemaMigration, error) {
+	tx := gormDB.WithContext(ctx)
+	if !tx.Migrator().HasTable(&SchemaMigration{}) {
+		return map[int64]*SchemaMigration{}, nil
+	}
+	var records []*SchemaMigration
+	if err := tx.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]*SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
@@ -0,0 +1,267 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// migrations 全部结构迁移，按版本升序，只允许在末尾追加
+var migrations = []*Migration{
+	{
+		Version:     1,
+		Description: "baseline tables",
+		// 已有库执行时 AutoMigrate 只补齐缺失的表和字段
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(
+				&v1Track{}, &v1Album{}, &v1Genre{}, &v1TrackPlayRecord{},
+				&v1TrackAlbum{}, &v1ReleaseMB{}, &v1AlbumReleaseMB{},
+				&v1TrackInsight{}, &v1TrackInsightFeedback{}, &v1TrackLyrics{}, &v1LLMCallLog{},
+				&v1ScrobbleOutbox{}, &v1ImportCheckpoint{},
+			)
+		},
+	},
+	{
+		Version:     2,
+		Description: "rebuild dashboard stat tables",
+		// 统计表都是可由播放记录重新计算的缓存，缺失时建表，旧版本遗留的列与当前定义不一致时才重建，
+		// 取代运行时的 ensureTableAndColumns 与 rebuildBrokenDashboardStatTable
+		Auto: true,
+		Up: func(tx *gorm.DB) error {
+			for _, stat := range v2DashboardStatModels {
+				if err := ensureStatTable(tx, stat); err != nil {
+					return err
+				}
+			}
+			return createTrackRankStatIndexes(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(v2DashboardStatModels...)
+		},
+	},
+	{
+		Version:     3,
+		Description: "add disc_number to track unique index",
+		// 基线的 uidx_t_aatdntn 已包含 disc_number，版本 1 建表时即已就绪；
+		// 保留版本号以兼容已记录该版本的库，升级与回滚均无需改动索引
+		Up:   func(tx *gorm.DB) error { return nil },
+		Down: func(tx *gorm.DB) error { return nil },
+	},
+	{
+		Version:     4,
+		Description: "add scrobble_session table",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v4ScrobbleSession{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v4ScrobbleSession{})
+		},
+	},
+	{
+		Version:     5,
+		Description: "add listen_token table",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v5ListenToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v5ListenToken{})
+		},
+	},
+	{
+		Version:     6,
+		Description: "add play_event table and track skip/listened counters",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&v6PlayEvent{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"SkipCount", "TotalListenedSeconds"} {
+				if !tx.Migrator().HasColumn(&v6TrackCounters{}, column) {
+					if err := tx.Migrator().AddColumn(&v6TrackCounters{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"SkipCount", "TotalListenedSeconds"} {
+				if tx.Migrator().HasColumn(&v6TrackCounters{}, column) {
+					if err := tx.Migrator().DropColumn(&v6TrackCounters{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			if err := restoreIndexes(tx, &v1Track{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&v6PlayEvent{})
+		},
+	},
+	{
+		Version:     7,
+		Description: "add listening_session and session_weekday_stat tables",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v7ListeningSession{}, &v7SessionWeekdayStat{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v7ListeningSession{}, &v7SessionWeekdayStat{})
+		},
+	},
+	{
+		Version:     8,
+		Description: "add track/album rating, user_tag, top_rated_stat and tag_stat tables",
+		Up: func(tx *gorm.DB) error {
+			for _, target := range []interface{}{&v8TrackRating{}, &v8AlbumRating{}} {
+				if !tx.Migrator().HasColumn(target, "Rating") {
+					if err := tx.Migrator().AddColumn(target, "Rating"); err != nil {
+						return err
+					}
+				}
+			}
+			return tx.AutoMigrate(&v8UserTag{}, &v8TopRatedStat{}, &v8TagStat{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, target := range []interface{}{&v8TrackRating{}, &v8AlbumRating{}} {
+				if tx.Migrator().HasColumn(target, "Rating") {
+					if err := tx.Migrator().DropColumn(target, "Rating"); err != nil {
+						return err
+					}
+				}
+			}
+			for _, snapshot := range []interface{}{&v1Track{}, &v1Album{}} {
+				if err := restoreIndexes(tx, snapshot); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&v8UserTag{}, &v8TopRatedStat{}, &v8TagStat{})
+		},
+	},
+	{
+		Version:     9,
+		Description: "add track_alias table",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v9TrackAlias{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v9TrackAlias{})
+		},
+	},
+	{
+		Version:     10,
+		Description: "add artist and track_artist tables and backfill parsed credits",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&v10Artist{}, &v10TrackArtist{}); err != nil {
+				return err
+			}
+			return backfillTrackArtists(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v10TrackArtist{}, &v10Artist{})
+		},
+	},
+	{
+		Version:     11,
+		Description: "add rewrite_rule table",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v11RewriteRule{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v11RewriteRule{})
+		},
+	},
+	{
+		Version:     12,
+		Description: "add last_record_id to listening_session",
+		// 已有收听的游标为 0，升级后第一次统计会整体重建一次
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&v12ListeningSessionCursor{}, "LastRecordID") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&v12ListeningSessionCursor{}, "LastRecordID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&v12ListeningSessionCursor{}, "LastRecordID") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&v12ListeningSessionCursor{}, "LastRecordID")
+		},
+	},
+}
+
+// v2DashboardStatModels dashboard 统计表
+var v2DashboardStatModels = []interface{}{
+	&v2DashboardStat{}, &v2PlaySourceStat{}, &v2TopArtistStat{}, &v2TopAlbumStat{}, &v2TopGenreStat{},
+	&v2PlayTrendDailyStat{}, &v2PlayTrendHourlyStat{}, &v2TrackRankStat{},
+}
+
+// ensureStatTable 统计表不存在时建表；已有表的列与定义不一致时（旧版本遗留）丢弃重建，一致时保留已有数据
+func ensureStatTable(tx *gorm.DB, stat interface{}) error {
+	migrator := tx.Migrator()
+	if !migrator.HasTable(stat) {
+		return migrator.CreateTable(stat)
+	}
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(stat); err != nil {
+		return err
+	}
+	columnTypes, err := migrator.ColumnTypes(stat)
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]bool, len(columnTypes))
+	for _, columnType := range columnTypes {
+		columns[columnType.Name()] = true
+	}
+	matched := len(columns) == len(stmt.Schema.DBNames)
+	for _, name := range stmt.Schema.DBNames {
+		matched = matched && columns[name]
+	}
+	if matched {
+		return nil
+	}
+	if err := migrator.DropTable(stat); err != nil {
+		return err
+	}
+	return migrator.CreateTable(stat)
+}
+
+// restoreIndexes 补建快照中定义但已缺失的索引，SQLite 删除列时会重建表，原有索引随之丢失
+func restoreIndexes(tx *gorm.DB, snapshot interface{}) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(snapshot); err != nil {
+		return err
+	}
+	migrator := tx.Migrator()
+	for _, index := range stmt.Schema.ParseIndexes() {
+		if migrator.HasIndex(snapshot, index.Name) {
+			continue
+		}
+		if err := migrator.CreateIndex(snapshot, index.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTrackRankStatIndexes 为 MySQL 创建排行查询与全文检索使用的索引，SQLite 不需要
+func createTrackRankStatIndexes(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "mysql" {
+		return nil
+	}
+	migrator := tx.Migrator()
+	if !migrator.HasIndex(&v2TrackRankStat{}, "idx_track_rank_period_rank") {
+		if err := tx.Exec("ALTER TABLE track_rank_stat ADD INDEX idx_track_rank_period_rank (period_type, `rank`)").Error; err != nil {
+			return err
+		}
+	}
+	if !migrator.HasIndex(&v2TrackRankStat{}, "uk_track_rank_period_track") {
+		// utf8mb4 下联合唯一索引总长度不能超过 3072 bytes，使用前缀索引规避超长。
+		if err := tx.Exec("ALTER TABLE track_rank_stat ADD UNIQUE KEY uk_track_rank_period_track (period_type, artist(191), album(191), track(191))").Error; err != nil {
+			return err
+		}
+	}
+	if !migrator.HasIndex(&v2TrackRankStat{}, "idx_track_rank_stat_fts") {
+		if err := tx.Exec("ALTER TABLE track_rank_stat ADD FULLTEXT idx_track_rank_stat_fts(track, artist, album) WITH PARSER ngram").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
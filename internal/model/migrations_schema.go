@@ -0,0 +1,480 @@
+package model
+
+import "time"
+
+// 已发布迁移使用的表结构快照，与迁移发布时的模型定义一致。
+// 迁移只能引用这里的结构，模型后续的字段调整需要追加新的迁移，不能修改这里的定义
+
+// 版本 1：基线表
+type v1Track struct {
+	ID              int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Artist          string    `gorm:"column:artist;type:varchar(255);not null;uniqueIndex:uidx_t_aatdntn"`
+	Album           string    `gorm:"column:album;type:varchar(255);not null;index:idx_track_album;uniqueIndex:uidx_t_aatdntn"`
+	Track           string    `gorm:"column:track;type:varchar(255);not null;index:idx_track_track;uniqueIndex:uidx_t_aatdntn"`
+	PlayCount       int       `gorm:"column:play_count;type:int;default:0"`
+	IsAppleMusicFav bool      `gorm:"column:is_apple_music_fav;type:tinyint(1);default:0"`
+	IsLastFmFav     bool      `gorm:"column:is_last_fm_fav;type:tinyint(1);default:0"`
+	Version         int       `gorm:"column:version;type:int;default:1"`
+	AlbumArtist     string    `gorm:"column:album_artist;type:varchar(255)"`
+	TrackNumber     int8      `gorm:"column:track_number;type:tinyint;uniqueIndex:uidx_t_aatdntn"`
+	DiscNumber      int8      `gorm:"column:disc_number;type:tinyint;default:1;uniqueIndex:uidx_t_aatdntn"`
+	Duration        int64     `gorm:"column:duration;type:int"`
+	Genre           string    `gorm:"column:genre;type:varchar(255);index:idx_track_genre"`
+	Composer        string    `gorm:"column:composer;type:varchar(255)"`
+	ReleaseDate     string    `gorm:"column:release_date;type:varchar(50)"`
+	MusicBrainzID   string    `gorm:"column:music_brainz_id;type:varchar(255)"`
+	Source          string    `gorm:"column:source;type:varchar(255);index:idx_track_source"`
+	BundleID        string    `gorm:"column:bundle_id;type:varchar(255)"`
+	UniqueID        string    `gorm:"column:unique_id;type:varchar(255);index:idx_track_unique_id"`
+	CreatedAt       time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1Track) TableName() string { return "track" }
+
+type v1Album struct {
+	ID          int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Name        string    `gorm:"column:name;type:varchar(255);not null;uniqueIndex:uidx_album_artist_name_release_date"`
+	Artist      string    `gorm:"column:artist;type:varchar(255);not null;uniqueIndex:uidx_album_artist_name_release_date"`
+	ReleaseDate string    `gorm:"column:release_date;type:varchar(50);uniqueIndex:uidx_album_artist_name_release_date"`
+	Genre       string    `gorm:"column:genre;type:varchar(255)"`
+	Country     string    `gorm:"column:country;type:varchar(50)"`
+	Status      string    `gorm:"column:status;type:varchar(50)"`
+	Packaging   string    `gorm:"column:packaging;type:varchar(50)"`
+	Barcode     string    `gorm:"column:barcode;type:varchar(255)"`
+	TotalDiscs  int       `gorm:"column:total_discs;type:int;default:1"`
+	DiscInfos   string    `gorm:"column:disc_infos;type:varchar(255)"`
+	SyncStatus  int       `gorm:"column:sync_status;type:tinyint;default:0"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1Album) TableName() string { return "album" }
+
+type v1Genre struct {
+	ID        int64     `gorm:"column:id;type:int;primaryKey;autoIncrement"`
+	Name      string    `gorm:"column:name;type:varchar(255);not null;unique;index:idx_genre_name"`
+	NameZh    string    `gorm:"column:name_zh;type:varchar(255)"`
+	Extra     string    `gorm:"column:extra;type:text"`
+	PlayCount int64     `gorm:"column:play_count;type:bigint"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1Genre) TableName() string { return "genre" }
+
+type v1TrackPlayRecord struct {
+	ID            int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Artist        string    `gorm:"column:artist;type:varchar(255);not null;index:idx_track_play_records_artist"`
+	AlbumArtist   string    `gorm:"column:album_artist;type:varchar(255)"`
+	Track         string    `gorm:"column:track;type:varchar(255);not null"`
+	Album         string    `gorm:"column:album;type:varchar(255);not null"`
+	AlbumID       int64     `gorm:"column:album_id;type:bigint;default:0;index:idx_track_play_records_album_id"`
+	Duration      int64     `gorm:"column:duration;type:int"`
+	PlayTime      time.Time `gorm:"column:play_time;type:timestamp;not null;default:CURRENT_TIMESTAMP"`
+	Scrobbled     bool      `gorm:"column:scrobbled;type:tinyint(1);not null;default:0;index:idx_track_play_records_scrobbled"`
+	MusicBrainzID string    `gorm:"column:music_brainz_id;type:varchar(255)"`
+	TrackNumber   int8      `gorm:"column:track_number;type:tinyint"`
+	Source        string    `gorm:"column:source;type:varchar(100);not null;index:idx_track_play_records_source"`
+	CreatedAt     time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1TrackPlayRecord) TableName() string { return "track_play_records" }
+
+type v1TrackAlbum struct {
+	ID                     int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TrackID                int64     `gorm:"column:track_id;type:bigint;not null;index:idx_ta_track_album"`
+	AlbumID                int64     `gorm:"column:album_id;type:bigint;not null;index:idx_ta_track_album;index:idx_ta_album_id"`
+	TrackNumber            int8      `gorm:"column:track_number;type:tinyint"`
+	DiscNumber             int8      `gorm:"column:disc_number;type:tinyint;default:1"`
+	MusicBrainzRecordingID string    `gorm:"column:mb_recording_id;type:varchar(255)"`
+	Track                  string    `gorm:"column:track;type:varchar(255)"`
+	CreatedAt              time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt              time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1TrackAlbum) TableName() string { return "track_album" }
+
+type v1ReleaseMB struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	MBID      string    `gorm:"column:mbid;type:varchar(255);not null;index:idx_release_mbid"`
+	AlbumID   int64     `gorm:"column:album_id;type:bigint;not null;index:idx_release_mbid_album"`
+	Name      string    `gorm:"column:name;type:varchar(255)"`
+	JSONData  string    `gorm:"column:json_data;type:longtext"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1ReleaseMB) TableName() string { return "release_mb" }
+
+type v1AlbumReleaseMB struct {
+	ID          int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	AlbumID     int64     `gorm:"column:album_id;type:bigint;not null;uniqueIndex:uidx_album_release"`
+	ReleaseMBID int64     `gorm:"column:release_mb_id;type:bigint;not null;uniqueIndex:uidx_album_release"`
+	MBID        string    `gorm:"column:mbid;type:varchar(255);not null"`
+	Confirmed   bool      `gorm:"column:confirmed;type:tinyint(1);default:1"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v1AlbumReleaseMB) TableName() string { return "album_release_mb" }
+
+type v1TrackInsight struct {
+	ID                int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TrackID           int64     `gorm:"column:track_id;type:bigint;index"`
+	Artist            string    `gorm:"column:artist;type:varchar(255);index:idx_insight_artist_album_track"`
+	Album             string    `gorm:"column:album;type:varchar(255);index:idx_insight_artist_album_track"`
+	Track             string    `gorm:"column:track;type:varchar(255);index:idx_insight_artist_album_track"`
+	LyricsTranslation string    `gorm:"column:lyrics_translation;type:text"`
+	AnalysisSummary   string    `gorm:"column:analysis_summary;type:text"`
+	AnalysisBySection string    `gorm:"type:text"`
+	BackgroundInfo    string    `gorm:"column:background_info;type:text"`
+	EraContext        string    `gorm:"column:era_context;type:text"`
+	LLMProvider       string    `gorm:"column:llm_provider;type:varchar(255)"`
+	LangSource        string    `gorm:"column:lang_source;type:varchar(32)"`
+	LangTarget        string    `gorm:"column:lang_target;type:varchar(32)"`
+	Metadata          string    `gorm:"column:metadata;type:text"`
+	LikeCount         int64     `gorm:"column:like_count;type:bigint;default:0"`
+	DislikeCount      int64     `gorm:"column:dislike_count;type:bigint;default:0"`
+	CreatedAt         time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt         time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+	LastUsedAt        time.Time `gorm:"column:last_used_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	IsDisabled        bool      `gorm:"column:is_disabled;type:tinyint(1);default:0;index"`
+}
+
+func (v1TrackInsight) TableName() string { return "track_insight" }
+
+type v1TrackInsightFeedback struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	InsightID int64     `gorm:"column:insight_id;type:bigint;index"`
+	Score     int       `gorm:"column:score;type:int"`
+	Comment   string    `gorm:"column:comment;type:text"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v1TrackInsightFeedback) TableName() string { return "track_insight_feedbacks" }
+
+type v1TrackLyrics struct {
+	ID             int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TrackID        int64     `gorm:"column:track_id;type:bigint;index"`
+	Artist         string    `gorm:"column:artist;type:varchar(255);uniqueIndex:idx_lyrics_artist_album_track"`
+	Album          string    `gorm:"column:album;type:varchar(255);uniqueIndex:idx_lyrics_artist_album_track"`
+	Track          string    `gorm:"column:track;type:varchar(255);uniqueIndex:idx_lyrics_artist_album_track"`
+	LyricsOriginal string    `gorm:"column:lyrics_original;type:text"`
+	LyricsSource   string    `gorm:"column:lyrics_source;type:varchar(64)"`
+	LangCode       string    `gorm:"column:lang_code;type:varchar(16)"`
+	Synced         bool      `gorm:"column:synced;type:tinyint(1);default:0"`
+	CreatedAt      time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1TrackLyrics) TableName() string { return "track_lyrics" }
+
+type v1LLMCallLog struct {
+	ID           int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Provider     string    `gorm:"column:provider;type:varchar(64);index"`
+	Model        string    `gorm:"column:model;type:varchar(128)"`
+	RequestJSON  string    `gorm:"column:request_json;type:text"`
+	ResponseJSON string    `gorm:"column:response_json;type:text"`
+	Status       string    `gorm:"column:status;type:varchar(32);index"`
+	ErrorMsg     string    `gorm:"column:error_msg;type:text"`
+	DurationMs   int64     `gorm:"column:duration_ms;type:bigint"`
+	TrackInfo    string    `gorm:"column:track_info;type:varchar(512);index"`
+	CallType     string    `gorm:"column:call_type;type:varchar(32)"`
+	CreatedAt    time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v1LLMCallLog) TableName() string { return "llm_call_logs" }
+
+type v1ScrobbleOutbox struct {
+	ID            int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	RecordID      int64     `gorm:"column:record_id;type:bigint;not null;uniqueIndex:idx_scrobble_outbox_record_target"`
+	Target        string    `gorm:"column:target;type:varchar(32);not null;uniqueIndex:idx_scrobble_outbox_record_target;index:idx_scrobble_outbox_due"`
+	Status        string    `gorm:"column:status;type:varchar(16);not null;default:'pending';index:idx_scrobble_outbox_due"`
+	Attempts      int       `gorm:"column:attempts;type:int;not null;default:0"`
+	NextAttemptAt time.Time `gorm:"column:next_attempt_at;type:timestamp;not null;default:CURRENT_TIMESTAMP;index:idx_scrobble_outbox_due"`
+	ErrorCode     int       `gorm:"column:error_code;type:int;not null;default:0"`
+	LastError     string    `gorm:"column:last_error;type:varchar(1024)"`
+	CreatedAt     time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1ScrobbleOutbox) TableName() string { return "scrobble_outbox" }
+
+type v1ImportCheckpoint struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Source    string    `gorm:"column:source;type:varchar(191);not null;uniqueIndex:idx_import_checkpoint_source"`
+	State     string    `gorm:"column:state;type:text"`
+	Imported  int64     `gorm:"column:imported;type:bigint;not null;default:0"`
+	Skipped   int64     `gorm:"column:skipped;type:bigint;not null;default:0"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v1ImportCheckpoint) TableName() string { return "import_checkpoint" }
+
+// 版本 2：dashboard 统计表
+type v2DashboardStat struct {
+	ID          int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TotalPlays  int64     `gorm:"column:total_plays;type:bigint;default:0"`
+	TotalTracks int64     `gorm:"column:total_tracks;type:bigint;default:0"`
+	TotalArtist int64     `gorm:"column:total_artist;type:bigint;default:0"`
+	TotalAlbums int64     `gorm:"column:total_albums;type:bigint;default:0"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v2DashboardStat) TableName() string { return "dashboard_stat" }
+
+type v2PlaySourceStat struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Source    string    `gorm:"column:source;type:varchar(100);not null;uniqueIndex:uk_source"`
+	Count     int64     `gorm:"column:count;type:bigint;default:0"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v2PlaySourceStat) TableName() string { return "play_source_stat" }
+
+type v2TopArtistStat struct {
+	ID          int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	PeriodDays  int       `gorm:"column:period_days;type:int;not null;default:0"`
+	MetricType  string    `gorm:"column:metric_type;type:varchar(20);not null"`
+	Artist      string    `gorm:"column:artist;type:varchar(255);not null"`
+	MetricValue int64     `gorm:"column:metric_value;type:bigint;default:0"`
+	Rank        int       `gorm:"column:rank;type:int;not null"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v2TopArtistStat) TableName() string { return "top_artist_stat" }
+
+type v2TopAlbumStat struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	PeriodDays int       `gorm:"column:period_days;type:int;not null"`
+	AlbumID    int64     `gorm:"column:album_id;type:bigint;index"`
+	Album      string    `gorm:"column:album;type:varchar(255);not null"`
+	Artist     string    `gorm:"column:artist;type:varchar(255);default:''"`
+	PlayCount  int64     `gorm:"column:play_count;type:bigint;default:0"`
+	Rank       int       `gorm:"column:rank;type:int;not null"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v2TopAlbumStat) TableName() string { return "top_album_stat" }
+
+type v2TopGenreStat struct {
+	ID              int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	GenreName       string    `gorm:"column:genre_name;type:varchar(255);not null;uniqueIndex:uk_genre_name"`
+	GenreNameZh     string    `gorm:"column:genre_name_zh;type:varchar(255);default:''"`
+	TrackGenreCount int64     `gorm:"column:track_genre_count;type:bigint;default:0"`
+	GenreCount      int64     `gorm:"column:genre_count;type:bigint;default:0"`
+	Rank            int       `gorm:"column:rank;type:int;not null"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v2TopGenreStat) TableName() string { return "top_genre_stat" }
+
+type v2PlayTrendDailyStat struct {
+	StatDate  time.Time `gorm:"column:stat_date;type:date;primaryKey"`
+	PlayCount int64     `gorm:"column:play_count;type:bigint;default:0"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v2PlayTrendDailyStat) TableName() string { return "play_trend_daily_stat" }
+
+type v2PlayTrendHourlyStat struct {
+	StatDate  time.Time `gorm:"column:stat_date;type:date;primaryKey"`
+	Hour      int       `gorm:"column:hour;type:tinyint;primaryKey"`
+	PlayCount int64     `gorm:"column:play_count;type:bigint;default:0"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v2PlayTrendHourlyStat) TableName() string { return "play_trend_hourly_stat" }
+
+type v2TrackRankStat struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	PeriodType string    `gorm:"column:period_type;type:varchar(20);not null"`
+	Artist     string    `gorm:"column:artist;type:varchar(255);not null"`
+	Album      string    `gorm:"column:album;type:varchar(255);not null"`
+	Track      string    `gorm:"column:track;type:varchar(255);not null"`
+	PlayCount  int64     `gorm:"column:play_count;type:bigint;default:0"`
+	Rank       int       `gorm:"column:rank;type:int;not null"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v2TrackRankStat) TableName() string { return "track_rank_stat" }
+
+// 版本 4-11：新增的表
+type v4ScrobbleSession struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	SessionKey string    `gorm:"column:session_key;type:varchar(64);not null;uniqueIndex:idx_scrobble_session_key"`
+	ApiKey     string    `gorm:"column:api_key;type:varchar(64);not null"`
+	Username   string    `gorm:"column:username;type:varchar(255);not null"`
+	CreatedAt  time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v4ScrobbleSession) TableName() string { return "scrobble_session" }
+
+type v5ListenToken struct {
+	ID         int64      `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Name       string     `gorm:"column:name;type:varchar(100);not null;uniqueIndex:idx_listen_token_name"`
+	Token      string     `gorm:"column:token;type:varchar(64);not null;uniqueIndex:idx_listen_token_token"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;type:timestamp;null"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v5ListenToken) TableName() string { return "listen_token" }
+
+type v6PlayEvent struct {
+	ID              int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Artist          string    `gorm:"column:artist;type:varchar(255);not null;index:idx_play_event_artist"`
+	AlbumArtist     string    `gorm:"column:album_artist;type:varchar(255)"`
+	Album           string    `gorm:"column:album;type:varchar(255)"`
+	Track           string    `gorm:"column:track;type:varchar(255);not null"`
+	Genre           string    `gorm:"column:genre;type:varchar(255);index:idx_play_event_genre"`
+	Source          string    `gorm:"column:source;type:varchar(100);not null"`
+	Duration        int64     `gorm:"column:duration;type:int"`
+	StartedAt       time.Time `gorm:"column:started_at;type:timestamp;not null;index:idx_play_event_started_at"`
+	EndedAt         time.Time `gorm:"column:ended_at;type:timestamp;not null"`
+	EndReason       string    `gorm:"column:end_reason;type:varchar(32);not null;index:idx_play_event_end_reason"`
+	EndPosition     int64     `gorm:"column:end_position;type:int"`
+	ListenedSeconds int64     `gorm:"column:listened_seconds;type:int"`
+	Seeks           string    `gorm:"column:seeks;type:text"`
+	Scrobbled       bool      `gorm:"column:scrobbled;type:tinyint(1);not null;default:0"`
+	CreatedAt       time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v6PlayEvent) TableName() string { return "play_event" }
+
+type v7ListeningSession struct {
+	ID              int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Source          string    `gorm:"column:source;type:varchar(100);not null;index:idx_listening_session_source"`
+	StartedAt       time.Time `gorm:"column:started_at;type:timestamp;not null;index:idx_listening_session_started_at"`
+	EndedAt         time.Time `gorm:"column:ended_at;type:timestamp;not null"`
+	DurationSeconds int64     `gorm:"column:duration_seconds;type:bigint"`
+	TrackCount      int       `gorm:"column:track_count;type:int"`
+	DominantGenre   string    `gorm:"column:dominant_genre;type:varchar(255)"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v7ListeningSession) TableName() string { return "listening_session" }
+
+type v7SessionWeekdayStat struct {
+	Weekday      int       `gorm:"column:weekday;type:tinyint;primaryKey;autoIncrement:false"`
+	SessionCount int64     `gorm:"column:session_count;type:bigint;default:0"`
+	TotalSeconds int64     `gorm:"column:total_seconds;type:bigint;default:0"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v7SessionWeekdayStat) TableName() string { return "session_weekday_stat" }
+
+type v8UserTag struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TargetType string    `gorm:"column:target_type;type:varchar(20);not null;uniqueIndex:uidx_user_tag_target_tag;index:idx_user_tag_tag,priority:2"`
+	TargetID   int64     `gorm:"column:target_id;type:bigint;not null;uniqueIndex:uidx_user_tag_target_tag"`
+	Tag        string    `gorm:"column:tag;type:varchar(100);not null;uniqueIndex:uidx_user_tag_target_tag;index:idx_user_tag_tag,priority:1"`
+	CreatedAt  time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v8UserTag) TableName() string { return "user_tag" }
+
+type v8TopRatedStat struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TargetType string    `gorm:"column:target_type;type:varchar(20);not null;index:idx_top_rated_type_rank"`
+	TargetID   int64     `gorm:"column:target_id;type:bigint;not null"`
+	Artist     string    `gorm:"column:artist;type:varchar(255);not null"`
+	Album      string    `gorm:"column:album;type:varchar(255);not null"`
+	Track      string    `gorm:"column:track;type:varchar(255);default:''"`
+	Rating     int8      `gorm:"column:rating;type:tinyint;not null"`
+	PlayCount  int64     `gorm:"column:play_count;type:bigint;default:0"`
+	Rank       int       `gorm:"column:rank;type:int;not null;index:idx_top_rated_type_rank"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v8TopRatedStat) TableName() string { return "top_rated_stat" }
+
+type v8TagStat struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TargetType string    `gorm:"column:target_type;type:varchar(20);not null;index:idx_tag_stat_type_rank"`
+	Tag        string    `gorm:"column:tag;type:varchar(100);not null"`
+	ItemCount  int64     `gorm:"column:item_count;type:bigint;default:0"`
+	PlayCount  int64     `gorm:"column:play_count;type:bigint;default:0"`
+	AvgRating  float64   `gorm:"column:avg_rating;type:double;default:0"`
+	Rank       int       `gorm:"column:rank;type:int;not null;index:idx_tag_stat_type_rank"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v8TagStat) TableName() string { return "tag_stat" }
+
+type v9TrackAlias struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TrackID   int64     `gorm:"column:track_id;type:bigint;not null;index:idx_track_alias_track_id"`
+	Artist    string    `gorm:"column:artist;type:varchar(255);not null;uniqueIndex:uidx_track_alias_aat"`
+	Album     string    `gorm:"column:album;type:varchar(255);not null;uniqueIndex:uidx_track_alias_aat"`
+	Track     string    `gorm:"column:track;type:varchar(255);not null;uniqueIndex:uidx_track_alias_aat"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v9TrackAlias) TableName() string { return "track_alias" }
+
+type v10Artist struct {
+	ID            int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Name          string    `gorm:"column:name;type:varchar(255);not null;uniqueIndex:uidx_artist_name"`
+	MusicBrainzID string    `gorm:"column:music_brainz_id;type:varchar(64);index:idx_artist_mbid"`
+	CreatedAt     time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v10Artist) TableName() string { return "artist" }
+
+type v10TrackArtist struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	TrackID   int64     `gorm:"column:track_id;type:bigint;not null;uniqueIndex:uidx_track_artist_role"`
+	ArtistID  int64     `gorm:"column:artist_id;type:bigint;not null;uniqueIndex:uidx_track_artist_role;index:idx_track_artist_artist"`
+	Role      string    `gorm:"column:role;type:varchar(20);not null;uniqueIndex:uidx_track_artist_role"`
+	Position  int       `gorm:"column:position;type:int;not null;default:0"`
+	Source    string    `gorm:"column:source;type:varchar(20);not null;default:'parsed'"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (v10TrackArtist) TableName() string { return "track_artist" }
+
+type v11RewriteRule struct {
+	ID          int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement"`
+	Name        string    `gorm:"column:name;type:varchar(255);not null"`
+	Enabled     bool      `gorm:"column:enabled;type:tinyint(1);not null;default:0"`
+	Priority    int       `gorm:"column:priority;type:int;not null;default:0"`
+	MatchSource string    `gorm:"column:match_source;type:varchar(255)"`
+	MatchArtist string    `gorm:"column:match_artist;type:varchar(255)"`
+	MatchAlbum  string    `gorm:"column:match_album;type:varchar(255)"`
+	MatchTitle  string    `gorm:"column:match_title;type:varchar(255)"`
+	Actions     string    `gorm:"column:actions;type:text"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+func (v11RewriteRule) TableName() string { return "rewrite_rule" }
+
+// 版本 6、8：为已有表追加的列
+type v6TrackCounters struct {
+	SkipCount            int   `gorm:"column:skip_count;type:int;not null;default:0"`
+	TotalListenedSeconds int64 `gorm:"column:total_listened_seconds;type:bigint;not null;default:0"`
+}
+
+func (v6TrackCounters) TableName() string { return "track" }
+
+type v8TrackRating struct {
+	Rating int8 `gorm:"column:rating;type:tinyint;not null;default:0"`
+}
+
+func (v8TrackRating) TableName() string { return "track" }
+
+type v8AlbumRating struct {
+	Rating int8 `gorm:"column:rating;type:tinyint;not null;default:0"`
+}
+
+func (v8AlbumRating) TableName() string { return "album" }
+
+// 版本 12：收听重建游标
+type v12ListeningSessionCursor struct {
+	LastRecordID int64 `gorm:"column:last_record_id;type:bigint;not null;default:0"`
+}
+
+func (v12ListeningSessionCursor) TableName() string { return "listening_session" }
@@ -2,9 +2,14 @@ package model
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"github.com/vincentchyu/sonic-lens/common"
 )
@@ -35,3 +40,526 @@ func TestIncrementTrackPlayCountValidation(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "艺术家名称不能为空", err.Error())
 }
+
+// 已有 SQLite 库再次启动时重复执行迁移不应报错，回滚后可以重新执行
+func openMigrationTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s_%d?mode=memory&cache=shared", t.Name(), testDBSeq.Add(1))
+	gormDB, err := OpenDB(string(common.DatabaseTypeSQLite), dsn, zap.NewNop())
+	require.NoError(t, err)
+	return gormDB
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	ctx := context.Background()
+	gormDB := openMigrationTestDB(t)
+
+	// 查看状态是只读操作，不会建 schema_migrations
+	pending, err := PendingMigrations(ctx, gormDB)
+	require.NoError(t, err)
+	assert.Len(t, pending, len(migrations))
+	assert.False(t, gormDB.Migrator().HasTable(&SchemaMigration{}))
+
+	done, err := MigrateUp(ctx, gormDB, 0)
+	require.NoError(t, err)
+	assert.Len(t, done, len(migrations))
+	done, err = MigrateUp(ctx, gormDB, 0)
+	require.NoError(t, err)
+	assert.Empty(t, done)
+	// 模拟升级前的旧库：已执行的迁移再次执行 AutoMigrate 不应重建表
+	require.NoError(t, migrations[0].Up(gormDB))
+	// 多碟专辑中同名同轨号的曲目，回滚时唯一索引保持包含 disc_number
+	for disc := int8(1); disc <= 2; disc++ {
+		require.NoError(
+			t, gormDB.Create(&Track{Artist: "A", Album: "X", Track: "Intro", TrackNumber: 1, DiscNumber: disc}).Error,
+		)
+	}
+
+	// 回滚到只剩 baseline
+	done, err = MigrateDown(ctx, gormDB, len(migrations)-1)
+	require.NoError(t, err)
+	assert.True(t, gormDB.Migrator().HasIndex(&v1Track{}, "uidx_t_aatdntn"))
+	assert.True(t, gormDB.Migrator().HasIndex(&v1Album{}, "uidx_album_artist_name_release_date"))
+	require.Len(t, done, len(migrations)-1)
+	assert.Equal(t, migrations[len(migrations)-1].Version, done[0].Version)
+	assert.False(t, gormDB.Migrator().HasTable(&DashboardStat{}))
+	assert.False(t, gormDB.Migrator().HasTable(&ScrobbleSession{}))
+
+	states, err := MigrationStatus(ctx, gormDB)
+	require.NoError(t, err)
+	assert.True(t, states[0].Applied)
+	for _, state := range states[1:] {
+		assert.False(t, state.Applied)
+	}
+
+	done, err = MigrateUp(ctx, gormDB, 2)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.True(t, gormDB.Migrator().HasTable(&DashboardStat{}))
+	pending, err = PendingMigrations(ctx, gormDB)
+	require.NoError(t, err)
+	require.Len(t, pending, len(migrations)-2)
+
+	_, err = MigrateDown(ctx, gormDB, len(migrations))
+	assert.ErrorIs(t, err, ErrIrreversibleMigration)
+}
+
+func TestMigrateAutoStatTables(t *testing.T) {
+	ctx := context.Background()
+	gormDB := openMigrationTestDB(t)
+
+	// 旧版本遗留的统计表缺少列，另一张统计表结构一致且已有数据
+	require.NoError(t, gormDB.Exec("CREATE TABLE dashboard_stat (id integer PRIMARY KEY, total_plays bigint)").Error)
+	require.NoError(t, gormDB.Migrator().CreateTable(&v2PlaySourceStat{}))
+	require.NoError(t, gormDB.Create(&PlaySourceStat{ID: 1, Source: "Roon", Count: 3}).Error)
+
+	// 生产环境启动时只执行统计表迁移
+	done, err := migrateAuto(ctx, gormDB)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.EqualValues(t, 2, done[0].Version)
+	assert.True(t, gormDB.Migrator().HasColumn(&DashboardStat{}, "TotalAlbums"), "legacy table is rebuilt")
+	var sources []*PlaySourceStat
+	require.NoError(t, gormDB.Find(&sources).Error)
+	require.Len(t, sources, 1, "matching table keeps its rows")
+	assert.False(t, gormDB.Migrator().HasTable(&ScrobbleSession{}))
+
+	pending, err := PendingMigrations(ctx, gormDB)
+	require.NoError(t, err)
+	assert.Len(t, pending, len(migrations)-1)
+	done, err = MigrateUp(ctx, gormDB, 0)
+	require.NoError(t, err)
+	assert.Len(t, done, len(migrations)-1)
+	assert.True(t, gormDB.Migrator().HasColumn(&Track{}, "SkipCount"))
+	assert.True(t, gormDB.Migrator().HasColumn(&Track{}, "Rating"))
+}
+
+func TestPlayEventSkipStats(t *testing.T) {
+	ctx := context.Background()
+	SetupTestDB(t)
+
+	require.NoError(
+		t, GetDB().Create(&Track{Artist: "A", Album: "X", Track: "one", PlayCount: 1, TrackNumber: 1, DiscNumber: 1}).Error,
+	)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local)
+	events := []*PlayEvent{
+		{Artist: "A", Album: "X", Track: "one", Genre: "Rock", EndReason: PlayEndSkipped, ListenedSeconds: 20},
+		{Artist: "A", Album: "X", Track: "one", Genre: "Rock", EndReason: PlayEndCompleted, ListenedSeconds: 200, Scrobbled: true},
+		{Artist: "A", Album: "X", Track: "two", Genre: "Rock", EndReason: PlayEndSkipped, ListenedSeconds: 5},
+		{Artist: "B", Album: "Y", Track: "three", Genre: "Jazz", EndReason: PlayEndStopped, ListenedSeconds: 60,
+			Seeks: []PlaySeek{{From: 10, To: 90}}},
+	}
+	for i, event := range events {
+		event.Source = "MPD"
+		event.StartedAt = start.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, SavePlayEvent(ctx, event))
+	}
+
+	// 只累加已入库的曲目
+	track, err := GetTrack(ctx, "A", "X", "one")
+	require.NoError(t, err)
+	assert.Equal(t, 1, track.SkipCount)
+	assert.EqualValues(t, 220, track.TotalListenedSeconds)
+
+	var saved PlayEvent
+	require.NoError(t, GetDB().Where("track = ?", "three").First(&saved).Error)
+	assert.Equal(t, []PlaySeek{{From: 10, To: 90}}, saved.Seeks)
+
+	skipped, err := GetMostSkippedTracks(ctx, time.Time{}, 10)
+	require.NoError(t, err)
+	require.Len(t, skipped, 2)
+	assert.Equal(t, "two", skipped[0].Track, "same skips, fewer plays first")
+	assert.InDelta(t, 1.0, skipped[0].SkipRate, 0.001)
+	assert.Equal(t, "one", skipped[1].Track)
+	assert.EqualValues(t, 2, skipped[1].Plays)
+	assert.InDelta(t, 0.5, skipped[1].SkipRate, 0.001)
+
+	skipped, err = GetMostSkippedTracks(ctx, start.Add(90*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "two", skipped[0].Track)
+
+	rates, err := GetSkipRates(ctx, "artist", time.Time{}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	assert.Equal(t, "A", rates[0].Name)
+	assert.EqualValues(t, 3, rates[0].Plays)
+	assert.EqualValues(t, 2, rates[0].Skips)
+	assert.EqualValues(t, 225, rates[0].ListenedSeconds)
+	assert.Equal(t, "B", rates[1].Name)
+	assert.Zero(t, rates[1].SkipRate)
+
+	rates, err = GetSkipRates(ctx, "genre", time.Time{}, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, rates, 1)
+	assert.Equal(t, "Rock", rates[0].Name)
+
+	_, err = GetSkipRates(ctx, "album; DROP TABLE track", time.Time{}, 1, 10)
+	assert.Error(t, err)
+}
+
+func TestListeningSessions(t *testing.T) {
+	ctx := context.Background()
+	SetupTestDB(t)
+
+	tracks := []*Track{
+		{Artist: "A", Album: "X", Track: "one", Genre: "Rock", TrackNumber: 1, DiscNumber: 1},
+		{Artist: "A", Album: "X", Track: "two", Genre: "Rock", TrackNumber: 2, DiscNumber: 1},
+		{Artist: "B", Album: "Y", Track: "three", Genre: "Jazz", TrackNumber: 1, DiscNumber: 1},
+	}
+	require.NoError(t, GetDB().Create(&tracks).Error)
+	// 周五晚上
+	night := time.Date(2025, 1, 3, 20, 0, 0, 0, time.Local)
+	play := func(track *Track, source string, at time.Time) {
+		t.Helper()
+		require.NoError(
+			t, InsertTrackPlayRecord(
+				ctx, &TrackPlayRecord{
+					Artist: track.Artist, Album: track.Album, Track: track.Track, Duration: 200, PlayTime: at, Source: source,
+				},
+			),
+		)
+	}
+	play(tracks[0], "Roon", night)
+	play(tracks[2], "Roon", night.Add(4*time.Minute))
+	play(tracks[1], "Roon", night.Add(8*time.Minute))
+	play(tracks[0], "MPD", night.Add(10*time.Minute)) // 其他来源单独划分
+	play(tracks[2], "Roon", night.Add(2*time.Hour))
+
+	cfg := GetDashboardStatRuntimeConfig()
+	require.NoError(t, refreshDashboardStatsHeavyWithOptions(ctx, cfg))
+
+	sessions, total, err := GetListeningSessions(ctx, "Roon", 10, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, total)
+	last := sessions[1]
+	assert.Equal(t, 3, last.TrackCount)
+	assert.EqualValues(t, 8*60+200, last.DurationSeconds)
+	assert.Equal(t, "Rock", last.DominantGenre)
+	assert.Equal(t, 1, sessions[0].TrackCount)
+
+	records, err := GetListeningSessionRecords(ctx, last)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "two", records[2].Track)
+
+	// 最后一次收听延续时重新划分，已结束的收听保持不变
+	play(tracks[1], "Roon", night.Add(2*time.Hour+5*time.Minute))
+	require.NoError(t, refreshDashboardStatsHeavyWithOptions(ctx, cfg))
+	sessions, total, err = GetListeningSessions(ctx, "", 10, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, total)
+	assert.Equal(t, 2, sessions[0].TrackCount)
+	assert.Equal(t, last.ID, sessions[2].ID)
+
+	stats, err := GetListeningSessionStatsFromStat(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, stats.SessionCount)
+	require.Len(t, stats.Weekdays, 7)
+	assert.EqualValues(t, 3, stats.Weekdays[time.Friday].SessionCount)
+	assert.EqualValues(t, (680+200+500)/3, stats.AverageSeconds)
+
+	// 补录的播放记录早于最后一次收听时，从受影响的收听起重建：20:40 距第一次收听结束不超过 30 分钟，并入其中
+	play(tracks[2], "Roon", night.Add(40*time.Minute))
+	require.NoError(t, refreshDashboardStatsHeavyWithOptions(ctx, cfg))
+	sessions, total, err = GetListeningSessions(ctx, "Roon", 10, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, total)
+	assert.Equal(t, 4, sessions[1].TrackCount)
+	assert.EqualValues(t, 40*60+200, sessions[1].DurationSeconds)
+	assert.Equal(t, 2, sessions[0].TrackCount, "later session is kept")
+}
+
+func TestRatingsAndTags(t *testing.T) {
+	ctx := context.Background()
+	SetupTestDB(t)
+
+	tracks := []*Track{
+		{Artist: "A", Album: "X", Track: "one", TrackNumber: 1, DiscNumber: 1, PlayCount: 5},
+		{Artist: "A", Album: "X", Track: "two", TrackNumber: 2, DiscNumber: 1, PlayCount: 9},
+		{Artist: "B", Album: "Y", Track: "three", TrackNumber: 1, DiscNumber: 1, PlayCount: 1},
+	}
+	require.NoError(t, GetDB().Create(&tracks).Error)
+	album := &Album{Name: "X", Artist: "A"}
+	require.NoError(t, GetDB().Create(album).Error)
+
+	assert.ErrorIs(t, SetRating(ctx, TagTargetTrack, tracks[0].ID, 6), ErrInvalidRating)
+	assert.ErrorIs(t, SetRating(ctx, TagTargetTrack, 999, 3), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, SetRating(ctx, "artist", tracks[0].ID, 3), ErrInvalidTagTarget)
+	require.NoError(t, SetRating(ctx, TagTargetTrack, tracks[0].ID, 5))
+	require.NoError(t, SetRating(ctx, TagTargetTrack, tracks[1].ID, 5))
+	require.NoError(t, SetRating(ctx, TagTargetTrack, tracks[2].ID, 2))
+	require.NoError(t, SetRating(ctx, TagTargetAlbum, album.ID, 4))
+
+	ids := []int64{tracks[0].ID, tracks[1].ID, tracks[1].ID}
+	require.NoError(t, AddTags(ctx, TagTargetTrack, ids, []string{"  Late   Night ", "jazz", "late night"}))
+	require.NoError(t, AddTags(ctx, TagTargetTrack, []int64{tracks[2].ID}, []string{"Jazz"}))
+	require.NoError(t, AddTags(ctx, TagTargetAlbum, []int64{album.ID}, []string{"jazz"}))
+	assert.ErrorIs(t, AddTags(ctx, TagTargetTrack, []int64{tracks[0].ID}, []string{" "}), ErrInvalidTag)
+	assert.ErrorIs(t, AddTags(ctx, TagTargetTrack, []int64{tracks[0].ID, 999}, []string{"x"}), gorm.ErrRecordNotFound)
+
+	tags, err := GetTags(ctx, TagTargetTrack, tracks[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jazz", "late night"}, tags)
+	counts, err := GetTagCounts(ctx, TagTargetTrack)
+	require.NoError(t, err)
+	assert.Equal(t, []*TagCount{{Tag: "jazz", Count: 3}, {Tag: "late night", Count: 2}}, counts)
+
+	filtered, err := GetTracksOrderedByAlbum(ctx, 10, 0, ListFilter{Tag: "Late Night", MinRating: 5})
+	require.NoError(t, err)
+	assert.Len(t, filtered, 2)
+	count, err := GetTracksOrderedByAlbumCount(ctx, ListFilter{Tag: "jazz", MinRating: 3})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	albums, err := GetAlbums(ctx, 10, 0, ListFilter{Keyword: "X", Tag: "jazz"})
+	require.NoError(t, err)
+	require.Len(t, albums, 1)
+	require.NoError(t, AttachAlbumTags(ctx, albums))
+	assert.Equal(t, []string{"jazz"}, albums[0].Tags)
+	assert.EqualValues(t, 4, albums[0].Rating)
+
+	require.NoError(t, RemoveTags(ctx, TagTargetTrack, []int64{tracks[1].ID}, []string{"LATE NIGHT"}))
+	require.NoError(t, AttachTrackTags(ctx, tracks))
+	assert.Equal(t, []string{"jazz", "late night"}, tracks[0].Tags)
+	assert.Equal(t, []string{"jazz"}, tracks[1].Tags)
+
+	require.NoError(t, refreshDashboardStatsHeavyWithOptions(ctx, GetDashboardStatRuntimeConfig()))
+	topRated, err := GetTopRatedFromStat(ctx, TagTargetTrack, 10)
+	require.NoError(t, err)
+	require.Len(t, topRated, 3)
+	assert.Equal(t, "two", topRated[0].Track, "same rating ordered by play count")
+	assert.Equal(t, "one", topRated[1].Track)
+	topAlbums, err := GetTopRatedFromStat(ctx, TagTargetAlbum, 10)
+	require.NoError(t, err)
+	require.Len(t, topAlbums, 1)
+	assert.EqualValues(t, 4, topAlbums[0].Rating)
+
+	tagStats, err := GetTagStatsFromStat(ctx, TagTargetTrack, 10)
+	require.NoError(t, err)
+	require.Len(t, tagStats, 2)
+	assert.Equal(t, "jazz", tagStats[0].Tag)
+	assert.EqualValues(t, 3, tagStats[0].ItemCount)
+	assert.EqualValues(t, 15, tagStats[0].PlayCount)
+	assert.InDelta(t, 4.0, tagStats[0].AvgRating, 0.001)
+}
+
+func TestMergeTracks(t *testing.T) {
+	ctx := context.Background()
+	SetupTestDB(t)
+
+	play := func(album, source string) {
+		meta := TrackMetadata{TrackNumber: 1, DiscNumber: 1, Source: source}
+		require.NoError(
+			t, IncrementTrackPlayCount(
+				IncrementTrackPlayCountParams{Ctx: ctx, Artist: "A", Album: album, Track: "song", TrackMetadata: meta},
+			),
+		)
+		require.NoError(
+			t, InsertTrackPlayRecord(
+				ctx, &TrackPlayRecord{Artist: "A", Album: album, Track: "song", TrackNumber: 1, Source: source},
+			),
+		)
+	}
+	play("X", "Audirvana")
+	play("X", "Audirvana")
+	play("X (Deluxe)", "Apple Music")
+	require.NoError(
+		t, SetAppleMusicFavorite(
+			SetFavoriteParams{
+				Ctx: ctx, Artist: "A", Album: "X (Deluxe)", Track: "song", IsFavorite: true,
+				TrackMetadata: TrackMetadata{TrackNumber: 1, DiscNumber: 1},
+			},
+		),
+	)
+	canonical, err := GetTrack(ctx, "A", "X", "song")
+	require.NoError(t, err)
+	deluxe, err := GetTrack(ctx, "A", "X (Deluxe)", "song")
+	require.NoError(t, err)
+	require.NoError(t, CreateTrackInsight(ctx, &TrackInsight{TrackID: deluxe.ID, Artist: "A", Album: "X (Deluxe)", Track: "song"}))
+	require.NoError(t, CreateTrackLyrics(ctx, &TrackLyrics{TrackID: deluxe.ID, Artist: "A", Album: "X (Deluxe)", Track: "song"}))
+	require.NoError(t, AddTags(ctx, TagTargetTrack, []int64{deluxe.ID}, []string{"live"}))
+	skip := func(album string, listened int64) {
+		require.NoError(
+			t, SavePlayEvent(
+				ctx, &PlayEvent{
+					Artist: "A", Album: album, Track: "song", Source: "Apple Music",
+					EndReason: PlayEndSkipped, ListenedSeconds: listened,
+				},
+			),
+		)
+	}
+	skip("X (Deluxe)", 30)
+	// 被合并曲目带有 MusicBrainz 主艺术家署名与作曲署名
+	require.NoError(
+		t, ReplaceTrackArtists(
+			GetDB(), deluxe.ID, []string{ArtistRolePrimary, ArtistRoleComposer}, []ArtistCredit{
+				{Name: "A", Role: ArtistRolePrimary, MusicBrainzID: "mbid-a"},
+				{Name: "B", Role: ArtistRolePrimary, MusicBrainzID: "mbid-b"},
+				{Name: "D", Role: ArtistRoleComposer},
+			}, CreditSourceMusicBrainz,
+		),
+	)
+
+	_, err = MergeTracks(ctx, canonical.ID, []int64{canonical.ID})
+	assert.ErrorIs(t, err, ErrMergeSameTrack)
+	_, err = MergeTracks(ctx, canonical.ID, []int64{deluxe.ID, 999})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = GetTrack(ctx, "A", "X (Deluxe)", "song")
+	require.NoError(t, err, "failed merge is rolled back")
+
+	result, err := MergeTracks(ctx, canonical.ID, []int64{deluxe.ID, deluxe.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{deluxe.ID}, result.Merged)
+	assert.EqualValues(t, 1, result.PlayRecords)
+	assert.Equal(t, 3, result.Track.PlayCount)
+	assert.True(t, result.Track.IsAppleMusicFav)
+	require.Len(t, result.Aliases, 1)
+	assert.Equal(t, "X (Deluxe)", result.Aliases[0].Album)
+
+	_, err = GetTrack(ctx, "A", "X (Deluxe)", "song")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	var records []*TrackPlayRecord
+	require.NoError(t, GetDB().Where("track = ?", "song").Find(&records).Error)
+	require.Len(t, records, 3)
+	for _, record := range records {
+		assert.Equal(t, "X", record.Album)
+	}
+	insight, err := GetTrackInsight(ctx, "A", "X", "song")
+	require.NoError(t, err)
+	assert.Equal(t, canonical.ID, insight.TrackID)
+	lyrics, err := GetTrackLyrics(ctx, "A", "X", "song")
+	require.NoError(t, err)
+	assert.Equal(t, canonical.ID, lyrics.TrackID)
+	var links int64
+	require.NoError(t, GetDB().Model(&TrackAlbum{}).Where("track_id = ?", canonical.ID).Count(&links).Error)
+	assert.EqualValues(t, 2, links)
+	tags, err := GetTags(ctx, TagTargetTrack, canonical.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"live"}, tags)
+	var events []*PlayEvent
+	require.NoError(t, GetDB().Find(&events).Error)
+	require.Len(t, events, 1)
+	assert.Equal(t, "X", events[0].Album)
+	assert.Equal(t, 1, result.Track.SkipCount)
+	// 署名迁到规范曲目，MusicBrainz 署名替换解析出的主艺术家
+	credits, err := GetTrackArtists(ctx, canonical.ID)
+	require.NoError(t, err)
+	names := make([]string, 0, len(credits))
+	for _, credit := range credits {
+		names = append(names, credit.Role+":"+credit.Name+":"+credit.Source)
+	}
+	assert.Equal(t, []string{"primary:A:musicbrainz", "primary:B:musicbrainz", "composer:D:musicbrainz"}, names)
+	assert.EqualValues(t, 30, result.Track.TotalListenedSeconds)
+
+	// 之后命中别名的播放与跳过落到规范曲目
+	play("X (Deluxe)", "Apple Music")
+	skip("X (Deluxe)", 20)
+	merged, err := GetTrack(ctx, "A", "X", "song")
+	require.NoError(t, err)
+	assert.Equal(t, 4, merged.PlayCount)
+	assert.Equal(t, 2, merged.SkipCount)
+	assert.EqualValues(t, 50, merged.TotalListenedSeconds)
+	require.NoError(t, GetDB().Where("album = ?", "X (Deluxe)").Find(&events).Error)
+	assert.Empty(t, events)
+	var tracks int64
+	require.NoError(t, GetDB().Model(&Track{}).Count(&tracks).Error)
+	assert.EqualValues(t, 1, tracks)
+	require.NoError(t, GetDB().Where("album = ?", "X (Deluxe)").Find(&records).Error)
+	assert.Empty(t, records)
+
+	// 没有对应曲目的别名只迁移已有播放记录
+	require.NoError(
+		t, InsertTrackPlayRecord(ctx, &TrackPlayRecord{Artist: "A", Album: "X", Track: "song (Remastered)", Source: "Roon"}),
+	)
+	_, err = AddTrackAlias(ctx, canonical.ID, " ", "X", "song")
+	assert.ErrorIs(t, err, ErrInvalidAlias)
+	result, err = AddTrackAlias(ctx, canonical.ID, "A", "X", "song (Remastered)")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, result.PlayRecords)
+	aliases, err := GetTrackAliases(ctx, canonical.ID)
+	require.NoError(t, err)
+	require.Len(t, aliases, 2)
+	require.NoError(t, DeleteTrackAlias(ctx, canonical.ID, aliases[1].ID))
+	assert.ErrorIs(t, DeleteTrackAlias(ctx, canonical.ID, aliases[1].ID), gorm.ErrRecordNotFound)
+}
+
+func TestTrackArtists(t *testing.T) {
+	ctx := context.Background()
+	SetupTestDB(t)
+
+	play := func(artist, credit, track string, meta TrackMetadata) {
+		meta.ArtistCredit, meta.DiscNumber = credit, 1
+		require.NoError(
+			t, IncrementTrackPlayCount(
+				IncrementTrackPlayCountParams{Ctx: ctx, Artist: artist, Album: "X", Track: track, TrackMetadata: meta},
+			),
+		)
+	}
+	// 播放器明确以逗号分隔多位艺术家，作曲未给出分隔写法时保留完整名字
+	roon := []string{","}
+	play("A", "A, B", "one (feat. C)", TrackMetadata{AlbumArtist: "A", Composer: "A & D", ArtistSeparators: roon})
+	play("A", "A, B", "one (feat. C)", TrackMetadata{ArtistSeparators: roon})
+	play("B", "", "two", TrackMetadata{})
+	// 已有的艺术家名字即使包含允许的分隔符也不再拆分
+	require.NoError(t, GetDB().Create(&Artist{Name: "E & F"}).Error)
+	play("E & F", "", "three", TrackMetadata{ArtistSeparators: []string{"&"}})
+	// 名字本身带 , & 的乐队默认不拆分
+	for i := 0; i < 4; i++ {
+		play("Earth, Wind & Fire", "", "four", TrackMetadata{})
+	}
+
+	one, err := GetTrack(ctx, "A", "X", "one (feat. C)")
+	require.NoError(t, err)
+	credits, err := GetTrackArtists(ctx, one.ID)
+	require.NoError(t, err)
+	names := make([]string, 0, len(credits))
+	for _, credit := range credits {
+		names = append(names, credit.Role+":"+credit.Name)
+	}
+	assert.Equal(
+		t, []string{"primary:A", "primary:B", "featured:C", "composer:A & D", "album_artist:A"}, names,
+	)
+	for artist, track := range map[string]string{"E & F": "three", "Earth, Wind & Fire": "four"} {
+		row, err := GetTrack(ctx, artist, "X", track)
+		require.NoError(t, err)
+		credits, err = GetTrackArtists(ctx, row.ID)
+		require.NoError(t, err)
+		require.Len(t, credits, 1)
+		assert.Equal(t, artist, credits[0].Name)
+	}
+
+	// MusicBrainz 署名替换主艺术家与合作艺术家，作曲保留
+	require.NoError(
+		t, ReplaceTrackArtists(
+			GetDB(), one.ID, []string{ArtistRolePrimary, ArtistRoleFeatured},
+			[]ArtistCredit{{Name: "A", Role: ArtistRolePrimary, MusicBrainzID: "mbid-a"}}, CreditSourceMusicBrainz,
+		),
+	)
+	credits, err = GetTrackArtists(ctx, one.ID)
+	require.NoError(t, err)
+	require.Len(t, credits, 3)
+	assert.Equal(t, "mbid-a", credits[0].MusicBrainzID)
+	assert.Equal(t, CreditSourceMusicBrainz, credits[0].Source)
+	require.NoError(
+		t, ReplaceTrackArtists(
+			GetDB(), one.ID, []string{ArtistRolePrimary, ArtistRoleFeatured},
+			[]ArtistCredit{{Name: "A", Role: ArtistRolePrimary}, {Name: "B", Role: ArtistRolePrimary}}, CreditSourceMusicBrainz,
+		),
+	)
+
+	// 合作曲目计入每位主艺术家
+	require.NoError(t, refreshDashboardStatsHeavyWithOptions(ctx, GetDashboardStatRuntimeConfig()))
+	plays, err := GetTopArtistsByPlayCount(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(
+		t, []map[string]interface{}{
+			{"artist": "Earth, Wind & Fire", "play_count": int64(4)},
+			{"artist": "B", "play_count": int64(3)},
+			{"artist": "A", "play_count": int64(2)},
+			{"artist": "E & F", "play_count": int64(1)},
+		}, plays,
+	)
+	tracks, err := GetTopArtistsByTrackCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"artist": "B", "track_count": int64(2)}}, tracks)
+}
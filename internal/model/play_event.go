@@ -0,0 +1,174 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PlayEndReason 一次播放结束的原因
+type PlayEndReason string
+
+const (
+	PlayEndCompleted      PlayEndReason = "completed"       // 播放到曲目末尾
+	PlayEndSkipped        PlayEndReason = "skipped"         // 未播完即切到下一首
+	PlayEndStopped        PlayEndReason = "stopped"         // 未播完即停止播放或退出播放器
+	PlayEndSwitchedPlayer PlayEndReason = "switched_player" // 未播完即在其他播放器开始播放
+)
+
+// PlaySeek 一次进度跳转，单位秒
+type PlaySeek struct {
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+// PlayEvent 对应 play_event 表，记录每一次曲目播放，无论是否达到标记阈值
+type PlayEvent struct {
+	ID              int64         `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	Artist          string        `gorm:"column:artist;type:varchar(255);not null;index:idx_play_event_artist" json:"artist"`
+	AlbumArtist     string        `gorm:"column:album_artist;type:varchar(255)" json:"album_artist"`
+	Album           string        `gorm:"column:album;type:varchar(255)" json:"album"`
+	Track           string        `gorm:"column:track;type:varchar(255);not null" json:"track"`
+	Genre           string        `gorm:"column:genre;type:varchar(255);index:idx_play_event_genre" json:"genre"`
+	Source          string        `gorm:"column:source;type:varchar(100);not null" json:"source"` // 播放器
+	Duration        int64         `gorm:"column:duration;type:int" json:"duration"`               // 曲目时长(秒)
+	StartedAt       time.Time     `gorm:"column:started_at;type:timestamp;not null;index:idx_play_event_started_at" json:"started_at"`
+	EndedAt         time.Time     `gorm:"column:ended_at;type:timestamp;not null" json:"ended_at"`
+	EndReason       PlayEndReason `gorm:"column:end_reason;type:varchar(32);not null;index:idx_play_event_end_reason" json:"end_reason"`
+	EndPosition     int64         `gorm:"column:end_position;type:int" json:"end_position"`         // 结束时的播放进度(秒)
+	ListenedSeconds int64         `gorm:"column:listened_seconds;type:int" json:"listened_seconds"` // 实际收听秒数，不含跳过与暂停
+	Seeks           []PlaySeek    `gorm:"column:seeks;type:text;serializer:json" json:"seeks"`
+	Scrobbled       bool          `gorm:"column:scrobbled;type:tinyint(1);not null;default:0" json:"scrobbled"` // 是否达到标记阈值
+	CreatedAt       time.Time     `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName sets the table name for the PlayEvent model
+func (PlayEvent) TableName() string {
+	return "play_event"
+}
+
+// SavePlayEvent 保存结束的播放，并累加曲目的收听秒数与跳过次数，曲目命中别名时记到规范曲目上。
+// 曲目尚未入库（未达到过标记阈值）时只保存播放事件
+func SavePlayEvent(ctx context.Context, event *PlayEvent) error {
+	if event == nil {
+		return errors.New("play event is nil")
+	}
+	if event.Artist == "" || event.Track == "" {
+		return errors.New("play event artist and track are required")
+	}
+	if event.EndedAt.IsZero() {
+		event.EndedAt = time.Now()
+	}
+	if event.StartedAt.IsZero() {
+		event.StartedAt = event.EndedAt
+	}
+	return GetDB().WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			// 命中别名时播放事件与收听统计都记到规范曲目上
+			canonical, err := resolveTrackAlias(tx, event.Artist, event.Album, event.Track)
+			if err != nil {
+				return err
+			}
+			if canonical != nil {
+				event.Artist, event.Album, event.Track = canonical.Artist, canonical.Album, canonical.Track
+			}
+			if err := tx.Create(event).Error; err != nil {
+				return err
+			}
+			updates := map[string]interface{}{
+				"total_listened_seconds": gorm.Expr("total_listened_seconds + ?", event.ListenedSeconds),
+			}
+			if event.EndReason == PlayEndSkipped {
+				updates["skip_count"] = gorm.Expr("skip_count + 1")
+			}
+			return tx.Model(&Track{}).
+				Where("artist = ? AND album = ? AND track = ?", event.Artist, event.Album, event.Track).
+				Updates(updates).Error
+		},
+	)
+}
+
+// SkippedTrack 跳过次数统计
+type SkippedTrack struct {
+	Artist          string  `json:"artist"`
+	Album           string  `json:"album"`
+	Track           string  `json:"track"`
+	Plays           int64   `json:"plays"`
+	Skips           int64   `json:"skips"`
+	SkipRate        float64 `json:"skip_rate"`
+	ListenedSeconds int64   `json:"listened_seconds"`
+}
+
+// GetMostSkippedTracks 按跳过次数统计曲目，since 为零值时统计全部
+func GetMostSkippedTracks(ctx context.Context, since time.Time, limit int) ([]*SkippedTrack, error) {
+	var rows []*SkippedTrack
+	err := playEventQuery(ctx, since).
+		Select(
+			"artist, album, track, COUNT(*) AS plays, "+
+				"SUM(CASE WHEN end_reason = ? THEN 1 ELSE 0 END) AS skips, "+
+				"SUM(listened_seconds) AS listened_seconds", PlayEndSkipped,
+		).
+		Group("artist, album, track").
+		Having("SUM(CASE WHEN end_reason = ? THEN 1 ELSE 0 END) > 0", PlayEndSkipped).
+		Order("skips DESC, plays ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	for _, row := range rows {
+		row.SkipRate = skipRate(row.Skips, row.Plays)
+	}
+	return rows, err
+}
+
+// SkipRate 按艺术家或流派统计的跳过率
+type SkipRate struct {
+	Name            string  `json:"name"`
+	Plays           int64   `json:"plays"`
+	Skips           int64   `json:"skips"`
+	SkipRate        float64 `json:"skip_rate"`
+	ListenedSeconds int64   `json:"listened_seconds"`
+}
+
+// GetSkipRates 按 artist 或 genre 统计跳过率，只统计播放次数不少于 minPlays 的分组，按跳过率降序
+func GetSkipRates(ctx context.Context, groupBy string, since time.Time, minPlays, limit int) ([]*SkipRate, error) {
+	if groupBy != "artist" && groupBy != "genre" {
+		return nil, fmt.Errorf("unsupported skip rate group: %s", groupBy)
+	}
+	query := playEventQuery(ctx, since)
+	if groupBy == "genre" {
+		query = query.Where("genre <> ''")
+	}
+	var rows []*SkipRate
+	err := query.
+		Select(
+			groupBy+" AS name, COUNT(*) AS plays, "+
+				"SUM(CASE WHEN end_reason = ? THEN 1 ELSE 0 END) AS skips, "+
+				"SUM(listened_seconds) AS listened_seconds", PlayEndSkipped,
+		).
+		Group(groupBy).
+		Having("COUNT(*) >= ?", max(minPlays, 1)).
+		Order("SUM(CASE WHEN end_reason = '" + string(PlayEndSkipped) + "' THEN 1 ELSE 0 END) * 1.0 / COUNT(*) DESC, plays DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	for _, row := range rows {
+		row.SkipRate = skipRate(row.Skips, row.Plays)
+	}
+	return rows, err
+}
+
+func playEventQuery(ctx context.Context, since time.Time) *gorm.DB {
+	query := GetDB().WithContext(ctx).Model(&PlayEvent{})
+	if !since.IsZero() {
+		query = query.Where("started_at >= ?", since)
+	}
+	return query
+}
+
+func skipRate(skips, plays int64) float64 {
+	if plays == 0 {
+		return 0
+	}
+	return float64(skips) / float64(plays)
+}
@@ -0,0 +1,221 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 改写规则可修改的字段
+const (
+	RewriteFieldArtist      = "artist"
+	RewriteFieldAlbum       = "album"
+	RewriteFieldTitle       = "title"
+	RewriteFieldAlbumArtist = "album_artist"
+	RewriteFieldGenre       = "genre"
+	RewriteFieldComposer    = "composer"
+)
+
+// RewriteAction 改写一个字段：Pattern 为空时整个字段替换为 Replace，否则按正则替换，Replace 中可用 $1 引用分组
+type RewriteAction struct {
+	Field   string `json:"field"`
+	Pattern string `json:"pattern,omitempty"`
+	Replace string `json:"replace"`
+}
+
+type RewriteActions []RewriteAction
+
+func (a RewriteActions) Value() (driver.Value, error) {
+	data, err := json.Marshal(a)
+	return string(data), err
+}
+
+func (a *RewriteActions) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+	return fmt.Errorf("invalid scan")
+}
+
+// RewriteRule 对应 rewrite_rule 表，播放器元数据的改写规则。
+// 匹配条件均为正则，为空表示不限；规则按 priority、id 升序依次执行，后面的规则看到的是前面改写后的值
+type RewriteRule struct {
+	ID          int64          `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	Name        string         `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Enabled     bool           `gorm:"column:enabled;type:tinyint(1);not null;default:0" json:"enabled"`
+	Priority    int            `gorm:"column:priority;type:int;not null;default:0" json:"priority"`
+	MatchSource string         `gorm:"column:match_source;type:varchar(255)" json:"match_source"` // 播放器，如 Apple Music、Roon
+	MatchArtist string         `gorm:"column:match_artist;type:varchar(255)" json:"match_artist"`
+	MatchAlbum  string         `gorm:"column:match_album;type:varchar(255)" json:"match_album"`
+	MatchTitle  string         `gorm:"column:match_title;type:varchar(255)" json:"match_title"`
+	Actions     RewriteActions `gorm:"column:actions;type:text" json:"actions"`
+	CreatedAt   time.Time      `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the table name for the RewriteRule model
+func (RewriteRule) TableName() string {
+	return "rewrite_rule"
+}
+
+// ListRewriteRules 按执行顺序获取规则，enabledOnly 时只返回启用的规则
+func ListRewriteRules(ctx context.Context, enabledOnly bool) ([]*RewriteRule, error) {
+	rules := make([]*RewriteRule, 0)
+	db := GetDB().WithContext(ctx)
+	if enabledOnly {
+		db = db.Where("enabled = ?", true)
+	}
+	err := db.Order("priority ASC, id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func GetRewriteRule(ctx context.Context, id int64) (*RewriteRule, error) {
+	var rule RewriteRule
+	if err := GetDB().WithContext(ctx).First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func CreateRewriteRule(ctx context.Context, rule *RewriteRule) error {
+	return GetDB().WithContext(ctx).Create(rule).Error
+}
+
+// UpdateRewriteRule 更新规则的全部可编辑字段
+func UpdateRewriteRule(ctx context.Context, rule *RewriteRule) error {
+	result := GetDB().WithContext(ctx).Model(&RewriteRule{}).Where("id = ?", rule.ID).Updates(
+		map[string]any{
+			"name":         rule.Name,
+			"enabled":      rule.Enabled,
+			"priority":     rule.Priority,
+			"match_source": rule.MatchSource,
+			"match_artist": rule.MatchArtist,
+			"match_album":  rule.MatchAlbum,
+			"match_title":  rule.MatchTitle,
+			"actions":      rule.Actions,
+			"updated_at":   time.Now(),
+		},
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func DeleteRewriteRule(ctx context.Context, id int64) error {
+	result := GetDB().WithContext(ctx).Delete(&RewriteRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TrackRewrite 曲目改写后的元数据
+type TrackRewrite struct {
+	Artist      string
+	Album       string
+	Track       string
+	AlbumArtist string
+	Genre       string
+	Composer    string
+}
+
+// RewriteTrack 把改写规则应用到已有曲目。改写后与另一曲目重名时合并到该曲目并返回其 ID；
+// 否则更新曲目并把原名称下的播放记录、解读、歌词与专辑关联一并改写，返回 0
+func RewriteTrack(ctx context.Context, trackID int64, rewrite TrackRewrite) (int64, error) {
+	var track Track
+	if err := GetDB().WithContext(ctx).First(&track, trackID).Error; err != nil {
+		return 0, err
+	}
+	targetID, err := RewriteTarget(ctx, &track, rewrite)
+	if err != nil {
+		return 0, err
+	}
+	if targetID > 0 {
+		if _, err := MergeTracks(ctx, targetID, []int64{trackID}); err != nil {
+			return 0, err
+		}
+		return targetID, nil
+	}
+
+	return 0, GetDB().WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			oldArtist, oldAlbum, oldTrack := track.Artist, track.Album, track.Track
+			track.Artist, track.Album, track.Track = rewrite.Artist, rewrite.Album, rewrite.Track
+			track.AlbumArtist, track.Genre, track.Composer = rewrite.AlbumArtist, rewrite.Genre, rewrite.Composer
+			if err := tx.Model(&Track{}).Where("id = ?", trackID).Updates(
+				map[string]any{
+					"artist":       track.Artist,
+					"album":        track.Album,
+					"track":        track.Track,
+					"album_artist": track.AlbumArtist,
+					"genre":        track.Genre,
+					"composer":     track.Composer,
+					"version":      gorm.Expr("version + 1"),
+					"updated_at":   time.Now(),
+				},
+			).Error; err != nil {
+				return err
+			}
+			if oldArtist == track.Artist && oldAlbum == track.Album && oldTrack == track.Track {
+				return nil
+			}
+
+			// 专辑变化时改挂到新专辑
+			if oldArtist != track.Artist || oldAlbum != track.Album {
+				album := Album{Name: track.Album, Artist: track.Artist}
+				if err := tx.Where("artist = ? AND name = ?", album.Artist, album.Name).
+					FirstOrCreate(&album).Error; err != nil {
+					return err
+				}
+				var oldAlbumIDs []int64
+				if err := tx.Model(&Album{}).Where("artist = ? AND name = ?", oldArtist, oldAlbum).
+					Pluck("id", &oldAlbumIDs).Error; err != nil {
+					return err
+				}
+				if len(oldAlbumIDs) > 0 {
+					if err := tx.Model(&TrackAlbum{}).Where("track_id = ? AND album_id IN ?", trackID, oldAlbumIDs).
+						Update("album_id", album.ID).Error; err != nil {
+						return err
+					}
+				}
+			}
+			if _, err := moveTrackData(tx, &track, oldArtist, oldAlbum, oldTrack); err != nil {
+				return err
+			}
+			// 艺术家或曲名变化后重新解析署名，MusicBrainz 署名保留
+			if err := tx.Where("track_id = ? AND source = ?", trackID, CreditSourceParsed).
+				Delete(&TrackArtist{}).Error; err != nil {
+				return err
+			}
+			return ensureTrackArtists(tx, &track, "", nil)
+		},
+	)
+}
+
+// RewriteTarget 返回改写后与 track 重名的另一曲目 ID，没有时返回 0
+func RewriteTarget(ctx context.Context, track *Track, rewrite TrackRewrite) (int64, error) {
+	var target Track
+	if err := GetDB().WithContext(ctx).Select("id").Where(
+		"artist = ? AND album = ? AND track = ? AND track_number = ? AND disc_number = ? AND id <> ?",
+		rewrite.Artist, rewrite.Album, rewrite.Track, track.TrackNumber, track.DiscNumber, track.ID,
+	).Limit(1).Find(&target).Error; err != nil {
+		return 0, err
+	}
+	return target.ID, nil
+}
@@ -0,0 +1,198 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 投递状态
+const (
+	OutboxStatusPending = "pending" // 待投递或等待重试
+	OutboxStatusSent    = "sent"    // 已被目标接受
+	OutboxStatusFailed  = "failed"  // 被目标拒绝或忽略，不再重试
+)
+
+// 投递目标
+const (
+	OutboxTargetLastfm       = "lastfm"
+	OutboxTargetListenBrainz = "listenbrainz"
+)
+
+// ScrobbleOutbox 对应 scrobble_outbox 表，每条播放记录在每个投递目标上一行
+type ScrobbleOutbox struct {
+	ID            int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	RecordID      int64     `gorm:"column:record_id;type:bigint;not null;uniqueIndex:idx_scrobble_outbox_record_target" json:"record_id"`
+	Target        string    `gorm:"column:target;type:varchar(32);not null;uniqueIndex:idx_scrobble_outbox_record_target;index:idx_scrobble_outbox_due" json:"target"`
+	Status        string    `gorm:"column:status;type:varchar(16);not null;default:'pending';index:idx_scrobble_outbox_due" json:"status"`
+	Attempts      int       `gorm:"column:attempts;type:int;not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time `gorm:"column:next_attempt_at;type:timestamp;not null;default:CURRENT_TIMESTAMP;index:idx_scrobble_outbox_due" json:"next_attempt_at"`
+	ErrorCode     int       `gorm:"column:error_code;type:int;not null;default:0" json:"error_code"`
+	LastError     string    `gorm:"column:last_error;type:varchar(1024)" json:"last_error"`
+	CreatedAt     time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName sets the table name for the ScrobbleOutbox model
+func (ScrobbleOutbox) TableName() string {
+	return "scrobble_outbox"
+}
+
+// ScrobbleOutboxItem 待投递条目及其播放记录
+type ScrobbleOutboxItem struct {
+	Outbox *ScrobbleOutbox
+	Record *TrackPlayRecord
+}
+
+// EnqueueUnscrobbledRecords 将尚未进入该目标 outbox 的未同步播放记录入队，返回入队条数
+func EnqueueUnscrobbledRecords(ctx context.Context, target string, limit int) (int, error) {
+	var ids []int64
+	err := GetDB().WithContext(ctx).Model(&TrackPlayRecord{}).
+		Where("scrobbled = ?", false).
+		Where(
+			"NOT EXISTS (?)",
+			GetDB().Model(&ScrobbleOutbox{}).Select("1").
+				Where("scrobble_outbox.record_id = track_play_records.id AND scrobble_outbox.target = ?", target),
+		).
+		Order("play_time ASC").Limit(limit).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return len(ids), EnqueueScrobbleOutbox(ctx, target, ids...)
+}
+
+// EnqueueScrobbleOutbox 为播放记录创建待投递条目，已存在的条目保持不变
+func EnqueueScrobbleOutbox(ctx context.Context, target string, recordIDs ...int64) error {
+	if len(recordIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	rows := make([]*ScrobbleOutbox, 0, len(recordIDs))
+	for _, id := range recordIDs {
+		rows = append(
+			rows, &ScrobbleOutbox{
+				RecordID:      id,
+				Target:        target,
+				Status:        OutboxStatusPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			},
+		)
+	}
+	return GetDB().WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 100).Error
+}
+
+// SaveScrobbleDelivery 记录实时上报的结果：成功直接标记为已投递，失败则入队等待后台重试
+func SaveScrobbleDelivery(ctx context.Context, recordID int64, target string, sent bool, code int, lastErr string) error {
+	now := time.Now()
+	row := &ScrobbleOutbox{
+		RecordID:      recordID,
+		Target:        target,
+		Status:        OutboxStatusPending,
+		Attempts:      1,
+		NextAttemptAt: now,
+		ErrorCode:     code,
+		LastError:     truncateOutboxError(lastErr),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if sent {
+		row.Status = OutboxStatusSent
+	}
+	return GetDB().WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "record_id"}, {Name: "target"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "attempts", "error_code", "last_error", "updated_at"}),
+		},
+	).Create(row).Error
+}
+
+// GetScrobbleDeliveries 获取播放记录在各投递目标上的状态
+func GetScrobbleDeliveries(ctx context.Context, recordID int64) ([]*ScrobbleOutbox, error) {
+	var rows []*ScrobbleOutbox
+	err := GetDB().WithContext(ctx).Where("record_id = ?", recordID).Order("target ASC").Find(&rows).Error
+	return rows, err
+}
+
+// GetDueScrobbleOutbox 获取到期待投递的条目，按下次重试时间升序
+func GetDueScrobbleOutbox(ctx context.Context, target string, now time.Time, limit int) ([]*ScrobbleOutboxItem, error) {
+	var rows []*ScrobbleOutbox
+	err := GetDB().WithContext(ctx).
+		Where("target = ? AND status = ? AND next_attempt_at <= ?", target, OutboxStatusPending, now).
+		Order("next_attempt_at ASC, id ASC").Limit(limit).Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	recordIDs := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		recordIDs = append(recordIDs, row.RecordID)
+	}
+	var records []*TrackPlayRecord
+	if err := GetDB().WithContext(ctx).Where("id IN ?", recordIDs).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	recordMap := make(map[int64]*TrackPlayRecord, len(records))
+	for _, record := range records {
+		recordMap[record.ID] = record
+	}
+
+	items := make([]*ScrobbleOutboxItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, &ScrobbleOutboxItem{Outbox: row, Record: recordMap[row.RecordID]})
+	}
+	return items, nil
+}
+
+// MarkScrobbleOutboxSent 标记条目投递成功
+func MarkScrobbleOutboxSent(ctx context.Context, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return GetDB().WithContext(ctx).Model(&ScrobbleOutbox{}).Where("id IN ?", ids).Updates(
+		map[string]any{
+			"status":     OutboxStatusSent,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"error_code": 0,
+			"last_error": "",
+			"updated_at": time.Now(),
+		},
+	).Error
+}
+
+// MarkScrobbleOutboxRetry 记录一次临时失败并安排下次重试时间
+func MarkScrobbleOutboxRetry(ctx context.Context, id int64, nextAttemptAt time.Time, code int, lastErr string) error {
+	return GetDB().WithContext(ctx).Model(&ScrobbleOutbox{}).Where("id = ?", id).Updates(
+		map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": nextAttemptAt,
+			"error_code":      code,
+			"last_error":      truncateOutboxError(lastErr),
+			"updated_at":      time.Now(),
+		},
+	).Error
+}
+
+// MarkScrobbleOutboxFailed 标记条目永久失败，不再重试
+func MarkScrobbleOutboxFailed(ctx context.Context, id int64, code int, lastErr string) error {
+	return GetDB().WithContext(ctx).Model(&ScrobbleOutbox{}).Where("id = ?", id).Updates(
+		map[string]any{
+			"status":     OutboxStatusFailed,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"error_code": code,
+			"last_error": truncateOutboxError(lastErr),
+			"updated_at": time.Now(),
+		},
+	).Error
+}
+
+func truncateOutboxError(msg string) string {
+	const maxLen = 1000
+	if runes := []rune(msg); len(runes) > maxLen {
+		return string(runes[:maxLen])
+	}
+	return msg
+}
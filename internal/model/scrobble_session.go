@@ -0,0 +1,44 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScrobbleSession 对应 scrobble_session 表，Last.fm 兼容接口通过 auth.getMobileSession 签发的会话
+type ScrobbleSession struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	SessionKey string    `gorm:"column:session_key;type:varchar(64);not null;uniqueIndex:idx_scrobble_session_key" json:"session_key"`
+	ApiKey     string    `gorm:"column:api_key;type:varchar(64);not null" json:"api_key"` // 签发时使用的 api_key，会话只对该客户端有效
+	Username   string    `gorm:"column:username;type:varchar(255);not null" json:"username"`
+	CreatedAt  time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName sets the table name for the ScrobbleSession model
+func (ScrobbleSession) TableName() string {
+	return "scrobble_session"
+}
+
+// CreateScrobbleSession 保存新签发的会话
+func CreateScrobbleSession(ctx context.Context, session *ScrobbleSession) error {
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	return GetDB().WithContext(ctx).Create(session).Error
+}
+
+// GetScrobbleSession 按 session key 获取会话，不存在时返回 nil
+func GetScrobbleSession(ctx context.Context, sessionKey string) (*ScrobbleSession, error) {
+	var session ScrobbleSession
+	err := GetDB().WithContext(ctx).Where("session_key = ?", sessionKey).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/log"
+)
+
+var testDBSeq atomic.Int64
+
+// SetupTestDB 供各包测试使用：为每次调用新建一个内存 SQLite 库并初始化全局数据库，测试结束后恢复数据库类型配置
+func SetupTestDB(t testing.TB) {
+	t.Helper()
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	dbType := config.ConfigObj.Database.Type
+	config.ConfigObj.Database.Type = string(common.DatabaseTypeSQLite)
+	t.Cleanup(func() { config.ConfigObj.Database.Type = dbType })
+	if err := InitDB(fmt.Sprintf("file:%s_%d?mode=memory&cache=shared", t.Name(), testDBSeq.Add(1)), zap.NewNop()); err != nil {
+		t.Fatalf("init test db: %v", err)
+	}
+}
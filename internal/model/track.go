@@ -37,27 +37,31 @@ import (
 	UpdatedAt       time.Time `json:"updated_at"`
 }*/
 type Track struct {
-	ID              int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
-	Artist          string    `gorm:"column:artist;type:varchar(255);not null;uniqueIndex:uidx_t_aatdntn" json:"artist"`
-	Album           string    `gorm:"column:album;type:varchar(255);not null;index:idx_track_album;uniqueIndex:uidx_t_aatdntn" json:"album"`
-	Track           string    `gorm:"column:track;type:varchar(255);not null;index:idx_track_track;uniqueIndex:uidx_t_aatdntn" json:"track"`
-	PlayCount       int       `gorm:"column:play_count;type:int;default:0" json:"play_count"`
-	IsAppleMusicFav bool      `gorm:"column:is_apple_music_fav;type:tinyint(1);default:0" json:"is_apple_music_fav"`
-	IsLastFmFav     bool      `gorm:"column:is_last_fm_fav;type:tinyint(1);default:0" json:"is_last_fm_fav"`
-	Version         int       `gorm:"column:version;type:int;default:1" json:"version"`
-	AlbumArtist     string    `gorm:"column:album_artist;type:varchar(255)" json:"album_artist"`
-	TrackNumber     int8      `gorm:"column:track_number;type:tinyint;uniqueIndex:uidx_t_aatdntn" json:"track_number"`
-	DiscNumber      int8      `gorm:"column:disc_number;type:tinyint;default:1;uniqueIndex:uidx_t_aatdntn" json:"disc_number"` // 碟号
-	Duration        int64     `gorm:"column:duration;type:int" json:"duration"`
-	Genre           string    `gorm:"column:genre;type:varchar(255);index:idx_track_genre" json:"genre"`
-	Composer        string    `gorm:"column:composer;type:varchar(255)" json:"composer"`
-	ReleaseDate     string    `gorm:"column:release_date;type:varchar(50)" json:"release_date"`
-	MusicBrainzID   string    `gorm:"column:music_brainz_id;type:varchar(255)" json:"music_brainz_id"`
-	Source          string    `gorm:"column:source;type:varchar(255);index:idx_track_source" json:"source"`
-	BundleID        string    `gorm:"column:bundle_id;type:varchar(255)" json:"bundle_id"`
-	UniqueID        string    `gorm:"column:unique_id;type:varchar(255);index:idx_track_unique_id" json:"unique_id"`
-	CreatedAt       time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt       time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+	ID                   int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	Artist               string    `gorm:"column:artist;type:varchar(255);not null;uniqueIndex:uidx_t_aatdntn" json:"artist"`
+	Album                string    `gorm:"column:album;type:varchar(255);not null;index:idx_track_album;uniqueIndex:uidx_t_aatdntn" json:"album"`
+	Track                string    `gorm:"column:track;type:varchar(255);not null;index:idx_track_track;uniqueIndex:uidx_t_aatdntn" json:"track"`
+	PlayCount            int       `gorm:"column:play_count;type:int;default:0" json:"play_count"`
+	SkipCount            int       `gorm:"column:skip_count;type:int;not null;default:0" json:"skip_count"`                            // 未播完即切歌的次数
+	TotalListenedSeconds int64     `gorm:"column:total_listened_seconds;type:bigint;not null;default:0" json:"total_listened_seconds"` // 累计实际收听秒数
+	IsAppleMusicFav      bool      `gorm:"column:is_apple_music_fav;type:tinyint(1);default:0" json:"is_apple_music_fav"`
+	IsLastFmFav          bool      `gorm:"column:is_last_fm_fav;type:tinyint(1);default:0" json:"is_last_fm_fav"`
+	Rating               int8      `gorm:"column:rating;type:tinyint;not null;default:0" json:"rating"` // 本地评分 1-5，0 表示未评分
+	Version              int       `gorm:"column:version;type:int;default:1" json:"version"`
+	AlbumArtist          string    `gorm:"column:album_artist;type:varchar(255)" json:"album_artist"`
+	TrackNumber          int8      `gorm:"column:track_number;type:tinyint;uniqueIndex:uidx_t_aatdntn" json:"track_number"`
+	DiscNumber           int8      `gorm:"column:disc_number;type:tinyint;default:1;uniqueIndex:uidx_t_aatdntn" json:"disc_number"` // 碟号
+	Duration             int64     `gorm:"column:duration;type:int" json:"duration"`
+	Genre                string    `gorm:"column:genre;type:varchar(255);index:idx_track_genre" json:"genre"`
+	Composer             string    `gorm:"column:composer;type:varchar(255)" json:"composer"`
+	ReleaseDate          string    `gorm:"column:release_date;type:varchar(50)" json:"release_date"`
+	MusicBrainzID        string    `gorm:"column:music_brainz_id;type:varchar(255)" json:"music_brainz_id"`
+	Source               string    `gorm:"column:source;type:varchar(255);index:idx_track_source" json:"source"`
+	BundleID             string    `gorm:"column:bundle_id;type:varchar(255)" json:"bundle_id"`
+	UniqueID             string    `gorm:"column:unique_id;type:varchar(255);index:idx_track_unique_id" json:"unique_id"`
+	CreatedAt            time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt            time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+	Tags                 []string  `gorm:"-" json:"tags,omitempty"` // 本地标签，由 AttachTrackTags 填充
 }
 
 // TableName sets the table name for the Track model
@@ -78,6 +82,9 @@ type TrackMetadata struct {
 	BundleID      string `json:"bundle_id"`      // 应用标识符 (用于media-control)
 	UniqueID      string `json:"unique_id"`      // 唯一标识符 (用于media-control)
 	DiscNumber    int8   `json:"disc_number"`    // 盘编号
+	ArtistCredit  string `json:"artist_credit"`  // 完整艺术家署名，Artist 只保留主艺术家时用于解析多艺术家，为空时使用 Artist
+	// ArtistSeparators 播放器分隔 ArtistCredit 中多位艺术家的写法，为空时不按分隔符拆分
+	ArtistSeparators []string `json:"artist_separators"`
 }
 
 // IncrementTrackPlayCountParams represents parameters for IncrementTrackPlayCount function
@@ -105,6 +112,12 @@ func IncrementTrackPlayCount(params IncrementTrackPlayCountParams) error {
 	if err := common.ValidateTrackInfo(params.Ctx, params.Artist, params.Album, params.Track); err != nil {
 		return err
 	}
+	// 命中别名时落到规范曲目上
+	if err := applyTrackAlias(
+		params.Ctx, &params.Artist, &params.Album, &params.Track, &params.TrackMetadata,
+	); err != nil {
+		return err
+	}
 
 	return GetDB().WithContext(params.Ctx).Transaction(
 		func(tx *gorm.DB) error {
@@ -231,6 +244,15 @@ func IncrementTrackPlayCount(params IncrementTrackPlayCountParams) error {
 				// 版本冲突，循环重试
 			}
 
+			// 补齐多艺术家署名
+			if track.ID > 0 {
+				if err := ensureTrackArtists(
+					tx, &track, params.TrackMetadata.ArtistCredit, params.TrackMetadata.ArtistSeparators,
+				); err != nil {
+					return err
+				}
+			}
+
 			// 4. 处理 TrackAlbum 关联 (优先消耗占位符)
 			var ta TrackAlbum
 			foundPlaceholder := false
@@ -287,6 +309,12 @@ func SetAppleMusicFavorite(params SetFavoriteParams) error {
 	if err := common.ValidateTrackInfo(params.Ctx, params.Artist, params.Album, params.Track); err != nil {
 		return err
 	}
+	// 命中别名时落到规范曲目上
+	if err := applyTrackAlias(
+		params.Ctx, &params.Artist, &params.Album, &params.Track, &params.TrackMetadata,
+	); err != nil {
+		return err
+	}
 
 	// 使用乐观锁机制更新喜欢状态
 	for {
@@ -360,6 +388,12 @@ func SetLastFmFavorite(params SetFavoriteParams) error {
 	if err := common.ValidateTrackInfo(params.Ctx, params.Artist, params.Album, params.Track); err != nil {
 		return err
 	}
+	// 命中别名时落到规范曲目上
+	if err := applyTrackAlias(
+		params.Ctx, &params.Artist, &params.Album, &params.Track, &params.TrackMetadata,
+	); err != nil {
+		return err
+	}
 
 	// 使用乐观锁机制更新喜欢状态
 	for {
@@ -427,6 +461,42 @@ func SetLastFmFavorite(params SetFavoriteParams) error {
 	return nil
 }
 
+// SetLastFmFavoriteByArtistTrack 按艺术家与曲目名设置 Last.fm 喜欢状态，不区分专辑与碟号，返回更新的曲目数
+// 用于 track.love 等只携带艺术家与曲目名的请求
+func SetLastFmFavoriteByArtistTrack(ctx context.Context, artist, track string, isFavorite bool) (int64, error) {
+	result := GetDB().WithContext(ctx).Model(&Track{}).Where("artist = ? AND track = ?", artist, track).Updates(
+		map[string]any{
+			"is_last_fm_fav": isFavorite,
+			"version":        gorm.Expr("version + 1"),
+			"updated_at":     time.Now(),
+		},
+	)
+	return result.RowsAffected, result.Error
+}
+
+// GetTrackFavoriteStates 获取全部曲目的艺术家、曲目名与喜欢状态，用于与 Last.fm 喜欢列表对账
+func GetTrackFavoriteStates(ctx context.Context) ([]*Track, error) {
+	var tracks []*Track
+	err := GetDB().WithContext(ctx).Model(&Track{}).
+		Select("id", "artist", "track", "is_apple_music_fav", "is_last_fm_fav").
+		Order("id").Find(&tracks).Error
+	return tracks, err
+}
+
+// SetLastFmFavoritesByIDs 批量设置曲目的 Last.fm 喜欢状态，不影响 Apple Music 喜欢状态
+func SetLastFmFavoritesByIDs(ctx context.Context, ids []int64, loved bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return GetDB().WithContext(ctx).Model(&Track{}).Where("id IN ?", ids).Updates(
+		map[string]any{
+			"is_last_fm_fav": loved,
+			"version":        gorm.Expr("version + 1"),
+			"updated_at":     time.Now(),
+		},
+	).Error
+}
+
 // GetTracks retrieves track play counts with pagination and optional keyword search
 func GetTracks(ctx context.Context, limit, offset int, keyword string) ([]*Track, error) {
 	if statRows, err := GetTrackPlayCountsFromStat(
@@ -558,10 +628,9 @@ func GetTopArtistsByPlayCount(ctx context.Context, limit int) ([]map[string]inte
 		return statResult, nil
 	}
 	var result []map[string]interface{}
-	err = GetDB().WithContext(ctx).Model(&Track{}).
-		Select("artist, SUM(play_count) as play_count").
-		Group("artist").
-		Order("SUM(play_count) DESC").
+	err = creditedArtists(GetDB().WithContext(ctx)).
+		Select("COALESCE(a.name, t.artist) AS artist, SUM(t.play_count) as play_count").
+		Order("SUM(t.play_count) DESC").
 		Limit(limit).
 		Find(&result).Error
 	if err != nil {
@@ -577,9 +646,8 @@ func GetTopArtistsByTrackCount(ctx context.Context, limit int) ([]map[string]int
 		return statResult, nil
 	}
 	var result []map[string]interface{}
-	err = GetDB().WithContext(ctx).Model(&Track{}).
-		Select("artist, COUNT(*) as track_count").
-		Group("artist").
+	err = creditedArtists(GetDB().WithContext(ctx)).
+		Select("COALESCE(a.name, t.artist) AS artist, COUNT(*) as track_count").
 		Order("COUNT(*) DESC").
 		Limit(limit).
 		Find(&result).Error
@@ -717,24 +785,23 @@ func UpdateTrackWithTrackMetadata(track *Track, newTrack *TrackMetadata) {
 }
 
 // GetTracksOrderedByAlbum retrieves tracks ordered by album name, disc number and track number
-func GetTracksOrderedByAlbum(ctx context.Context, limit, offset int, keyword string) ([]*Track, error) {
+func GetTracksOrderedByAlbum(ctx context.Context, limit, offset int, filter ListFilter) ([]*Track, error) {
 	var tracks []*Track
-	db := GetDB().WithContext(ctx)
-	if keyword != "" {
-		kw := "%" + keyword + "%"
-		db = db.Where("track LIKE ? OR artist LIKE ? OR album LIKE ?", kw, kw, kw)
-	}
-	err := db.Order("album ASC, disc_number ASC, track_number ASC").Limit(limit).Offset(offset).Find(&tracks).Error
+	err := filterTracks(GetDB().WithContext(ctx), filter).
+		Order("album ASC, disc_number ASC, track_number ASC").Limit(limit).Offset(offset).Find(&tracks).Error
 	return tracks, err
 }
 
-func GetTracksOrderedByAlbumCount(ctx context.Context, keyword string) (int64, error) {
+func GetTracksOrderedByAlbumCount(ctx context.Context, filter ListFilter) (int64, error) {
 	var count int64
-	db := GetDB().WithContext(ctx).Model(&Track{})
-	if keyword != "" {
-		kw := "%" + keyword + "%"
+	err := filterTracks(GetDB().WithContext(ctx).Model(&Track{}), filter).Count(&count).Error
+	return count, err
+}
+
+func filterTracks(db *gorm.DB, filter ListFilter) *gorm.DB {
+	if filter.Keyword != "" {
+		kw := "%" + filter.Keyword + "%"
 		db = db.Where("track LIKE ? OR artist LIKE ? OR album LIKE ?", kw, kw, kw)
 	}
-	err := db.Count(&count).Error
-	return count, err
+	return filter.apply(db, TagTargetTrack, "track")
 }
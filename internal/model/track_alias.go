@@ -0,0 +1,333 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrMergeSameTrack = errors.New("cannot merge a track into itself")
+	ErrInvalidAlias   = errors.New("artist, album and track are required")
+)
+
+// TrackAlias 对应 track_alias 表，把另一组艺术家/专辑/曲目名指向规范曲目。
+// 合并后被合并的曲目行会删除，之后命中别名的播放、喜欢都会落到规范曲目上
+type TrackAlias struct {
+	ID        int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	TrackID   int64     `gorm:"column:track_id;type:bigint;not null;index:idx_track_alias_track_id" json:"track_id"` // 规范曲目
+	Artist    string    `gorm:"column:artist;type:varchar(255);not null;uniqueIndex:uidx_track_alias_aat" json:"artist"`
+	Album     string    `gorm:"column:album;type:varchar(255);not null;uniqueIndex:uidx_track_alias_aat" json:"album"`
+	Track     string    `gorm:"column:track;type:varchar(255);not null;uniqueIndex:uidx_track_alias_aat" json:"track"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName sets the table name for the TrackAlias model
+func (TrackAlias) TableName() string {
+	return "track_alias"
+}
+
+// MergeResult 合并结果
+type MergeResult struct {
+	Track       *Track        `json:"track"`        // 合并后的规范曲目
+	Merged      []int64       `json:"merged"`       // 被合并并删除的曲目
+	PlayRecords int64         `json:"play_records"` // 迁移的播放记录数
+	Aliases     []*TrackAlias `json:"aliases"`
+}
+
+// ResolveTrackAlias 查找别名指向的规范曲目，未命中别名时返回 nil
+func ResolveTrackAlias(ctx context.Context, artist, album, track string) (*Track, error) {
+	return resolveTrackAlias(GetDB().WithContext(ctx), artist, album, track)
+}
+
+func resolveTrackAlias(db *gorm.DB, artist, album, track string) (*Track, error) {
+	var alias TrackAlias
+	err := db.Where("artist = ? AND album = ? AND track = ?", artist, album, track).
+		Limit(1).Find(&alias).Error
+	if err != nil || alias.ID == 0 {
+		return nil, err
+	}
+	var canonical Track
+	if err := db.Where("id = ?", alias.TrackID).Limit(1).Find(&canonical).Error; err != nil || canonical.ID == 0 {
+		return nil, err
+	}
+	return &canonical, nil
+}
+
+// applyTrackAlias 曲目命中别名时，把标识改写为规范曲目的艺术家、专辑、曲目名与曲目号
+func applyTrackAlias(ctx context.Context, artist, album, track *string, metadata *TrackMetadata) error {
+	canonical, err := ResolveTrackAlias(ctx, *artist, *album, *track)
+	if err != nil || canonical == nil {
+		return err
+	}
+	*artist, *album, *track = canonical.Artist, canonical.Album, canonical.Track
+	metadata.TrackNumber, metadata.DiscNumber = canonical.TrackNumber, canonical.DiscNumber
+	return nil
+}
+
+// GetTrackAliases 获取指向规范曲目的全部别名
+func GetTrackAliases(ctx context.Context, trackID int64) ([]*TrackAlias, error) {
+	if err := GetDB().WithContext(ctx).Select("id").First(&Track{}, trackID).Error; err != nil {
+		return nil, err
+	}
+	aliases := make([]*TrackAlias, 0)
+	err := GetDB().WithContext(ctx).Where("track_id = ?", trackID).Order("id").Find(&aliases).Error
+	return aliases, err
+}
+
+// MergeTracks 在一个事务内把 sourceIDs 合并到 canonicalID：播放记录、播放事件、解读、歌词、署名与专辑关联迁到规范曲目，
+// 播放次数、跳过次数与收听时长累加，喜欢标记取并集，评分与标签在规范曲目缺失时补齐；
+// 被合并曲目的艺术家/专辑/曲目名记为别名后删除
+func MergeTracks(ctx context.Context, canonicalID int64, sourceIDs []int64) (*MergeResult, error) {
+	result := &MergeResult{Merged: make([]int64, 0, len(sourceIDs))}
+	err := GetDB().WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			var canonical Track
+			if err := tx.First(&canonical, canonicalID).Error; err != nil {
+				return err
+			}
+			seen := make(map[int64]struct{}, len(sourceIDs))
+			for _, id := range sourceIDs {
+				if id == canonicalID {
+					return ErrMergeSameTrack
+				}
+				if _, ok := seen[id]; ok {
+					continue
+				}
+				seen[id] = struct{}{}
+				var source Track
+				if err := tx.First(&source, id).Error; err != nil {
+					return err
+				}
+				moved, err := mergeTrack(tx, &canonical, &source)
+				if err != nil {
+					return err
+				}
+				result.PlayRecords += moved
+				result.Merged = append(result.Merged, id)
+			}
+			if err := tx.First(&canonical, canonicalID).Error; err != nil {
+				return err
+			}
+			result.Track = &canonical
+			return tx.Where("track_id = ?", canonicalID).Order("id").Find(&result.Aliases).Error
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AddTrackAlias 为规范曲目添加别名；已有同名曲目时直接合并，否则只迁移该名称下已有的播放记录、解读与歌词
+func AddTrackAlias(ctx context.Context, trackID int64, artist, album, track string) (*MergeResult, error) {
+	artist, album, track = strings.TrimSpace(artist), strings.TrimSpace(album), strings.TrimSpace(track)
+	if artist == "" || album == "" || track == "" {
+		return nil, ErrInvalidAlias
+	}
+	var ids []int64
+	if err := GetDB().WithContext(ctx).Model(&Track{}).
+		Where("artist = ? AND album = ? AND track = ?", artist, album, track).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		return MergeTracks(ctx, trackID, ids)
+	}
+
+	result := &MergeResult{Merged: []int64{}}
+	err := GetDB().WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			var canonical Track
+			if err := tx.First(&canonical, trackID).Error; err != nil {
+				return err
+			}
+			moved, err := moveTrackData(tx, &canonical, artist, album, track)
+			if err != nil {
+				return err
+			}
+			if err := upsertTrackAlias(tx, &canonical, artist, album, track); err != nil {
+				return err
+			}
+			result.Track, result.PlayRecords = &canonical, moved
+			return tx.Where("track_id = ?", trackID).Order("id").Find(&result.Aliases).Error
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteTrackAlias 删除别名，已合并的数据不会拆分，之后的播放会重新生成独立曲目
+func DeleteTrackAlias(ctx context.Context, trackID, aliasID int64) error {
+	result := GetDB().WithContext(ctx).Where("id = ? AND track_id = ?", aliasID, trackID).Delete(&TrackAlias{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// mergeTrack 把 source 合并进 canonical 并删除 source，返回迁移的播放记录数
+func mergeTrack(tx *gorm.DB, canonical, source *Track) (int64, error) {
+	moved, err := moveTrackData(tx, canonical, source.Artist, source.Album, source.Track)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&TrackInsight{}).Where("track_id = ?", source.ID).
+		Updates(
+			map[string]any{
+				"track_id": canonical.ID, "artist": canonical.Artist, "album": canonical.Album, "track": canonical.Track,
+			},
+		).Error; err != nil {
+		return 0, err
+	}
+
+	// 专辑关联：同一专辑已有规范曲目时删除重复行
+	var links []*TrackAlbum
+	if err := tx.Where("track_id = ?", source.ID).Find(&links).Error; err != nil {
+		return 0, err
+	}
+	for _, link := range links {
+		var count int64
+		if err := tx.Model(&TrackAlbum{}).Where("track_id = ? AND album_id = ?", canonical.ID, link.AlbumID).
+			Count(&count).Error; err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			err = tx.Delete(link).Error
+		} else {
+			err = tx.Model(link).Update("track_id", canonical.ID).Error
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// 标签并入规范曲目
+	var tags []*UserTag
+	if err := tx.Where("target_type = ? AND target_id = ?", TagTargetTrack, source.ID).Find(&tags).Error; err != nil {
+		return 0, err
+	}
+	for _, tag := range tags {
+		tag.ID, tag.TargetID = 0, canonical.ID
+	}
+	if len(tags) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Where("target_type = ? AND target_id = ?", TagTargetTrack, source.ID).
+		Delete(&UserTag{}).Error; err != nil {
+		return 0, err
+	}
+
+	canonical.PlayCount += source.PlayCount
+	canonical.SkipCount += source.SkipCount
+	canonical.TotalListenedSeconds += source.TotalListenedSeconds
+	canonical.IsLastFmFav = canonical.IsLastFmFav || source.IsLastFmFav
+	canonical.IsAppleMusicFav = canonical.IsAppleMusicFav || source.IsAppleMusicFav
+	if canonical.Rating == 0 {
+		canonical.Rating = source.Rating
+	}
+	if err := tx.Model(&Track{}).Where("id = ?", canonical.ID).Updates(
+		map[string]any{
+			"play_count":             canonical.PlayCount,
+			"skip_count":             canonical.SkipCount,
+			"total_listened_seconds": canonical.TotalListenedSeconds,
+			"is_last_fm_fav":         canonical.IsLastFmFav,
+			"is_apple_music_fav":     canonical.IsAppleMusicFav,
+			"rating":                 canonical.Rating,
+			"version":                gorm.Expr("version + 1"),
+			"updated_at":             time.Now(),
+		},
+	).Error; err != nil {
+		return 0, err
+	}
+
+	if err := moveTrackArtists(tx, canonical.ID, source.ID); err != nil {
+		return 0, err
+	}
+
+	// 指向 source 的别名改指规范曲目，再记录 source 本身
+	if err := tx.Model(&TrackAlias{}).Where("track_id = ?", source.ID).
+		Update("track_id", canonical.ID).Error; err != nil {
+		return 0, err
+	}
+	if err := upsertTrackAlias(tx, canonical, source.Artist, source.Album, source.Track); err != nil {
+		return 0, err
+	}
+	return moved, tx.Delete(&Track{}, source.ID).Error
+}
+
+// moveTrackData 把指定艺术家/专辑/曲目名下的播放记录、播放事件、解读与歌词改写为规范曲目
+func moveTrackData(tx *gorm.DB, canonical *Track, artist, album, track string) (int64, error) {
+	if artist == canonical.Artist && album == canonical.Album && track == canonical.Track {
+		return 0, nil
+	}
+	var canonicalAlbum Album
+	if err := tx.Where("artist = ? AND name = ?", canonical.Artist, canonical.Album).
+		Limit(1).Find(&canonicalAlbum).Error; err != nil {
+		return 0, err
+	}
+	where := "artist = ? AND album = ? AND track = ?"
+	result := tx.Model(&TrackPlayRecord{}).Where(where, artist, album, track).Updates(
+		map[string]any{
+			"artist": canonical.Artist, "album": canonical.Album, "track": canonical.Track,
+			"album_id": canonicalAlbum.ID, "track_number": canonical.TrackNumber, "updated_at": time.Now(),
+		},
+	)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if err := tx.Model(&PlayEvent{}).Where(where, artist, album, track).Updates(
+		map[string]any{"artist": canonical.Artist, "album": canonical.Album, "track": canonical.Track},
+	).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&TrackInsight{}).Where(where, artist, album, track).Updates(
+		map[string]any{
+			"track_id": canonical.ID, "artist": canonical.Artist, "album": canonical.Album, "track": canonical.Track,
+		},
+	).Error; err != nil {
+		return 0, err
+	}
+
+	// 歌词按艺术家/专辑/曲目名唯一，规范曲目已有歌词时丢弃别名的歌词
+	var count int64
+	if err := tx.Model(&TrackLyrics{}).Where(where, canonical.Artist, canonical.Album, canonical.Track).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		err := tx.Where(where, artist, album, track).Delete(&TrackLyrics{}).Error
+		return result.RowsAffected, err
+	}
+	err := tx.Model(&TrackLyrics{}).Where(where, artist, album, track).Updates(
+		map[string]any{
+			"track_id": canonical.ID, "artist": canonical.Artist, "album": canonical.Album, "track": canonical.Track,
+		},
+	).Error
+	return result.RowsAffected, err
+}
+
+// upsertTrackAlias 记录别名，与规范曲目同名（仅曲目号不同）时不需要别名
+func upsertTrackAlias(tx *gorm.DB, canonical *Track, artist, album, track string) error {
+	if artist == canonical.Artist && album == canonical.Album && track == canonical.Track {
+		return nil
+	}
+	alias := &TrackAlias{TrackID: canonical.ID, Artist: artist, Album: album, Track: track}
+	return tx.Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "artist"}, {Name: "album"}, {Name: "track"}},
+			DoUpdates: clause.AssignmentColumns([]string{"track_id"}),
+		},
+	).Create(alias).Error
+}
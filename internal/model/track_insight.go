@@ -57,11 +57,16 @@ func (j JSONText) Value() (driver.Value, error) {
 }
 
 func (j *JSONText) Scan(value interface{}) error {
-	b, ok := value.([]byte)
-	if !ok {
-		return fmt.Errorf("invalid scan")
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, j)
+	case string:
+		// SQLite 的 text 列以 string 返回
+		return json.Unmarshal([]byte(v), j)
 	}
-	return json.Unmarshal(b, j)
+	return fmt.Errorf("invalid scan")
 }
 
 // TableName 自定义表名
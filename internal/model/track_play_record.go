@@ -109,6 +109,16 @@ func InsertTrackPlayRecord(ctx context.Context, record *TrackPlayRecord) error {
 		return err
 	}
 
+	// 命中别名时记到规范曲目上
+	canonical, err := ResolveTrackAlias(ctx, record.Artist, record.Album, record.Track)
+	if err != nil {
+		return err
+	}
+	if canonical != nil {
+		record.Artist, record.Album, record.Track = canonical.Artist, canonical.Album, canonical.Track
+		record.TrackNumber, record.AlbumID = canonical.TrackNumber, 0
+	}
+
 	// 避免零时间被写入 MySQL 为 "0000-00-00 00:00:00"
 	if record.PlayTime.IsZero() {
 		record.PlayTime = time.Now()
@@ -122,6 +132,17 @@ func InsertTrackPlayRecord(ctx context.Context, record *TrackPlayRecord) error {
 	return GetDB().WithContext(ctx).Create(record).Error
 }
 
+// ExistsTrackPlayRecord 判断同一艺术家、曲目在该时间点（秒级）是否已有播放记录，用于导入去重
+func ExistsTrackPlayRecord(ctx context.Context, artist, track string, playTime time.Time) (bool, error) {
+	var count int64
+	start := playTime.Truncate(time.Second)
+	err := GetDB().WithContext(ctx).Model(&TrackPlayRecord{}).
+		Where("artist = ? AND track = ?", artist, track).
+		Where("play_time >= ? AND play_time < ?", start, start.Add(time.Second)).
+		Count(&count).Error
+	return count > 0, err
+}
+
 // getAlbumIDByTrackInfo 通过 Track -> TrackAlbum 关联获取 AlbumID
 func getAlbumIDByTrackInfo(ctx context.Context, artist, album, track string) int64 {
 	var trackObj Track
@@ -0,0 +1,467 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 评分与标签的对象类型
+const (
+	TagTargetTrack = "track"
+	TagTargetAlbum = "album"
+)
+
+const (
+	MaxRating    = 5
+	maxTagLength = 100
+)
+
+var (
+	ErrInvalidRating    = errors.New("rating must be between 0 and 5")
+	ErrInvalidTagTarget = errors.New("target type must be track or album")
+	ErrInvalidTag       = errors.New("invalid tag")
+)
+
+// UserTag 对应 user_tag 表，曲目或专辑上的自由标签，标签统一去除首尾空白并转为小写
+type UserTag struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	TargetType string    `gorm:"column:target_type;type:varchar(20);not null;uniqueIndex:uidx_user_tag_target_tag;index:idx_user_tag_tag,priority:2" json:"target_type"`
+	TargetID   int64     `gorm:"column:target_id;type:bigint;not null;uniqueIndex:uidx_user_tag_target_tag" json:"target_id"`
+	Tag        string    `gorm:"column:tag;type:varchar(100);not null;uniqueIndex:uidx_user_tag_target_tag;index:idx_user_tag_tag,priority:1" json:"tag"`
+	CreatedAt  time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName sets the table name for the UserTag model
+func (UserTag) TableName() string {
+	return "user_tag"
+}
+
+// TopRatedStat 评分最高的曲目或专辑，由 dashboard 重型统计重建
+type TopRatedStat struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	TargetType string    `gorm:"column:target_type;type:varchar(20);not null;index:idx_top_rated_type_rank" json:"target_type"`
+	TargetID   int64     `gorm:"column:target_id;type:bigint;not null" json:"target_id"`
+	Artist     string    `gorm:"column:artist;type:varchar(255);not null" json:"artist"`
+	Album      string    `gorm:"column:album;type:varchar(255);not null" json:"album"`
+	Track      string    `gorm:"column:track;type:varchar(255);default:''" json:"track"` // 专辑为空
+	Rating     int8      `gorm:"column:rating;type:tinyint;not null" json:"rating"`
+	PlayCount  int64     `gorm:"column:play_count;type:bigint;default:0" json:"play_count"`
+	Rank       int       `gorm:"column:rank;type:int;not null;index:idx_top_rated_type_rank" json:"rank"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (TopRatedStat) TableName() string {
+	return "top_rated_stat"
+}
+
+// TagStat 按标签汇总的曲目或专辑数、播放次数与平均评分（只计已评分的），由 dashboard 重型统计重建
+type TagStat struct {
+	ID         int64     `gorm:"column:id;type:bigint;primaryKey;autoIncrement" json:"id"`
+	TargetType string    `gorm:"column:target_type;type:varchar(20);not null;index:idx_tag_stat_type_rank" json:"target_type"`
+	Tag        string    `gorm:"column:tag;type:varchar(100);not null" json:"tag"`
+	ItemCount  int64     `gorm:"column:item_count;type:bigint;default:0" json:"item_count"`
+	PlayCount  int64     `gorm:"column:play_count;type:bigint;default:0" json:"play_count"`
+	AvgRating  float64   `gorm:"column:avg_rating;type:double;default:0" json:"avg_rating"`
+	Rank       int       `gorm:"column:rank;type:int;not null;index:idx_tag_stat_type_rank" json:"rank"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (TagStat) TableName() string {
+	return "tag_stat"
+}
+
+// TagCount 标签及使用它的曲目或专辑数
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// ListFilter /api/tracks 与 /api/albums 的筛选条件，字段为空时不筛选
+type ListFilter struct {
+	Keyword   string
+	Tag       string
+	MinRating int
+}
+
+// apply 追加标签与评分条件，table 为查询的表名或别名
+func (f ListFilter) apply(db *gorm.DB, targetType, table string) *gorm.DB {
+	if tag := NormalizeTag(f.Tag); tag != "" {
+		db = db.Where(
+			fmt.Sprintf("EXISTS (SELECT 1 FROM user_tag ut WHERE ut.target_type = ? AND ut.target_id = %s.id AND ut.tag = ?)", table),
+			targetType, tag,
+		)
+	}
+	if f.MinRating > 0 {
+		db = db.Where(table+".rating >= ?", f.MinRating)
+	}
+	return db
+}
+
+// NormalizeTag 去除首尾空白、合并连续空白并转为小写
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, raw := range tags {
+		tag := NormalizeTag(raw)
+		if tag == "" {
+			return nil, fmt.Errorf("%w: empty tag", ErrInvalidTag)
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidTag, tag, maxTagLength)
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		result = append(result, tag)
+	}
+	return result, nil
+}
+
+func tagTargetModel(targetType string) (interface{}, error) {
+	switch targetType {
+	case TagTargetTrack:
+		return &Track{}, nil
+	case TagTargetAlbum:
+		return &Album{}, nil
+	default:
+		return nil, ErrInvalidTagTarget
+	}
+}
+
+// touchTargets 更新对象的 updated_at 与 updates 中的字段，使 D1 增量同步带上评分与标签的变化
+func touchTargets(tx *gorm.DB, targetType string, ids []int64, updates map[string]any) error {
+	target, err := tagTargetModel(targetType)
+	if err != nil {
+		return err
+	}
+	if updates == nil {
+		updates = make(map[string]any)
+	}
+	updates["updated_at"] = time.Now()
+	if targetType == TagTargetTrack {
+		updates["version"] = gorm.Expr("version + 1")
+	}
+	return tx.Model(target).Where("id IN ?", ids).Updates(updates).Error
+}
+
+// checkTargetsExist 任一对象不存在时返回 gorm.ErrRecordNotFound
+func checkTargetsExist(tx *gorm.DB, targetType string, ids []int64) ([]int64, error) {
+	target, err := tagTargetModel(targetType)
+	if err != nil {
+		return nil, err
+	}
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	var count int64
+	if err := tx.Model(target).Where("id IN ?", unique).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if int(count) != len(unique) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return unique, nil
+}
+
+// SetRating 设置曲目或专辑的本地评分，0 表示清除评分
+func SetRating(ctx context.Context, targetType string, id int64, rating int) error {
+	if rating < 0 || rating > MaxRating {
+		return ErrInvalidRating
+	}
+	return GetDB().WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if _, err := checkTargetsExist(tx, targetType, []int64{id}); err != nil {
+				return err
+			}
+			return touchTargets(tx, targetType, []int64{id}, map[string]any{"rating": rating})
+		},
+	)
+}
+
+// GetTags 获取曲目或专辑的标签，按名称排序
+func GetTags(ctx context.Context, targetType string, id int64) ([]string, error) {
+	if _, err := tagTargetModel(targetType); err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0)
+	err := GetDB().WithContext(ctx).Model(&UserTag{}).
+		Where("target_type = ? AND target_id = ?", targetType, id).
+		Order("tag ASC").Pluck("tag", &tags).Error
+	return tags, err
+}
+
+// AddTags 为多个曲目或专辑添加标签，已有的标签忽略
+func AddTags(ctx context.Context, targetType string, ids []int64, tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 || len(tags) == 0 {
+		return nil
+	}
+	return GetDB().WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			ids, err := checkTargetsExist(tx, targetType, ids)
+			if err != nil {
+				return err
+			}
+			rows := make([]UserTag, 0, len(ids)*len(tags))
+			for _, id := range ids {
+				for _, tag := range tags {
+					rows = append(rows, UserTag{TargetType: targetType, TargetID: id, Tag: tag})
+				}
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, 500).Error; err != nil {
+				return err
+			}
+			return touchTargets(tx, targetType, ids, nil)
+		},
+	)
+}
+
+// RemoveTags 移除多个曲目或专辑上的标签
+func RemoveTags(ctx context.Context, targetType string, ids []int64, tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 || len(tags) == 0 {
+		return nil
+	}
+	if _, err := tagTargetModel(targetType); err != nil {
+		return err
+	}
+	return GetDB().WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Where(
+				"target_type = ? AND target_id IN ? AND tag IN ?", targetType, ids, tags,
+			).Delete(&UserTag{}).Error; err != nil {
+				return err
+			}
+			return touchTargets(tx, targetType, ids, nil)
+		},
+	)
+}
+
+// GetTagCounts 全部标签及使用次数，按使用次数倒序
+func GetTagCounts(ctx context.Context, targetType string) ([]*TagCount, error) {
+	if _, err := tagTargetModel(targetType); err != nil {
+		return nil, err
+	}
+	rows := make([]*TagCount, 0)
+	err := GetDB().WithContext(ctx).Model(&UserTag{}).
+		Select("tag, COUNT(*) AS count").
+		Where("target_type = ?", targetType).
+		Group("tag").Order("count DESC, tag ASC").Find(&rows).Error
+	return rows, err
+}
+
+// getTagsByTargets 批量获取标签，键为对象 ID
+func getTagsByTargets(ctx context.Context, targetType string, ids []int64) (map[int64][]string, error) {
+	result := make(map[int64][]string, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var rows []*UserTag
+	if err := GetDB().WithContext(ctx).
+		Where("target_type = ? AND target_id IN ?", targetType, ids).
+		Order("tag ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.TargetID] = append(result[row.TargetID], row.Tag)
+	}
+	return result, nil
+}
+
+// AttachTrackTags 填充曲目的 Tags
+func AttachTrackTags(ctx context.Context, tracks []*Track) error {
+	ids := make([]int64, 0, len(tracks))
+	for _, track := range tracks {
+		ids = append(ids, track.ID)
+	}
+	tags, err := getTagsByTargets(ctx, TagTargetTrack, ids)
+	if err != nil {
+		return err
+	}
+	for _, track := range tracks {
+		track.Tags = tags[track.ID]
+	}
+	return nil
+}
+
+// AttachAlbumTags 填充专辑的 Tags
+func AttachAlbumTags(ctx context.Context, albums []*Album) error {
+	ids := make([]int64, 0, len(albums))
+	for _, album := range albums {
+		ids = append(ids, album.ID)
+	}
+	tags, err := getTagsByTargets(ctx, TagTargetAlbum, ids)
+	if err != nil {
+		return err
+	}
+	for _, album := range albums {
+		album.Tags = tags[album.ID]
+	}
+	return nil
+}
+
+// albumPlaysJoin 按 album_id 汇总的播放记录数，别名 p
+const albumPlaysJoin = "LEFT JOIN (SELECT album_id, COUNT(*) AS plays FROM track_play_records GROUP BY album_id) p ON p.album_id = a.id"
+
+// refreshTopRatedStats 按评分、播放次数排序重建曲目与专辑的前 topN 名，专辑的播放次数取关联的播放记录数
+func refreshTopRatedStats(tx *gorm.DB, topN int) error {
+	var tracks []TopRatedStat
+	if err := tx.Model(&Track{}).
+		Select("id AS target_id, artist, album, track, rating, play_count").
+		Where("rating > 0").
+		Order("rating DESC, play_count DESC, id ASC").
+		Limit(topN).Find(&tracks).Error; err != nil {
+		return err
+	}
+	var albums []TopRatedStat
+	if err := tx.Table("album AS a").
+		Select("a.id AS target_id, a.artist, a.name AS album, a.rating, COALESCE(p.plays, 0) AS play_count").
+		Joins(albumPlaysJoin).
+		Where("a.rating > 0").
+		Order("a.rating DESC, play_count DESC, a.id ASC").
+		Limit(topN).Find(&albums).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("1 = 1").Delete(&TopRatedStat{}).Error; err != nil {
+		return err
+	}
+	items := make([]TopRatedStat, 0, len(tracks)+len(albums))
+	for i, row := range tracks {
+		row.TargetType, row.Rank = TagTargetTrack, i+1
+		items = append(items, row)
+	}
+	for i, row := range albums {
+		row.TargetType, row.Rank = TagTargetAlbum, i+1
+		items = append(items, row)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Create(&items).Error
+}
+
+// refreshTagStats 按使用次数重建曲目与专辑标签的前 topN 名
+func refreshTagStats(tx *gorm.DB, topN int) error {
+	var tracks []TagStat
+	if err := tx.Table("user_tag AS ut").
+		Select(
+			"ut.tag, COUNT(*) AS item_count, COALESCE(SUM(t.play_count), 0) AS play_count, "+
+				"COALESCE(AVG(CASE WHEN t.rating > 0 THEN t.rating END), 0) AS avg_rating",
+		).
+		Joins("JOIN track t ON t.id = ut.target_id").
+		Where("ut.target_type = ?", TagTargetTrack).
+		Group("ut.tag").
+		Order("item_count DESC, play_count DESC, ut.tag ASC").
+		Limit(topN).Find(&tracks).Error; err != nil {
+		return err
+	}
+	var albums []TagStat
+	if err := tx.Table("user_tag AS ut").
+		Select(
+			"ut.tag, COUNT(*) AS item_count, COALESCE(SUM(p.plays), 0) AS play_count, "+
+				"COALESCE(AVG(CASE WHEN a.rating > 0 THEN a.rating END), 0) AS avg_rating",
+		).
+		Joins("JOIN album a ON a.id = ut.target_id").
+		Joins(albumPlaysJoin).
+		Where("ut.target_type = ?", TagTargetAlbum).
+		Group("ut.tag").
+		Order("item_count DESC, play_count DESC, ut.tag ASC").
+		Limit(topN).Find(&albums).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("1 = 1").Delete(&TagStat{}).Error; err != nil {
+		return err
+	}
+	items := make([]TagStat, 0, len(tracks)+len(albums))
+	for i, row := range tracks {
+		row.TargetType, row.Rank = TagTargetTrack, i+1
+		items = append(items, row)
+	}
+	for i, row := range albums {
+		row.TargetType, row.Rank = TagTargetAlbum, i+1
+		items = append(items, row)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Create(&items).Error
+}
+
+// GetTopRatedFromStat 评分最高的曲目或专辑
+func GetTopRatedFromStat(ctx context.Context, targetType string, limit int) ([]*TopRatedStat, error) {
+	if _, err := tagTargetModel(targetType); err != nil {
+		return nil, err
+	}
+	rows := make([]*TopRatedStat, 0)
+	err := GetDB().WithContext(ctx).Where("target_type = ?", targetType).
+		Order("`rank` ASC").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+// GetTagStatsFromStat 使用最多的曲目或专辑标签
+func GetTagStatsFromStat(ctx context.Context, targetType string, limit int) ([]*TagStat, error) {
+	if _, err := tagTargetModel(targetType); err != nil {
+		return nil, err
+	}
+	rows := make([]*TagStat, 0)
+	err := GetDB().WithContext(ctx).Where("target_type = ?", targetType).
+		Order("`rank` ASC").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+func GetTopRatedStatsUpdatedSince(ctx context.Context, since time.Time) ([]*TopRatedStat, error) {
+	var rows []*TopRatedStat
+	query := GetDB().WithContext(ctx).Model(&TopRatedStat{})
+	if !since.IsZero() {
+		query = query.Where("updated_at >= ?", since)
+	}
+	err := query.Order("updated_at ASC").Find(&rows).Error
+	return rows, err
+}
+
+func GetTagStatsUpdatedSince(ctx context.Context, since time.Time) ([]*TagStat, error) {
+	var rows []*TagStat
+	query := GetDB().WithContext(ctx).Model(&TagStat{})
+	if !since.IsZero() {
+		query = query.Where("updated_at >= ?", since)
+	}
+	err := query.Order("updated_at ASC").Find(&rows).Error
+	return rows, err
+}
+
+// GetAlbumsUpdatedSince 获取自指定时间后更新的专辑，并填充 Tags
+func GetAlbumsUpdatedSince(ctx context.Context, since time.Time) ([]*Album, error) {
+	var albums []*Album
+	err := GetDB().WithContext(ctx).
+		Where("updated_at >= ?", since).
+		Order("updated_at ASC").
+		Find(&albums).Error
+	if err != nil {
+		return nil, err
+	}
+	return albums, AttachAlbumTags(ctx, albums)
+}
@@ -0,0 +1,220 @@
+package nowplaying
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/core/websocket"
+)
+
+const (
+	defaultHistorySize = 200
+	subscriberBuffer   = 32
+)
+
+// SourceState 单个播放器的最新状态
+type SourceState struct {
+	Source    string                 `json:"source"`
+	State     string                 `json:"state"` // Playing / Paused / Stopped
+	Track     *websocket.WsTrackInfo `json:"now_playing,omitempty"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// Transition 一次播放器状态变化：开始播放、切歌、暂停或停止，进度更新不记录
+type Transition struct {
+	At     time.Time `json:"at"`
+	Source string    `json:"source"`
+	From   string    `json:"from"` // 变化前的状态，首次出现时为空
+	State  string    `json:"state"`
+	Title  string    `json:"title,omitempty"`
+	Artist string    `json:"artist,omitempty"`
+	Album  string    `json:"album,omitempty"`
+}
+
+// Event 分发给订阅者的消息，与 websocket 推送的格式一致
+type Event struct {
+	Message *websocket.WsTrackInfo
+	Changed bool // 曲目、播放器或类型发生变化，只更新进度时为 false
+}
+
+// Hub 正在播放中心，保存各播放器的状态、持有者的曲目与最近的状态变化，
+// 并把持有者的消息分发给 websocket、SSE 与 webhook 等订阅者。订阅者处理不及时时丢弃消息，不阻塞检查器
+type Hub struct {
+	mu          sync.RWMutex
+	sources     map[string]*SourceState
+	current     *websocket.WsTrackInfo // 持有正在播放的播放器的曲目，没有播放器在播放时为 nil
+	history     []Transition           // 环形缓冲
+	next        int
+	full        bool
+	subscribers map[chan Event]struct{}
+	now         func() time.Time
+}
+
+var defaultHub = NewHub(defaultHistorySize)
+
+// Default 服务内共享的中心
+func Default() *Hub {
+	return defaultHub
+}
+
+// NewHub historySize 为保留的状态变化条数
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		sources:     make(map[string]*SourceState),
+		history:     make([]Transition, max(historySize, 1)),
+		subscribers: make(map[chan Event]struct{}),
+		now:         time.Now,
+	}
+}
+
+// Update 记录播放器的状态，track 为空时暂停保留上一首、停止清空。状态或曲目变化时记入历史
+func (h *Hub) Update(source, state string, track *websocket.WsTrackInfo) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	prev, ok := h.sources[source]
+	if !ok {
+		if state == common.PlayerStateStopped {
+			return
+		}
+		prev = &SourceState{Source: source}
+		h.sources[source] = prev
+	}
+	if track == nil && state == common.PlayerStatePaused {
+		track = prev.Track
+	}
+	if state == common.PlayerStateStopped {
+		track = nil
+	}
+
+	now := h.now()
+	if prev.State != state || !sameTrack(prev.Track, track) {
+		transition := Transition{At: now, Source: source, From: prev.State, State: state}
+		if info := track; info != nil || prev.Track != nil {
+			if info == nil {
+				info = prev.Track
+			}
+			transition.Title, transition.Artist, transition.Album = info.Data.Title, info.Data.Artist, info.Data.Album
+		}
+		h.history[h.next] = transition
+		h.next = (h.next + 1) % len(h.history)
+		h.full = h.full || h.next == 0
+	}
+	prev.State = state
+	prev.Track = track
+	prev.UpdatedAt = now
+}
+
+// Publish 分发持有者的消息，type 为 stop 时清空当前曲目
+func (h *Hub) Publish(ctx context.Context, message *websocket.WsTrackInfo) {
+	h.mu.Lock()
+	event := Event{Message: message, Changed: !sameMessage(h.current, message)}
+	if message.Type == "stop" {
+		h.current = nil
+	} else {
+		h.current = message
+	}
+	subscribers := make([]chan Event, 0, len(h.subscribers))
+	for ch := range h.subscribers {
+		subscribers = append(subscribers, ch)
+	}
+	h.mu.Unlock()
+
+	for _, ch := range subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Warn(ctx, "now playing subscriber is full, dropping message", zap.String("type", message.Type))
+		}
+	}
+}
+
+// Subscribe 订阅持有者的消息，调用 cancel 后通道关闭
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(
+			func() {
+				h.mu.Lock()
+				delete(h.subscribers, ch)
+				h.mu.Unlock()
+				close(ch)
+			},
+		)
+	}
+}
+
+// Current 持有正在播放的播放器的曲目，没有播放器在播放时返回 nil
+func (h *Hub) Current() *websocket.WsTrackInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.current
+}
+
+// Sources 各播放器的最新状态，按名称排序
+func (h *Hub) Sources() []SourceState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	states := make([]SourceState, 0, len(h.sources))
+	for _, state := range h.sources {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Source < states[j].Source })
+	return states
+}
+
+// History 最近的状态变化，按时间倒序，limit <= 0 时返回全部
+func (h *Hub) History(limit int) []Transition {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	count := h.next
+	if h.full {
+		count = len(h.history)
+	}
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+	transitions := make([]Transition, 0, limit)
+	for i := 1; i <= limit; i++ {
+		transitions = append(transitions, h.history[(h.next-i+len(h.history))%len(h.history)])
+	}
+	return transitions
+}
+
+// ForwardToWebSocket 把持有者的消息推送给 websocket 客户端，ctx 结束时退出
+func (h *Hub) ForwardToWebSocket(ctx context.Context) {
+	events, cancel := h.Subscribe()
+	defer cancel()
+	for {
+		select {
+		case event := <-events:
+			websocket.BroadcastMessage(ctx, event.Message)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func sameTrack(a, b *websocket.WsTrackInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Data.Title == b.Data.Title && a.Data.Artist == b.Data.Artist && a.Data.Album == b.Data.Album
+}
+
+func sameMessage(a, b *websocket.WsTrackInfo) bool {
+	if a == nil {
+		return b.Type == "stop"
+	}
+	return a.Type == b.Type && a.Source == b.Source && sameTrack(a, b)
+}
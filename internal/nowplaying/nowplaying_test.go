@@ -0,0 +1,147 @@
+package nowplaying
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/core/websocket"
+)
+
+func track(source, title string, position int64) *websocket.WsTrackInfo {
+	info := &websocket.WsTrackInfo{Type: "now_playing", Source: source}
+	info.Data.Title = title
+	info.Data.Artist = "Pink Floyd"
+	info.Data.Position = position
+	return info
+}
+
+func newTestHub(size int) *Hub {
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	hub := NewHub(size)
+	clock := time.Date(2025, 3, 1, 20, 0, 0, 0, time.UTC)
+	hub.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return hub
+}
+
+func TestHub_UpdateHistory(t *testing.T) {
+	hub := newTestHub(3)
+	hub.Update("Roon", common.PlayerStateStopped, nil) // 未出现过的播放器停止不记录
+	hub.Update("Roon", common.PlayerStatePlaying, track("Roon", "Time", 0))
+	hub.Update("Roon", common.PlayerStatePlaying, track("Roon", "Time", 3)) // 只更新进度
+	hub.Update("Roon", common.PlayerStatePaused, nil)
+
+	sources := hub.Sources()
+	require.Len(t, sources, 1)
+	assert.Equal(t, common.PlayerStatePaused, sources[0].State)
+	require.NotNil(t, sources[0].Track, "paused keeps the last track")
+	assert.EqualValues(t, 3, sources[0].Track.Data.Position)
+
+	history := hub.History(0)
+	require.Len(t, history, 2)
+	assert.Equal(t, common.PlayerStatePaused, history[0].State)
+	assert.Equal(t, common.PlayerStatePlaying, history[0].From)
+	assert.Equal(t, "Time", history[0].Title)
+	assert.Equal(t, "", history[1].From)
+
+	hub.Update("Roon", common.PlayerStatePlaying, track("Roon", "Money", 0))
+	hub.Update("Roon", common.PlayerStateStopped, nil)
+	history = hub.History(0)
+	require.Len(t, history, 3, "ring buffer keeps the latest transitions")
+	assert.Equal(t, common.PlayerStateStopped, history[0].State)
+	assert.Equal(t, "Money", history[0].Title)
+	assert.Equal(t, "Money", history[1].Title)
+	assert.Equal(t, common.PlayerStatePaused, history[2].State)
+	assert.Len(t, hub.History(1), 1)
+	assert.Nil(t, hub.Sources()[0].Track)
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := newTestHub(10)
+	ctx := context.Background()
+	events, cancel := hub.Subscribe()
+
+	hub.Publish(ctx, track("Roon", "Time", 0))
+	hub.Publish(ctx, track("Roon", "Time", 3))
+	hub.Publish(ctx, &websocket.WsTrackInfo{Type: "stop", Source: "Roon"})
+	assert.Nil(t, hub.Current())
+
+	var changed []bool
+	for range 3 {
+		changed = append(changed, (<-events).Changed)
+	}
+	assert.Equal(t, []bool{true, false, true}, changed)
+
+	hub.Publish(ctx, track("MPD", "Money", 0))
+	require.NotNil(t, hub.Current())
+	assert.Equal(t, "Money", hub.Current().Data.Title)
+
+	cancel()
+	cancel()
+	<-events
+	_, ok := <-events
+	assert.False(t, ok, "channel closed after cancel")
+	hub.Publish(ctx, track("MPD", "Money", 3))
+}
+
+func TestHub_StartWebhooks(t *testing.T) {
+	hub := newTestHub(10)
+	received := make(chan map[string]any, 10)
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				var payload map[string]any
+				_ = json.NewDecoder(r.Body).Decode(&payload)
+				received <- payload
+			},
+		),
+	)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.StartWebhooks(ctx, []string{server.URL})
+	}()
+	require.Eventually(
+		t, func() bool {
+			hub.mu.RLock()
+			defer hub.mu.RUnlock()
+			return len(hub.subscribers) == 1
+		}, time.Second, 10*time.Millisecond,
+	)
+
+	hub.Publish(ctx, track("Roon", "Time", 0))
+	hub.Publish(ctx, track("Roon", "Time", 3)) // 只更新进度不推送
+	hub.Publish(ctx, &websocket.WsTrackInfo{Type: "stop", Source: "Roon"})
+
+	first := <-received
+	assert.Equal(t, "now_playing", first["type"])
+	assert.Equal(t, "Time", first["data"].(map[string]any)["title"])
+	assert.NotEmpty(t, first["at"])
+	assert.Equal(t, "stop", (<-received)["type"])
+	select {
+	case payload := <-received:
+		t.Fatalf("unexpected webhook %v", payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,70 @@
+package nowplaying
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/core/websocket"
+)
+
+const webhookTimeout = 5 * time.Second
+
+// WebhookPayload 推送给 webhook 的内容，data 与 websocket 消息一致
+type WebhookPayload struct {
+	*websocket.WsTrackInfo
+	At time.Time `json:"at"`
+}
+
+// StartWebhooks 曲目、播放器或播放状态变化时逐个 POST 到 urls，只更新进度时不推送；ctx 结束时退出
+func (h *Hub) StartWebhooks(ctx context.Context, urls []string) {
+	if len(urls) == 0 {
+		return
+	}
+	events, cancel := h.Subscribe()
+	defer cancel()
+	client := &http.Client{Timeout: webhookTimeout}
+	for {
+		select {
+		case event := <-events:
+			if !event.Changed {
+				continue
+			}
+			body, err := json.Marshal(WebhookPayload{WsTrackInfo: event.Message, At: h.now()})
+			if err != nil {
+				log.Warn(ctx, "Failed to marshal now playing webhook", zap.Error(err))
+				continue
+			}
+			for _, url := range urls {
+				if err := postWebhook(ctx, client, url, body); err != nil {
+					log.Warn(ctx, "Failed to post now playing webhook", zap.String("url", url), zap.Error(err))
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func postWebhook(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package scrobbler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/websocket"
+	"github.com/vincentchyu/sonic-lens/internal/nowplaying"
+)
+
+// PlayerArbiter 多个播放器同时播放时决定由谁持有"正在播放"。
+// 只有持有者会广播 now_playing 并向各目标上报 UpdateNowPlaying；
+// 持有者按 playerPriority 配置的顺序选出，优先级相同（或均未配置）时最近开始播放的播放器持有
+type PlayerArbiter struct {
+	mu       sync.Mutex
+	priority map[common.PlayerType]int
+	claims   map[common.PlayerType]*playerClaim
+	owner    common.PlayerType
+	tokens   ClaimToken
+	now      func() time.Time
+}
+
+// ClaimToken 标识一次检查器运行。同一播放器停用后重新启用时，
+// 旧检查器迟到的上报与释放不会覆盖或清除新检查器的持有
+type ClaimToken uint64
+
+type playerClaim struct {
+	token ClaimToken
+	info  *websocket.WsTrackInfo
+	since time.Time // 开始播放的时间，切歌不更新
+}
+
+// NewPlayerArbiter priority 中越靠前优先级越高，名称不区分大小写，未列出的播放器优先级最低
+func NewPlayerArbiter(priority []string) *PlayerArbiter {
+	a := &PlayerArbiter{
+		priority: make(map[common.PlayerType]int, len(priority)),
+		claims:   make(map[common.PlayerType]*playerClaim),
+		now:      time.Now,
+	}
+	for i, name := range priority {
+		a.priority[common.PlayerType(strings.ToLower(name))] = i
+	}
+	return a
+}
+
+// NewToken 为新启动的检查器分配令牌，后分配的令牌更新
+func (a *PlayerArbiter) NewToken() ClaimToken {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens++
+	return a.tokens
+}
+
+// Claim 播放器上报正在播放的曲目，返回该播放器是否持有正在播放。
+// 已被更新的令牌占用时忽略本次上报
+func (a *PlayerArbiter) Claim(source common.PlayerType, token ClaimToken, info *websocket.WsTrackInfo) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	claim, ok := a.claims[source]
+	if ok && claim.token > token {
+		return false
+	}
+	if !ok || claim.token != token {
+		claim = &playerClaim{token: token, since: a.now()}
+		a.claims[source] = claim
+	}
+	claim.info = info
+	a.elect()
+	return a.owner == source
+}
+
+// Release 播放器停止播放或被停用，只释放 token 对应的持有。持有者发生变化时 changed 为 true，
+// next 为接管的播放器最近一次上报的曲目，没有播放器在播放时为 nil
+func (a *PlayerArbiter) Release(source common.PlayerType, token ClaimToken) (next *websocket.WsTrackInfo, changed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if claim, ok := a.claims[source]; !ok || claim.token != token {
+		return nil, false
+	}
+	delete(a.claims, source)
+	if a.owner != source {
+		return nil, false
+	}
+	a.elect()
+	if a.owner == "" {
+		return nil, true
+	}
+	return a.claims[a.owner].info, true
+}
+
+// Owner 当前持有正在播放的播放器，没有播放器在播放时返回 false
+func (a *PlayerArbiter) Owner() (common.PlayerType, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.owner, a.owner != ""
+}
+
+// IsOwner 播放器是否以 token 对应的持有占有正在播放，已被更新的令牌接管时返回 false
+func (a *PlayerArbiter) IsOwner(source common.PlayerType, token ClaimToken) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	claim, ok := a.claims[source]
+	return a.owner == source && ok && claim.token == token
+}
+
+// NowPlaying 返回播放器最近一次上报的曲目，未在播放时返回 nil
+func (a *PlayerArbiter) NowPlaying(source common.PlayerType) *websocket.WsTrackInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if claim, ok := a.claims[source]; ok {
+		return claim.info
+	}
+	return nil
+}
+
+// elect 重新选出持有者，调用方需持有锁
+func (a *PlayerArbiter) elect() {
+	a.owner = ""
+	var best *playerClaim
+	for source, claim := range a.claims {
+		if best == nil || a.before(source, claim, a.owner, best) {
+			a.owner, best = source, claim
+		}
+	}
+}
+
+// before 判断 x 是否应优先于 y 持有正在播放
+func (a *PlayerArbiter) before(x common.PlayerType, xc *playerClaim, y common.PlayerType, yc *playerClaim) bool {
+	if px, py := a.rank(x), a.rank(y); px != py {
+		return px < py
+	}
+	if !xc.since.Equal(yc.since) {
+		return xc.since.After(yc.since)
+	}
+	return x < y
+}
+
+func (a *PlayerArbiter) rank(source common.PlayerType) int {
+	if i, ok := a.priority[common.PlayerType(strings.ToLower(string(source)))]; ok {
+		return i
+	}
+	return len(a.priority)
+}
+
+// broadcastRelease 持有者释放后推送接管播放器的曲目，没有播放器在播放时推送 stop
+func broadcastRelease(ctx context.Context, source common.PlayerType, next *websocket.WsTrackInfo, changed bool) {
+	if !changed {
+		return
+	}
+	if next != nil {
+		nowplaying.Default().Publish(ctx, next)
+		return
+	}
+	nowplaying.Default().Publish(
+		ctx,
+		&websocket.WsTrackInfo{
+			Type:   "stop",
+			Source: string(source),
+		},
+	)
+}
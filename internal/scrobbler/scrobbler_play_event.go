@@ -0,0 +1,182 @@
+package scrobbler
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+const (
+	completedTailSeconds = 5 // 结束时距曲目末尾不超过五秒视为播完
+	seekToleranceSeconds = 3 // 进度与墙钟偏差超过三秒视为跳转
+)
+
+// PlayEventRecorder 记录每一次曲目播放的开始、收听秒数、跳转与结束原因，由全部检查器共享。
+// 某个播放器暂停后在其他播放器开始播放时，暂停中的播放以 switched_player 结束；
+// 多个播放器同时播放时互不影响
+type PlayEventRecorder struct {
+	mu   sync.Mutex
+	open map[common.PlayerType]*openPlay
+	save func(ctx context.Context, event *model.PlayEvent) error
+}
+
+// openPlay 进行中的播放
+type openPlay struct {
+	event    *model.PlayEvent
+	base     float64 // 开始时检查器已累计的收听秒数，同一曲目暂停后在其他播放器播放再恢复时不重复计入
+	listened float64
+	position float64
+	seenAt   time.Time
+	playing  bool
+	extra    float64 // 暂停前最后一次观测之后仍在播放的秒数，暂停中结束时计入
+}
+
+// NewPlayEventRecorder 创建记录器，结束的播放写入 play_event
+func NewPlayEventRecorder() *PlayEventRecorder {
+	return &PlayEventRecorder{
+		open: make(map[common.PlayerType]*openPlay),
+		save: model.SavePlayEvent,
+	}
+}
+
+// IsOpen 播放器是否有进行中的播放
+func (r *PlayEventRecorder) IsOpen(source common.PlayerType) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.open[source]
+	return ok
+}
+
+// Start 开始一次播放，listened 为检查器当前累计的收听秒数
+func (r *PlayEventRecorder) Start(
+	ctx context.Context, source common.PlayerType, event *model.PlayEvent, listened, position float64, now time.Time,
+) {
+	r.mu.Lock()
+	var ended []*model.PlayEvent
+	for other, play := range r.open {
+		if other != source && !play.playing {
+			delete(r.open, other)
+			ended = append(ended, play.end(model.PlayEndSwitchedPlayer, now))
+		}
+	}
+	event.Source = string(source)
+	event.StartedAt = now
+	r.open[source] = &openPlay{
+		event:    event,
+		base:     listened,
+		listened: listened,
+		position: position,
+		seenAt:   now,
+		playing:  true,
+	}
+	r.mu.Unlock()
+
+	for _, e := range ended {
+		r.persist(ctx, e)
+	}
+}
+
+// Observe 播放中的一次观测，进度变化与墙钟不符时记录一次跳转
+func (r *PlayEventRecorder) Observe(source common.PlayerType, listened, position float64, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	play, ok := r.open[source]
+	if !ok {
+		return
+	}
+	delta := position - play.position
+	elapsed := max(now.Sub(play.seenAt).Seconds(), 0)
+	if !play.playing {
+		// 暂停期间进度不变，恢复后最多经过一个轮询间隔才会观测到
+		elapsed = play.extra + min(elapsed, defaultSleep)
+	}
+	if delta < -seekToleranceSeconds || delta > elapsed+seekToleranceSeconds {
+		play.event.Seeks = append(play.event.Seeks, model.PlaySeek{From: round(play.position), To: round(position)})
+	}
+	play.listened = listened
+	play.position = position
+	play.seenAt = now
+	play.playing = true
+	play.extra = 0
+}
+
+// Pause 播放器暂停，播放保持进行中
+func (r *PlayEventRecorder) Pause(source common.PlayerType, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	play, ok := r.open[source]
+	if !ok || !play.playing {
+		return
+	}
+	play.extra = play.pending(now)
+	play.seenAt = now
+	play.playing = false
+}
+
+// MarkScrobbled 进行中的播放已达到标记阈值
+func (r *PlayEventRecorder) MarkScrobbled(source common.PlayerType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if play, ok := r.open[source]; ok {
+		play.event.Scrobbled = true
+	}
+}
+
+// Finish 结束播放器进行中的播放，播放到末尾时原因记为 completed
+func (r *PlayEventRecorder) Finish(ctx context.Context, source common.PlayerType, reason model.PlayEndReason, now time.Time) {
+	r.mu.Lock()
+	play, ok := r.open[source]
+	delete(r.open, source)
+	r.mu.Unlock()
+	if ok {
+		r.persist(ctx, play.end(reason, now))
+	}
+}
+
+func (r *PlayEventRecorder) persist(ctx context.Context, event *model.PlayEvent) {
+	if err := r.save(ctx, event); err != nil {
+		log.Warn(ctx, event.Source+" Failed to save play event", zap.String("track", event.Track), zap.Error(err))
+		return
+	}
+	log.Debug(
+		ctx, event.Source+" 播放结束", zap.String("track", event.Track), zap.String("reason", string(event.EndReason)),
+		zap.Int64("listened", event.ListenedSeconds),
+	)
+}
+
+// pending 上次观测之后仍在播放的秒数，不超过曲目剩余时长
+func (p *openPlay) pending(now time.Time) float64 {
+	extra := max(now.Sub(p.seenAt).Seconds(), 0)
+	if duration := float64(p.event.Duration); duration > 0 {
+		extra = min(extra, max(duration-p.position, 0))
+	}
+	return extra
+}
+
+func (p *openPlay) end(reason model.PlayEndReason, now time.Time) *model.PlayEvent {
+	extra := p.extra
+	if p.playing {
+		extra = p.pending(now)
+	}
+	position := p.position + extra
+	duration := p.event.Duration
+	if duration > 0 && position >= float64(duration-completedTailSeconds) {
+		reason = model.PlayEndCompleted
+	}
+	p.event.EndedAt = now
+	p.event.EndReason = reason
+	p.event.EndPosition = int64(math.Round(position))
+	p.event.ListenedSeconds = int64(math.Round(max(p.listened-p.base, 0) + extra))
+	return p.event
+}
+
+func round(seconds float64) float64 {
+	return math.Round(seconds*10) / 10
+}
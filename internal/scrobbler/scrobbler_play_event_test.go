@@ -0,0 +1,116 @@
+package scrobbler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+func newTestRecorder(t *testing.T) (*PlayEventRecorder, *[]*model.PlayEvent) {
+	t.Helper()
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	var saved []*model.PlayEvent
+	r := NewPlayEventRecorder()
+	r.save = func(ctx context.Context, event *model.PlayEvent) error {
+		saved = append(saved, event)
+		return nil
+	}
+	return r, &saved
+}
+
+func TestPlayEventRecorder(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds float64) time.Time { return start.Add(time.Duration(seconds * float64(time.Second))) }
+	track := func(name string, duration int64) *model.PlayEvent {
+		return &model.PlayEvent{Artist: "A", Album: "X", Track: name, Duration: duration}
+	}
+
+	t.Run(
+		"切歌前未播完记为跳过并记录跳转", func(t *testing.T) {
+			r, saved := newTestRecorder(t)
+			r.Start(ctx, common.PlayerMpd, track("one", 200), 0, 0, at(0))
+			r.Observe(common.PlayerMpd, 3, 3, at(3))
+			r.Observe(common.PlayerMpd, 6, 120, at(6)) // 向前拖动
+			r.Observe(common.PlayerMpd, 9, 123, at(9))
+			r.Finish(ctx, common.PlayerMpd, model.PlayEndSkipped, at(10))
+
+			require.Len(t, *saved, 1)
+			event := (*saved)[0]
+			assert.Equal(t, model.PlayEndSkipped, event.EndReason)
+			assert.Equal(t, string(common.PlayerMpd), event.Source)
+			assert.EqualValues(t, 10, event.ListenedSeconds)
+			assert.EqualValues(t, 124, event.EndPosition)
+			assert.Equal(t, []model.PlaySeek{{From: 3, To: 120}}, event.Seeks)
+			assert.False(t, r.IsOpen(common.PlayerMpd))
+		},
+	)
+
+	t.Run(
+		"播放到末尾记为完成", func(t *testing.T) {
+			r, saved := newTestRecorder(t)
+			r.Start(ctx, common.PlayerMpd, track("one", 100), 0, 0, at(0))
+			r.Observe(common.PlayerMpd, 97, 97, at(97))
+			r.MarkScrobbled(common.PlayerMpd)
+			// 下一次观测时已经切到下一首，剩余三秒按播完计入
+			r.Finish(ctx, common.PlayerMpd, model.PlayEndSkipped, at(102))
+
+			require.Len(t, *saved, 1)
+			event := (*saved)[0]
+			assert.Equal(t, model.PlayEndCompleted, event.EndReason)
+			assert.EqualValues(t, 100, event.ListenedSeconds)
+			assert.True(t, event.Scrobbled)
+			assert.Empty(t, event.Seeks)
+		},
+	)
+
+	t.Run(
+		"暂停后在其他播放器播放记为切换播放器", func(t *testing.T) {
+			r, saved := newTestRecorder(t)
+			r.Start(ctx, common.PlayerMpd, track("one", 300), 0, 0, at(0))
+			r.Observe(common.PlayerMpd, 30, 30, at(30))
+			r.Pause(common.PlayerMpd, at(31))
+			r.Pause(common.PlayerMpd, at(40))
+			require.Empty(t, *saved)
+
+			// 另一个播放器仍在播放时不受影响
+			r.Start(ctx, common.PlayerRoon, track("two", 300), 0, 0, at(50))
+			r.Start(ctx, common.PlayerAppleMusic, track("three", 300), 0, 0, at(60))
+
+			require.Len(t, *saved, 1)
+			event := (*saved)[0]
+			assert.Equal(t, "one", event.Track)
+			assert.Equal(t, model.PlayEndSwitchedPlayer, event.EndReason)
+			assert.EqualValues(t, 31, event.ListenedSeconds)
+			assert.True(t, r.IsOpen(common.PlayerRoon))
+		},
+	)
+
+	t.Run(
+		"暂停恢复不算跳转，停止后结束", func(t *testing.T) {
+			r, saved := newTestRecorder(t)
+			r.Start(ctx, common.PlayerMpd, track("one", 300), 10, 0, at(0))
+			r.Observe(common.PlayerMpd, 40, 30, at(30))
+			r.Pause(common.PlayerMpd, at(32))
+			r.Observe(common.PlayerMpd, 43, 33, at(600))
+			r.Finish(ctx, common.PlayerMpd, model.PlayEndStopped, at(601))
+			r.Finish(ctx, common.PlayerMpd, model.PlayEndStopped, at(700))
+
+			require.Len(t, *saved, 1)
+			event := (*saved)[0]
+			assert.Equal(t, model.PlayEndStopped, event.EndReason)
+			assert.Empty(t, event.Seeks)
+			assert.EqualValues(t, 34, event.ListenedSeconds, "excludes listening counted before the play started")
+		},
+	)
+}
@@ -7,11 +7,20 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
 	"github.com/vincentchyu/sonic-lens/core/applemusic"
 	"github.com/vincentchyu/sonic-lens/core/log"
 	"github.com/vincentchyu/sonic-lens/internal/cache"
 )
 
+func init() {
+	RegisterPlayer(
+		common.PlayerAppleMusic, func(cfg *config.Config) PlayerController {
+			return &AppleMusicPlayerController{}
+		},
+	)
+}
+
 // AppleMusicTrackInfoWrapper 包装 AppleMusic TrackInfo 以实现 PlayerInfoHandler 接口
 type AppleMusicTrackInfoWrapper struct {
 	*applemusic.TrackInfo
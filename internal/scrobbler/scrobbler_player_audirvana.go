@@ -4,10 +4,19 @@ import (
 	"context"
 
 	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
 	"github.com/vincentchyu/sonic-lens/core/audirvana"
 	"github.com/vincentchyu/sonic-lens/internal/cache"
 )
 
+func init() {
+	RegisterPlayer(
+		common.PlayerAudirvana, func(cfg *config.Config) PlayerController {
+			return &AudirvanaPlayerController{}
+		},
+	)
+}
+
 // AudirvanaTrackInfoWrapper 包装 Audirvana TrackInfo 以实现 PlayerInfoHandler 接口
 type AudirvanaTrackInfoWrapper struct {
 	*audirvana.TrackInfo
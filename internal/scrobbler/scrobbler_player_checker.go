@@ -2,7 +2,6 @@ package scrobbler
 
 import (
 	"context"
-	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,8 +12,10 @@ import (
 	"github.com/vincentchyu/sonic-lens/core/log"
 	"github.com/vincentchyu/sonic-lens/core/telemetry"
 	"github.com/vincentchyu/sonic-lens/core/websocket"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
 	"github.com/vincentchyu/sonic-lens/internal/logic/track"
 	"github.com/vincentchyu/sonic-lens/internal/model"
+	"github.com/vincentchyu/sonic-lens/internal/nowplaying"
 )
 
 // NewBasePlayerChecker 创建基础播放器检查器
@@ -22,31 +23,60 @@ func NewBasePlayerChecker(
 	controller PlayerController,
 	source common.PlayerType,
 	pushCount *atomic.Uint32,
-	atomicPlaying *atomic.Bool,
-	currentPlayingCache *sync.Map,
+	arbiter *PlayerArbiter,
 	trackService track.TrackService,
+	policy ScrobblePolicy,
+	targets []scrobble.Target,
+	plays *PlayEventRecorder,
+	token ClaimToken,
 ) *BasePlayerChecker {
 	return &BasePlayerChecker{
-		controller:          controller,
-		source:              source,
-		defaultSleep:        time.Second * defaultSleep,
-		longSleep:           time.Second * longSleep,
-		checkCount:          checkCount,
-		percentScrobble:     percentScrobble,
-		mapedTracks:         make(map[string]bool),
-		pushCount:           pushCount,
-		atomicPlaying:       atomicPlaying,
-		currentPlayingCache: currentPlayingCache,
-		trackService:        trackService,
+		controller:   controller,
+		source:       source,
+		defaultSleep: time.Second * defaultSleep,
+		longSleep:    time.Second * longSleep,
+		checkCount:   checkCount,
+		policy:       policy,
+		targets:      targets,
+		plays:        plays,
+		mapedTracks:  make(map[string]bool),
+		pushCount:    pushCount,
+		arbiter:      arbiter,
+		token:        token,
+		trackService: trackService,
+		clock:        time.Now,
+		newTimer:     newStdScrobbleTimer,
 	}
 }
 
-// _CheckPlayingTrack 基础播放检查逻辑
+// CheckPlayingTrack 基础播放检查逻辑，控制器支持推送时消费事件，否则按 ticker 轮询
 func (b *BasePlayerChecker) CheckPlayingTrack(ctx context.Context, stop <-chan struct{}) {
-	b.timer = time.NewTicker(b.defaultSleep)
 	b.tmpCount = 0
 	b.previousTrack = ""
 	b.currentTrack = ""
+	// 停用或退出时结束进行中的播放，并等待后台的喜欢状态同步完成
+	defer func() {
+		b.plays.Finish(context.WithoutCancel(ctx), b.source, model.PlayEndStopped, b.clock())
+		b.Wait()
+	}()
+
+	if source, ok := b.controller.(PlayerEventSource); ok {
+		if b.watchEvents(ctx, source, stop) {
+			return
+		}
+	}
+	b.pollLoop(ctx, stop)
+}
+
+// Wait 等待标记后在后台执行的喜欢状态同步完成
+func (b *BasePlayerChecker) Wait() {
+	b.background.Wait()
+}
+
+// pollLoop 轮询模式，空闲 checkCount 次后放大为 longSleep
+func (b *BasePlayerChecker) pollLoop(ctx context.Context, stop <-chan struct{}) {
+	b.timer = time.NewTicker(b.defaultSleep)
+	defer b.timer.Stop()
 
 	for {
 		select {
@@ -59,7 +89,64 @@ func (b *BasePlayerChecker) CheckPlayingTrack(ctx context.Context, stop <-chan s
 	}
 }
 
-// checkCycle 执行一次检查周期
+// watchEvents 推送模式，收到事件或到达标记阈值时刷新一次播放状态
+// 返回 true 表示收到退出信号，false 表示事件源不可用需要回退为轮询
+func (b *BasePlayerChecker) watchEvents(ctx context.Context, source PlayerEventSource, stop <-chan struct{}) bool {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := source.Events(watchCtx)
+	if err != nil {
+		log.Warn(ctx, string(b.source)+" 订阅播放器事件失败，回退为轮询", zap.Error(err))
+		return false
+	}
+	log.Info(ctx, string(b.source)+" 使用推送模式检查播放")
+
+	b.scrobbleTimer = b.newTimer()
+	defer func() {
+		b.scrobbleTimer.Stop()
+		b.scrobbleTimer = nil
+	}()
+
+	// 启动时播放器可能已在播放，先主动刷新一次
+	b.refresh(ctx, "init")
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				log.Warn(ctx, string(b.source)+" 播放器事件通道已关闭，回退为轮询")
+				return false
+			}
+			log.Debug(
+				ctx, string(b.source)+" 收到播放器事件", zap.String("type", string(event.Type)),
+				zap.String("state", event.State), zap.Float64("position", event.Position),
+			)
+			// 同一时刻常会连续推送状态与曲目变化，合并后只刷新一次
+			drainEvents(events)
+			b.refresh(ctx, string(event.Type))
+		case <-b.scrobbleTimer.C():
+			b.refresh(ctx, "scrobble_timer")
+		case <-stop:
+			log.Info(ctx, string(b.source)+" check playing track exit")
+			return true
+		}
+	}
+}
+
+func drainEvents(events <-chan PlayerEvent) {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// checkCycle 执行一次轮询检查周期
 func (b *BasePlayerChecker) checkCycle(ctx context.Context) {
 	// Start a new span for this check cycle
 	checkCtx, span := telemetry.StartSpanForTracerName(
@@ -82,62 +169,80 @@ func (b *BasePlayerChecker) checkCycle(ctx context.Context) {
 		log.Info(checkCtx, string(b.source)+"60秒检查", zap.Uint32("共计上传歌曲标记", b.pushCount.Load()))
 	}
 
-	running := b.controller.IsRunning(checkCtx)
-	log.Debug(checkCtx, string(b.source)+" 程序运行是否运行", zap.Bool("running", running))
+	if b.checkPlayer(checkCtx) {
+		if b.tmpCount > b.checkCount {
+			b.isLongCheck = false
+			b.timer.Reset(b.defaultSleep)
+		}
+		b.tmpCount = 0
+	}
+}
 
-	var playerInfo PlayerInfoHandler
-	if running {
-		playerInfo = nil
-		state, _ := b.controller.GetState(checkCtx)
-		log.Debug(checkCtx, string(b.source)+" 播放状态", zap.Any("state", state))
-		if state == common.PlayerStatePlaying {
-			if b.tmpCount > b.checkCount {
-				b.isLongCheck = false
-				b.timer.Reset(b.defaultSleep)
-			}
-			b.tmpCount = 0
-			playerInfo = b.controller.GetNowPlayingTrackInfo(checkCtx)
+// refresh 推送模式下的一次检查
+func (b *BasePlayerChecker) refresh(ctx context.Context, reason string) {
+	checkCtx, span := telemetry.StartSpanForTracerName(
+		ctx, _TracerName, string(b.source)+"_RefreshPlayingTrack",
+	)
+	defer span.End()
+
+	log.Debug(checkCtx, string(b.source)+" Refresh playing track", zap.String("reason", reason))
+	if !b.checkPlayer(checkCtx) {
+		b.scrobbleTimer.Stop()
+	}
+}
+
+// checkPlayer 读取控制器状态并处理，返回是否正在播放
+func (b *BasePlayerChecker) checkPlayer(ctx context.Context) bool {
+	running := b.controller.IsRunning(ctx)
+	log.Debug(ctx, string(b.source)+" 程序运行是否运行", zap.Bool("running", running))
+	if !running {
+		b.plays.Finish(ctx, b.source, model.PlayEndStopped, b.clock())
+		nowplaying.Default().Update(string(b.source), common.PlayerStateStopped, nil)
+		return false
+	}
+
+	state, _ := b.controller.GetState(ctx)
+	log.Debug(ctx, string(b.source)+" 播放状态", zap.Any("state", state))
+	if state != common.PlayerStatePlaying {
+		if state == common.PlayerStatePaused {
+			b.plays.Pause(b.source, b.clock())
 		} else {
-			if _, ok := b.currentPlayingCache.Load(b.source); ok {
-				b.currentPlayingCache.Delete(b.source)
-				b.handleStopEvent(checkCtx)
-			}
+			b.plays.Finish(ctx, b.source, model.PlayEndStopped, b.clock())
+		}
+		nowplaying.Default().Update(string(b.source), state, nil)
+		if b.arbiter.NowPlaying(b.source) != nil {
+			b.handleStopEvent(ctx)
 		}
+		return false
 	}
 
+	playerInfo := b.controller.GetNowPlayingTrackInfo(ctx)
 	if playerInfo != nil {
-		b.processPlayingTrack(checkCtx, playerInfo)
+		b.processPlayingTrack(ctx, applyRewriteRules(ctx, b.source, playerInfo))
 	}
+	return true
 }
 
-// handleStopEvent 处理停止事件
-func (b *BasePlayerChecker) handleStopEvent(ctx context.Context) {
-	// 检查是否还有其他播放器在播放
-	_, audirvanaPlaying := b.currentPlayingCache.Load(common.PlayerAudirvana)
-	_, roonPlaying := b.currentPlayingCache.Load(common.PlayerRoon)
-	_, appleMusicPlaying := b.currentPlayingCache.Load(common.PlayerAppleMusic)
-
-	// 如果没有其他播放器在播放，则发送停止消息
-	shouldStop := false
-	switch b.source {
-	case common.PlayerAudirvana:
-		shouldStop = !roonPlaying && !appleMusicPlaying
-	case common.PlayerRoon:
-		shouldStop = !audirvanaPlaying && !appleMusicPlaying
-	case common.PlayerAppleMusic:
-		shouldStop = !audirvanaPlaying && !roonPlaying
-	}
-
-	if shouldStop {
-		websocket.BroadcastMessage(
-			ctx,
-			&websocket.WsTrackInfo{
-				Type:   "stop",
-				Source: string(b.source),
-			},
-		)
-		b.atomicPlaying.Store(false)
+// armScrobbleTimer 推送模式下没有轮询，需要在累计收听到达标记阈值时主动刷新
+func (b *BasePlayerChecker) armScrobbleTimer(remaining float64) {
+	if b.scrobbleTimer == nil {
+		return
 	}
+	b.scrobbleTimer.Stop()
+	if b.mapedTracks[b.currentTrack] {
+		return
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	// 多等一秒，保证触发时累计收听已越过阈值
+	b.scrobbleTimer.Reset(time.Duration(remaining*float64(time.Second)) + time.Second)
+}
+
+// handleStopEvent 处理停止事件，由其他仍在播放的播放器接管，没有播放器在播放时广播 stop
+func (b *BasePlayerChecker) handleStopEvent(ctx context.Context) {
+	next, changed := b.arbiter.Release(b.source, b.token)
+	broadcastRelease(ctx, b.source, next, changed)
 }
 
 // processPlayingTrack 处理正在播放的曲目
@@ -183,14 +288,25 @@ func (b *BasePlayerChecker) processPlayingTrack(ctx context.Context, playerInfo
 			DiscNumber:  int8(playerInfo.GetDiscNumber()),
 		},
 	}
-	// 向WebSocket客户端广播播放信息
-	// 将播放信息写入本地缓存
-	b.currentPlayingCache.Store(b.source, wti)
-	b.atomicPlaying.Store(true)
-	websocket.BroadcastMessage(ctx, wti)
+	// 上报给仲裁器，只有持有正在播放的播放器向订阅者推送
+	nowplaying.Default().Update(string(b.source), common.PlayerStatePlaying, wti)
+	if b.arbiter.Claim(b.source, b.token, wti) {
+		nowplaying.Default().Publish(ctx, wti)
+	}
+
+	// 单曲循环或从头重新播放时曲目标识不变，按切到新曲目处理：重新计数、新的时间戳与正在播放
+	now := b.clock()
+	if b.currentTrack == b.previousTrack && b.listen.Restart(b.currentTrack, position, now) {
+		log.Info(ctx, string(b.source)+" 同一曲目重新播放", zap.String("track", playerInfo.GetTitle()))
+		delete(b.mapedTracks, b.currentTrack)
+		b.previousTrack = ""
+	}
 
-	// 检查是否需要标记听歌完成
-	if position/float64(duration) > b.percentScrobble && !b.mapedTracks[b.currentTrack] {
+	// 检查是否需要标记听歌完成，按累计收听时长而非当前进度判定
+	listened := b.listen.Observe(b.currentTrack, position, now)
+	b.observePlay(ctx, playerInfo, listened, position, now)
+	threshold, eligible := b.policy.Threshold(b.source, duration)
+	if eligible && listened >= threshold && !b.mapedTracks[b.currentTrack] {
 		b.handleTrackScrobble(ctx, playerInfo)
 	}
 
@@ -200,6 +316,11 @@ func (b *BasePlayerChecker) processPlayingTrack(ctx context.Context, playerInfo
 	}
 
 	b.previousTrack = tmpTrack
+	if eligible {
+		b.armScrobbleTimer(threshold - listened)
+	} else if b.scrobbleTimer != nil {
+		b.scrobbleTimer.Stop()
+	}
 }
 
 func (b *BasePlayerChecker) trackLikeCheckAndHandle(ctx context.Context, playerInfo PlayerInfoHandler) (bool, bool) {
@@ -429,36 +550,66 @@ func (b *BasePlayerChecker) trackLikeCheckAndHandle(ctx context.Context, playerI
 // handleTrackScrobble 处理曲目标记
 func (b *BasePlayerChecker) handleTrackScrobble(ctx context.Context, playerInfo PlayerInfoHandler) {
 	// 标记听歌完成
-	pushTrackScrobbleReq := &lastfm.PushTrackScrobbleReq{
+	scrobbleReq := &scrobble.Scrobble{
 		Artist:      playerInfo.GetArtist(),
 		AlbumArtist: playerInfo.GetArtist(),
 		Track:       playerInfo.GetTitle(),
 		Album:       playerInfo.GetAlbum(),
 		Duration:    playerInfo.GetDuration(),
 		Timestamp:   b.now.UTC().Unix(),
+		Source:      string(b.source),
+	}
+
+	// 逐个目标上报，track_play_records.scrobbled 仅反映 Last.fm 的结果
+	scrobbleErrs := make([]error, len(b.targets))
+	for i, target := range b.targets {
+		scrobbleErrs[i] = target.Scrobble(ctx, scrobbleReq)
+		if scrobbleErrs[i] != nil {
+			log.Warn(
+				ctx, string(b.source)+" handleTrackScrobble err", zap.String("target", target.Name()),
+				zap.Error(scrobbleErrs[i]),
+			)
+		}
 	}
 
 	// Save to database
 	record := &model.TrackPlayRecord{
-		Artist:        pushTrackScrobbleReq.Artist,
-		AlbumArtist:   pushTrackScrobbleReq.AlbumArtist,
-		Track:         pushTrackScrobbleReq.Track,
-		Album:         pushTrackScrobbleReq.Album,
-		Duration:      pushTrackScrobbleReq.Duration,
-		PlayTime:      time.Unix(pushTrackScrobbleReq.Timestamp, 0),
-		Scrobbled:     true,
-		MusicBrainzID: pushTrackScrobbleReq.MusicBrainzTrackID,
-		TrackNumber:   int8(pushTrackScrobbleReq.TrackNumber),
+		Artist:        scrobbleReq.Artist,
+		AlbumArtist:   scrobbleReq.AlbumArtist,
+		Track:         scrobbleReq.Track,
+		Album:         scrobbleReq.Album,
+		Duration:      scrobbleReq.Duration,
+		PlayTime:      time.Unix(scrobbleReq.Timestamp, 0),
+		MusicBrainzID: scrobbleReq.MusicBrainzID,
+		TrackNumber:   int8(scrobbleReq.TrackNumber),
 		Source:        string(b.source),
 	}
-	_, err := lastfm.PushTrackScrobble(ctx, pushTrackScrobbleReq)
-	if err != nil {
-		log.Warn(ctx, string(b.source)+" handleTrackScrobble err", zap.Error(err))
-		record.Scrobbled = false
+	for i, target := range b.targets {
+		if target.Name() == model.OutboxTargetLastfm {
+			record.Scrobbled = scrobbleErrs[i] == nil
+		}
 	}
 
 	if err := b.trackService.InsertTrackPlayRecord(ctx, record); err != nil {
 		log.Warn(ctx, string(b.source)+" Failed to insert track play record", zap.Error(err))
+	} else {
+		// 记录每个目标的投递状态，失败的目标由 outbox 后台重试
+		for i, target := range b.targets {
+			scrobbleErr := scrobbleErrs[i]
+			var code int
+			var msg string
+			if scrobbleErr != nil {
+				code, msg = target.ErrorCode(scrobbleErr), scrobbleErr.Error()
+			}
+			if err := model.SaveScrobbleDelivery(
+				ctx, record.ID, target.Name(), scrobbleErr == nil, code, msg,
+			); err != nil {
+				log.Warn(
+					ctx, string(b.source)+" Failed to save scrobble delivery", zap.String("target", target.Name()),
+					zap.Error(err),
+				)
+			}
+		}
 	}
 
 	// Update track play count
@@ -481,6 +632,10 @@ func (b *BasePlayerChecker) handleTrackScrobble(ctx context.Context, playerInfo
 			DiscNumber:    playerInfo.GetDiscNumber(),
 		},
 	}
+	if provider, ok := playerInfo.(ArtistCreditProvider); ok {
+		incrementTrackPlayCountParams.TrackMetadata.ArtistCredit = provider.GetArtistCredit()
+		incrementTrackPlayCountParams.TrackMetadata.ArtistSeparators = provider.GetArtistSeparators()
+	}
 	if incrementTrackPlayCountParams.TrackMetadata.Source == "" {
 		incrementTrackPlayCountParams.TrackMetadata.Source = playerInfo.GetSource()
 	} else {
@@ -492,7 +647,9 @@ func (b *BasePlayerChecker) handleTrackScrobble(ctx context.Context, playerInfo
 		log.Warn(ctx, string(b.source)+" Failed to increment track play count", zap.Error(err))
 	}
 
+	b.background.Add(1)
 	go func() {
+		defer b.background.Done()
 		if getTrack, _ := b.trackService.GetTrack(ctx, record.Artist, record.Artist, record.Track); getTrack != nil {
 			// 暂时重点关注 PlayerAppleMusic
 			if b.source == common.PlayerAppleMusic {
@@ -539,31 +696,62 @@ func (b *BasePlayerChecker) handleTrackScrobble(ctx context.Context, playerInfo
 	}()
 
 	b.mapedTracks[b.currentTrack] = true
+	b.plays.MarkScrobbled(b.source)
 	b.pushCount.Add(1)
 	log.Info(
-		ctx, string(b.source)+"标记听歌完成", zap.String("track", pushTrackScrobbleReq.Track),
+		ctx, string(b.source)+"标记听歌完成", zap.String("track", scrobbleReq.Track),
 		zap.Bool("scrobbled", record.Scrobbled),
 	)
 }
 
+// observePlay 切歌或停止后重新播放时结束上一次播放并开始新的播放，否则更新进行中的播放
+func (b *BasePlayerChecker) observePlay(
+	ctx context.Context, playerInfo PlayerInfoHandler, listened, position float64, now time.Time,
+) {
+	if b.currentTrack == b.previousTrack && b.plays.IsOpen(b.source) {
+		b.plays.Observe(b.source, listened, position, now)
+		return
+	}
+	b.plays.Finish(ctx, b.source, model.PlayEndSkipped, now)
+	b.plays.Start(
+		ctx, b.source, &model.PlayEvent{
+			Artist:      playerInfo.GetArtist(),
+			AlbumArtist: playerInfo.GetAlbumArtist(),
+			Album:       playerInfo.GetAlbum(),
+			Track:       playerInfo.GetTitle(),
+			Genre:       playerInfo.GetGenre(),
+			Duration:    playerInfo.GetDuration(),
+		}, listened, position, now,
+	)
+}
+
 // handleNewTrack 处理新曲目
 func (b *BasePlayerChecker) handleNewTrack(ctx context.Context, playerInfo PlayerInfoHandler) {
 	// 产生新歌曲
 	delete(b.mapedTracks, b.previousTrack)
-	b.now = time.Now()
-	playingReq := lastfm.TrackUpdateNowPlayingReq{
+	b.now = b.clock()
+	playingReq := &scrobble.Scrobble{
 		Artist:      playerInfo.GetArtist(),
 		AlbumArtist: playerInfo.GetArtist(),
 		Track:       playerInfo.GetTitle(),
 		Album:       playerInfo.GetAlbum(),
 		Duration:    playerInfo.GetDuration(),
+		Source:      string(b.source),
 	}
 
 	log.Info(
 		ctx, string(b.source)+"NowPlayingTrackInfo", zap.Any("playerInfo", playerInfo),
 	)
-	err := lastfm.TrackUpdateNowPlaying(ctx, &playingReq)
-	if err != nil {
-		log.Warn(ctx, string(b.source)+" TrackUpdateNowPlaying err", zap.Error(err))
+	// 其他播放器持有正在播放时不覆盖目标上的 now playing
+	if !b.arbiter.IsOwner(b.source, b.token) {
+		return
+	}
+	for _, target := range b.targets {
+		if err := target.UpdateNowPlaying(ctx, playingReq); err != nil {
+			log.Warn(
+				ctx, string(b.source)+" TrackUpdateNowPlaying err", zap.String("target", target.Name()),
+				zap.Error(err),
+			)
+		}
 	}
 }
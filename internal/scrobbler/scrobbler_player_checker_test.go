@@ -0,0 +1,225 @@
+package scrobbler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
+	"github.com/vincentchyu/sonic-lens/internal/logic/track"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// eventController 支持推送的控制器，状态由测试修改，检查器在自己的协程中读取
+type eventController struct {
+	mu       sync.Mutex
+	state    string
+	track    *TraceTrack
+	at       time.Time // 虚拟时钟
+	checks   int       // GetState 调用次数，即检查次数
+	reads    int       // GetNowPlayingTrackInfo 调用次数
+	events   chan PlayerEvent
+	eventErr error
+}
+
+func (c *eventController) set(state string, position float64, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state = state
+	c.track.Position = position
+	c.at = at
+}
+
+func (c *eventController) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.at
+}
+
+func (c *eventController) checkCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checks
+}
+
+func (c *eventController) readCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reads
+}
+
+func (c *eventController) IsRunning(context.Context) bool { return true }
+
+func (c *eventController) GetState(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks++
+	return c.state, nil
+}
+
+func (c *eventController) GetNowPlayingTrackInfo(context.Context) PlayerInfoHandler {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reads++
+	info := *c.track
+	return &info
+}
+
+func (c *eventController) IsFavorite(context.Context) bool { return false }
+
+func (c *eventController) SetFavorite(context.Context) error { return nil }
+
+func (c *eventController) Events(context.Context) (<-chan PlayerEvent, error) {
+	return c.events, c.eventErr
+}
+
+// manualTimer 由测试手动触发的标记定时器，记录最近一次设定的时长，停止后为 0
+type manualTimer struct {
+	mu    sync.Mutex
+	c     chan time.Time
+	armed time.Duration
+}
+
+func newManualTimer() *manualTimer {
+	return &manualTimer{c: make(chan time.Time)}
+}
+
+func (t *manualTimer) C() <-chan time.Time { return t.c }
+
+func (t *manualTimer) Reset(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.armed = d
+}
+
+func (t *manualTimer) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.armed = 0
+}
+
+func (t *manualTimer) duration() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.armed
+}
+
+func newEventChecker(controller *eventController, pushCount *atomic.Uint32) *BasePlayerChecker {
+	arbiter := NewPlayerArbiter(nil)
+	checker := NewBasePlayerChecker(
+		controller, common.PlayerMpris, pushCount, arbiter, track.NewTrackService(),
+		NewScrobblePolicy(config.ScrobbleConfig{}), []scrobble.Target{&fakeSink{Target: scrobble.NewLastfmTarget()}},
+		NewPlayEventRecorder(), arbiter.NewToken(),
+	)
+	checker.clock = controller.now
+	checker.defaultSleep = 10 * time.Millisecond
+	return checker
+}
+
+// runChecker 在后台运行检查器，返回停止并等待其退出的函数
+func runChecker(checker *BasePlayerChecker) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		checker.CheckPlayingTrack(context.Background(), stop)
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+func newEventController(events chan PlayerEvent) *eventController {
+	return &eventController{
+		state:  common.PlayerStateStopped,
+		track:  &TraceTrack{Title: "Breathe", Artist: "Pink Floyd", Album: "The Dark Side of the Moon", Duration: 240, DiscNumber: 1},
+		at:     time.Date(2025, 3, 1, 20, 0, 0, 0, time.UTC),
+		events: events,
+	}
+}
+
+func TestWatchEvents_FallbackToPolling(t *testing.T) {
+	model.SetupTestDB(t)
+	var pushCount atomic.Uint32
+
+	// 订阅失败时不刷新，直接回退为轮询
+	controller := newEventController(nil)
+	controller.eventErr = errors.New("session bus unavailable")
+	checker := newEventChecker(controller, &pushCount)
+	assert.False(t, checker.watchEvents(context.Background(), controller, make(chan struct{})))
+	assert.Zero(t, controller.checkCount())
+
+	stopChecker := runChecker(checker)
+	assert.Eventually(t, func() bool { return controller.checkCount() >= 3 }, time.Second, 5*time.Millisecond)
+	stopChecker()
+}
+
+func TestWatchEvents_RefreshOnEvent(t *testing.T) {
+	model.SetupTestDB(t)
+	var pushCount atomic.Uint32
+	events := make(chan PlayerEvent, 4)
+	controller := newEventController(events)
+	checker := newEventChecker(controller, &pushCount)
+	stopChecker := runChecker(checker)
+	defer stopChecker()
+
+	// 启动时刷新一次，之后只在收到事件时刷新，不按 defaultSleep 轮询
+	assert.Eventually(t, func() bool { return controller.checkCount() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, controller.checkCount())
+
+	events <- PlayerEvent{Type: PlayerEventState, State: common.PlayerStatePlaying}
+	assert.Eventually(t, func() bool { return controller.checkCount() == 2 }, time.Second, 5*time.Millisecond)
+
+	// 通道关闭后回退为轮询
+	close(events)
+	assert.Eventually(t, func() bool { return controller.checkCount() >= 5 }, time.Second, 5*time.Millisecond)
+}
+
+func TestWatchEvents_ScrobbleTimer(t *testing.T) {
+	model.SetupTestDB(t)
+	var pushCount atomic.Uint32
+	events := make(chan PlayerEvent, 4)
+	controller := newEventController(events)
+	start := controller.now()
+	controller.set(common.PlayerStatePlaying, 0, start)
+	checker := newEventChecker(controller, &pushCount)
+	timer := newManualTimer()
+	checker.newTimer = func() scrobbleTimer { return timer }
+	stopChecker := runChecker(checker)
+
+	assert.Eventually(t, func() bool { return controller.readCount() == 1 }, time.Second, 5*time.Millisecond)
+	// 240 秒的曲目需要累计收听 132 秒，这次刷新后定时器设为剩余 0.5 秒再加 1 秒
+	controller.set(common.PlayerStatePlaying, 131.5, start.Add(131500*time.Millisecond))
+	events <- PlayerEvent{Type: PlayerEventSeek, Position: 131.5}
+	require.Eventually(
+		t, func() bool {
+			d := timer.duration()
+			return d > 1400*time.Millisecond && d < 1600*time.Millisecond
+		}, time.Second, 5*time.Millisecond,
+	)
+	assert.Equal(t, 2, controller.readCount())
+	assert.Zero(t, pushCount.Load())
+
+	// 没有新的事件，由定时器刷新并标记，标记后定时器停止
+	controller.set(common.PlayerStatePlaying, 133, start.Add(133*time.Second))
+	timer.c <- controller.now()
+	require.Eventually(t, func() bool { return pushCount.Load() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return timer.duration() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 3, controller.readCount())
+	stopChecker()
+
+	records, err := model.GetRecentPlayRecords(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Breathe", records[0].Track)
+}
@@ -0,0 +1,169 @@
+package scrobbler
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/core/mpd"
+	"github.com/vincentchyu/sonic-lens/internal/cache"
+)
+
+func init() {
+	RegisterPlayer(
+		common.PlayerMpd, func(cfg *config.Config) PlayerController {
+			return NewMpdPlayerController(mpd.NewClient(cfg.Mpd.Address, cfg.Mpd.Password))
+		},
+	)
+}
+
+// MpdTrackInfoWrapper 包装 mpd.TrackInfo 以实现 PlayerInfoHandler 接口
+// MPD 直接读取文件标签，AlbumArtist、Disc、Genre、MusicBrainz ID 等字段都能拿到
+type MpdTrackInfoWrapper struct {
+	*mpd.TrackInfo
+	address     string
+	baseWrapper BaseWrapper
+}
+
+func (m *MpdTrackInfoWrapper) GetTitle() string {
+	return m.baseWrapper.ConversionSimplified(common.UnityFixAll(common.TrackCustomFit(m.Title)))
+}
+
+func (m *MpdTrackInfoWrapper) GetAlbum() string {
+	return m.baseWrapper.ConversionSimplified(m.Album)
+}
+
+func (m *MpdTrackInfoWrapper) GetArtist() string {
+	// Artist 可能有多个，与 MPRIS 保持一致仅取第一位艺术家
+	if len(m.Artists) == 0 {
+		return ""
+	}
+	return m.baseWrapper.ConversionSimplified(common.ArtistCustomFit(m.Artists[0]))
+}
+
+func (m *MpdTrackInfoWrapper) GetPosition() float64 {
+	return m.Position
+}
+
+func (m *MpdTrackInfoWrapper) GetDuration() int64 {
+	return m.Duration
+}
+
+func (m *MpdTrackInfoWrapper) GetUrl() string {
+	return m.File
+}
+
+func (m *MpdTrackInfoWrapper) GetAlbumArtist() string {
+	if len(m.AlbumArtists) > 0 {
+		return m.baseWrapper.ConversionSimplified(common.ArtistCustomFit(m.AlbumArtists[0]))
+	}
+	return m.GetArtist()
+}
+
+func (m *MpdTrackInfoWrapper) GetTrackNumber() int64 {
+	return m.TrackNumber
+}
+
+func (m *MpdTrackInfoWrapper) GetGenre() string {
+	if len(m.Genres) == 0 {
+		return ""
+	}
+	return cache.GetEnglishGenre(common.GenreCustomFit(m.Genres[0]))
+}
+
+func (m *MpdTrackInfoWrapper) GetComposer() string {
+	return m.baseWrapper.ConversionSimplified(strings.Join(m.Composers, ", "))
+}
+
+func (m *MpdTrackInfoWrapper) GetReleaseDate() string {
+	// Date 可能只有年份，也可能是完整日期
+	if len(m.Date) > len("2006-01-02") {
+		return m.Date[:len("2006-01-02")]
+	}
+	return m.Date
+}
+
+func (m *MpdTrackInfoWrapper) GetMusicBrainzID() string {
+	return m.MusicBrainzTrackID
+}
+
+func (m *MpdTrackInfoWrapper) GetSource() string {
+	return string(common.PlayerMpd)
+}
+
+func (m *MpdTrackInfoWrapper) GetBundleID() string {
+	return m.address
+}
+
+func (m *MpdTrackInfoWrapper) GetUniqueID() string {
+	// 队列中的 Id 重新入队就会变化，文件路径才能稳定标识曲目
+	return m.File
+}
+
+func (m *MpdTrackInfoWrapper) GetDiscNumber() int8 {
+	if m.DiscNumber <= 0 {
+		return 1
+	}
+	return int8(m.DiscNumber)
+}
+
+// MpdPlayerController 通过 MPD 协议读取 Music Player Daemon
+type MpdPlayerController struct {
+	client *mpd.Client
+}
+
+// NewMpdPlayerController 创建 MPD 控制器，每次查询建立新连接，MPD 重启后无需重连
+func NewMpdPlayerController(client *mpd.Client) *MpdPlayerController {
+	return &MpdPlayerController{client: client}
+}
+
+func (m *MpdPlayerController) IsRunning(ctx context.Context) bool {
+	return m.client.IsRunning(ctx)
+}
+
+func (m *MpdPlayerController) GetState(ctx context.Context) (string, error) {
+	state, err := m.client.GetState(ctx)
+	return string(state), err
+}
+
+func (m *MpdPlayerController) GetNowPlayingTrackInfo(ctx context.Context) PlayerInfoHandler {
+	info, err := m.client.GetNowPlayingTrackInfo(ctx)
+	if err != nil {
+		log.Warn(ctx, "MpdPlayerController GetNowPlayingTrackInfo err", zap.Error(err))
+		return nil
+	}
+	return &MpdTrackInfoWrapper{TrackInfo: info, address: m.client.Address()}
+}
+
+func (m *MpdPlayerController) SetFavorite(ctx context.Context) error {
+	return nil
+}
+
+func (m *MpdPlayerController) IsFavorite(ctx context.Context) bool {
+	return false
+}
+
+// Events 通过 idle player 接收播放状态、切歌与跳转通知，实现 PlayerEventSource
+func (m *MpdPlayerController) Events(ctx context.Context) (<-chan PlayerEvent, error) {
+	changes, err := m.client.Watch(ctx, "player")
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan PlayerEvent, 16)
+	go func() {
+		defer close(events)
+		for range changes {
+			// player 子系统不区分播放、暂停、切歌与跳转，检查器收到后统一重新读取状态
+			select {
+			case events <- PlayerEvent{Type: PlayerEventState}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,45 @@
+package scrobbler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vincentchyu/sonic-lens/core/mpd"
+)
+
+func TestMpdTrackInfoWrapper(t *testing.T) {
+	wrapper := &MpdTrackInfoWrapper{
+		TrackInfo: &mpd.TrackInfo{
+			File:               "Pink Floyd/The Wall/2-01 Hey You.flac",
+			Title:              "Hey You",
+			Album:              "The Wall",
+			Artists:            []string{"Pink Floyd", "Guest"},
+			Composers:          []string{"Roger Waters", "David Gilmour"},
+			TrackNumber:        1,
+			DiscNumber:         2,
+			Date:               "1979-11-30T00:00:00Z",
+			Duration:           280,
+			Position:           12.5,
+			MusicBrainzTrackID: "8a2b6e3c-0000-4c3e-9f0e-000000000014",
+		},
+		address: "localhost:6600",
+	}
+
+	assert.Equal(t, "Hey You", wrapper.GetTitle())
+	assert.Equal(t, "Pink Floyd", wrapper.GetArtist())
+	assert.Equal(t, "Pink Floyd", wrapper.GetAlbumArtist(), "falls back to the first artist")
+	assert.Equal(t, "Roger Waters, David Gilmour", wrapper.GetComposer())
+	assert.Equal(t, "1979-11-30", wrapper.GetReleaseDate())
+	assert.EqualValues(t, 2, wrapper.GetDiscNumber())
+	assert.EqualValues(t, 1, wrapper.GetTrackNumber())
+	assert.Equal(t, "8a2b6e3c-0000-4c3e-9f0e-000000000014", wrapper.GetMusicBrainzID())
+	assert.Equal(t, "Pink Floyd/The Wall/2-01 Hey You.flac", wrapper.GetUrl())
+	assert.Equal(t, "Pink Floyd/The Wall/2-01 Hey You.flac", wrapper.GetUniqueID())
+	assert.Equal(t, "MPD", wrapper.GetSource())
+
+	wrapper.DiscNumber = 0
+	wrapper.Date = "1979"
+	assert.EqualValues(t, 1, wrapper.GetDiscNumber(), "missing Disc tag defaults to 1")
+	assert.Equal(t, "1979", wrapper.GetReleaseDate())
+}
@@ -0,0 +1,220 @@
+package scrobbler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/core/mpris"
+	"github.com/vincentchyu/sonic-lens/internal/cache"
+)
+
+func init() {
+	RegisterPlayer(
+		common.PlayerMpris, func(cfg *config.Config) PlayerController {
+			return NewMprisPlayerController(nil, cfg.Mpris.Players)
+		},
+	)
+}
+
+// MprisTrackInfoWrapper 包装 mpris.TrackInfo 以实现 PlayerInfoHandler 接口
+type MprisTrackInfoWrapper struct {
+	*mpris.TrackInfo
+	baseWrapper BaseWrapper
+}
+
+func (m *MprisTrackInfoWrapper) GetTitle() string {
+	return m.baseWrapper.ConversionSimplified(common.UnityFixAll(common.TrackCustomFit(m.Title)))
+}
+
+func (m *MprisTrackInfoWrapper) GetAlbum() string {
+	return m.baseWrapper.ConversionSimplified(m.Album)
+}
+
+func (m *MprisTrackInfoWrapper) GetArtist() string {
+	// xesam:artist 为列表，与 Roon 保持一致仅取第一位艺术家
+	if len(m.Artists) == 0 {
+		return ""
+	}
+	return m.baseWrapper.ConversionSimplified(common.ArtistCustomFit(m.Artists[0]))
+}
+
+func (m *MprisTrackInfoWrapper) GetPosition() float64 {
+	return m.Position
+}
+
+func (m *MprisTrackInfoWrapper) GetDuration() int64 {
+	return m.Duration
+}
+
+func (m *MprisTrackInfoWrapper) GetUrl() string {
+	return m.Url
+}
+
+func (m *MprisTrackInfoWrapper) GetAlbumArtist() string {
+	if len(m.AlbumArtists) > 0 {
+		return m.baseWrapper.ConversionSimplified(common.ArtistCustomFit(m.AlbumArtists[0]))
+	}
+	return m.GetArtist()
+}
+
+func (m *MprisTrackInfoWrapper) GetTrackNumber() int64 {
+	return m.TrackNumber
+}
+
+func (m *MprisTrackInfoWrapper) GetGenre() string {
+	if len(m.Genres) == 0 {
+		return ""
+	}
+	return cache.GetEnglishGenre(common.GenreCustomFit(m.Genres[0]))
+}
+
+func (m *MprisTrackInfoWrapper) GetComposer() string {
+	return m.baseWrapper.ConversionSimplified(strings.Join(m.Composers, ", "))
+}
+
+func (m *MprisTrackInfoWrapper) GetReleaseDate() string {
+	// xesam:contentCreated 为 ISO 8601 时间，仅保留日期部分
+	if len(m.ContentCreated) >= len("2006-01-02") {
+		return m.ContentCreated[:len("2006-01-02")]
+	}
+	return m.ContentCreated
+}
+
+func (m *MprisTrackInfoWrapper) GetMusicBrainzID() string {
+	return m.MusicBrainzTrackID
+}
+
+func (m *MprisTrackInfoWrapper) GetSource() string {
+	if m.Identity != "" {
+		return m.Identity
+	}
+	return strings.TrimPrefix(m.BusName, mpris.BusNamePrefix)
+}
+
+func (m *MprisTrackInfoWrapper) GetBundleID() string {
+	return m.BusName
+}
+
+func (m *MprisTrackInfoWrapper) GetUniqueID() string {
+	return m.TrackID
+}
+
+func (m *MprisTrackInfoWrapper) GetDiscNumber() int8 {
+	if m.DiscNumber <= 0 {
+		return 1
+	}
+	return int8(m.DiscNumber)
+}
+
+// MprisPlayerController 通过会话 D-Bus 读取 Linux 上的 MPRIS 播放器
+type MprisPlayerController struct {
+	mu      sync.Mutex
+	client  *mpris.Client
+	connect func(players []string) (*mpris.Client, error)
+	players []string
+}
+
+// NewMprisPlayerController 创建 MPRIS 控制器，client 为空或连接断开时（重新）连接会话总线
+func NewMprisPlayerController(client *mpris.Client, players []string) *MprisPlayerController {
+	return &MprisPlayerController{client: client, connect: mpris.SessionClient, players: players}
+}
+
+func (m *MprisPlayerController) getClient(ctx context.Context) *mpris.Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client != nil {
+		if m.client.Conn().Connected() {
+			return m.client
+		}
+		// 会话总线断开或重启后缓存的连接不再可用，丢弃后重新连接
+		log.Warn(ctx, "MprisPlayerController session bus disconnected, reconnecting")
+		m.client = nil
+	}
+	client, err := m.connect(m.players)
+	if err != nil {
+		log.Debug(ctx, "MprisPlayerController connect session bus err", zap.Error(err))
+		return nil
+	}
+	m.client = client
+	return client
+}
+
+func (m *MprisPlayerController) IsRunning(ctx context.Context) bool {
+	client := m.getClient(ctx)
+	if client == nil {
+		return false
+	}
+	return client.IsRunning(ctx)
+}
+
+func (m *MprisPlayerController) GetState(ctx context.Context) (string, error) {
+	client := m.getClient(ctx)
+	if client == nil {
+		return common.PlayerStateStopped, nil
+	}
+	state, err := client.GetState(ctx)
+	return string(state), err
+}
+
+func (m *MprisPlayerController) GetNowPlayingTrackInfo(ctx context.Context) PlayerInfoHandler {
+	client := m.getClient(ctx)
+	if client == nil {
+		return nil
+	}
+	info, err := client.GetNowPlayingTrackInfo(ctx)
+	if err != nil {
+		log.Warn(ctx, "MprisPlayerController GetNowPlayingTrackInfo err", zap.Error(err))
+		return nil
+	}
+	return &MprisTrackInfoWrapper{info, BaseWrapper{}}
+}
+
+func (m *MprisPlayerController) SetFavorite(ctx context.Context) error {
+	return nil
+}
+
+func (m *MprisPlayerController) IsFavorite(ctx context.Context) bool {
+	return false
+}
+
+// Events 订阅 MPRIS 信号，实现 PlayerEventSource
+func (m *MprisPlayerController) Events(ctx context.Context) (<-chan PlayerEvent, error) {
+	client := m.getClient(ctx)
+	if client == nil {
+		return nil, errors.New("mpris session bus unavailable")
+	}
+	signals, err := client.Watch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan PlayerEvent, 16)
+	go func() {
+		defer close(events)
+		for signal := range signals {
+			event := PlayerEvent{Position: signal.Position}
+			switch signal.Type {
+			case mpris.EventTrack:
+				event.Type = PlayerEventTrack
+			case mpris.EventSeek:
+				event.Type = PlayerEventSeek
+			default:
+				// PlaybackStatus 取值与 common.PlayerState 一致
+				event.Type = PlayerEventState
+				event.State = signal.Status
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
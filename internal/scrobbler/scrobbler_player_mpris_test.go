@@ -0,0 +1,56 @@
+package scrobbler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/core/mpris"
+)
+
+// pipeClient 基于内存管道的 MPRIS 客户端，只用于检查连接状态
+func pipeClient(t *testing.T) (*mpris.Client, *dbus.Conn) {
+	t.Helper()
+	local, remote := net.Pipe()
+	t.Cleanup(func() { _ = remote.Close() })
+	conn, err := dbus.NewConn(local)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return mpris.NewClient(conn, nil), conn
+}
+
+func TestMprisPlayerController_Reconnect(t *testing.T) {
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	ctx := context.Background()
+	first, firstConn := pipeClient(t)
+	second, _ := pipeClient(t)
+
+	var connects int
+	controller := NewMprisPlayerController(first, nil)
+	controller.connect = func([]string) (*mpris.Client, error) {
+		connects++
+		if connects == 1 {
+			return nil, errors.New("session bus unavailable")
+		}
+		return second, nil
+	}
+
+	assert.Same(t, first, controller.getClient(ctx))
+	assert.Zero(t, connects)
+
+	// 连接断开后丢弃缓存，重连失败时下次调用继续重试
+	require.NoError(t, firstConn.Close())
+	assert.Nil(t, controller.getClient(ctx))
+	assert.Same(t, second, controller.getClient(ctx))
+	assert.Same(t, second, controller.getClient(ctx))
+	assert.Equal(t, 2, connects)
+}
@@ -0,0 +1,291 @@
+package scrobbler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/internal/cache"
+	"github.com/vincentchyu/sonic-lens/internal/ingest"
+)
+
+const (
+	pushStaleGrace = 30 * time.Second // 推算进度超过时长该值后仍未收到推送，视为已停止
+	pushStaleAfter = 30 * time.Minute // 时长未知时，超过该值未收到推送视为已停止
+)
+
+// ErrPlayerDisabled 播放器未启用，推送的事件被丢弃
+var ErrPlayerDisabled = errors.New("player disabled")
+
+var (
+	pushPlayersMu sync.Mutex
+	pushPlayers   = make(map[common.PlayerType]*PushPlayerController)
+)
+
+func init() {
+	for _, playerType := range []common.PlayerType{
+		common.PlayerJellyfin, common.PlayerPlex, common.PlayerWebScrobbler,
+	} {
+		RegisterPlayer(
+			playerType, func(cfg *config.Config) PlayerController {
+				return pushPlayerController(playerType)
+			},
+		)
+	}
+}
+
+// pushPlayerController 同一播放器共用一个控制器，停用后重新启用仍能收到推送
+func pushPlayerController(playerType common.PlayerType) *PushPlayerController {
+	pushPlayersMu.Lock()
+	defer pushPlayersMu.Unlock()
+	controller, ok := pushPlayers[playerType]
+	if !ok {
+		controller = NewPushPlayerController()
+		pushPlayers[playerType] = controller
+	}
+	return controller
+}
+
+// Ingest 将 webhook 推送的播放事件交给对应播放器的检查器，
+// 与本地播放器走同样的正在播放、标记阈值与播放记录写入流程
+func Ingest(playerType common.PlayerType, event *ingest.Event) error {
+	manager := playerManager.Load()
+	if manager == nil {
+		return ErrScrobblerNotStarted
+	}
+	if !manager.Enabled(playerType) {
+		return ErrPlayerDisabled
+	}
+	pushPlayerController(playerType).Push(event)
+	return nil
+}
+
+// PushTrackInfoWrapper 包装 ingest.Track 以实现 PlayerInfoHandler 接口
+type PushTrackInfoWrapper struct {
+	*ingest.Track
+	position    float64
+	baseWrapper BaseWrapper
+}
+
+func (p *PushTrackInfoWrapper) GetTitle() string {
+	return p.baseWrapper.ConversionSimplified(common.UnityFixAll(common.TrackCustomFit(p.Title)))
+}
+
+func (p *PushTrackInfoWrapper) GetAlbum() string {
+	return p.baseWrapper.ConversionSimplified(p.Album)
+}
+
+func (p *PushTrackInfoWrapper) GetArtist() string {
+	return p.baseWrapper.ConversionSimplified(common.ArtistCustomFit(p.Artist))
+}
+
+func (p *PushTrackInfoWrapper) GetPosition() float64 {
+	return p.position
+}
+
+func (p *PushTrackInfoWrapper) GetDuration() int64 {
+	return p.Duration
+}
+
+func (p *PushTrackInfoWrapper) GetUrl() string {
+	return p.Url
+}
+
+func (p *PushTrackInfoWrapper) GetAlbumArtist() string {
+	if p.AlbumArtist != "" {
+		return p.baseWrapper.ConversionSimplified(common.ArtistCustomFit(p.AlbumArtist))
+	}
+	return p.GetArtist()
+}
+
+func (p *PushTrackInfoWrapper) GetTrackNumber() int64 {
+	return p.TrackNumber
+}
+
+func (p *PushTrackInfoWrapper) GetGenre() string {
+	if p.Genre == "" {
+		return ""
+	}
+	return cache.GetEnglishGenre(common.GenreCustomFit(p.Genre))
+}
+
+func (p *PushTrackInfoWrapper) GetComposer() string {
+	return ""
+}
+
+func (p *PushTrackInfoWrapper) GetReleaseDate() string {
+	return p.ReleaseDate
+}
+
+func (p *PushTrackInfoWrapper) GetMusicBrainzID() string {
+	return p.MusicBrainzID
+}
+
+func (p *PushTrackInfoWrapper) GetSource() string {
+	return p.Client
+}
+
+func (p *PushTrackInfoWrapper) GetBundleID() string {
+	return p.Client
+}
+
+func (p *PushTrackInfoWrapper) GetUniqueID() string {
+	return p.UniqueID
+}
+
+func (p *PushTrackInfoWrapper) GetDiscNumber() int8 {
+	if p.DiscNumber <= 0 {
+		return 1
+	}
+	return int8(p.DiscNumber)
+}
+
+// PushPlayerController 由 webhook 推送驱动的播放器。
+// 推送之间按墙钟推算播放进度，检查器据此累计收听时长并在到达阈值时标记
+type PushPlayerController struct {
+	mu        sync.Mutex
+	state     common.PlayerState
+	track     *ingest.Track
+	position  float64
+	updatedAt time.Time
+	events    chan PlayerEvent
+	expire    *time.Timer // 播放中超时未收到推送时通知检查器按已停止处理
+	now       func() time.Time
+}
+
+func NewPushPlayerController() *PushPlayerController {
+	return &PushPlayerController{state: common.PlayerStateStopped, now: time.Now}
+}
+
+// Push 更新播放状态并通知检查器刷新
+func (p *PushPlayerController) Push(event *ingest.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if event.Track != nil && !sameIngestTrack(p.track, event.Track) {
+		p.position = 0
+	} else {
+		// 先按旧状态推算到当前时刻，暂停时进度随之冻结
+		p.position = p.positionAt(now)
+	}
+	if event.Track != nil {
+		p.track = event.Track
+	}
+	if event.Position != nil {
+		p.position = *event.Position
+	}
+	p.state = event.State
+	p.updatedAt = now
+	p.notify()
+
+	if p.expire != nil {
+		p.expire.Stop()
+	}
+	if p.state == common.PlayerStatePlaying {
+		p.expire = time.AfterFunc(p.expireAfter(), p.onExpire)
+	}
+}
+
+// notify 通知检查器刷新，调用方需持有锁
+func (p *PushPlayerController) notify() {
+	select {
+	case p.events <- PlayerEvent{Type: PlayerEventState, State: string(p.state)}:
+	default:
+		// 检查器未订阅或尚未消费，下次刷新时读取最新状态
+	}
+}
+
+// expireAfter 距离判定为已停止的时长，调用方需持有锁
+func (p *PushPlayerController) expireAfter() time.Duration {
+	if p.track != nil && p.track.Duration > 0 {
+		remaining := float64(p.track.Duration) - p.position
+		return time.Duration(max(remaining, 0)*float64(time.Second)) + pushStaleGrace + time.Second
+	}
+	return pushStaleAfter + time.Second
+}
+
+func (p *PushPlayerController) onExpire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.notify()
+}
+
+func (p *PushPlayerController) positionAt(now time.Time) float64 {
+	if p.state != common.PlayerStatePlaying || p.updatedAt.IsZero() {
+		return p.position
+	}
+	return p.position + now.Sub(p.updatedAt).Seconds()
+}
+
+// stale 长时间没有推送（如漏掉了停止事件）时不再视为播放中
+func (p *PushPlayerController) stale(now time.Time) bool {
+	if p.track == nil {
+		return true
+	}
+	if p.track.Duration > 0 {
+		return p.positionAt(now) > float64(p.track.Duration)+pushStaleGrace.Seconds()
+	}
+	return now.Sub(p.updatedAt) > pushStaleAfter
+}
+
+func (p *PushPlayerController) IsRunning(ctx context.Context) bool {
+	return true
+}
+
+func (p *PushPlayerController) GetState(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == common.PlayerStatePlaying && p.stale(p.now()) {
+		return common.PlayerStateStopped, nil
+	}
+	return string(p.state), nil
+}
+
+func (p *PushPlayerController) GetNowPlayingTrackInfo(ctx context.Context) PlayerInfoHandler {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.track == nil {
+		return nil
+	}
+	return &PushTrackInfoWrapper{Track: p.track, position: p.positionAt(p.now())}
+}
+
+func (p *PushPlayerController) SetFavorite(ctx context.Context) error {
+	return nil
+}
+
+func (p *PushPlayerController) IsFavorite(ctx context.Context) bool {
+	return false
+}
+
+// Events 实现 PlayerEventSource，同一时刻只有一个检查器订阅
+func (p *PushPlayerController) Events(ctx context.Context) (<-chan PlayerEvent, error) {
+	events := make(chan PlayerEvent, 16)
+	p.mu.Lock()
+	p.events = events
+	p.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.events == events {
+			p.events = nil
+		}
+		close(events)
+	}()
+	return events, nil
+}
+
+// sameIngestTrack 优先按服务端条目 ID 判断是否为同一曲目
+func sameIngestTrack(a, b *ingest.Track) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.UniqueID != "" && b.UniqueID != "" {
+		return a.UniqueID == b.UniqueID
+	}
+	return a.Title == b.Title && a.Artist == b.Artist && a.Album == b.Album
+}
@@ -0,0 +1,78 @@
+package scrobbler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/internal/ingest"
+)
+
+func TestPushPlayerController(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	controller := NewPushPlayerController()
+	controller.now = func() time.Time { return clock }
+	events, err := controller.Events(ctx)
+	require.NoError(t, err)
+
+	state, _ := controller.GetState(ctx)
+	assert.Equal(t, common.PlayerStateStopped, state)
+	assert.Nil(t, controller.GetNowPlayingTrackInfo(ctx))
+
+	track := &ingest.Track{Title: "Time", Artist: "Pink Floyd", Album: "The Dark Side of the Moon", Duration: 400}
+	controller.Push(&ingest.Event{State: common.PlayerStatePlaying, Track: track})
+	event := <-events
+	assert.Equal(t, PlayerEventState, event.Type)
+	assert.Equal(t, common.PlayerStatePlaying, event.State)
+
+	// 两次推送之间按墙钟推算进度
+	clock = clock.Add(90 * time.Second)
+	info := controller.GetNowPlayingTrackInfo(ctx)
+	require.NotNil(t, info)
+	assert.InDelta(t, 90, info.GetPosition(), 0.001)
+	assert.Equal(t, "Pink Floyd", info.GetAlbumArtist())
+	assert.EqualValues(t, 1, info.GetDiscNumber())
+
+	// 暂停后进度冻结，恢复时沿用
+	controller.Push(&ingest.Event{State: common.PlayerStatePaused})
+	clock = clock.Add(time.Hour)
+	assert.InDelta(t, 90, controller.GetNowPlayingTrackInfo(ctx).GetPosition(), 0.001)
+	controller.Push(&ingest.Event{State: common.PlayerStatePlaying, Track: track})
+	clock = clock.Add(10 * time.Second)
+	assert.InDelta(t, 100, controller.GetNowPlayingTrackInfo(ctx).GetPosition(), 0.001)
+
+	// 推送携带的进度优先
+	position := 200.0
+	controller.Push(&ingest.Event{State: common.PlayerStatePlaying, Track: track, Position: &position})
+	assert.InDelta(t, 200, controller.GetNowPlayingTrackInfo(ctx).GetPosition(), 0.001)
+
+	// 漏掉停止事件时，超过时长后视为已停止
+	clock = clock.Add(250 * time.Second)
+	state, _ = controller.GetState(ctx)
+	assert.Equal(t, common.PlayerStateStopped, state)
+
+	// 新曲目从头计算
+	next := &ingest.Track{Title: "Money", Artist: "Pink Floyd", Album: "The Dark Side of the Moon", Duration: 382}
+	controller.Push(&ingest.Event{State: common.PlayerStatePlaying, Track: next})
+	assert.InDelta(t, 0, controller.GetNowPlayingTrackInfo(ctx).GetPosition(), 0.001)
+	state, _ = controller.GetState(ctx)
+	assert.Equal(t, common.PlayerStatePlaying, state)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestIngest_RequiresEnabledPlayer(t *testing.T) {
+	manager := NewPlayerManager(context.Background(), make(chan struct{}), nil, NewPlayerArbiter(nil), nil)
+	previous := playerManager.Swap(manager)
+	defer playerManager.Store(previous)
+
+	err := Ingest(common.PlayerPlex, &ingest.Event{State: common.PlayerStatePlaying})
+	assert.ErrorIs(t, err, ErrPlayerDisabled)
+}
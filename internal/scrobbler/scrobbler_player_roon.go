@@ -5,10 +5,19 @@ import (
 	"strings"
 
 	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
 	"github.com/vincentchyu/sonic-lens/core/exec"
 	"github.com/vincentchyu/sonic-lens/internal/cache"
 )
 
+func init() {
+	RegisterPlayer(
+		common.PlayerRoon, func(cfg *config.Config) PlayerController {
+			return &RoonPlayerController{}
+		},
+	)
+}
+
 // RoonTrackInfoWrapper 包装 MRMediaNowPlaying 以实现 PlayerInfoHandler 接口
 type RoonTrackInfoWrapper struct {
 	*exec.MediaControlNowPlayingInfo
@@ -31,6 +40,15 @@ func (r *RoonTrackInfoWrapper) GetArtist() string {
 	return r.baseWrapper.ConversionSimplified(r.Artist)
 }
 
+// GetArtistCredit Roon 以逗号分隔多位艺术家，GetArtist 只取第一位，这里返回完整署名
+func (r *RoonTrackInfoWrapper) GetArtistCredit() string {
+	return r.baseWrapper.ConversionSimplified(r.Artist)
+}
+
+func (r *RoonTrackInfoWrapper) GetArtistSeparators() []string {
+	return []string{","}
+}
+
 func (r *RoonTrackInfoWrapper) GetPosition() float64 {
 	return r.ElapsedTimeNow
 }
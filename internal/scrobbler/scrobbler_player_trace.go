@@ -0,0 +1,218 @@
+package scrobbler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+)
+
+// TraceSample 控制器的一次采样，轨迹文件每行一条
+type TraceSample struct {
+	At      time.Time   `json:"at"`
+	Running bool        `json:"running"`
+	State   string      `json:"state,omitempty"`
+	Track   *TraceTrack `json:"track,omitempty"` // 未在播放或读取失败时为空
+}
+
+// TraceTrack 采样时的曲目信息，实现 PlayerInfoHandler
+type TraceTrack struct {
+	Title         string  `json:"title"`
+	Album         string  `json:"album,omitempty"`
+	Artist        string  `json:"artist"`
+	AlbumArtist   string  `json:"album_artist,omitempty"`
+	Position      float64 `json:"position"`
+	Duration      int64   `json:"duration"`
+	Url           string  `json:"url,omitempty"`
+	TrackNumber   int64   `json:"track_number,omitempty"`
+	DiscNumber    int8    `json:"disc_number,omitempty"`
+	Genre         string  `json:"genre,omitempty"`
+	Composer      string  `json:"composer,omitempty"`
+	ReleaseDate   string  `json:"release_date,omitempty"`
+	MusicBrainzID string  `json:"musicbrainz_id,omitempty"`
+	Source        string  `json:"source,omitempty"`
+	BundleID      string  `json:"bundle_id,omitempty"`
+	UniqueID      string  `json:"unique_id,omitempty"`
+}
+
+// NewTraceTrack 复制播放器返回的曲目信息
+func NewTraceTrack(info PlayerInfoHandler) *TraceTrack {
+	return &TraceTrack{
+		Title:         info.GetTitle(),
+		Album:         info.GetAlbum(),
+		Artist:        info.GetArtist(),
+		AlbumArtist:   info.GetAlbumArtist(),
+		Position:      info.GetPosition(),
+		Duration:      info.GetDuration(),
+		Url:           info.GetUrl(),
+		TrackNumber:   info.GetTrackNumber(),
+		DiscNumber:    info.GetDiscNumber(),
+		Genre:         info.GetGenre(),
+		Composer:      info.GetComposer(),
+		ReleaseDate:   info.GetReleaseDate(),
+		MusicBrainzID: info.GetMusicBrainzID(),
+		Source:        info.GetSource(),
+		BundleID:      info.GetBundleID(),
+		UniqueID:      info.GetUniqueID(),
+	}
+}
+
+func (t *TraceTrack) GetTitle() string         { return t.Title }
+func (t *TraceTrack) GetAlbum() string         { return t.Album }
+func (t *TraceTrack) GetArtist() string        { return t.Artist }
+func (t *TraceTrack) GetPosition() float64     { return t.Position }
+func (t *TraceTrack) GetDuration() int64       { return t.Duration }
+func (t *TraceTrack) GetUrl() string           { return t.Url }
+func (t *TraceTrack) GetTrackNumber() int64    { return t.TrackNumber }
+func (t *TraceTrack) GetGenre() string         { return t.Genre }
+func (t *TraceTrack) GetComposer() string      { return t.Composer }
+func (t *TraceTrack) GetReleaseDate() string   { return t.ReleaseDate }
+func (t *TraceTrack) GetMusicBrainzID() string { return t.MusicBrainzID }
+func (t *TraceTrack) GetSource() string        { return t.Source }
+func (t *TraceTrack) GetBundleID() string      { return t.BundleID }
+func (t *TraceTrack) GetUniqueID() string      { return t.UniqueID }
+
+// GetDiscNumber 与各播放器一致，未记录盘号时按第 1 张处理
+func (t *TraceTrack) GetDiscNumber() int8 {
+	if t.DiscNumber <= 0 {
+		return 1
+	}
+	return t.DiscNumber
+}
+
+// GetAlbumArtist 未提供时与播放器包装一致，使用艺术家
+func (t *TraceTrack) GetAlbumArtist() string {
+	if t.AlbumArtist == "" {
+		return t.Artist
+	}
+	return t.AlbumArtist
+}
+
+// NewPlayerController 按注册的后端创建播放器控制器
+func NewPlayerController(playerType common.PlayerType, cfg *config.Config) (PlayerController, error) {
+	factory, ok := lookupPlayer(playerType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPlayer, playerType)
+	}
+	return factory(cfg), nil
+}
+
+// SampleController 按检查器的读取顺序采样一次控制器
+func SampleController(ctx context.Context, controller PlayerController, at time.Time) TraceSample {
+	sample := TraceSample{At: at, Running: controller.IsRunning(ctx)}
+	if !sample.Running {
+		return sample
+	}
+	sample.State, _ = controller.GetState(ctx)
+	if sample.State != common.PlayerStatePlaying && sample.State != common.PlayerStatePaused {
+		return sample
+	}
+	if info := controller.GetNowPlayingTrackInfo(ctx); info != nil {
+		sample.Track = NewTraceTrack(info)
+	}
+	return sample
+}
+
+// RecordTrace 每隔 interval 采样一次控制器并写入轨迹，ctx 结束时返回 nil
+func RecordTrace(ctx context.Context, controller PlayerController, w io.Writer, interval time.Duration) error {
+	encoder := json.NewEncoder(w)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := encoder.Encode(SampleController(ctx, controller, time.Now())); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReadTrace 读取轨迹文件，忽略空行，返回的采样按时间升序
+func ReadTrace(r io.Reader) ([]TraceSample, error) {
+	var samples []TraceSample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var sample TraceSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].At.Before(samples[j].At) })
+	return samples, nil
+}
+
+// TraceController 按虚拟时钟回放轨迹的控制器，返回不晚于当前时间的最后一次采样。
+// 两次采样之间仍在播放时按时钟推算进度，第一条采样之前视为播放器未运行
+type TraceController struct {
+	samples []TraceSample
+	clock   func() time.Time
+}
+
+// NewTraceController 创建回放控制器，samples 需按时间升序
+func NewTraceController(samples []TraceSample, clock func() time.Time) *TraceController {
+	return &TraceController{samples: samples, clock: clock}
+}
+
+func (c *TraceController) current() (TraceSample, time.Duration, bool) {
+	now := c.clock()
+	i := sort.Search(len(c.samples), func(i int) bool { return c.samples[i].At.After(now) })
+	if i == 0 {
+		return TraceSample{}, 0, false
+	}
+	sample := c.samples[i-1]
+	return sample, now.Sub(sample.At), true
+}
+
+func (c *TraceController) IsRunning(ctx context.Context) bool {
+	sample, _, ok := c.current()
+	return ok && sample.Running
+}
+
+func (c *TraceController) GetState(ctx context.Context) (string, error) {
+	sample, _, ok := c.current()
+	if !ok || !sample.Running || sample.State == "" {
+		return common.PlayerStateStopped, nil
+	}
+	return sample.State, nil
+}
+
+func (c *TraceController) GetNowPlayingTrackInfo(ctx context.Context) PlayerInfoHandler {
+	sample, elapsed, ok := c.current()
+	if !ok || sample.Track == nil {
+		return nil
+	}
+	track := *sample.Track
+	if sample.State == common.PlayerStatePlaying {
+		track.Position += elapsed.Seconds()
+		if track.Duration > 0 {
+			track.Position = min(track.Position, float64(track.Duration))
+		}
+	}
+	return &track
+}
+
+// IsFavorite 轨迹不记录喜欢状态
+func (c *TraceController) IsFavorite(ctx context.Context) bool {
+	return false
+}
+
+func (c *TraceController) SetFavorite(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,266 @@
+package scrobbler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
+	"github.com/vincentchyu/sonic-lens/internal/logic/track"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+// fakeSink 沿用 Last.fm 目标的名称与错误分类，记录正在播放与标记请求
+type fakeSink struct {
+	scrobble.Target
+	nowPlaying []*scrobble.Scrobble
+	scrobbles  []*scrobble.Scrobble
+}
+
+func (f *fakeSink) UpdateNowPlaying(_ context.Context, s *scrobble.Scrobble) error {
+	f.nowPlaying = append(f.nowPlaying, s)
+	return nil
+}
+
+func (f *fakeSink) Scrobble(_ context.Context, s *scrobble.Scrobble) error {
+	f.scrobbles = append(f.scrobbles, s)
+	return nil
+}
+
+func (f *fakeSink) tracks(items []*scrobble.Scrobble) []string {
+	var tracks []string
+	for _, item := range items {
+		tracks = append(tracks, item.Track)
+	}
+	return tracks
+}
+
+// replayResult 回放后的上报与落库结果
+type replayResult struct {
+	sink    *fakeSink
+	records []*model.TrackPlayRecord
+	events  []*model.PlayEvent
+	start   time.Time
+}
+
+// replayTraces 在内存 SQLite 上用虚拟时钟回放各播放器的轨迹，每个采样时刻依次检查一次全部播放器
+func replayTraces(t *testing.T, traces map[common.PlayerType]string) *replayResult {
+	t.Helper()
+	model.SetupTestDB(t)
+
+	var now time.Time
+	clock := func() time.Time { return now }
+	sink := &fakeSink{Target: scrobble.NewLastfmTarget()}
+	arbiter := NewPlayerArbiter(nil)
+	plays := NewPlayEventRecorder()
+	var pushCount atomic.Uint32
+
+	var sources []common.PlayerType
+	for source := range traces {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i] > sources[j] })
+
+	var checkers []*BasePlayerChecker
+	steps := make(map[time.Time]bool)
+	for _, source := range sources {
+		data, err := os.ReadFile(filepath.Join("testdata", "trace", traces[source]))
+		require.NoError(t, err)
+		samples, err := ReadTrace(bytes.NewReader(data))
+		require.NoError(t, err)
+		for _, sample := range samples {
+			steps[sample.At] = true
+		}
+		checker := NewBasePlayerChecker(
+			NewTraceController(samples, clock), source, &pushCount, arbiter, track.NewTrackService(),
+			NewScrobblePolicy(config.ScrobbleConfig{}), []scrobble.Target{sink}, plays, arbiter.NewToken(),
+		)
+		checker.clock = clock
+		checkers = append(checkers, checker)
+	}
+
+	var times []time.Time
+	for at := range steps {
+		times = append(times, at)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	ctx := context.Background()
+	for _, at := range times {
+		now = at
+		for _, checker := range checkers {
+			checker.checkPlayer(ctx)
+		}
+	}
+	// 等待后台任务结束后再读取结果，测试结束时会重置数据库与配置
+	for _, checker := range checkers {
+		checker.Wait()
+	}
+
+	result := &replayResult{sink: sink, start: times[0]}
+	require.NoError(t, model.GetDB().Order("id ASC").Find(&result.records).Error)
+	require.NoError(t, model.GetDB().Order("id ASC").Find(&result.events).Error)
+	return result
+}
+
+func TestReplayTrace_Seek(t *testing.T) {
+	result := replayTraces(t, map[common.PlayerType]string{common.PlayerMpd: "seek.jsonl"})
+
+	assert.Equal(t, []string{"Speak to Me", "Breathe"}, result.sink.tracks(result.sink.nowPlaying))
+	// 向前拖动的部分不计入收听，第一首未达到标记阈值
+	assert.Equal(t, []string{"Breathe"}, result.sink.tracks(result.sink.scrobbles))
+	require.Len(t, result.records, 1)
+	assert.True(t, result.records[0].Scrobbled)
+	assert.Equal(t, result.start.Add(27*time.Second).Unix(), result.records[0].PlayTime.Unix(), "timestamp is when the track started")
+
+	require.Len(t, result.events, 2)
+	assert.Equal(t, model.PlayEndSkipped, result.events[0].EndReason)
+	assert.EqualValues(t, 27, result.events[0].ListenedSeconds)
+	assert.Equal(t, []model.PlaySeek{{From: 15, To: 30}}, result.events[0].Seeks)
+	assert.False(t, result.events[0].Scrobbled)
+	assert.Equal(t, model.PlayEndStopped, result.events[1].EndReason)
+	assert.EqualValues(t, 42, result.events[1].ListenedSeconds)
+	assert.True(t, result.events[1].Scrobbled)
+}
+
+func TestReplayTrace_Pause(t *testing.T) {
+	result := replayTraces(t, map[common.PlayerType]string{common.PlayerMpd: "pause.jsonl"})
+
+	// 暂停后恢复不重新上报正在播放，暂停期间不计入收听
+	assert.Equal(t, []string{"Time"}, result.sink.tracks(result.sink.nowPlaying))
+	assert.Equal(t, []string{"Time"}, result.sink.tracks(result.sink.scrobbles))
+	require.Len(t, result.records, 1)
+	assert.Equal(t, result.start.Unix(), result.records[0].PlayTime.Unix())
+
+	require.Len(t, result.events, 1)
+	assert.Equal(t, model.PlayEndCompleted, result.events[0].EndReason)
+	assert.EqualValues(t, 60, result.events[0].ListenedSeconds)
+	assert.Empty(t, result.events[0].Seeks)
+}
+
+func TestReplayTrace_Repeat(t *testing.T) {
+	result := replayTraces(t, map[common.PlayerType]string{common.PlayerMpd: "repeat.jsonl"})
+
+	// 单曲循环每播完一遍都是新的一次播放
+	assert.Equal(t, []string{"Money", "Money"}, result.sink.tracks(result.sink.nowPlaying))
+	assert.Equal(t, []string{"Money", "Money"}, result.sink.tracks(result.sink.scrobbles))
+	require.Len(t, result.records, 2)
+	assert.Equal(t, result.start.Unix(), result.records[0].PlayTime.Unix())
+	assert.Equal(t, result.start.Add(60*time.Second).Unix(), result.records[1].PlayTime.Unix())
+
+	require.Len(t, result.events, 2)
+	for _, event := range result.events {
+		assert.Equal(t, model.PlayEndCompleted, event.EndReason)
+		assert.EqualValues(t, 60, event.ListenedSeconds)
+		assert.Empty(t, event.Seeks)
+		assert.True(t, event.Scrobbled)
+	}
+
+	var tr model.Track
+	require.NoError(t, model.GetDB().Where("track = ?", "Money").First(&tr).Error)
+	assert.EqualValues(t, 2, tr.PlayCount)
+}
+
+func TestReplayTrace_Restart(t *testing.T) {
+	result := replayTraces(t, map[common.PlayerType]string{common.PlayerMpd: "restart.jsonl"})
+
+	// 刚开始时拖回开头仍是同一次播放，播放一段后从头播放是新的一次
+	assert.Equal(t, []string{"Eclipse", "Eclipse"}, result.sink.tracks(result.sink.nowPlaying))
+	assert.Equal(t, []string{"Eclipse", "Eclipse"}, result.sink.tracks(result.sink.scrobbles))
+	require.Len(t, result.records, 2)
+	assert.Equal(t, result.start.Add(60*time.Second).Unix(), result.records[1].PlayTime.Unix())
+
+	require.Len(t, result.events, 2)
+	assert.Equal(t, []model.PlaySeek{{From: 9, To: 0}}, result.events[0].Seeks)
+	assert.EqualValues(t, 57, result.events[0].ListenedSeconds)
+	assert.Empty(t, result.events[1].Seeks)
+	assert.Equal(t, model.PlayEndStopped, result.events[1].EndReason)
+	assert.EqualValues(t, 42, result.events[1].ListenedSeconds)
+}
+
+func TestReplayTrace_PlayerSwitch(t *testing.T) {
+	result := replayTraces(
+		t, map[common.PlayerType]string{
+			common.PlayerRoon: "switch_roon.jsonl",
+			common.PlayerMpd:  "switch_mpd.jsonl",
+		},
+	)
+
+	// roon 暂停后释放正在播放，mpd 接管并上报
+	assert.Equal(t, []string{"Us and Them", "Brain Damage"}, result.sink.tracks(result.sink.nowPlaying))
+	assert.Equal(t, []string{"Brain Damage"}, result.sink.tracks(result.sink.scrobbles))
+	require.Len(t, result.records, 1)
+	assert.Equal(t, string(common.PlayerMpd), result.records[0].Source)
+
+	require.Len(t, result.events, 2)
+	assert.Equal(t, "Us and Them", result.events[0].Track)
+	assert.Equal(t, string(common.PlayerRoon), result.events[0].Source)
+	assert.Equal(t, model.PlayEndSwitchedPlayer, result.events[0].EndReason)
+	assert.EqualValues(t, 24, result.events[0].ListenedSeconds)
+	assert.Equal(t, model.PlayEndStopped, result.events[1].EndReason)
+	assert.True(t, result.events[1].Scrobbled)
+}
+
+func TestReplayTrace_AudirvanaCue(t *testing.T) {
+	result := replayTraces(t, map[common.PlayerType]string{common.PlayerAudirvana: "audirvana_cue.jsonl"})
+
+	// 同一 cue 文件的曲目共用 URL 按标题区分，不同专辑的同名曲目按 URL 区分
+	expected := []string{"Speak to Me", "Breathe", "Breathe"}
+	assert.Equal(t, expected, result.sink.tracks(result.sink.nowPlaying))
+	assert.Equal(t, expected, result.sink.tracks(result.sink.scrobbles))
+	require.Len(t, result.records, 3)
+	assert.Equal(t, "The Dark Side of the Moon", result.records[1].Album)
+	assert.Equal(t, "Delicate Sound of Thunder", result.records[2].Album)
+
+	require.Len(t, result.events, 3)
+	assert.Equal(t, model.PlayEndCompleted, result.events[0].EndReason)
+	assert.Equal(t, model.PlayEndSkipped, result.events[1].EndReason)
+	assert.Equal(t, model.PlayEndStopped, result.events[2].EndReason)
+}
+
+func TestTraceController(t *testing.T) {
+	start := time.Date(2025, 3, 1, 20, 0, 0, 0, time.UTC)
+	samples := []TraceSample{
+		{At: start, Running: true, State: common.PlayerStatePlaying, Track: &TraceTrack{Title: "Time", Artist: "Pink Floyd", Position: 10, Duration: 60}},
+		{At: start.Add(30 * time.Second), Running: true, State: common.PlayerStatePaused, Track: &TraceTrack{Title: "Time", Artist: "Pink Floyd", Position: 40, Duration: 60}},
+		{At: start.Add(60 * time.Second)},
+	}
+	var buf bytes.Buffer
+	for _, sample := range samples {
+		require.NoError(t, json.NewEncoder(&buf).Encode(sample))
+	}
+	read, err := ReadTrace(&buf)
+	require.NoError(t, err)
+	require.Equal(t, samples, read)
+
+	ctx := context.Background()
+	now := start.Add(-time.Second)
+	controller := NewTraceController(read, func() time.Time { return now })
+	assert.False(t, controller.IsRunning(ctx), "not running before the first sample")
+
+	// 播放中按时钟推算进度，暂停后进度不变
+	now = start.Add(5 * time.Second)
+	state, _ := controller.GetState(ctx)
+	assert.Equal(t, common.PlayerStatePlaying, state)
+	assert.InDelta(t, 15, controller.GetNowPlayingTrackInfo(ctx).GetPosition(), 0.001)
+	assert.Equal(t, "Pink Floyd", controller.GetNowPlayingTrackInfo(ctx).GetAlbumArtist())
+
+	now = start.Add(45 * time.Second)
+	assert.InDelta(t, 40, controller.GetNowPlayingTrackInfo(ctx).GetPosition(), 0.001)
+
+	now = start.Add(90 * time.Second)
+	assert.False(t, controller.IsRunning(ctx))
+	state, _ = controller.GetState(ctx)
+	assert.Equal(t, common.PlayerStateStopped, state)
+	assert.Nil(t, controller.GetNowPlayingTrackInfo(ctx))
+}
@@ -0,0 +1,137 @@
+package scrobbler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+)
+
+const (
+	defaultScrobbleMaxSeconds  = 240 // Last.fm：收听满四分钟或一半即可标记
+	defaultScrobbleMinDuration = 30  // Last.fm：短于三十秒的曲目不标记
+	restartMinSeconds          = 30  // 回到开头前至少播放到第三十秒才算重新播放，刚开始时的回拖不算
+)
+
+// ScrobblePolicy 标记听歌完成的判定策略
+type ScrobblePolicy interface {
+	// Threshold 返回该播放器下曲目需要累计收听的秒数，ok 为 false 表示该曲目不应标记
+	Threshold(source common.PlayerType, duration int64) (seconds float64, ok bool)
+}
+
+// ScrobbleRule 单条标记规则，MaxSeconds/MinDuration 为 0 表示不启用
+type ScrobbleRule struct {
+	Percent     float64
+	MaxSeconds  int
+	MinDuration int
+}
+
+// Threshold 计算累计收听阈值，时长未知（如电台流）时仅按 MaxSeconds 判定
+func (r ScrobbleRule) Threshold(duration int64) (float64, bool) {
+	if duration <= 0 {
+		if r.MaxSeconds > 0 {
+			return float64(r.MaxSeconds), true
+		}
+		return 0, false
+	}
+	if r.MinDuration > 0 && duration < int64(r.MinDuration) {
+		return 0, false
+	}
+	seconds := r.Percent * float64(duration)
+	if r.MaxSeconds > 0 && float64(r.MaxSeconds) < seconds {
+		seconds = float64(r.MaxSeconds)
+	}
+	return seconds, true
+}
+
+// RulePolicy 默认规则加按播放器覆盖的策略
+type RulePolicy struct {
+	Default ScrobbleRule
+	Players map[common.PlayerType]ScrobbleRule
+}
+
+func (p *RulePolicy) Threshold(source common.PlayerType, duration int64) (float64, bool) {
+	return p.rule(source).Threshold(duration)
+}
+
+func (p *RulePolicy) rule(source common.PlayerType) ScrobbleRule {
+	if rule, ok := p.Players[source]; ok {
+		return rule
+	}
+	// viper 会将 map 键转为小写，这里不区分大小写匹配
+	for player, rule := range p.Players {
+		if strings.EqualFold(string(player), string(source)) {
+			return rule
+		}
+	}
+	return p.Default
+}
+
+// NewScrobblePolicy 根据配置构建策略，未配置的字段使用默认值
+func NewScrobblePolicy(cfg config.ScrobbleConfig) *RulePolicy {
+	defaults := ScrobbleRule{
+		Percent:     percentScrobble,
+		MaxSeconds:  defaultScrobbleMaxSeconds,
+		MinDuration: defaultScrobbleMinDuration,
+	}
+	policy := &RulePolicy{
+		Default: mergeScrobbleRule(defaults, cfg.Default),
+		Players: make(map[common.PlayerType]ScrobbleRule, len(cfg.Players)),
+	}
+	for player, rule := range cfg.Players {
+		policy.Players[common.PlayerType(player)] = mergeScrobbleRule(policy.Default, rule)
+	}
+	return policy
+}
+
+// mergeScrobbleRule 用配置覆盖 base，0 沿用 base，负数关闭该条件
+func mergeScrobbleRule(base ScrobbleRule, cfg config.ScrobbleRuleConfig) ScrobbleRule {
+	if cfg.Percent > 0 {
+		base.Percent = cfg.Percent
+	}
+	if cfg.MaxSeconds != 0 {
+		base.MaxSeconds = max(cfg.MaxSeconds, 0)
+	}
+	if cfg.MinDuration != 0 {
+		base.MinDuration = max(cfg.MinDuration, 0)
+	}
+	return base
+}
+
+// listenTracker 累计当前曲目的实际收听时长
+// 两次观测之间只计入 min(进度增量, 墙钟增量)，向前拖动进度不会被计为收听，暂停期间也不计入
+type listenTracker struct {
+	track        string
+	lastPosition float64
+	lastSeen     time.Time
+	listened     float64
+}
+
+// Observe 记录一次播放中的观测并返回累计收听秒数，曲目变化时重新计数
+func (l *listenTracker) Observe(track string, position float64, now time.Time) float64 {
+	if track != l.track || l.lastSeen.IsZero() {
+		l.track = track
+		l.listened = 0
+	} else if delta := position - l.lastPosition; delta > 0 {
+		l.listened += min(delta, now.Sub(l.lastSeen).Seconds())
+	}
+	l.lastPosition = position
+	l.lastSeen = now
+	return l.listened
+}
+
+// Restart 同一曲目的进度回到开头时返回 true，下一次 Observe 重新计数。
+// 单曲循环播完一遍或播放一段后从头播放，都视为重新收听
+func (l *listenTracker) Restart(track string, position float64, now time.Time) bool {
+	if track != l.track || l.lastSeen.IsZero() || l.lastPosition < restartMinSeconds {
+		return false
+	}
+	// 从开头播放到这次观测最多经过一个轮询间隔
+	elapsed := min(max(now.Sub(l.lastSeen).Seconds(), 0), defaultSleep)
+	if position >= l.lastPosition || position > elapsed+seekToleranceSeconds {
+		return false
+	}
+	l.lastSeen = time.Time{}
+	return true
+}
@@ -0,0 +1,175 @@
+package scrobbler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+)
+
+func TestScrobbleRule_Threshold(t *testing.T) {
+	lastfmRule := ScrobbleRule{Percent: 0.5, MaxSeconds: 240, MinDuration: 30}
+	tests := []struct {
+		name     string
+		rule     ScrobbleRule
+		duration int64
+		want     float64
+		wantOk   bool
+	}{
+		{name: "短曲目取一半", rule: lastfmRule, duration: 200, want: 100, wantOk: true},
+		{name: "长曲目四分钟封顶", rule: lastfmRule, duration: 1200, want: 240, wantOk: true},
+		{name: "恰好八分钟", rule: lastfmRule, duration: 480, want: 240, wantOk: true},
+		{name: "短于最短时长", rule: lastfmRule, duration: 29, wantOk: false},
+		{name: "等于最短时长", rule: lastfmRule, duration: 30, want: 15, wantOk: true},
+		{name: "时长未知按四分钟", rule: lastfmRule, duration: 0, want: 240, wantOk: true},
+		{name: "时长未知且关闭四分钟规则", rule: ScrobbleRule{Percent: 0.5}, duration: 0, wantOk: false},
+		{name: "关闭四分钟规则", rule: ScrobbleRule{Percent: 0.55}, duration: 1000, want: 550, wantOk: true},
+		{name: "关闭最短时长", rule: ScrobbleRule{Percent: 0.5}, duration: 10, want: 5, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, ok := tt.rule.Threshold(tt.duration)
+				assert.Equal(t, tt.wantOk, ok)
+				if tt.wantOk {
+					assert.InDelta(t, tt.want, got, 0.001)
+				}
+			},
+		)
+	}
+}
+
+func TestNewScrobblePolicy(t *testing.T) {
+	policy := NewScrobblePolicy(
+		config.ScrobbleConfig{
+			Default: config.ScrobbleRuleConfig{MinDuration: 60},
+			Players: map[string]config.ScrobbleRuleConfig{
+				// viper 读取后的键为小写
+				"roon":        {Percent: 0.9, MaxSeconds: -1},
+				"Apple Music": {MinDuration: -1},
+			},
+		},
+	)
+	tests := []struct {
+		name     string
+		source   common.PlayerType
+		duration int64
+		want     float64
+		wantOk   bool
+	}{
+		{name: "默认比例", source: common.PlayerAudirvana, duration: 200, want: 110, wantOk: true},
+		{name: "默认四分钟封顶", source: common.PlayerAudirvana, duration: 600, want: 240, wantOk: true},
+		{name: "默认最短时长被覆盖为60", source: common.PlayerAudirvana, duration: 45, wantOk: false},
+		{name: "Roon 覆盖比例并关闭封顶", source: common.PlayerRoon, duration: 600, want: 540, wantOk: true},
+		{name: "Roon 继承最短时长", source: common.PlayerRoon, duration: 45, wantOk: false},
+		{name: "Apple Music 关闭最短时长", source: common.PlayerAppleMusic, duration: 20, want: 11, wantOk: true},
+		{name: "未配置播放器使用默认", source: common.PlayerMpris, duration: 100, want: 55, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, ok := policy.Threshold(tt.source, tt.duration)
+				assert.Equal(t, tt.wantOk, ok)
+				if tt.wantOk {
+					assert.InDelta(t, tt.want, got, 0.001)
+				}
+			},
+		)
+	}
+}
+
+func TestListenTracker_Observe(t *testing.T) {
+	type observation struct {
+		track    string
+		position float64
+		at       float64 // 相对起点的墙钟秒数
+	}
+	tests := []struct {
+		name         string
+		observations []observation
+		want         float64
+	}{
+		{
+			name:         "首次观测不计入",
+			observations: []observation{{"a", 120, 0}},
+			want:         0,
+		},
+		{
+			name:         "正常播放按进度累计",
+			observations: []observation{{"a", 0, 0}, {"a", 3, 3}, {"a", 6, 6}, {"a", 9, 9}},
+			want:         9,
+		},
+		{
+			name:         "向前拖动越过阈值只计墙钟时间",
+			observations: []observation{{"a", 0, 0}, {"a", 3, 3}, {"a", 200, 6}},
+			want:         6,
+		},
+		{
+			name:         "向后拖动不计入",
+			observations: []observation{{"a", 100, 0}, {"a", 103, 3}, {"a", 10, 6}, {"a", 13, 9}},
+			want:         6,
+		},
+		{
+			name:         "暂停期间不计入",
+			observations: []observation{{"a", 0, 0}, {"a", 30, 30}, {"a", 33, 600}},
+			want:         33,
+		},
+		{
+			name:         "切换曲目重新计数",
+			observations: []observation{{"a", 0, 0}, {"a", 60, 60}, {"b", 0, 61}, {"b", 5, 66}},
+			want:         5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+				var tracker listenTracker
+				var got float64
+				for _, o := range tt.observations {
+					got = tracker.Observe(o.track, o.position, start.Add(time.Duration(o.at*float64(time.Second))))
+				}
+				assert.InDelta(t, tt.want, got, 0.001)
+			},
+		)
+	}
+}
+
+func TestListenTracker_Restart(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds float64) time.Time { return start.Add(time.Duration(seconds * float64(time.Second))) }
+
+	t.Run(
+		"单曲循环回到开头重新计数", func(t *testing.T) {
+			var tracker listenTracker
+			tracker.Observe("a", 0, at(0))
+			tracker.Observe("a", 57, at(57))
+			assert.False(t, tracker.Restart("b", 0, at(60)), "different track")
+			require.True(t, tracker.Restart("a", 1, at(60)))
+			assert.InDelta(t, 0, tracker.Observe("a", 1, at(60)), 0.001)
+			assert.InDelta(t, 3, tracker.Observe("a", 4, at(63)), 0.001)
+		},
+	)
+	t.Run(
+		"刚开始时拖回开头不算", func(t *testing.T) {
+			var tracker listenTracker
+			tracker.Observe("a", 0, at(0))
+			tracker.Observe("a", 9, at(9))
+			assert.False(t, tracker.Restart("a", 0, at(12)))
+		},
+	)
+	t.Run(
+		"拖回中段不算", func(t *testing.T) {
+			var tracker listenTracker
+			tracker.Observe("a", 0, at(0))
+			tracker.Observe("a", 100, at(100))
+			assert.False(t, tracker.Restart("a", 40, at(103)))
+			// 暂停很久后恢复时从开头附近以外的位置继续也不算
+			assert.False(t, tracker.Restart("a", 20, at(700)))
+		},
+	)
+}
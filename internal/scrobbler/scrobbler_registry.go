@@ -0,0 +1,244 @@
+package scrobbler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/core/websocket"
+	"github.com/vincentchyu/sonic-lens/internal/nowplaying"
+)
+
+var (
+	// ErrUnknownPlayer 播放器未注册
+	ErrUnknownPlayer = errors.New("unknown player")
+	// ErrScrobblerNotStarted scrobbler.Init 尚未执行
+	ErrScrobblerNotStarted = errors.New("scrobbler not started")
+)
+
+// PlayerFactory 按配置创建播放器控制器，每次启用播放器时调用一次
+type PlayerFactory func(cfg *config.Config) PlayerController
+
+var (
+	registryMu      sync.RWMutex
+	playerFactories = make(map[common.PlayerType]PlayerFactory)
+)
+
+// RegisterPlayer 注册播放器后端，在各播放器文件的 init 中调用，名称即 scrobblers 配置中的值
+func RegisterPlayer(playerType common.PlayerType, factory PlayerFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("scrobbler: RegisterPlayer factory is nil")
+	}
+	if _, dup := playerFactories[playerType]; dup {
+		panic("scrobbler: RegisterPlayer called twice for " + string(playerType))
+	}
+	playerFactories[playerType] = factory
+}
+
+// RegisteredPlayers 返回全部已注册的播放器，按名称排序
+func RegisteredPlayers() []common.PlayerType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	players := make([]common.PlayerType, 0, len(playerFactories))
+	for playerType := range playerFactories {
+		players = append(players, playerType)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i] < players[j] })
+	return players
+}
+
+func lookupPlayer(playerType common.PlayerType) (PlayerFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := playerFactories[playerType]
+	return factory, ok
+}
+
+// resolvePlayer 按名称查找已注册的播放器，与配置一致不区分大小写
+func resolvePlayer(name string) (common.PlayerType, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for playerType := range playerFactories {
+		if strings.EqualFold(string(playerType), name) {
+			return playerType, true
+		}
+	}
+	return "", false
+}
+
+// PlayerStatus 播放器运行状态
+type PlayerStatus struct {
+	Name       string                 `json:"name"`
+	Enabled    bool                   `json:"enabled"`
+	Playing    bool                   `json:"playing"`
+	Owner      bool                   `json:"owner"` // 是否持有正在播放
+	NowPlaying *websocket.WsTrackInfo `json:"now_playing,omitempty"`
+}
+
+// CheckerFactory 为启用的播放器创建检查器，检查器以 token 向仲裁器上报正在播放
+type CheckerFactory func(playerType common.PlayerType, controller PlayerController, token ClaimToken) PlayerChecker
+
+// PlayerManager 管理已注册播放器的启停，启用时创建新的检查器，停用时退出检查并释放正在播放
+type PlayerManager struct {
+	mu         sync.Mutex
+	ctx        context.Context
+	stop       <-chan struct{}
+	cfg        *config.Config
+	arbiter    *PlayerArbiter
+	newChecker CheckerFactory
+	running    map[common.PlayerType]*runningPlayer
+}
+
+type runningPlayer struct {
+	disable chan struct{}
+	done    chan struct{}
+}
+
+// NewPlayerManager stop 关闭后全部检查器退出
+func NewPlayerManager(
+	ctx context.Context, stop <-chan struct{}, cfg *config.Config, arbiter *PlayerArbiter, newChecker CheckerFactory,
+) *PlayerManager {
+	return &PlayerManager{
+		ctx:        ctx,
+		stop:       stop,
+		cfg:        cfg,
+		arbiter:    arbiter,
+		newChecker: newChecker,
+		running:    make(map[common.PlayerType]*runningPlayer),
+	}
+}
+
+// Enable 启用播放器，已启用时不做处理
+func (m *PlayerManager) Enable(playerType common.PlayerType) error {
+	factory, ok := lookupPlayer(playerType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPlayer, playerType)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.running[playerType]; ok {
+		return nil
+	}
+
+	player := &runningPlayer{disable: make(chan struct{}), done: make(chan struct{})}
+	token := m.arbiter.NewToken()
+	checker := m.newChecker(playerType, factory(m.cfg), token)
+	stop := mergeStop(m.stop, player.disable)
+	m.running[playerType] = player
+	go func() {
+		defer close(player.done)
+		checker.CheckPlayingTrack(m.ctx, stop)
+		// 检查器退出后才释放，避免退出前最后一次检查重新占用正在播放；
+		// 只释放本检查器的持有，停用等待超时后重新启用的新检查器不受影响
+		next, changed := m.arbiter.Release(playerType, token)
+		m.mu.Lock()
+		if _, restarted := m.running[playerType]; !restarted {
+			nowplaying.Default().Update(string(playerType), common.PlayerStateStopped, nil)
+		}
+		m.mu.Unlock()
+		broadcastRelease(m.ctx, playerType, next, changed)
+	}()
+	log.Info(m.ctx, string(playerType)+" 播放器已启用")
+	return nil
+}
+
+// Disable 停用播放器并等待检查器退出，未启用时不做处理
+func (m *PlayerManager) Disable(ctx context.Context, playerType common.PlayerType) error {
+	if _, ok := lookupPlayer(playerType); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPlayer, playerType)
+	}
+	m.mu.Lock()
+	player, ok := m.running[playerType]
+	if ok {
+		delete(m.running, playerType)
+		close(player.disable)
+	}
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	select {
+	case <-player.done:
+		log.Info(ctx, string(playerType)+" 播放器已停用")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Enabled 播放器是否已启用
+func (m *PlayerManager) Enabled(playerType common.PlayerType) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.running[playerType]
+	return ok
+}
+
+// Players 返回全部已注册播放器的状态
+func (m *PlayerManager) Players() []PlayerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	owner, _ := m.arbiter.Owner()
+	var players []PlayerStatus
+	for _, playerType := range RegisteredPlayers() {
+		_, enabled := m.running[playerType]
+		nowPlaying := m.arbiter.NowPlaying(playerType)
+		players = append(
+			players, PlayerStatus{
+				Name:       string(playerType),
+				Enabled:    enabled,
+				Playing:    nowPlaying != nil,
+				Owner:      playerType == owner,
+				NowPlaying: nowPlaying,
+			},
+		)
+	}
+	return players
+}
+
+// mergeStop 任一通道关闭时返回的通道关闭
+func mergeStop(stop, disable <-chan struct{}) <-chan struct{} {
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		select {
+		case <-stop:
+		case <-disable:
+		}
+	}()
+	return merged
+}
+
+// Players 返回全部已注册播放器的状态，scrobbler 未启动时返回 nil
+func Players() []PlayerStatus {
+	manager := playerManager.Load()
+	if manager == nil {
+		return nil
+	}
+	return manager.Players()
+}
+
+// SetPlayerEnabled 运行时启用或停用播放器
+func SetPlayerEnabled(ctx context.Context, name string, enabled bool) error {
+	manager := playerManager.Load()
+	if manager == nil {
+		return ErrScrobblerNotStarted
+	}
+	playerType, ok := resolvePlayer(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPlayer, name)
+	}
+	if enabled {
+		return manager.Enable(playerType)
+	}
+	return manager.Disable(ctx, playerType)
+}
@@ -0,0 +1,241 @@
+package scrobbler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/core/websocket"
+)
+
+func nowPlaying(source common.PlayerType, title string) *websocket.WsTrackInfo {
+	info := &websocket.WsTrackInfo{Type: "now_playing", Source: string(source)}
+	info.Data.Title = title
+	return info
+}
+
+func TestPlayerArbiter(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	arbiter := NewPlayerArbiter(nil)
+	arbiter.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	roon, mpris := arbiter.NewToken(), arbiter.NewToken()
+
+	assert.True(t, arbiter.Claim(common.PlayerRoon, roon, nowPlaying(common.PlayerRoon, "A")))
+	// 后开始播放的播放器接管
+	assert.True(t, arbiter.Claim(common.PlayerMpris, mpris, nowPlaying(common.PlayerMpris, "B")))
+	// 切歌不改变开始播放时间
+	assert.False(t, arbiter.Claim(common.PlayerRoon, roon, nowPlaying(common.PlayerRoon, "C")))
+	owner, ok := arbiter.Owner()
+	require.True(t, ok)
+	assert.Equal(t, common.PlayerMpris, owner)
+
+	// 非持有者停止不影响持有者
+	next, changed := arbiter.Release(common.PlayerRoon, roon)
+	assert.False(t, changed)
+	assert.Nil(t, next)
+	assert.Nil(t, arbiter.NowPlaying(common.PlayerRoon))
+
+	arbiter.Claim(common.PlayerRoon, roon, nowPlaying(common.PlayerRoon, "D"))
+	next, changed = arbiter.Release(common.PlayerRoon, roon)
+	assert.True(t, changed)
+	require.NotNil(t, next)
+	assert.Equal(t, "B", next.Data.Title, "remaining player takes over")
+
+	next, changed = arbiter.Release(common.PlayerMpris, mpris)
+	assert.True(t, changed)
+	assert.Nil(t, next, "nobody is playing")
+	_, ok = arbiter.Owner()
+	assert.False(t, ok)
+
+	_, changed = arbiter.Release(common.PlayerMpris, mpris)
+	assert.False(t, changed)
+}
+
+func TestPlayerArbiter_Token(t *testing.T) {
+	arbiter := NewPlayerArbiter(nil)
+	stale, current := arbiter.NewToken(), arbiter.NewToken()
+
+	assert.True(t, arbiter.Claim(common.PlayerRoon, stale, nowPlaying(common.PlayerRoon, "A")))
+	// 重新启用后的检查器接管同一播放器
+	assert.True(t, arbiter.Claim(common.PlayerRoon, current, nowPlaying(common.PlayerRoon, "B")))
+	// 旧检查器迟到的上报与释放都被忽略
+	assert.False(t, arbiter.Claim(common.PlayerRoon, stale, nowPlaying(common.PlayerRoon, "C")))
+	assert.False(t, arbiter.IsOwner(common.PlayerRoon, stale), "stale checker must not send now playing")
+	assert.True(t, arbiter.IsOwner(common.PlayerRoon, current))
+	_, changed := arbiter.Release(common.PlayerRoon, stale)
+	assert.False(t, changed)
+	require.NotNil(t, arbiter.NowPlaying(common.PlayerRoon))
+	assert.Equal(t, "B", arbiter.NowPlaying(common.PlayerRoon).Data.Title)
+
+	_, changed = arbiter.Release(common.PlayerRoon, current)
+	assert.True(t, changed)
+	assert.Nil(t, arbiter.NowPlaying(common.PlayerRoon))
+}
+
+func TestPlayerArbiter_Priority(t *testing.T) {
+	arbiter := NewPlayerArbiter([]string{"roon", "Audirvana"})
+	token := arbiter.NewToken()
+
+	assert.True(t, arbiter.Claim(common.PlayerAudirvana, token, nowPlaying(common.PlayerAudirvana, "A")))
+	// 未配置优先级的播放器排在最后
+	assert.False(t, arbiter.Claim(common.PlayerAppleMusic, token, nowPlaying(common.PlayerAppleMusic, "B")))
+	assert.True(t, arbiter.Claim(common.PlayerRoon, token, nowPlaying(common.PlayerRoon, "C")))
+	assert.False(t, arbiter.Claim(common.PlayerAudirvana, token, nowPlaying(common.PlayerAudirvana, "D")))
+
+	next, changed := arbiter.Release(common.PlayerRoon, token)
+	assert.True(t, changed)
+	require.NotNil(t, next)
+	assert.Equal(t, "D", next.Data.Title)
+}
+
+func TestRegisteredPlayers(t *testing.T) {
+	assert.Equal(
+		t,
+		[]common.PlayerType{
+			common.PlayerAppleMusic, common.PlayerAudirvana, common.PlayerJellyfin, common.PlayerMpd,
+			common.PlayerMpris, common.PlayerPlex, common.PlayerRoon, common.PlayerWebScrobbler,
+		},
+		RegisteredPlayers(),
+	)
+	assert.Panics(
+		t, func() {
+			RegisterPlayer(common.PlayerRoon, func(cfg *config.Config) PlayerController { return &RoonPlayerController{} })
+		},
+	)
+}
+
+// fakeChecker 启动后立即上报正在播放，直到收到退出信号；hold 不为空时收到信号后等 hold 关闭才退出
+type fakeChecker struct {
+	source  common.PlayerType
+	arbiter *PlayerArbiter
+	token   ClaimToken
+	started chan struct{}
+	hold    chan struct{}
+}
+
+func (f *fakeChecker) CheckPlayingTrack(ctx context.Context, stop <-chan struct{}) {
+	f.arbiter.Claim(f.source, f.token, nowPlaying(f.source, string(f.source)))
+	close(f.started)
+	<-stop
+	if f.hold != nil {
+		<-f.hold
+	}
+}
+
+func TestPlayerManager(t *testing.T) {
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	ctx := context.Background()
+	stop := make(chan struct{})
+	defer close(stop)
+
+	arbiter := NewPlayerArbiter([]string{string(common.PlayerRoon)})
+	checkers := make(map[common.PlayerType][]*fakeChecker)
+	manager := NewPlayerManager(
+		ctx, stop, &config.Config{}, arbiter,
+		func(playerType common.PlayerType, controller PlayerController, token ClaimToken) PlayerChecker {
+			checker := &fakeChecker{source: playerType, arbiter: arbiter, token: token, started: make(chan struct{})}
+			checkers[playerType] = append(checkers[playerType], checker)
+			return checker
+		},
+	)
+
+	require.NoError(t, manager.Enable(common.PlayerRoon))
+	require.NoError(t, manager.Enable(common.PlayerMpris))
+	require.NoError(t, manager.Enable(common.PlayerRoon), "enabling twice is a no-op")
+	assert.ErrorIs(t, manager.Enable("Winamp"), ErrUnknownPlayer)
+	for name, want := range map[string]common.PlayerType{
+		"mpd": common.PlayerMpd, "MPD": common.PlayerMpd, "roon": common.PlayerRoon,
+		"web scrobbler": common.PlayerWebScrobbler,
+	} {
+		playerType, ok := resolvePlayer(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, playerType, name)
+	}
+	_, ok := resolvePlayer("Winamp")
+	assert.False(t, ok)
+	require.Len(t, checkers[common.PlayerRoon], 1)
+	<-checkers[common.PlayerRoon][0].started
+	<-checkers[common.PlayerMpris][0].started
+
+	statuses := make(map[string]PlayerStatus)
+	for _, status := range manager.Players() {
+		statuses[status.Name] = status
+	}
+	require.Len(t, statuses, len(RegisteredPlayers()))
+	assert.True(t, statuses["Roon"].Enabled)
+	assert.True(t, statuses["Roon"].Owner)
+	assert.True(t, statuses["MPRIS"].Playing)
+	assert.False(t, statuses["MPRIS"].Owner)
+	assert.False(t, statuses["Audirvana"].Enabled)
+
+	// 停用后释放正在播放，由仍在播放的播放器接管
+	require.NoError(t, manager.Disable(ctx, common.PlayerRoon))
+	owner, _ := arbiter.Owner()
+	assert.Equal(t, common.PlayerMpris, owner)
+	assert.Nil(t, arbiter.NowPlaying(common.PlayerRoon))
+
+	// 重新启用时创建新的检查器
+	require.NoError(t, manager.Enable(common.PlayerRoon))
+	require.Len(t, checkers[common.PlayerRoon], 2)
+	<-checkers[common.PlayerRoon][1].started
+	owner, _ = arbiter.Owner()
+	assert.Equal(t, common.PlayerRoon, owner)
+
+	require.NoError(t, manager.Disable(ctx, common.PlayerAudirvana), "disabling a stopped player is a no-op")
+}
+
+func TestPlayerManager_ReenableBeforeExit(t *testing.T) {
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	ctx := context.Background()
+	stop := make(chan struct{})
+	defer close(stop)
+
+	arbiter := NewPlayerArbiter(nil)
+	hold := make(chan struct{})
+	var checkers []*fakeChecker
+	manager := NewPlayerManager(
+		ctx, stop, &config.Config{}, arbiter,
+		func(playerType common.PlayerType, controller PlayerController, token ClaimToken) PlayerChecker {
+			checker := &fakeChecker{source: playerType, arbiter: arbiter, token: token, started: make(chan struct{})}
+			if len(checkers) == 0 {
+				checker.hold = hold
+			}
+			checkers = append(checkers, checker)
+			return checker
+		},
+	)
+
+	require.NoError(t, manager.Enable(common.PlayerRoon))
+	<-checkers[0].started
+	// 旧检查器迟迟不退出，停用等待超时后立即重新启用
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, manager.Disable(timeout, common.PlayerRoon), context.DeadlineExceeded)
+	require.NoError(t, manager.Enable(common.PlayerRoon))
+	require.Len(t, checkers, 2)
+	<-checkers[1].started
+
+	// 旧检查器退出时的释放不影响新检查器的持有
+	close(hold)
+	assert.Never(
+		t, func() bool { return arbiter.NowPlaying(common.PlayerRoon) == nil }, 50*time.Millisecond, 5*time.Millisecond,
+	)
+	owner, ok := arbiter.Owner()
+	require.True(t, ok)
+	assert.Equal(t, common.PlayerRoon, owner)
+}
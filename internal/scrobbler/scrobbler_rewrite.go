@@ -0,0 +1,55 @@
+package scrobbler
+
+import (
+	"context"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/internal/logic/rewrite"
+)
+
+// rewrittenPlayerInfo 应用改写规则后的曲目信息，未改写的字段仍取自原播放器
+type rewrittenPlayerInfo struct {
+	PlayerInfoHandler
+	fields           rewrite.Fields
+	artistCredit     string
+	artistSeparators []string
+}
+
+func (r *rewrittenPlayerInfo) GetTitle() string       { return r.fields.Title }
+func (r *rewrittenPlayerInfo) GetAlbum() string       { return r.fields.Album }
+func (r *rewrittenPlayerInfo) GetArtist() string      { return r.fields.Artist }
+func (r *rewrittenPlayerInfo) GetAlbumArtist() string { return r.fields.AlbumArtist }
+func (r *rewrittenPlayerInfo) GetGenre() string       { return r.fields.Genre }
+func (r *rewrittenPlayerInfo) GetComposer() string    { return r.fields.Composer }
+
+// GetArtistCredit 艺术家被改写后以改写结果作为署名
+func (r *rewrittenPlayerInfo) GetArtistCredit() string {
+	return r.artistCredit
+}
+
+func (r *rewrittenPlayerInfo) GetArtistSeparators() []string {
+	return r.artistSeparators
+}
+
+// applyRewriteRules 按用户定义的改写规则修正播放器上报的元数据，没有规则命中时原样返回
+func applyRewriteRules(ctx context.Context, source common.PlayerType, info PlayerInfoHandler) PlayerInfoHandler {
+	before := rewrite.Fields{
+		Source:      string(source),
+		Artist:      info.GetArtist(),
+		Album:       info.GetAlbum(),
+		Title:       info.GetTitle(),
+		AlbumArtist: info.GetAlbumArtist(),
+		Genre:       info.GetGenre(),
+		Composer:    info.GetComposer(),
+	}
+	after, _ := rewrite.Rewrite(ctx, before)
+	if after == before {
+		return info
+	}
+
+	rewritten := &rewrittenPlayerInfo{PlayerInfoHandler: info, fields: after, artistCredit: after.Artist}
+	if provider, ok := info.(ArtistCreditProvider); ok && after.Artist == before.Artist {
+		rewritten.artistCredit, rewritten.artistSeparators = provider.GetArtistCredit(), provider.GetArtistSeparators()
+	}
+	return rewritten
+}
@@ -5,8 +5,13 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"go.uber.org/zap"
+
 	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/config"
 	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
 	"github.com/vincentchyu/sonic-lens/internal/logic/track"
 	"github.com/vincentchyu/sonic-lens/internal/model"
 )
@@ -16,16 +21,9 @@ var (
 	one             sync.Once
 
 	// 共享状态变量
-	pushCount           = atomic.Uint32{} // 多渠道上报
-	atomicPlaying       = atomic.Bool{}   // 并发播放状态
-	currentPlayingCache = sync.Map{}      // 本地缓存当前播放信息
-
-	// 播放器检查器实例
-	audirvanaChecker  *BasePlayerChecker
-	roonChecker       *BasePlayerChecker
-	appleMusicChecker *BasePlayerChecker
+	pushCount = atomic.Uint32{} // 多渠道上报
 
-	playerCheckers map[common.PlayerType]PlayerChecker // playerCheckers 存储所有支持的播放器检查器
+	playerManager atomic.Pointer[PlayerManager] // 播放器启停管理，Init 后可用
 )
 
 func Init(
@@ -44,51 +42,41 @@ func Init(
 				userPassword,
 			)
 
-			// 初始化播放器检查器
-			audirvanaChecker = NewBasePlayerChecker(
-				&AudirvanaPlayerController{},
-				common.PlayerAudirvana,
-				&pushCount,
-				&atomicPlaying,
-				&currentPlayingCache,
-				newTrackService,
-			)
-
-			roonChecker = NewBasePlayerChecker(
-				&RoonPlayerController{},
-				common.PlayerRoon,
-				&pushCount,
-				&atomicPlaying,
-				&currentPlayingCache,
-				newTrackService,
+			// 每次启用播放器时按注册的后端创建新的检查器
+			scrobblePolicy := NewScrobblePolicy(config.ConfigObj.Scrobble)
+			scrobbleTargets := scrobble.NewTargets(config.ConfigObj)
+			arbiter := NewPlayerArbiter(config.ConfigObj.PlayerPriority)
+			plays := NewPlayEventRecorder()
+			manager := NewPlayerManager(
+				ctx, c, config.ConfigObj, arbiter,
+				func(playerType common.PlayerType, controller PlayerController, token ClaimToken) PlayerChecker {
+					return NewBasePlayerChecker(
+						controller,
+						playerType,
+						&pushCount,
+						arbiter,
+						newTrackService,
+						scrobblePolicy,
+						scrobbleTargets,
+						plays,
+						token,
+					)
+				},
 			)
 
-			appleMusicChecker = NewBasePlayerChecker(
-				&AppleMusicPlayerController{},
-				common.PlayerAppleMusic,
-				&pushCount,
-				&atomicPlaying,
-				&currentPlayingCache,
-				newTrackService,
-			)
-			playerCheckers = map[common.PlayerType]PlayerChecker{
-				common.PlayerAudirvana:  audirvanaChecker,
-				common.PlayerRoon:       roonChecker,
-				common.PlayerAppleMusic: appleMusicChecker,
-			}
-
 			// 初始化检查器
 			var playerTypes []common.PlayerType
 			for _, player := range scrobblers {
 				playerTypes = append(playerTypes, common.PlayerType(player))
 			}
-			_CheckPlayingTrack(ctx, playerTypes, c)
+			_CheckPlayingTrack(ctx, manager, playerTypes)
+			playerManager.Store(manager)
 		},
 	)
 }
 
 // _CheckPlayingTrack 统一的播放检查函数
-func _CheckPlayingTrack(ctx context.Context, playerTypes []common.PlayerType, stop <-chan struct{}) {
+func _CheckPlayingTrack(ctx context.Context, manager *PlayerManager, playerTypes []common.PlayerType) {
 	counts, err := model.GetTrackCounts(ctx)
 	if err != nil {
 		panic(err)
@@ -98,11 +86,8 @@ func _CheckPlayingTrack(ctx context.Context, playerTypes []common.PlayerType, st
 
 	// 为每个playerType启动一个goroutine
 	for _, playerType := range playerTypes {
-		if checker, exists := playerCheckers[playerType]; exists {
-			if checker == nil {
-				panic("player checker is nil")
-			}
-			go checker.CheckPlayingTrack(ctx, stop)
+		if err := manager.Enable(playerType); err != nil {
+			log.Warn(ctx, "scrobblers 中的播放器未注册", zap.String("player", string(playerType)), zap.Error(err))
 		}
 	}
 }
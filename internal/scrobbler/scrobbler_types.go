@@ -7,11 +7,12 @@ import (
 	"time"
 
 	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
 	"github.com/vincentchyu/sonic-lens/internal/logic/track"
 )
 
 const (
-	percentScrobble = 0.55
+	percentScrobble = 0.55 // 默认累计收听比例，可通过 scrobble 配置覆盖
 	defaultSleep    = 3
 	longSleep       = 60 // 休眠间隔六十秒
 	checkCount      = 100
@@ -39,6 +40,13 @@ type PlayerInfoHandler interface {
 	GetDiscNumber() int8      // 盘位
 }
 
+// ArtistCreditProvider 可选接口，GetArtist 只返回主艺术家的播放器实现后，
+// 以完整的艺术家署名解析合作艺术家
+type ArtistCreditProvider interface {
+	GetArtistCredit() string
+	GetArtistSeparators() []string // 署名中分隔多位艺术家的写法，为空时不拆分
+}
+
 // PlayerController 定义播放器控制接口
 type PlayerController interface {
 	IsRunning(ctx context.Context) bool
@@ -48,32 +56,85 @@ type PlayerController interface {
 	SetFavorite(ctx context.Context) error
 }
 
+// PlayerEventType 播放器推送事件类型
+type PlayerEventType string
+
+const (
+	PlayerEventTrack PlayerEventType = "track" // 曲目切换
+	PlayerEventState PlayerEventType = "state" // 播放状态变化（播放/暂停/停止/退出）
+	PlayerEventSeek  PlayerEventType = "seek"  // 播放进度跳转
+)
+
+// PlayerEvent 播放器推送的事件，检查器收到后会重新读取控制器状态
+type PlayerEvent struct {
+	Type     PlayerEventType
+	State    string  // 仅 PlayerEventState 可能有值
+	Position float64 // 仅 PlayerEventSeek 有值，单位秒
+}
+
+// PlayerEventSource 可选接口，支持推送的控制器实现后检查器不再轮询
+// Events 返回错误时检查器回退为轮询；返回的通道关闭后同样回退为轮询
+type PlayerEventSource interface {
+	Events(ctx context.Context) (<-chan PlayerEvent, error)
+}
+
 // PlayerChecker 定义播放器检查器接口
 type PlayerChecker interface {
 	CheckPlayingTrack(ctx context.Context, stop <-chan struct{})
 }
 
+// scrobbleTimer 推送模式下的标记定时器，创建后处于停止状态
+type scrobbleTimer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// stdScrobbleTimer 基于 time.Timer 的标记定时器
+type stdScrobbleTimer struct {
+	timer *time.Timer
+}
+
+func newStdScrobbleTimer() scrobbleTimer {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	return &stdScrobbleTimer{timer: timer}
+}
+
+func (t *stdScrobbleTimer) C() <-chan time.Time { return t.timer.C }
+
+func (t *stdScrobbleTimer) Reset(d time.Duration) { t.timer.Reset(d) }
+
+func (t *stdScrobbleTimer) Stop() { t.timer.Stop() }
+
 // BasePlayerChecker 基础播放器检查器结构
 type BasePlayerChecker struct {
-	controller      PlayerController
-	source          common.PlayerType
-	defaultSleep    time.Duration
-	longSleep       time.Duration
-	checkCount      int
-	percentScrobble float64
+	controller   PlayerController
+	source       common.PlayerType
+	defaultSleep time.Duration
+	longSleep    time.Duration
+	checkCount   int
+	policy       ScrobblePolicy
+	targets      []scrobble.Target
 
 	// 状态变量
 	mapedTracks   map[string]bool
 	isLongCheck   bool
 	timer         *time.Ticker
+	scrobbleTimer scrobbleTimer // 推送模式下到达标记阈值时触发的定时器
 	previousTrack string
 	currentTrack  string
 	tmpCount      int
 	now           time.Time
+	listen        listenTracker
+	plays         *PlayEventRecorder   // 各检查器共享
+	clock         func() time.Time     // 默认 time.Now，回放轨迹时替换为虚拟时钟
+	newTimer      func() scrobbleTimer // 默认基于 time.Timer，测试时替换为手动触发的定时器
+	background    sync.WaitGroup       // 标记后在后台执行的喜欢状态同步
 
 	// 共享状态
-	pushCount           *atomic.Uint32
-	atomicPlaying       *atomic.Bool
-	currentPlayingCache *sync.Map
-	trackService        track.TrackService
+	pushCount    *atomic.Uint32
+	arbiter      *PlayerArbiter
+	token        ClaimToken // 本次运行向仲裁器上报时使用
+	trackService track.TrackService
 }
@@ -3,6 +3,7 @@ package d1sync
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -121,22 +122,29 @@ func (c *D1Client) SyncTracks(ctx context.Context, incremental bool) error {
 func (c *D1Client) getTracksFromLocal(ctx context.Context, incremental bool, lastSyncTime time.Time) (
 	[]*model.Track, error,
 ) {
+	var tracks []*model.Track
+	var err error
 	if incremental {
 		// 增量同步:仅获取自上次同步后更新的记录
 		log.Info(ctx, "Performing incremental sync", zap.Time("last_sync_time", lastSyncTime))
-		return model.GetTracksUpdatedSince(ctx, lastSyncTime)
+		tracks, err = model.GetTracksUpdatedSince(ctx, lastSyncTime)
+	} else {
+		// 全量同步:获取所有记录
+		log.Info(ctx, "Performing full sync")
+		tracks, err = model.GetAllTrackPlayCounts(ctx)
 	}
-
-	// 全量同步:获取所有记录
-	log.Info(ctx, "Performing full sync")
-	return model.GetAllTrackPlayCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 评分与标签变化时会更新曲目的 updated_at，随曲目一起同步
+	return tracks, model.AttachTrackTags(ctx, tracks)
 }
 
 // batchUpsertTracks 批量插入或更新曲目数据
 func (c *D1Client) batchUpsertTracks(ctx context.Context, tracks []*model.Track) error {
 	// D1 单次事务限制,使用批量处理
-	// 12 params per row -> floor(31/12)=2
-	batchSize := batchSizeByParams(12)
+	// 14 params per row -> floor(31/14)=2
+	batchSize := batchSizeByParams(14)
 	totalBatches := (len(tracks) + batchSize - 1) / batchSize
 
 	for i := 0; i < len(tracks); i += batchSize {
@@ -170,12 +178,12 @@ func (c *D1Client) upsertTracksBatch(ctx context.Context, tracks []*model.Track)
 	}
 
 	// 字段数量
-	const numFields = 12
+	const numFields = 14
 	placeholders := make([]string, len(tracks))
 	args := make([]interface{}, 0, len(tracks)*numFields)
 
 	for i, track := range tracks {
-		placeholders[i] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		placeholders[i] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 		args = append(
 			args,
 			track.Artist,
@@ -188,6 +196,8 @@ func (c *D1Client) upsertTracksBatch(ctx context.Context, tracks []*model.Track)
 			track.Source,
 			boolToInt(track.IsAppleMusicFav),
 			boolToInt(track.IsLastFmFav),
+			track.Rating,
+			tagsJSON(track.Tags),
 			track.CreatedAt.Format(time.RFC3339),
 			track.UpdatedAt.Format(time.RFC3339),
 		)
@@ -197,7 +207,7 @@ func (c *D1Client) upsertTracksBatch(ctx context.Context, tracks []*model.Track)
 		`
 		INSERT OR REPLACE INTO tracks (
 			artist, album, track, album_artist, play_count, genre, duration, source,
-			is_apple_music_fav, is_last_fm_fav, created_at, updated_at
+			is_apple_music_fav, is_last_fm_fav, rating, tags, created_at, updated_at
 		) VALUES %s
 	`, strings.Join(placeholders, ", "),
 	)
@@ -368,12 +378,64 @@ func (c *D1Client) SyncGenres(ctx context.Context, incremental bool) error {
 	return nil
 }
 
+// SyncAlbums 同步专辑的评分与标签到 D1
+func (c *D1Client) SyncAlbums(ctx context.Context) error {
+	log.Info(ctx, "Starting D1 albums sync")
+
+	lastSyncTime, err := c.getLastSyncTime(ctx, "albums")
+	if err != nil {
+		log.Warn(ctx, "Failed to get last sync time, performing full sync", zap.Error(err))
+		lastSyncTime = time.Time{}
+	}
+	albums, err := model.GetAlbumsUpdatedSince(ctx, lastSyncTime)
+	if err != nil {
+		return fmt.Errorf("failed to get albums from local db: %w", err)
+	}
+	if len(albums) == 0 {
+		log.Info(ctx, "No albums to sync")
+		return nil
+	}
+
+	// 7 params per row -> floor(31/7)=4
+	batchSize := batchSizeByParams(7)
+	for i := 0; i < len(albums); i += batchSize {
+		end := i + batchSize
+		if end > len(albums) {
+			end = len(albums)
+		}
+		if err := c.upsertAlbumsBatch(ctx, albums[i:end]); err != nil {
+			return fmt.Errorf("failed to upsert albums batch %d: %w", i/batchSize+1, err)
+		}
+	}
+
+	if err := c.updateSyncMetadata(ctx, "albums", len(albums)); err != nil {
+		log.Warn(ctx, "Failed to update sync metadata", zap.Error(err))
+	}
+	log.Info(ctx, "D1 albums sync completed", zap.Int("synced_count", len(albums)))
+	return nil
+}
+
+func (c *D1Client) upsertAlbumsBatch(ctx context.Context, albums []*model.Album) error {
+	placeholders := make([]string, len(albums))
+	args := make([]interface{}, 0, len(albums)*7)
+	for i, album := range albums {
+		placeholders[i] = "(?, ?, ?, ?, ?, ?, ?)"
+		args = append(
+			args, album.Name, album.Artist, album.ReleaseDate, album.Rating, tagsJSON(album.Tags),
+			album.CreatedAt.Format(time.RFC3339), album.UpdatedAt.Format(time.RFC3339),
+		)
+	}
+	query := fmt.Sprintf(
+		"INSERT OR REPLACE INTO albums (name, artist, release_date, rating, tags, created_at, updated_at) VALUES %s",
+		strings.Join(placeholders, ", "),
+	)
+	_, err := c.db.ExecContext(ctx, query, args...)
+	return err
+}
+
 // SyncDashboardStats 同步 dashboard 统计表到 D1
 func (c *D1Client) SyncDashboardStats(ctx context.Context) error {
 	log.Info(ctx, "Starting D1 dashboard stats sync")
-	if err := model.EnsureDashboardStatSchema(ctx); err != nil {
-		return fmt.Errorf("failed to ensure dashboard stat schema: %w", err)
-	}
 
 	lastSyncTime, err := c.getLastSyncTime(ctx, "dashboard_stats")
 	if err != nil {
@@ -409,8 +471,17 @@ func (c *D1Client) SyncDashboardStats(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to get play_trend_hourly_stat from local db: %w", err)
 	}
+	topRatedRows, err := model.GetTopRatedStatsUpdatedSince(ctx, lastSyncTime)
+	if err != nil {
+		return fmt.Errorf("failed to get top_rated_stat from local db: %w", err)
+	}
+	tagRows, err := model.GetTagStatsUpdatedSince(ctx, lastSyncTime)
+	if err != nil {
+		return fmt.Errorf("failed to get tag_stat from local db: %w", err)
+	}
 
-	totalCount := len(overviewRows) + len(sourceRows) + len(artistRows) + len(albumRows) + len(genreRows) + len(trendDailyRows) + len(trendHourlyRows)
+	totalCount := len(overviewRows) + len(sourceRows) + len(artistRows) + len(albumRows) + len(genreRows) + len(trendDailyRows) + len(trendHourlyRows) +
+		len(topRatedRows) + len(tagRows)
 	if totalCount == 0 {
 		log.Info(ctx, "No dashboard stats changes to sync")
 		return nil
@@ -444,6 +515,12 @@ func (c *D1Client) SyncDashboardStats(ctx context.Context) error {
 	if err := c.batchUpsertPlayTrendHourlyStats(ctx, trendHourlyRows); err != nil {
 		return fmt.Errorf("failed to sync hourly trend stats: %w", err)
 	}
+	if err := c.batchUpsertTopRatedStats(ctx, topRatedRows); err != nil {
+		return fmt.Errorf("failed to sync top rated stats: %w", err)
+	}
+	if err := c.batchUpsertTagStats(ctx, tagRows); err != nil {
+		return fmt.Errorf("failed to sync tag stats: %w", err)
+	}
 
 	// 增量同步模式下，清理遗留旧数据（由本地“删后重建”造成）。
 	if !lastSyncTime.IsZero() {
@@ -465,6 +542,8 @@ func (c *D1Client) SyncDashboardStats(ctx context.Context) error {
 		zap.Int("top_genre_stat", len(genreRows)),
 		zap.Int("play_trend_daily_stat", len(trendDailyRows)),
 		zap.Int("play_trend_hourly_stat", len(trendHourlyRows)),
+		zap.Int("top_rated_stat", len(topRatedRows)),
+		zap.Int("tag_stat", len(tagRows)),
 	)
 	return nil
 }
@@ -479,6 +558,8 @@ func (c *D1Client) cleanupStaleDashboardStatRows(ctx context.Context, lastSyncTi
 		"top_genre_stat",
 		"play_trend_daily_stat",
 		"play_trend_hourly_stat",
+		"top_rated_stat",
+		"tag_stat",
 	}
 
 	for _, table := range tables {
@@ -499,6 +580,8 @@ func (c *D1Client) resetDashboardStatTables(ctx context.Context) error {
 		"top_genre_stat",
 		"play_trend_daily_stat",
 		"play_trend_hourly_stat",
+		"top_rated_stat",
+		"tag_stat",
 	}
 	for _, table := range tables {
 		if _, err := c.db.ExecContext(ctx, "DELETE FROM "+table); err != nil {
@@ -739,6 +822,78 @@ func (c *D1Client) upsertPlayTrendHourlyStatsBatch(ctx context.Context, rows []*
 	return err
 }
 
+func (c *D1Client) batchUpsertTopRatedStats(ctx context.Context, rows []*model.TopRatedStat) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	// 8 params per row -> floor(31/8)=3
+	batchSize := batchSizeByParams(8)
+	for i := 0; i < len(rows); i += batchSize {
+		end := i + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		if err := c.upsertTopRatedStatsBatch(ctx, rows[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *D1Client) upsertTopRatedStatsBatch(ctx context.Context, rows []*model.TopRatedStat) error {
+	placeholders := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*8)
+	for i, row := range rows {
+		placeholders[i] = "(?, ?, ?, ?, ?, ?, ?, ?)"
+		args = append(
+			args, row.TargetType, row.Artist, row.Album, row.Track, row.Rating, row.PlayCount, row.Rank,
+			row.UpdatedAt.Format(time.RFC3339),
+		)
+	}
+	query := fmt.Sprintf(
+		"INSERT OR REPLACE INTO top_rated_stat (target_type, artist, album, track, rating, play_count, rank, updated_at) VALUES %s",
+		strings.Join(placeholders, ", "),
+	)
+	_, err := c.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (c *D1Client) batchUpsertTagStats(ctx context.Context, rows []*model.TagStat) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	// 7 params per row -> floor(31/7)=4
+	batchSize := batchSizeByParams(7)
+	for i := 0; i < len(rows); i += batchSize {
+		end := i + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		if err := c.upsertTagStatsBatch(ctx, rows[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *D1Client) upsertTagStatsBatch(ctx context.Context, rows []*model.TagStat) error {
+	placeholders := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*7)
+	for i, row := range rows {
+		placeholders[i] = "(?, ?, ?, ?, ?, ?, ?)"
+		args = append(
+			args, row.TargetType, row.Tag, row.ItemCount, row.PlayCount, row.AvgRating, row.Rank,
+			row.UpdatedAt.Format(time.RFC3339),
+		)
+	}
+	query := fmt.Sprintf(
+		"INSERT OR REPLACE INTO tag_stat (target_type, tag, item_count, play_count, avg_rating, rank, updated_at) VALUES %s",
+		strings.Join(placeholders, ", "),
+	)
+	_, err := c.db.ExecContext(ctx, query, args...)
+	return err
+}
+
 func (c *D1Client) getGenresFromLocal(ctx context.Context, incremental bool, lastSyncTime time.Time) (
 	[]*model.Genre, error,
 ) {
@@ -847,6 +1002,15 @@ func (c *D1Client) updateSyncMetadata(ctx context.Context, tableName string, syn
 	return err
 }
 
+// tagsJSON 将标签编码为 JSON 数组，没有标签时为 []
+func tagsJSON(tags []string) string {
+	if len(tags) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(tags)
+	return string(data)
+}
+
 // boolToInt 将 bool 转换为 int
 func boolToInt(b bool) int {
 	if b {
@@ -877,6 +1041,12 @@ func (c *D1Client) SyncAll(ctx context.Context, incremental bool) error {
 		return err
 	}
 
+	// 同步专辑评分与标签
+	if err := c.SyncAlbums(ctx); err != nil {
+		log.Error(ctx, "Failed to sync albums", zap.Error(err))
+		return err
+	}
+
 	// 同步 dashboard 统计数据
 	if err := c.SyncDashboardStats(ctx); err != nil {
 		log.Warn(ctx, "Failed to sync dashboard stats, skipping", zap.Error(err))
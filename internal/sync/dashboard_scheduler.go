@@ -33,6 +33,7 @@ func StartDashboardStatScheduler(ctx context.Context) {
 				zap.Int("top_n", runtimeCfg.TopN),
 				zap.Int("trend_days", runtimeCfg.TrendDays),
 				zap.Int("hourly_trend_days", runtimeCfg.HourlyTrendDays),
+				zap.Int("session_gap_minutes", runtimeCfg.SessionGapMinutes),
 			)
 
 			go runDashboardStatLoop(ctx, lightInterval, heavyInterval, runtimeCfg.HeavyOnlyOnNewPlay)
@@ -106,5 +107,6 @@ func isDashboardConfigUnset(cfg config.DashboardConfig) bool {
 		!cfg.HeavyStatOnlyOnNewPlay &&
 		cfg.TopN == 0 &&
 		cfg.TrendDays == 0 &&
-		cfg.HourlyTrendDays == 0
+		cfg.HourlyTrendDays == 0 &&
+		cfg.SessionGapMinutes == 0
 }
@@ -0,0 +1,56 @@
+package d1sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/logic/favorite"
+)
+
+var favoriteReconcileOnce sync.Once
+
+// StartFavoriteReconcileScheduler 按配置的间隔定时对账 Last.fm 与本地的喜欢状态，间隔为 0 时不启动
+func StartFavoriteReconcileScheduler(ctx context.Context) {
+	favoriteReconcileOnce.Do(
+		func() {
+			cfg := config.ConfigObj.Favorites.Reconcile
+			if cfg.IntervalMinutes <= 0 {
+				log.Info(ctx, "favorite reconcile scheduler is disabled in config")
+				return
+			}
+			policy, err := favorite.ParsePolicy(cfg.Policy)
+			if err != nil {
+				log.Error(ctx, "favorite reconcile scheduler not started", zap.Error(err))
+				return
+			}
+			interval := time.Duration(cfg.IntervalMinutes) * time.Minute
+			log.Info(
+				ctx, "favorite reconcile scheduler started",
+				zap.Duration("interval", interval), zap.String("policy", string(policy)),
+			)
+
+			reconciler := favorite.NewReconciler(config.ConfigObj.Lastfm.UserUsername)
+			go func() {
+				// Last.fm API 在播放器检查器启动时才完成登录，首次对账放在第一个周期之后
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ticker.C:
+						if _, err := reconciler.Reconcile(ctx, policy, false); err != nil {
+							log.Error(ctx, "favorite reconcile failed", zap.Error(err))
+						}
+					case <-ctx.Done():
+						log.Info(ctx, "favorite reconcile scheduler stopped")
+						return
+					}
+				}
+			}()
+		},
+	)
+}
@@ -0,0 +1,274 @@
+package d1sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vincentchyu/sonic-lens/config"
+	"github.com/vincentchyu/sonic-lens/core/log"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+const (
+	defaultOutboxInterval   = time.Minute
+	defaultOutboxMaxBackoff = 6 * time.Hour
+	outboxBaseBackoff       = time.Minute
+	outboxEnqueueLimit      = 500
+	outboxMaxBatchesPerRun  = 20 // 单次扫描最多提交的批次数，避免积压过多时长时间占用
+)
+
+var scrobbleOutboxOnce sync.Once
+
+// scrobbleOutboxWorker 将未投递的播放记录按目标分批重试提交
+type scrobbleOutboxWorker struct {
+	targets    []scrobble.Target
+	maxBackoff time.Duration
+	now        func() time.Time
+	paused     map[string]*targetPause // 因鉴权或配置问题暂停的目标
+}
+
+// targetPause 目标级退避状态，暂停期间不提交该目标的任何条目
+type targetPause struct {
+	failures int
+	until    time.Time
+}
+
+// StartScrobbleOutboxScheduler 启动上报失败重试队列
+func StartScrobbleOutboxScheduler(ctx context.Context) {
+	scrobbleOutboxOnce.Do(
+		func() {
+			cfg := config.ConfigObj.Scrobble.Outbox
+			if cfg.Disabled {
+				log.Info(ctx, "scrobble outbox scheduler is disabled in config")
+				return
+			}
+			interval := defaultOutboxInterval
+			if cfg.IntervalSeconds > 0 {
+				interval = time.Duration(cfg.IntervalSeconds) * time.Second
+			}
+			maxBackoff := defaultOutboxMaxBackoff
+			if cfg.MaxBackoffMinutes > 0 {
+				maxBackoff = time.Duration(cfg.MaxBackoffMinutes) * time.Minute
+			}
+			targets := scrobble.NewTargets(config.ConfigObj)
+			log.Info(
+				ctx, "scrobble outbox scheduler started",
+				zap.Duration("interval", interval), zap.Duration("max_backoff", maxBackoff),
+				zap.Int("targets", len(targets)),
+			)
+
+			worker := newScrobbleOutboxWorker(targets, maxBackoff)
+			go worker.run(ctx, interval)
+		},
+	)
+}
+
+func newScrobbleOutboxWorker(targets []scrobble.Target, maxBackoff time.Duration) *scrobbleOutboxWorker {
+	return &scrobbleOutboxWorker{
+		targets:    targets,
+		maxBackoff: maxBackoff,
+		now:        time.Now,
+		paused:     map[string]*targetPause{},
+	}
+}
+
+func (w *scrobbleOutboxWorker) run(ctx context.Context, interval time.Duration) {
+	// Last.fm API 在播放器检查器启动时才完成登录，首次扫描放在第一个周期之后
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.runOnce(ctx); err != nil {
+				log.Error(ctx, "scrobble outbox run failed", zap.Error(err))
+			}
+		case <-ctx.Done():
+			log.Info(ctx, "scrobble outbox scheduler stopped")
+			return
+		}
+	}
+}
+
+// runOnce 依次处理每个上报目标，单个目标出错不影响其余目标
+func (w *scrobbleOutboxWorker) runOnce(ctx context.Context) error {
+	var errs []error
+	for _, target := range w.targets {
+		if err := w.runTarget(ctx, target); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runTarget 入队新的未同步记录，并提交该目标所有到期条目
+func (w *scrobbleOutboxWorker) runTarget(ctx context.Context, target scrobble.Target) error {
+	if pause := w.paused[target.Name()]; pause != nil && w.now().Before(pause.until) {
+		return nil
+	}
+	// track_play_records.scrobbled 仅代表 Last.fm 的状态，其余目标的条目由实时上报时写入
+	if target.Name() == model.OutboxTargetLastfm {
+		enqueued, err := model.EnqueueUnscrobbledRecords(ctx, target.Name(), outboxEnqueueLimit)
+		if err != nil {
+			return err
+		}
+		if enqueued > 0 {
+			log.Info(ctx, "scrobble outbox enqueued unscrobbled records", zap.Int("count", enqueued))
+		}
+	}
+
+	for i := 0; i < outboxMaxBatchesPerRun; i++ {
+		items, err := model.GetDueScrobbleOutbox(ctx, target.Name(), w.now(), target.BatchSize())
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		if err := w.deliver(ctx, target, items); err != nil {
+			w.pause(ctx, target, err)
+			return err
+		}
+		delete(w.paused, target.Name())
+	}
+	return nil
+}
+
+// pause 目标级退避：条目保持待投递且不累计重试次数，整个目标按指数退避暂停
+func (w *scrobbleOutboxWorker) pause(ctx context.Context, target scrobble.Target, err error) {
+	pause := w.paused[target.Name()]
+	if pause == nil {
+		pause = &targetPause{}
+		w.paused[target.Name()] = pause
+	}
+	pause.until = w.now().Add(w.backoff(pause.failures))
+	pause.failures++
+	log.Warn(
+		ctx, "scrobble outbox target paused", zap.String("target", target.Name()),
+		zap.Time("until", pause.until), zap.Error(err),
+	)
+}
+
+// deliver 提交一批条目并根据结果更新状态，目标本身不可用（鉴权或配置问题）时返回错误且不改动条目
+func (w *scrobbleOutboxWorker) deliver(
+	ctx context.Context, target scrobble.Target, items []*model.ScrobbleOutboxItem,
+) error {
+	isLastfm := target.Name() == model.OutboxTargetLastfm
+	ready := make([]*model.ScrobbleOutboxItem, 0, len(items))
+	var alreadySent []int64
+	for _, item := range items {
+		switch {
+		case item.Record == nil:
+			w.markFailed(ctx, item, 0, "play record not found")
+		case isLastfm && item.Record.Scrobbled:
+			// 已通过 sync-records 或页面手动同步
+			alreadySent = append(alreadySent, item.Outbox.ID)
+		default:
+			ready = append(ready, item)
+		}
+	}
+	if err := model.MarkScrobbleOutboxSent(ctx, alreadySent...); err != nil {
+		log.Warn(ctx, "scrobble outbox mark sent failed", zap.Error(err))
+	}
+	if len(ready) == 0 {
+		return nil
+	}
+
+	scrobbles := make([]*scrobble.Scrobble, 0, len(ready))
+	for _, item := range ready {
+		scrobbles = append(scrobbles, scrobble.FromRecord(item.Record))
+	}
+
+	results, err := target.ScrobbleBatch(ctx, scrobbles)
+	if err != nil {
+		code := target.ErrorCode(err)
+		class := target.ClassifyError(err)
+		switch {
+		case class == scrobble.ErrorTarget:
+			return err
+		case class == scrobble.ErrorPermanent && len(ready) > 1:
+			// 参数错误可能只由个别记录引起，拆成单条提交以免整批被判定失败
+			log.Warn(
+				ctx, "scrobble outbox batch rejected, retry one by one", zap.String("target", target.Name()),
+				zap.Int("code", code), zap.Error(err),
+			)
+			for _, item := range ready {
+				if err := w.deliver(ctx, target, []*model.ScrobbleOutboxItem{item}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, item := range ready {
+			if class == scrobble.ErrorTransient {
+				w.markRetry(ctx, item, code, err.Error())
+			} else {
+				w.markFailed(ctx, item, code, err.Error())
+			}
+		}
+		return nil
+	}
+
+	var sentIDs, recordIDs []int64
+	for i, item := range ready {
+		itemResult := results[i]
+		switch {
+		case itemResult.Accepted:
+			sentIDs = append(sentIDs, item.Outbox.ID)
+			recordIDs = append(recordIDs, item.Record.ID)
+		case itemResult.Permanent:
+			w.markFailed(ctx, item, itemResult.Code, itemResult.Message)
+		default:
+			w.markRetry(ctx, item, itemResult.Code, itemResult.Message)
+		}
+	}
+	if err := model.MarkScrobbleOutboxSent(ctx, sentIDs...); err != nil {
+		log.Warn(ctx, "scrobble outbox mark sent failed", zap.Error(err))
+	}
+	if isLastfm && len(recordIDs) > 0 {
+		if err := model.BatchUpdateScrobbledStatus(ctx, recordIDs, true); err != nil {
+			log.Warn(ctx, "scrobble outbox update scrobbled status failed", zap.Error(err))
+		}
+	}
+	log.Info(
+		ctx, "scrobble outbox delivered batch", zap.String("target", target.Name()), zap.Int("size", len(ready)),
+		zap.Int("accepted", len(sentIDs)),
+	)
+	return nil
+}
+
+func (w *scrobbleOutboxWorker) markRetry(ctx context.Context, item *model.ScrobbleOutboxItem, code int, msg string) {
+	next := w.now().Add(w.backoff(item.Outbox.Attempts))
+	if err := model.MarkScrobbleOutboxRetry(ctx, item.Outbox.ID, next, code, msg); err != nil {
+		log.Warn(ctx, "scrobble outbox mark retry failed", zap.Int64("id", item.Outbox.ID), zap.Error(err))
+	}
+}
+
+func (w *scrobbleOutboxWorker) markFailed(ctx context.Context, item *model.ScrobbleOutboxItem, code int, msg string) {
+	log.Warn(
+		ctx, "scrobble outbox permanently failed", zap.String("target", item.Outbox.Target),
+		zap.Int64("record_id", item.Outbox.RecordID),
+		zap.Int("code", code), zap.String("error", msg),
+	)
+	if err := model.MarkScrobbleOutboxFailed(ctx, item.Outbox.ID, code, msg); err != nil {
+		log.Warn(ctx, "scrobble outbox mark permanent failure failed", zap.Int64("id", item.Outbox.ID), zap.Error(err))
+	}
+}
+
+// backoff 指数退避：1 分钟起每次翻倍，不超过 maxBackoff
+func (w *scrobbleOutboxWorker) backoff(attempts int) time.Duration {
+	d := outboxBaseBackoff
+	for i := 0; i < attempts; i++ {
+		d *= 2
+		if d >= w.maxBackoff {
+			return w.maxBackoff
+		}
+	}
+	return min(d, w.maxBackoff)
+}
@@ -0,0 +1,330 @@
+package d1sync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincentchyu/sonic-lens/common"
+	"github.com/vincentchyu/sonic-lens/core/lastfm"
+	"github.com/vincentchyu/sonic-lens/core/listenbrainz"
+	"github.com/vincentchyu/sonic-lens/internal/logic/scrobble"
+	"github.com/vincentchyu/sonic-lens/internal/model"
+)
+
+func insertPlayRecords(t *testing.T, n int, scrobbled bool) []*model.TrackPlayRecord {
+	t.Helper()
+	records := make([]*model.TrackPlayRecord, 0, n)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		record := &model.TrackPlayRecord{
+			Artist:    "Artist",
+			Track:     fmt.Sprintf("Track %03d", i),
+			Album:     "Album",
+			Duration:  200,
+			PlayTime:  base.Add(time.Duration(i) * time.Minute),
+			Scrobbled: scrobbled,
+			Source:    string(common.PlayerRoon),
+			AlbumID:   -1,
+		}
+		require.NoError(t, model.GetDB().Create(record).Error)
+		records = append(records, record)
+	}
+	return records
+}
+
+func outboxRows(t *testing.T) []*model.ScrobbleOutbox {
+	t.Helper()
+	var rows []*model.ScrobbleOutbox
+	require.NoError(t, model.GetDB().Order("record_id ASC").Find(&rows).Error)
+	return rows
+}
+
+// fakeTarget 沿用被嵌入目标的名称、批大小与错误分类，记录每次提交的批次大小并按 ignored 映射返回单条结果
+type fakeTarget struct {
+	scrobble.Target
+	batches []int
+	ignored map[string]int // track -> ignoredMessage code
+	err     error
+}
+
+func newFakeLastfm() *fakeTarget {
+	return &fakeTarget{Target: scrobble.NewLastfmTarget()}
+}
+
+func newFakeListenBrainz() *fakeTarget {
+	return &fakeTarget{Target: scrobble.NewListenBrainzTarget(nil)}
+}
+
+func (f *fakeTarget) ScrobbleBatch(_ context.Context, items []*scrobble.Scrobble) ([]scrobble.ItemResult, error) {
+	f.batches = append(f.batches, len(items))
+	if f.err != nil {
+		return nil, f.err
+	}
+	results := make([]scrobble.ItemResult, len(items))
+	for i, item := range items {
+		code := f.ignored[item.Track]
+		itemResult := lastfm.ScrobbleItemResult{Accepted: code == 0, IgnoredCode: code}
+		results[i] = scrobble.ItemResult{
+			Accepted:  itemResult.Accepted,
+			Permanent: itemResult.IsPermanentIgnored(),
+			Code:      code,
+		}
+	}
+	return results, nil
+}
+
+// rejectingTarget 批次中包含 bad 曲目时整批返回参数错误，否则全部接受
+type rejectingTarget struct {
+	scrobble.Target
+	bad   string
+	calls int
+}
+
+func (r *rejectingTarget) ScrobbleBatch(_ context.Context, items []*scrobble.Scrobble) ([]scrobble.ItemResult, error) {
+	r.calls++
+	for _, item := range items {
+		if item.Track == r.bad {
+			return nil, &lastfm.APIError{Code: lastfm.ErrCodeInvalidParameters, Message: "Invalid parameters"}
+		}
+	}
+	results := make([]scrobble.ItemResult, len(items))
+	for i := range results {
+		results[i].Accepted = true
+	}
+	return results, nil
+}
+
+func TestScrobbleOutboxWorker_BatchesOf50(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	insertPlayRecords(t, 120, false)
+	insertPlayRecords(t, 3, true)
+
+	fake := newFakeLastfm()
+	fake.ignored = map[string]int{
+		"Track 005": lastfm.IgnoredCodeTimestampTooOld,
+		"Track 006": lastfm.IgnoredCodeDailyLimitReached,
+	}
+	worker := newScrobbleOutboxWorker([]scrobble.Target{fake}, defaultOutboxMaxBackoff)
+	require.NoError(t, worker.runOnce(ctx))
+
+	assert.Equal(t, []int{50, 50, 20}, fake.batches)
+	rows := outboxRows(t)
+	require.Len(t, rows, 120, "already scrobbled records must not be enqueued")
+
+	statusCount := map[string]int{}
+	for _, row := range rows {
+		statusCount[row.Status]++
+	}
+	assert.Equal(t, map[string]int{model.OutboxStatusSent: 118, model.OutboxStatusFailed: 1, model.OutboxStatusPending: 1}, statusCount)
+	assert.Equal(t, lastfm.IgnoredCodeTimestampTooOld, rows[5].ErrorCode)
+	assert.Equal(t, model.OutboxStatusPending, rows[6].Status)
+	assert.Equal(t, 1, rows[6].Attempts)
+
+	count, err := model.GetUnscrobbledRecordsCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// 再次运行：失败条目不会再次入队，重试条目尚未到期
+	fake.batches = nil
+	require.NoError(t, worker.runOnce(ctx))
+	assert.Empty(t, fake.batches)
+}
+
+func TestScrobbleOutboxWorker_TransientErrorBackoff(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	insertPlayRecords(t, 2, false)
+
+	now := time.Now().Add(time.Second)
+	fake := newFakeLastfm()
+	fake.err = &lastfm.APIError{Code: lastfm.ErrCodeServiceOffline, Message: "Service Offline"}
+	worker := newScrobbleOutboxWorker([]scrobble.Target{fake}, 10*time.Minute)
+	worker.now = func() time.Time { return now }
+
+	wantDelays := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute}
+	for i, want := range wantDelays {
+		require.NoError(t, worker.runOnce(ctx))
+		rows := outboxRows(t)
+		for _, row := range rows {
+			assert.Equal(t, model.OutboxStatusPending, row.Status)
+			assert.Equal(t, i+1, row.Attempts)
+			assert.Equal(t, lastfm.ErrCodeServiceOffline, row.ErrorCode)
+			assert.WithinDuration(t, now.Add(want), row.NextAttemptAt, time.Second)
+		}
+		// 未到期时不会提交
+		submitted := len(fake.batches)
+		require.NoError(t, worker.runOnce(ctx))
+		assert.Len(t, fake.batches, submitted)
+		now = now.Add(want)
+	}
+
+	// 服务恢复后全部成功
+	fake.err = nil
+	require.NoError(t, worker.runOnce(ctx))
+	for _, row := range outboxRows(t) {
+		assert.Equal(t, model.OutboxStatusSent, row.Status)
+	}
+}
+
+func TestScrobbleOutboxWorker_PermanentBatchErrorSplits(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	insertPlayRecords(t, 3, false)
+
+	fake := &rejectingTarget{Target: scrobble.NewLastfmTarget(), bad: "Track 001"}
+	worker := newScrobbleOutboxWorker([]scrobble.Target{fake}, defaultOutboxMaxBackoff)
+	require.NoError(t, worker.runOnce(ctx))
+
+	assert.Equal(t, 4, fake.calls, "one batch call plus one call per record")
+	rows := outboxRows(t)
+	assert.Equal(t, model.OutboxStatusSent, rows[0].Status)
+	assert.Equal(t, model.OutboxStatusFailed, rows[1].Status)
+	assert.Equal(t, lastfm.ErrCodeInvalidParameters, rows[1].ErrorCode)
+	assert.Equal(t, model.OutboxStatusSent, rows[2].Status)
+}
+
+func TestScrobbleOutboxWorker_AuthErrorPausesTarget(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	insertPlayRecords(t, 60, false)
+
+	for _, code := range []int{
+		lastfm.ErrCodeAuthFailed, lastfm.ErrCodeInvalidSessionKey, lastfm.ErrCodeInvalidAPIKey,
+		lastfm.ErrCodeSuspendedAPIKey,
+	} {
+		assert.Equal(
+			t, scrobble.ErrorTarget, scrobble.NewLastfmTarget().ClassifyError(&lastfm.APIError{Code: code}), code,
+		)
+	}
+	assert.Equal(
+		t, scrobble.ErrorTarget,
+		scrobble.NewListenBrainzTarget(nil).ClassifyError(&listenbrainz.APIError{Code: 401}),
+	)
+
+	// 会话失效与具体记录无关：不拆批、不标记失败，整个目标暂停
+	now := time.Now().Add(time.Second)
+	fake := newFakeLastfm()
+	fake.err = &lastfm.APIError{Code: lastfm.ErrCodeInvalidSessionKey, Message: "Invalid session key"}
+	worker := newScrobbleOutboxWorker([]scrobble.Target{fake}, defaultOutboxMaxBackoff)
+	worker.now = func() time.Time { return now }
+	require.Error(t, worker.runOnce(ctx))
+	assert.Equal(t, []int{50}, fake.batches)
+	rows := outboxRows(t)
+	require.Len(t, rows, 60)
+	for _, row := range rows {
+		assert.Equal(t, model.OutboxStatusPending, row.Status)
+		assert.Zero(t, row.Attempts)
+	}
+
+	// 暂停期间不再提交
+	require.NoError(t, worker.runOnce(ctx))
+	assert.Equal(t, []int{50}, fake.batches)
+
+	// 退避到期后再次失败，暂停时间翻倍
+	now = now.Add(outboxBaseBackoff)
+	require.Error(t, worker.runOnce(ctx))
+	assert.Equal(t, []int{50, 50}, fake.batches)
+	now = now.Add(outboxBaseBackoff)
+	require.NoError(t, worker.runOnce(ctx))
+	assert.Equal(t, []int{50, 50}, fake.batches)
+
+	// 修复配置后积压条目全部补传
+	fake.err = nil
+	now = now.Add(outboxBaseBackoff)
+	require.NoError(t, worker.runOnce(ctx))
+	assert.Equal(t, []int{50, 50, 50, 10}, fake.batches)
+	for _, row := range outboxRows(t) {
+		assert.Equal(t, model.OutboxStatusSent, row.Status)
+	}
+}
+
+func TestScrobbleOutboxWorker_SkipsManuallySynced(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	records := insertPlayRecords(t, 2, false)
+	require.NoError(t, model.EnqueueScrobbleOutbox(ctx, model.OutboxTargetLastfm, records[0].ID, records[1].ID))
+	// 入队后通过 sync-records 手动同步了第一条
+	require.NoError(t, model.UpdateScrobbledStatus(ctx, records[0].ID, true))
+
+	fake := newFakeLastfm()
+	worker := newScrobbleOutboxWorker([]scrobble.Target{fake}, defaultOutboxMaxBackoff)
+	require.NoError(t, worker.runOnce(ctx))
+
+	assert.Equal(t, []int{1}, fake.batches)
+	for _, row := range outboxRows(t) {
+		assert.Equal(t, model.OutboxStatusSent, row.Status)
+	}
+}
+
+func TestScrobbleOutboxWorker_TargetsAreIndependent(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	records := insertPlayRecords(t, 3, false)
+	// 实时上报时 Last.fm 与 ListenBrainz 均失败
+	for _, record := range records {
+		require.NoError(t, model.SaveScrobbleDelivery(ctx, record.ID, model.OutboxTargetLastfm, false, 0, "timeout"))
+		require.NoError(
+			t, model.SaveScrobbleDelivery(ctx, record.ID, model.OutboxTargetListenBrainz, false, 0, "timeout"),
+		)
+	}
+	// 未进入 outbox 的历史记录只会补传到 Last.fm
+	legacy := insertPlayRecords(t, 1, false)[0]
+
+	lastfmTarget := newFakeLastfm()
+	lbTarget := newFakeListenBrainz()
+	lbTarget.err = &listenbrainz.APIError{Code: 503, Message: "Service Unavailable"}
+	worker := newScrobbleOutboxWorker([]scrobble.Target{lbTarget, lastfmTarget}, defaultOutboxMaxBackoff)
+	worker.now = func() time.Time { return time.Now().Add(time.Second) }
+	require.NoError(t, worker.runOnce(ctx))
+
+	assert.Equal(t, []int{3}, lbTarget.batches)
+	assert.Equal(t, []int{4}, lastfmTarget.batches)
+	for _, record := range append(records, legacy) {
+		deliveries, err := model.GetScrobbleDeliveries(ctx, record.ID)
+		require.NoError(t, err)
+		statuses := map[string]string{}
+		for _, delivery := range deliveries {
+			statuses[delivery.Target] = delivery.Status
+		}
+		want := map[string]string{model.OutboxTargetLastfm: model.OutboxStatusSent}
+		if record.ID != legacy.ID {
+			want[model.OutboxTargetListenBrainz] = model.OutboxStatusPending
+		}
+		assert.Equal(t, want, statuses, "record %d", record.ID)
+	}
+
+	// ListenBrainz 失败不影响 Last.fm 的同步状态
+	count, err := model.GetUnscrobbledRecordsCount(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestSaveScrobbleDelivery(t *testing.T) {
+	model.SetupTestDB(t)
+	ctx := context.Background()
+	record := insertPlayRecords(t, 1, true)[0]
+
+	require.NoError(t, model.SaveScrobbleDelivery(ctx, record.ID, model.OutboxTargetLastfm, true, 0, ""))
+	require.NoError(t, model.SaveScrobbleDelivery(ctx, record.ID, model.OutboxTargetListenBrainz, false, 401, "bad token"))
+	deliveries, err := model.GetScrobbleDeliveries(ctx, record.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, model.OutboxTargetLastfm, deliveries[0].Target)
+	assert.Equal(t, model.OutboxStatusSent, deliveries[0].Status)
+	assert.Equal(t, model.OutboxStatusPending, deliveries[1].Status)
+	assert.Equal(t, 401, deliveries[1].ErrorCode)
+
+	// 同一目标再次写入时更新原有条目
+	require.NoError(t, model.SaveScrobbleDelivery(ctx, record.ID, model.OutboxTargetListenBrainz, true, 0, ""))
+	deliveries, err = model.GetScrobbleDeliveries(ctx, record.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, model.OutboxStatusSent, deliveries[1].Status)
+	assert.Empty(t, deliveries[1].LastError)
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/vincentchyu/sonic-lens/core/telemetry"
 	"github.com/vincentchyu/sonic-lens/internal/cache"
 	"github.com/vincentchyu/sonic-lens/internal/model"
+	"github.com/vincentchyu/sonic-lens/internal/nowplaying"
 	"github.com/vincentchyu/sonic-lens/internal/scrobbler"
 	d1sync "github.com/vincentchyu/sonic-lens/internal/sync"
 )
@@ -47,6 +48,21 @@ func main() {
 	// Add music-analysis subcommand
 	rootCmd.AddCommand(cmd.NewMusicAnalysisCommand())
 
+	// Add import subcommand
+	rootCmd.AddCommand(cmd.NewImportCommand())
+
+	// Add export subcommand
+	rootCmd.AddCommand(cmd.NewExportCommand())
+
+	// Add db subcommand
+	rootCmd.AddCommand(cmd.NewDBCommand())
+
+	// Add token subcommand
+	rootCmd.AddCommand(cmd.NewTokenCommand())
+
+	// Add trace subcommand
+	rootCmd.AddCommand(cmd.NewTraceCommand())
+
 	cobra.CheckErr(rootCmd.Execute())
 }
 
@@ -86,6 +102,13 @@ func initServer() error {
 	go d1sync.StartD1SyncScheduler(ctx)
 	// Start dashboard stat scheduler
 	go d1sync.StartDashboardStatScheduler(ctx)
+	// Start scrobble outbox scheduler
+	go d1sync.StartScrobbleOutboxScheduler(ctx)
+	// Start favorite reconcile scheduler
+	go d1sync.StartFavoriteReconcileScheduler(ctx)
+	// Forward now playing to websocket clients and webhooks
+	go nowplaying.Default().ForwardToWebSocket(ctx)
+	go nowplaying.Default().StartWebhooks(ctx, config.ConfigObj.NowPlaying.Webhooks)
 
 	// Start scrobblerRun goroutine
 	go api.StartHTTPServer(ctx, config.ConfigObj.Telemetry.Name)